@@ -1,16 +1,26 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/makeworld-the-better-one/amfora/bookmarks"
+	"github.com/makeworld-the-better-one/amfora/cache"
 	"github.com/makeworld-the-better-one/amfora/client"
 	"github.com/makeworld-the-better-one/amfora/config"
 	"github.com/makeworld-the-better-one/amfora/display"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+	"github.com/makeworld-the-better-one/amfora/logger"
+	"github.com/makeworld-the-better-one/amfora/plugins"
+	"github.com/makeworld-the-better-one/amfora/readinglist"
 	"github.com/makeworld-the-better-one/amfora/subscriptions"
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
 )
 
 var (
@@ -20,44 +30,80 @@ var (
 )
 
 func main() {
-	// err := logger.Init()
-	// if err != nil {
-	// 	panic(err)
-	// }
+	args, logPath, err := parseLogFlag(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
 
-	if len(os.Args) > 1 {
-		if os.Args[1] == "--version" || os.Args[1] == "-v" {
+	if len(args) > 0 {
+		if args[0] == "--version" || args[0] == "-v" {
 			fmt.Println("Amfora", version)
 			fmt.Println("Commit:", commit)
 			fmt.Println("Built by:", builtBy)
 			return
 		}
-		if os.Args[1] == "--help" || os.Args[1] == "-h" {
+		if args[0] == "--help" || args[0] == "-h" {
 			fmt.Println("Amfora is a fancy terminal browser for the Gemini protocol.")
 			fmt.Println()
 			fmt.Println("Usage:")
-			fmt.Println("amfora [URL]")
+			fmt.Println("amfora [--log FILE] [URL]")
+			fmt.Println("amfora --import-lagrange [FILE]")
 			fmt.Println("amfora --version, -v")
 			return
 		}
 	}
 
-	err := config.Init()
+	if logPath != "" {
+		// Started before the config is loaded, so that it's logged too
+		if err = logger.Init(logPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Log file error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			display.Crash(r)
+		}
+	}()
+	logger.Infof("Amfora %s, commit %s", version, commit)
+	start := time.Now()
+
+	err = config.Init()
+	// Pages the cache saved to disk are removed however Amfora quits
+	defer cache.CloseDisk()
 	if err != nil {
+		logger.Errorf("Config error: %v", err)
+		if !configErrorScreen(err) {
+			exit(1)
+		}
+		if err = config.UseDefaults(); err != nil {
+			logger.Errorf("Config error: %v", err)
+			fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+			exit(1)
+		}
+	}
+	if err = initLogFromConfig(logPath != ""); err != nil {
 		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
-		os.Exit(1)
+		exit(1)
+	}
+	if err = i18n.Init(viper.GetString("a-general.language"), config.LocalesDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Translation error: %v\n", err)
+		exit(1)
 	}
 	client.Init()
+	logger.Debugf("Startup: config loaded in %v", time.Since(start))
 
-	err = subscriptions.Init()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "subscriptions.json error: %v\n", err)
-		os.Exit(1)
-	}
-	err = bookmarks.Init()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "bookmarks.xml error: %v\n", err)
-		os.Exit(1)
+	if len(args) > 0 && args[0] == "--import-lagrange" {
+		if err = loadStores(); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			exit(1)
+		}
+		if err = importLagrange(args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Import error: %v\n", err)
+			exit(1)
+		}
+		return
 	}
 
 	// Initialize lower-level cview app
@@ -68,16 +114,130 @@ func main() {
 	// Initialize Amfora's settings
 	display.Init(version, commit, builtBy)
 	display.NewTab()
-	if len(os.Args[1:]) > 0 {
-		display.URL(os.Args[1])
-	} else if !isStdinEmpty() {
+	if len(args) == 0 && !isStdinEmpty() {
 		renderFromStdin()
 	}
+	logger.Debugf("Startup: UI ready in %v", time.Since(start))
+
+	// The UI appears right away, and keys work once the stores and plugins are loaded
+	storesErr := make(chan error, 1)
+	go func() {
+		defer display.RecoverCrash()
+		if err := loadStores(); err != nil {
+			storesErr <- err
+			display.App.QueueUpdate(display.App.Stop)
+			return
+		}
+		logger.Debugf("Startup: subscriptions, bookmarks, and reading list loaded in %v", time.Since(start))
+		subscriptions.StartUpdating()
+
+		plugins.Init()
+		logger.Debugf("Startup: plugins started in %v", time.Since(start))
+
+		u := ""
+		if len(args) > 0 {
+			u = args[0]
+		}
+		display.Ready(u)
+
+		// One after the other, they both ask the user something
+		display.OfferRestore()
+		display.CheckForUpdate(version)
+	}()
+	go display.WarnExpiringCerts()
 
 	// Start
 	if err = display.App.Run(); err != nil {
 		panic(err)
 	}
+	select {
+	case err = <-storesErr:
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		exit(1)
+	default:
+	}
+	logger.Infof("Quit")
+}
+
+// loadStores loads the subscriptions, bookmarks, and reading list, all at
+// once since they're separate files. The error names the file it's from.
+func loadStores() error {
+	stores := []struct {
+		file string
+		init func() error
+	}{
+		{"subscriptions.json", subscriptions.Init},
+		{"bookmarks.xml", bookmarks.Init},
+		{"reading-list.json", readinglist.Init},
+	}
+	errs := make([]error, len(stores))
+	var wg sync.WaitGroup
+	for i := range stores {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = stores[i].init()
+		}(i)
+	}
+	wg.Wait()
+	for i := range stores {
+		if errs[i] != nil {
+			return fmt.Errorf("%s error: %w", stores[i].file, errs[i])
+		}
+	}
+	return nil
+}
+
+// parseLogFlag removes the --log flag and its file path from the command
+// line arguments. The path is empty if there was no flag.
+func parseLogFlag(args []string) ([]string, string, error) {
+	rest := make([]string, 0, len(args))
+	path := ""
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--log":
+			if i+1 >= len(args) {
+				return nil, "", errors.New("--log needs a file path")
+			}
+			path = args[i+1]
+			i++
+		case strings.HasPrefix(args[i], "--log="):
+			path = strings.TrimPrefix(args[i], "--log=")
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	return rest, path, nil
+}
+
+// initLogFromConfig sets the log level, and starts logging to the file
+// from the config if the --log flag wasn't used.
+func initLogFromConfig(flagUsed bool) error {
+	level, err := logger.ParseLevel(viper.GetString("a-general.log_level"))
+	if err != nil {
+		return err
+	}
+	logger.SetLevel(level)
+
+	path := viper.GetString("a-general.log_file")
+	if flagUsed || path == "" {
+		return nil
+	}
+	path, err = homedir.Expand(path)
+	if err != nil {
+		return err
+	}
+	if err = logger.Init(path); err != nil {
+		return fmt.Errorf("log file: %w", err)
+	}
+	return nil
+}
+
+// exit removes the pages the cache saved to disk, which deferred calls
+// wouldn't do, and exits with the code.
+func exit(code int) {
+	cache.CloseDisk()
+	os.Exit(code)
 }
 
 func isStdinEmpty() bool {
@@ -90,9 +250,55 @@ func renderFromStdin() {
 	_, err := io.Copy(stdinTextBuilder, os.Stdin)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error reading from standard input: %v\n", err)
-		os.Exit(1)
+		exit(1)
 	}
 
 	stdinText := stdinTextBuilder.String()
 	display.RenderFromString(stdinText)
 }
+
+// importLagrange subscribes to the subscriptions of Lagrange, from the
+// bookmarks file given in args or the one in Lagrange's config directory.
+func importLagrange(args []string) error {
+	path := subscriptions.LagrangeBookmarksPath()
+	if len(args) > 0 {
+		path = args[0]
+	}
+	if path == "" {
+		return errors.New("the bookmarks file of Lagrange wasn't found, pass its path after --import-lagrange")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	urls, err := subscriptions.LagrangeSubscriptions(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if len(urls) == 0 {
+		fmt.Println("There are no subscriptions in", path)
+		return nil
+	}
+
+	n := 0
+	for _, u := range urls {
+		if subscriptions.IsSubscribed(u) {
+			fmt.Println("Already subscribed:", u)
+			continue
+		}
+		isFeed, err := subscriptions.Subscribe(u)
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Couldn't subscribe to %s: %v\n", u, err)
+		case isFeed:
+			fmt.Println("Subscribed to feed:", u)
+			n++
+		default:
+			fmt.Println("Tracking page for changes:", u)
+			n++
+		}
+	}
+	fmt.Printf("Imported %d of %d subscriptions from %s\n", n, len(urls), path)
+	return nil
+}
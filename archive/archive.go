@@ -0,0 +1,165 @@
+// Package archive stores snapshots of pages, so old versions of them can be
+// viewed later.
+//
+// Each archived URL has a directory named after the hash of the URL, holding
+// a JSON file with the details of each snapshot, and a file with its content.
+package archive
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+)
+
+var ErrInvalidID = errors.New("invalid snapshot ID")
+
+// Snapshot is a version of a page saved in the archive.
+type Snapshot struct {
+	ID        string    `json:"-"` // Unique for each snapshot of the URL
+	URL       string    `json:"url"`
+	Time      time.Time `json:"time"`
+	Header    string    `json:"header"`    // The response header as received, like "20 text/gemini; lang=en"
+	Mediatype string    `json:"mediatype"` // The structs.Mediatype the page was rendered as
+	Lang      string    `json:"lang,omitempty"`
+}
+
+// Meta returns the meta of the response header.
+func (s Snapshot) Meta() string {
+	if i := strings.IndexByte(s.Header, ' '); i != -1 {
+		return s.Header[i+1:]
+	}
+	return ""
+}
+
+func urlDir(u string) string {
+	return filepath.Join(config.ArchiveDir, fmt.Sprintf("%x", sha256.Sum256([]byte(u))))
+}
+
+// Save adds a snapshot of the page to the archive, with the body of the
+// response as it was received. The ID and time of the snapshot are set.
+func Save(s Snapshot, body []byte) (Snapshot, error) {
+	dir := urlDir(s.URL)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return s, err
+	}
+	s.Time = time.Now()
+	s.ID = strconv.FormatInt(s.Time.UnixNano(), 10)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, s.ID+".body"), body, 0666); err != nil {
+		return s, err
+	}
+	jsonBytes, err := json.MarshalIndent(&s, "", "  ")
+	if err != nil {
+		return s, err
+	}
+	return s, ioutil.WriteFile(filepath.Join(dir, s.ID+".json"), jsonBytes, 0666)
+}
+
+// readSnapshots returns the snapshots in the directory, newest first.
+func readSnapshots(dir string) ([]Snapshot, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]Snapshot, 0, len(files)/2)
+	for _, f := range files {
+		if !strings.HasSuffix(f.Name(), ".json") {
+			continue
+		}
+		jsonBytes, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var s Snapshot
+		if err := json.Unmarshal(jsonBytes, &s); err != nil {
+			return nil, fmt.Errorf("%s is corrupted: %w", f.Name(), err)
+		}
+		s.ID = strings.TrimSuffix(f.Name(), ".json")
+		snapshots = append(snapshots, s)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Time.After(snapshots[j].Time) })
+	return snapshots, nil
+}
+
+// Snapshots returns the snapshots of the URL, newest first.
+func Snapshots(u string) ([]Snapshot, error) {
+	snapshots, err := readSnapshots(urlDir(u))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return snapshots, err
+}
+
+// Latest returns the newest snapshot of each archived URL, newest first.
+func Latest() ([]Snapshot, error) {
+	dirs, err := ioutil.ReadDir(config.ArchiveDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	latest := make([]Snapshot, 0, len(dirs))
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		snapshots, err := readSnapshots(filepath.Join(config.ArchiveDir, d.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if len(snapshots) > 0 {
+			latest = append(latest, snapshots[0])
+		}
+	}
+	sort.Slice(latest, func(i, j int) bool { return latest[i].Time.After(latest[j].Time) })
+	return latest, nil
+}
+
+// Get returns the snapshot of the URL with the ID, and the body of its response.
+func Get(u, id string) (Snapshot, []byte, error) {
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return Snapshot{}, nil, ErrInvalidID
+	}
+	dir := urlDir(u)
+	jsonBytes, err := ioutil.ReadFile(filepath.Join(dir, id+".json"))
+	if err != nil {
+		return Snapshot{}, nil, err
+	}
+	var s Snapshot
+	if err := json.Unmarshal(jsonBytes, &s); err != nil {
+		return Snapshot{}, nil, err
+	}
+	s.ID = id
+	body, err := ioutil.ReadFile(filepath.Join(dir, id+".body"))
+	if err != nil {
+		return Snapshot{}, nil, err
+	}
+	return s, body, nil
+}
+
+// Remove deletes the snapshot of the URL with the ID.
+func Remove(u, id string) error {
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return ErrInvalidID
+	}
+	dir := urlDir(u)
+	for _, name := range []string{id + ".json", id + ".body"} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	// Remove the directory if it's empty now, ignoring the error if it isn't
+	os.Remove(dir)
+	return nil
+}
@@ -0,0 +1,61 @@
+package archive
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+)
+
+func TestArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "amfora-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.ArchiveDir = dir
+
+	const u = "gemini://example.com/"
+	first, err := Save(Snapshot{URL: u, Header: "20 text/gemini", Mediatype: "text/gemini"}, []byte("# Old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Save(Snapshot{URL: u, Header: "20 text/gemini; charset=iso-8859-1", Mediatype: "text/gemini"},
+		[]byte("# New \xe9"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshots, err := Snapshots(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 || snapshots[0].ID != second.ID || snapshots[1].ID != first.ID {
+		t.Fatalf("Snapshots: expected %s and %s, actual %v", second.ID, first.ID, snapshots)
+	}
+	if s, body, err := Get(u, first.ID); err != nil || string(body) != "# Old" || s.URL != u {
+		t.Errorf("Get: expected %q, actual %q, %v", "# Old", body, err)
+	}
+	// The body is kept as it was received
+	s, body, err := Get(u, second.ID)
+	if err != nil || string(body) != "# New \xe9" || s.Meta() != "text/gemini; charset=iso-8859-1" {
+		t.Errorf("Get: expected %q, actual %q %q, %v", "# New \xe9", s.Meta(), body, err)
+	}
+	if latest, err := Latest(); err != nil || len(latest) != 1 || latest[0].ID != second.ID {
+		t.Errorf("Latest: expected %s, actual %v, %v", second.ID, latest, err)
+	}
+
+	if err := Remove(u, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := Remove(u, second.ID); err != nil {
+		t.Fatal(err)
+	}
+	if snapshots, err := Snapshots(u); err != nil || len(snapshots) != 0 {
+		t.Errorf("Snapshots after removing: expected none, actual %v, %v", snapshots, err)
+	}
+	if _, _, err := Get(u, "../x"); err != ErrInvalidID {
+		t.Errorf("Get with a bad ID: expected ErrInvalidID, actual %v", err)
+	}
+}
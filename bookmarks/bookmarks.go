@@ -10,6 +10,8 @@ import (
 	"strings"
 
 	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/hooks"
+	"github.com/spf13/viper"
 )
 
 func Init() error {
@@ -71,6 +73,9 @@ func Init() error {
 		config.BkmkStore = nil
 	}
 
+	if viper.GetString("a-general.bookmarks_format") == "gemtext" {
+		return initGemtext()
+	}
 	return nil
 }
 
@@ -119,6 +124,11 @@ func writeXbel() error {
 
 // Change the name of the bookmark at the provided URL.
 func Change(url, name string) {
+	if gemtextMode {
+		reloadGemtext()
+		changeGemtext(url, func(string) (string, string) { return url, name }, false)
+		return
+	}
 	for _, bkmk := range data.Bookmarks {
 		if bkmk.URL == url {
 			bkmk.Name = name
@@ -128,18 +138,43 @@ func Change(url, name string) {
 	}
 }
 
+// ChangeURL changes the URL of the bookmark at the old URL, keeping its name.
+// It's used when a bookmarked page has permanently moved.
+func ChangeURL(oldURL, newURL string) {
+	if gemtextMode {
+		reloadGemtext()
+		changeGemtext(oldURL, func(name string) (string, string) { return newURL, name }, false)
+		return
+	}
+	for _, bkmk := range data.Bookmarks {
+		if bkmk.URL == oldURL {
+			bkmk.URL = newURL
+			writeXbel() //nolint:errcheck
+			return
+		}
+	}
+}
+
 // Add will add a new bookmark.
 func Add(url, name string) {
-	data.Bookmarks = append(data.Bookmarks, &xbelBookmark{
-		URL:  url,
-		Name: name,
-	})
-	writeXbel() //nolint:errcheck
+	if gemtextMode {
+		reloadGemtext()
+		gmiLines = append(gmiLines, linkLine(url, name))
+		writeGemtext() //nolint:errcheck
+	} else {
+		data.Bookmarks = append(data.Bookmarks, &xbelBookmark{
+			URL:  url,
+			Name: name,
+		})
+		writeXbel() //nolint:errcheck
+	}
+	hooks.Run(hooks.BookmarkAdded, map[string]string{"URL": url, "NAME": name}, "")
 }
 
 // Get returns the NAME of the bookmark, given the URL.
 // It also returns a bool indicating whether it exists.
 func Get(url string) (string, bool) {
+	reloadGemtext()
 	for _, bkmk := range data.Bookmarks {
 		if bkmk.URL == url {
 			return bkmk.Name, true
@@ -149,6 +184,11 @@ func Get(url string) (string, bool) {
 }
 
 func Remove(url string) {
+	if gemtextMode {
+		reloadGemtext()
+		changeGemtext(url, nil, true)
+		return
+	}
 	for i, bkmk := range data.Bookmarks {
 		if bkmk.URL == url {
 			data.Bookmarks[i] = data.Bookmarks[len(data.Bookmarks)-1]
@@ -180,6 +220,7 @@ func (b *bkmkNameSlice) Swap(i, j int) {
 // All returns all the bookmarks, as two arrays, one for names and one for URLs.
 // They are sorted alphabetically.
 func All() ([]string, []string) {
+	reloadGemtext()
 	b := bkmkNameSlice{
 		make([]string, len(data.Bookmarks)),
 		make([]string, len(data.Bookmarks)),
@@ -0,0 +1,154 @@
+package bookmarks
+
+// Bookmarks can be stored as a regular gemtext file instead of XBEL, when
+// "a-general.bookmarks_format" is "gemtext". Every link line is a bookmark,
+// and the other lines, like headings used as folders, are kept as they are.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+)
+
+// gemtextMode is true if the bookmarks are stored in config.GemtextBkmkPath.
+var gemtextMode bool
+
+// The lines of the gemtext file, and when it was last modified, to reload it
+// if it was edited by hand while Amfora is running.
+var (
+	gmiLines   []string
+	gmiModTime time.Time
+)
+
+// parseLinkLine returns the URL and name of a gemtext link line. The name is
+// empty if the link has none.
+func parseLinkLine(line string) (url, name string, ok bool) {
+	if !strings.HasPrefix(line, "=>") {
+		return "", "", false
+	}
+	fields := strings.Fields(line[2:])
+	if len(fields) == 0 {
+		return "", "", false
+	}
+	url = fields[0]
+	name = strings.TrimSpace(strings.TrimSpace(line[2:])[len(url):])
+	return url, name, true
+}
+
+// linkLine returns the gemtext link line for a bookmark.
+func linkLine(url, name string) string {
+	if name == "" {
+		return "=> " + url
+	}
+	return "=> " + url + " " + name
+}
+
+// gemtextBookmarks returns the bookmarks in the gemtext lines, in order.
+// Links without a name use the URL as one.
+func gemtextBookmarks(lines []string) []*xbelBookmark {
+	bkmks := make([]*xbelBookmark, 0)
+	for _, line := range lines {
+		if url, name, ok := parseLinkLine(line); ok {
+			if name == "" {
+				name = url
+			}
+			bkmks = append(bkmks, &xbelBookmark{URL: url, Name: name})
+		}
+	}
+	return bkmks
+}
+
+// initGemtext reads the gemtext file, or makes it from the XBEL bookmarks
+// if it doesn't exist yet.
+func initGemtext() error {
+	_, err := os.Stat(config.GemtextBkmkPath)
+	if os.IsNotExist(err) {
+		gmiLines = []string{"# Bookmarks", ""}
+		names, urls := All()
+		for i := range names {
+			gmiLines = append(gmiLines, linkLine(urls[i], names[i]))
+		}
+		if err := writeGemtext(); err != nil {
+			return fmt.Errorf("couldn't make bookmarks.gmi: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("open bookmarks.gmi error: %w", err)
+	}
+	if err := loadGemtext(); err != nil {
+		return err
+	}
+	gemtextMode = true
+	return nil
+}
+
+// loadGemtext reads the gemtext file, if it changed since it was last read.
+func loadGemtext() error {
+	fi, err := os.Stat(config.GemtextBkmkPath)
+	if err != nil {
+		return err
+	}
+	if fi.ModTime().Equal(gmiModTime) {
+		return nil
+	}
+	b, err := ioutil.ReadFile(config.GemtextBkmkPath)
+	if err != nil {
+		return fmt.Errorf("read bookmarks.gmi error: %w", err)
+	}
+	gmiLines = strings.Split(strings.TrimRight(strings.ReplaceAll(string(b), "\r\n", "\n"), "\n"), "\n")
+	gmiModTime = fi.ModTime()
+	data.Bookmarks = gemtextBookmarks(gmiLines)
+	return nil
+}
+
+// reloadGemtext reads the gemtext file again if it's being used and was
+// edited. Errors are ignored, the bookmarks that were read last are kept.
+func reloadGemtext() {
+	if gemtextMode {
+		loadGemtext() //nolint:errcheck
+	}
+}
+
+func writeGemtext() error {
+	err := ioutil.WriteFile(config.GemtextBkmkPath, []byte(strings.Join(gmiLines, "\n")+"\n"), 0666)
+	if err != nil {
+		return err
+	}
+	if fi, err := os.Stat(config.GemtextBkmkPath); err == nil {
+		gmiModTime = fi.ModTime()
+	}
+	data.Bookmarks = gemtextBookmarks(gmiLines)
+	return nil
+}
+
+// changeGemtext replaces the link line of the bookmark with the URL, using
+// the function to get the new URL and name. If remove is true the line is
+// removed instead.
+func changeGemtext(url string, change func(name string) (string, string), remove bool) {
+	for i, line := range gmiLines {
+		u, name, ok := parseLinkLine(line)
+		if !ok || u != url {
+			continue
+		}
+		if remove {
+			gmiLines = append(gmiLines[:i], gmiLines[i+1:]...)
+		} else {
+			gmiLines[i] = linkLine(change(name))
+		}
+		writeGemtext() //nolint:errcheck
+		return
+	}
+}
+
+// Gemtext returns the gemtext file of the bookmarks, and true, if that's how
+// they're stored. It's shown as it is, instead of a list of the bookmarks.
+func Gemtext() (string, bool) {
+	if !gemtextMode {
+		return "", false
+	}
+	reloadGemtext()
+	return strings.Join(gmiLines, "\n") + "\n", true
+}
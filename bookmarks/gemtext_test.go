@@ -0,0 +1,26 @@
+package bookmarks
+
+import "testing"
+
+func TestParseLinkLine(t *testing.T) {
+	tests := []struct {
+		line string
+		url  string
+		name string
+		ok   bool
+	}{
+		{"=> gemini://example.com/ Example", "gemini://example.com/", "Example", true},
+		{"=>gemini://example.com/\tTwo  words ", "gemini://example.com/", "Two  words", true},
+		{"=> gemini://example.com/", "gemini://example.com/", "", true},
+		{"=>", "", "", false},
+		{"## Folder", "", "", false},
+		{" => gemini://example.com/", "", "", false},
+	}
+	for _, tt := range tests {
+		url, name, ok := parseLinkLine(tt.line)
+		if url != tt.url || name != tt.name || ok != tt.ok {
+			t.Errorf("parseLinkLine(%q) = %q, %q, %v, want %q, %q, %v",
+				tt.line, url, name, ok, tt.url, tt.name, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/logger"
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// Pages removed from the cache to keep it inside its limits are saved to
+// disk, so going back to them in a tab's history doesn't load them again.
+// Only what's needed to render them again is saved. Each session saves them
+// in its own directory, made when the first page is saved, and removed when
+// Amfora quits. Pages from private tabs are never saved.
+
+var diskParent string            // Where the directories of sessions are made, or empty to not save pages
+var diskDir string               // The directory of this session, or empty if it wasn't made yet
+var diskURLs = make([]string, 0) // The saved pages, oldest first
+var maxDiskPages = 0             // Max allowed number of pages on disk
+var diskMu = sync.Mutex{}
+
+// Directories of sessions that weren't changed for this long are from
+// sessions that didn't quit cleanly, and are removed.
+const staleSessionAge = 24 * time.Hour
+
+// diskPage is a page as it's saved to disk.
+type diskPage struct {
+	URL          string
+	Mediatype    structs.Mediatype
+	RawMediatype string
+	Lang         string
+	Charset      string
+	Raw          string
+	Meta         string
+	Body         []byte
+	Links        []string
+	MadeAt       time.Time
+	TLSVersion   uint16
+	Cert         []byte // DER, empty if there was no cert
+	Redirects    []string
+	ViewedAs     structs.Mediatype
+}
+
+// SetDisk sets how many pages removed from the cache are saved to disk, in a
+// new directory inside dir. A max <= 0 turns saving pages off.
+// The directories of earlier sessions that are stale are removed.
+// CloseDisk should be called before quitting.
+func SetDisk(dir string, max int) {
+	diskMu.Lock()
+	defer diskMu.Unlock()
+
+	closeDisk()
+	diskParent = ""
+	maxDiskPages = max
+	if max <= 0 || dir == "" {
+		return
+	}
+	diskParent = dir
+	removeStaleSessions(dir)
+}
+
+// removeStaleSessions removes the directories in dir of sessions that
+// didn't quit cleanly. Directories still in use by other instances of Amfora
+// have been changed recently, and are kept.
+func removeStaleSessions(dir string) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warnf("Cache: couldn't read the directory for pages saved to disk: %v", err)
+		}
+		return
+	}
+	for _, f := range files {
+		if !f.IsDir() || !strings.HasPrefix(f.Name(), "session-") || time.Since(f.ModTime()) < staleSessionAge {
+			continue
+		}
+		logger.Debugf("Cache: removing the stale pages of %s", f.Name())
+		if err := os.RemoveAll(filepath.Join(dir, f.Name())); err != nil {
+			logger.Warnf("Cache: couldn't remove the stale pages of %s: %v", f.Name(), err)
+		}
+	}
+}
+
+// CloseDisk removes the pages saved to disk, and their directory.
+// It can be called more than once.
+func CloseDisk() {
+	diskMu.Lock()
+	defer diskMu.Unlock()
+	closeDisk()
+}
+
+// closeDisk is CloseDisk, with diskMu held.
+func closeDisk() {
+	diskURLs = make([]string, 0)
+	if diskDir == "" {
+		return
+	}
+	if err := os.RemoveAll(diskDir); err != nil {
+		logger.Warnf("Cache: couldn't remove the pages saved to disk: %v", err)
+	}
+	diskDir = ""
+}
+
+func diskPath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(diskDir, hex.EncodeToString(sum[:])+".json")
+}
+
+// removeDiskURL removes the URL from diskURLs and deletes its file.
+// diskMu must be held.
+func removeDiskURL(url string) {
+	for i := range diskURLs {
+		if diskURLs[i] == url {
+			diskURLs = append(diskURLs[:i], diskURLs[i+1:]...)
+			os.Remove(diskPath(url))
+			return
+		}
+	}
+}
+
+// spillPage saves the page to disk, removing the oldest saved pages as needed.
+func spillPage(p *structs.Page) {
+	diskMu.Lock()
+	defer diskMu.Unlock()
+
+	if diskParent == "" || p.Private {
+		return
+	}
+	if diskDir == "" {
+		// The first page saved this session
+		if err := os.MkdirAll(diskParent, 0700); err != nil {
+			logger.Warnf("Cache: couldn't make the directory for pages saved to disk: %v", err)
+			return
+		}
+		sessionDir, err := ioutil.TempDir(diskParent, "session-")
+		if err != nil {
+			logger.Warnf("Cache: couldn't make the directory for pages saved to disk: %v", err)
+			return
+		}
+		diskDir = sessionDir
+	}
+	removeDiskURL(p.URL)
+	for len(diskURLs) >= maxDiskPages {
+		logger.Debugf("Cache: removing %s from disk, too many pages", diskURLs[0])
+		removeDiskURL(diskURLs[0])
+	}
+
+	dp := diskPage{
+		URL:          p.URL,
+		Mediatype:    p.Mediatype,
+		RawMediatype: p.RawMediatype,
+		Lang:         p.Lang,
+		Charset:      p.Charset,
+		Raw:          p.Raw,
+		Meta:         p.Meta,
+		Body:         p.Body,
+		Links:        p.Links,
+		MadeAt:       p.MadeAt,
+		TLSVersion:   p.TLSVersion,
+		Redirects:    p.Redirects,
+		ViewedAs:     p.ViewedAs,
+	}
+	if p.Cert != nil {
+		dp.Cert = p.Cert.Raw
+	}
+	data, err := json.Marshal(&dp)
+	if err == nil {
+		err = ioutil.WriteFile(diskPath(p.URL), data, 0600)
+	}
+	if err != nil {
+		logger.Warnf("Cache: couldn't save %s to disk: %v", p.URL, err)
+		return
+	}
+	diskURLs = append(diskURLs, p.URL)
+	logger.Debugf("Cache: saved %s to disk", p.URL)
+}
+
+// loadSpilled returns the page saved to disk, and removes it from there.
+// It has to be rendered again.
+func loadSpilled(url string) (*structs.Page, bool) {
+	diskMu.Lock()
+	defer diskMu.Unlock()
+
+	if diskDir == "" {
+		return nil, false
+	}
+	found := false
+	for i := range diskURLs {
+		if diskURLs[i] == url {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, false
+	}
+	data, err := ioutil.ReadFile(diskPath(url))
+	removeDiskURL(url)
+	if err != nil {
+		logger.Warnf("Cache: couldn't read %s from disk: %v", url, err)
+		return nil, false
+	}
+	var dp diskPage
+	if err := json.Unmarshal(data, &dp); err != nil {
+		logger.Warnf("Cache: couldn't read %s from disk: %v", url, err)
+		return nil, false
+	}
+
+	p := structs.Page{
+		URL:          dp.URL,
+		Mediatype:    dp.Mediatype,
+		RawMediatype: dp.RawMediatype,
+		Lang:         dp.Lang,
+		Charset:      dp.Charset,
+		Raw:          dp.Raw,
+		Meta:         dp.Meta,
+		Body:         dp.Body,
+		Links:        dp.Links,
+		TermWidth:    -1, // Not rendered yet
+		MadeAt:       dp.MadeAt,
+		TLSVersion:   dp.TLSVersion,
+		Redirects:    dp.Redirects,
+		ViewedAs:     dp.ViewedAs,
+	}
+	if len(dp.Cert) > 0 {
+		p.Cert, _ = x509.ParseCertificate(dp.Cert)
+	}
+	return &p, true
+}
+
+// removeSpilled deletes the page saved to disk, if there is one.
+func removeSpilled(url string) {
+	diskMu.Lock()
+	defer diskMu.Unlock()
+	removeDiskURL(url)
+}
+
+// clearSpilled deletes all the pages saved to disk.
+func clearSpilled() {
+	diskMu.Lock()
+	defer diskMu.Unlock()
+	for _, url := range diskURLs {
+		os.Remove(diskPath(url))
+	}
+	diskURLs = make([]string, 0)
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDisk(t *testing.T) {
+	reset()
+	dir, err := ioutil.TempDir("", "amfora-cache-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	SetDisk(dir, 1)
+	defer SetDisk("", 0)
+
+	assert := assert.New(t)
+	SetMaxPages(1)
+	p := structs.Page{URL: "gemini://example.com/", Mediatype: structs.TextGemini, Raw: "# Page", Content: "rendered"}
+	AddPage(&p)
+	files, _ := ioutil.ReadDir(dir)
+	assert.Empty(files, "the directory for the session should only be made when a page is saved")
+	AddPage(&p2)
+	assert.Equal(1, NumPages(), "the first page should be removed from memory")
+
+	got, ok := GetPage(p.URL)
+	if !assert.True(ok, "the first page should be read from disk") {
+		return
+	}
+	assert.Equal(p.Raw, got.Raw)
+	assert.Equal("", got.Content, "the page should have to be rendered again")
+	assert.Equal(-1, got.TermWidth)
+	assert.Equal(1, NumPages(), "the page should be back in memory, and the second one on disk")
+
+	// Only one page is kept on disk
+	AddPage(&structs.Page{URL: "example.net"})
+	_, ok = GetPage(p2.URL)
+	assert.False(ok, "the second page should have been removed from disk")
+
+	RemovePage(p.URL)
+	_, ok = GetPage(p.URL)
+	assert.False(ok, "removed pages should be removed from disk too")
+
+	CloseDisk()
+	files, _ = ioutil.ReadDir(dir)
+	assert.Empty(files, "the directory for the session should be removed")
+}
+
+func TestDiskPrivate(t *testing.T) {
+	reset()
+	dir, err := ioutil.TempDir("", "amfora-cache-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	SetDisk(dir, 1)
+	defer SetDisk("", 0)
+
+	spillPage(&structs.Page{URL: "gemini://example.com/", Raw: "# Page", Private: true})
+	_, ok := loadSpilled("gemini://example.com/")
+	assert.False(t, ok, "pages from private tabs should never be saved to disk")
+	files, _ := ioutil.ReadDir(dir)
+	assert.Empty(t, files)
+}
+
+func TestRemoveStaleSessions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "amfora-cache-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	stale := filepath.Join(dir, "session-stale")
+	recent := filepath.Join(dir, "session-recent")
+	other := filepath.Join(dir, "other")
+	for _, d := range []string{stale, recent, other} {
+		if err := os.Mkdir(d, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-2 * staleSessionAge)
+	for _, d := range []string{stale, other} {
+		if err := os.Chtimes(d, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	SetDisk(dir, 1)
+	defer SetDisk("", 0)
+
+	assert.NoDirExists(t, stale, "stale sessions should be removed")
+	assert.DirExists(t, recent, "sessions that could still be in use should be kept")
+	assert.DirExists(t, other, "only the directories of sessions should be removed")
+}
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/makeworld-the-better-one/amfora/logger"
 	"github.com/makeworld-the-better-one/amfora/structs"
 )
 
@@ -58,13 +59,14 @@ func removeURL(url string) {
 // If your page is larger than the max cache size, the provided page
 // will silently not be added to the cache.
 func AddPage(p *structs.Page) {
-	if p.URL == "" {
+	if p.URL == "" || p.Private {
 		// Just in case, these pages shouldn't be cached
 		return
 	}
 
 	if p.Size() > maxSize && maxSize > 0 {
 		// This page can never be added
+		logger.Debugf("Cache: %s is too large to add", p.URL)
 		return
 	}
 
@@ -72,11 +74,13 @@ func AddPage(p *structs.Page) {
 	// There should only ever be 1 page to remove at most,
 	// but this handles more just in case.
 	for NumPages() >= maxPages && maxPages > 0 {
-		RemovePage(urls[0])
+		logger.Debugf("Cache: removing %s, too many pages", urls[0])
+		evictPage(urls[0])
 	}
 	// Do the same but for cache size
 	for SizePages()+p.Size() > maxSize && maxSize > 0 {
-		RemovePage(urls[0])
+		logger.Debugf("Cache: removing %s, cache is too large", urls[0])
+		evictPage(urls[0])
 	}
 
 	mu.Lock()
@@ -85,23 +89,50 @@ func AddPage(p *structs.Page) {
 	// Remove the URL if it was already there, then add it to the end
 	removeURL(p.URL)
 	urls = append(urls, p.URL)
+	logger.Debugf("Cache: added %s", p.URL)
 }
 
-// RemovePage will remove a page from the cache.
+// evictPage removes a page from the cache to make room for another one,
+// saving it to disk, see SetDisk.
+func evictPage(url string) {
+	mu.Lock()
+	p, ok := pages[url]
+	delete(pages, url)
+	removeURL(url)
+	mu.Unlock()
+	if ok {
+		spillPage(p)
+	}
+}
+
+// RemovePage will remove a page from the cache, and from disk.
 // Even if the page doesn't exist there will be no error.
 func RemovePage(url string) {
 	mu.Lock()
 	defer mu.Unlock()
 	delete(pages, url)
 	removeURL(url)
+	removeSpilled(url)
+}
+
+// MarkUnrendered makes the cached pages be rendered again when they're
+// displayed, after something changed how they look. The pages are shared
+// with the tabs, so it should be called in the same goroutine as the UI.
+func MarkUnrendered() {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, p := range pages {
+		p.TermWidth = -1
+	}
 }
 
-// ClearPages removes all pages from the cache.
+// ClearPages removes all pages from the cache, and from disk.
 func ClearPages() {
 	mu.Lock()
 	defer mu.Unlock()
 	pages = make(map[string]*structs.Page)
 	urls = make([]string, 0)
+	clearSpilled()
 }
 
 // SizePages returns the approx. current size of the cache in bytes.
@@ -123,13 +154,26 @@ func NumPages() int {
 
 // GetPage returns the page struct, and a bool indicating if the page was in the cache or not.
 // (nil, false) is returned if the page isn't in the cache.
+// Pages that were saved to disk are added back to the cache, and have to be rendered again.
 func GetPage(url string) (*structs.Page, bool) {
 	mu.RLock()
-	defer mu.RUnlock()
-
 	p, ok := pages[url]
+	mu.RUnlock()
+	if !ok {
+		p, ok = loadSpilled(url)
+		if ok && (timeout == 0 || time.Since(p.MadeAt) < timeout) {
+			logger.Debugf("Cache: hit on disk for %s", url)
+			AddPage(p)
+			return p, true
+		}
+	}
+
 	if ok && (timeout == 0 || time.Since(p.MadeAt) < timeout) {
+		logger.Debugf("Cache: hit for %s", url)
 		return p, ok
 	}
+	if ok {
+		logger.Debugf("Cache: %s has expired", url)
+	}
 	return nil, false
 }
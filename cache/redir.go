@@ -1,12 +1,105 @@
 package cache
 
-import "sync"
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/logger"
+)
 
 // Functions for caching redirects.
 
-var redirUrls = make(map[string]string) // map original URL to redirect
+var redirUrls = make(map[string]string)     // map original URL to redirect
+var redirAdded = make(map[string]time.Time) // map original URL to when the redirect was added
 var redirMu = sync.RWMutex{}
 
+// The file redirects are saved to, so they're remembered the next time
+// Amfora is opened. Redirects aren't saved if it's empty. See LoadRedirs.
+var redirPath string
+
+// Limits for the redirects saved to the file, so it doesn't grow forever.
+// The newest ones are kept, and ones older than the max age are dropped.
+var (
+	maxSavedRedirs   = 1000
+	savedRedirMaxAge = 90 * 24 * time.Hour
+)
+
+// savedRedir is a redirect in the file, by the original URL.
+type savedRedir struct {
+	URL   string    `json:"url"`
+	Added time.Time `json:"added"`
+}
+
+// LoadRedirs loads the redirects saved in the JSON file at path, and saves
+// the redirects that are added from now on to it.
+func LoadRedirs(path string) error {
+	redirMu.Lock()
+	defer redirMu.Unlock()
+
+	redirPath = path
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	loaded := make(map[string]savedRedir)
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		// The file from before it had times, they're counted from now
+		var old map[string]string
+		if json.Unmarshal(data, &old) != nil {
+			return err
+		}
+		for og, redir := range old {
+			loaded[og] = savedRedir{redir, time.Now()}
+		}
+	}
+	for og, saved := range loaded {
+		if time.Since(saved.Added) > savedRedirMaxAge {
+			continue
+		}
+		redirUrls[og] = saved.URL
+		redirAdded[og] = saved.Added
+	}
+	return nil
+}
+
+// saveRedirs saves the redirects to the file set by LoadRedirs, if there is one.
+// redirMu must be locked.
+func saveRedirs() {
+	if redirPath == "" {
+		return
+	}
+	ogs := make([]string, 0, len(redirUrls))
+	for og := range redirUrls {
+		if time.Since(redirAdded[og]) <= savedRedirMaxAge {
+			ogs = append(ogs, og)
+		}
+	}
+	// Newest first
+	sort.Slice(ogs, func(i, j int) bool { return redirAdded[ogs[i]].After(redirAdded[ogs[j]]) })
+	if len(ogs) > maxSavedRedirs {
+		ogs = ogs[:maxSavedRedirs]
+	}
+	saved := make(map[string]savedRedir, len(ogs))
+	for _, og := range ogs {
+		saved[og] = savedRedir{redirUrls[og], redirAdded[og]}
+	}
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err == nil {
+		err = ioutil.WriteFile(redirPath, data, 0600)
+	}
+	if err != nil {
+		logger.Warnf("Couldn't save redirects: %v", err)
+	}
+}
+
 // AddRedir adds a original-to-redirect pair to the cache.
 func AddRedir(og, redir string) {
 	redirMu.Lock()
@@ -23,15 +116,20 @@ func AddRedir(og, redir string) {
 			// There's a loop
 			// The newer version is preferred
 			delete(redirUrls, k)
+			delete(redirAdded, k)
 		}
 	}
 	redirUrls[og] = redir
+	redirAdded[og] = time.Now()
+	saveRedirs()
 }
 
 // ClearRedirs removes all redirects from the cache.
 func ClearRedirs() {
 	redirMu.Lock()
 	redirUrls = make(map[string]string)
+	redirAdded = make(map[string]time.Time)
+	saveRedirs()
 	redirMu.Unlock()
 }
 
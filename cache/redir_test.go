@@ -1,7 +1,11 @@
 package cache
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -22,3 +26,67 @@ func TestAddRedir(t *testing.T) {
 	AddRedir("B", "A")
 	assert.Equal(t, "A", Redirect("B"), "B redirects to A - most recent version of loop is used")
 }
+
+func TestLoadRedirs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "amfora-redirs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "redirects.json")
+	defer func() { redirPath = "" }()
+
+	ClearRedirs()
+	assert.NoError(t, LoadRedirs(path), "a missing file isn't an error")
+	AddRedir("A", "B")
+
+	// Like opening Amfora again
+	redirMu.Lock()
+	redirUrls = make(map[string]string)
+	redirMu.Unlock()
+	assert.NoError(t, LoadRedirs(path))
+	assert.Equal(t, "B", Redirect("A"), "A still redirects to B")
+
+	// The file from before redirects had times
+	redirPath = ""
+	ClearRedirs()
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"C": "D"}`), 0600))
+	assert.NoError(t, LoadRedirs(path))
+	assert.Equal(t, "D", Redirect("C"), "C redirects to D from the old file")
+}
+
+func TestSavedRedirLimits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "amfora-redirs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "redirects.json")
+	defer func() { redirPath = "" }()
+	defer func(max int) { maxSavedRedirs = max }(maxSavedRedirs)
+	maxSavedRedirs = 2
+
+	ClearRedirs()
+	assert.NoError(t, LoadRedirs(path))
+	redirMu.Lock()
+	now := time.Now()
+	redirUrls["old"] = "x"
+	redirAdded["old"] = now.Add(-savedRedirMaxAge - time.Hour)
+	redirUrls["A"] = "x"
+	redirAdded["A"] = now.Add(-3 * time.Hour)
+	redirUrls["B"] = "x"
+	redirAdded["B"] = now.Add(-2 * time.Hour)
+	redirMu.Unlock()
+	AddRedir("C", "x")
+
+	// Like opening Amfora again
+	redirMu.Lock()
+	redirUrls = make(map[string]string)
+	redirAdded = make(map[string]time.Time)
+	redirMu.Unlock()
+	assert.NoError(t, LoadRedirs(path))
+	assert.Equal(t, "old", Redirect("old"), "expired redirects aren't saved")
+	assert.Equal(t, "A", Redirect("A"), "the oldest redirect over the limit isn't saved")
+	assert.Equal(t, "x", Redirect("B"))
+	assert.Equal(t, "x", Redirect("C"))
+}
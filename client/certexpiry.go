@@ -0,0 +1,162 @@
+package client
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/logger"
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+)
+
+// How long renewed certificates are valid for, if the old one's validity
+// period couldn't be used.
+const defaultRenewValidity = 365 * 24 * time.Hour
+
+// ConfiguredCert is a client certificate set in the "auth" section of the config.
+type ConfiguredCert struct {
+	Host     string // The host it's set for in the config
+	Name     string // The common name of the certificate
+	Path     string // Path of the certificate file
+	NotAfter time.Time
+	Err      error // Why the certificate couldn't be loaded, if it couldn't
+}
+
+// ExpiresWithin returns true if the certificate has expired, or will within d.
+func (c ConfiguredCert) ExpiresWithin(d time.Duration) bool {
+	return c.Err == nil && time.Until(c.NotAfter) < d
+}
+
+// certPaths returns the paths of the certificate and key files set for the host.
+// Paths starting with ~/ are expanded.
+func certPaths(host string) (string, string) {
+	certPath, err := homedir.Expand(viper.GetString("auth.certs." + host))
+	if err != nil {
+		certPath = viper.GetString("auth.certs." + host)
+	}
+	keyPath, err := homedir.Expand(viper.GetString("auth.keys." + host))
+	if err != nil {
+		keyPath = viper.GetString("auth.keys." + host)
+	}
+	return certPath, keyPath
+}
+
+// loadKeyPair loads the certificate and key files set for the host.
+func loadKeyPair(host string) (*x509.Certificate, crypto.PrivateKey, error) {
+	certPath, keyPath := certPaths(host)
+	pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, pair.PrivateKey, nil
+}
+
+// ConfiguredCerts returns the client certificates set in the config,
+// the ones that expire first coming first. Ones that couldn't be loaded
+// come last, with Err set.
+func ConfiguredCerts() []ConfiguredCert {
+	var certs []ConfiguredCert
+	for host := range viper.GetStringMapString("auth.certs") {
+		c := ConfiguredCert{Host: host}
+		c.Path, _ = certPaths(host)
+		cert, _, err := loadKeyPair(host)
+		if err != nil {
+			c.Err = err
+		} else {
+			c.Name = cert.Subject.CommonName
+			c.NotAfter = cert.NotAfter
+		}
+		certs = append(certs, c)
+	}
+	sort.Slice(certs, func(i, j int) bool {
+		if (certs[i].Err == nil) != (certs[j].Err == nil) {
+			return certs[i].Err == nil
+		}
+		if !certs[i].NotAfter.Equal(certs[j].NotAfter) {
+			return certs[i].NotAfter.Before(certs[j].NotAfter)
+		}
+		return certs[i].Host < certs[j].Host
+	})
+	return certs
+}
+
+// RenewCert replaces the certificate set for the host with a new one that has
+// the same name and key, but starts being valid now. It's valid for as long as
+// the old one was. The old certificate file is kept, with ".old" added to its name.
+//
+// The key is kept so that servers that identify users by their public key still
+// recognize them. Servers that use the fingerprint of the whole certificate won't.
+func RenewCert(host string) (time.Time, error) {
+	if IsTemporaryCert(host) {
+		return time.Time{}, errors.New("temporary certificates can't be renewed")
+	}
+	old, key, err := loadKeyPair(host)
+	if err != nil {
+		return time.Time{}, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return time.Time{}, errors.New("unsupported key type")
+	}
+	validity := old.NotAfter.Sub(old.NotBefore)
+	if validity <= 0 {
+		validity = defaultRenewValidity
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return time.Time{}, err
+	}
+	now := time.Now().Truncate(time.Second) // Certificates don't store fractions
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               old.Subject,
+		NotBefore:             now.Add(-time.Hour), // In case the server's clock is behind
+		NotAfter:              now.Add(validity),
+		KeyUsage:              old.KeyUsage,
+		ExtKeyUsage:           old.ExtKeyUsage,
+		DNSNames:              old.DNSNames,
+		BasicConstraintsValid: old.BasicConstraintsValid,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, signer.Public(), signer)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	certPath, _ := certPaths(host)
+	oldData, err := ioutil.ReadFile(certPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := ioutil.WriteFile(certPath+".old", oldData, 0600); err != nil {
+		return time.Time{}, err
+	}
+	info, err := os.Stat(certPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+	err = ioutil.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), info.Mode())
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Load the new certificate on the next request
+	certCacheMu.Lock()
+	delete(certCache, host)
+	certGen++
+	certCacheMu.Unlock()
+	logger.Infof("Renewed the client certificate for %s, it's now valid until %s", host, template.NotAfter)
+	return template.NotAfter, nil
+}
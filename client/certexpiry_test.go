@@ -0,0 +1,73 @@
+package client
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func TestRenewCert(t *testing.T) {
+	dir, err := ioutil.TempDir("", "amfora-cert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cert, key, err := newTemporaryCert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPath := filepath.Join(dir, "id.crt")
+	keyPath := filepath.Join(dir, "id.key")
+	if err := ioutil.WriteFile(certPath, cert, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyPath, key, 0600); err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("auth.certs.localhost", certPath)
+	viper.Set("auth.keys.localhost", keyPath)
+	defer viper.Set("auth.certs.localhost", "")
+	defer viper.Set("auth.keys.localhost", "")
+
+	old, _, err := loadKeyPair("localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	notAfter, err := RenewCert("localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	renewed, _, err := loadKeyPair("localhost")
+	if err != nil {
+		t.Fatalf("renewed certificate doesn't match the key: %v", err)
+	}
+	if !renewed.NotAfter.Equal(notAfter) || !notAfter.After(old.NotAfter) {
+		t.Errorf("renewed certificate expires %v, old one %v", notAfter, old.NotAfter)
+	}
+	if time.Until(notAfter) > old.NotAfter.Sub(old.NotBefore) {
+		t.Errorf("renewed certificate is valid for longer than the old one")
+	}
+	if !bytes.Equal(renewed.RawSubjectPublicKeyInfo, old.RawSubjectPublicKeyInfo) {
+		t.Error("the public key changed")
+	}
+
+	backup, err := ioutil.ReadFile(certPath + ".old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(backup)
+	if block == nil {
+		t.Fatal("backup isn't PEM")
+	}
+	if parsed, err := x509.ParseCertificate(block.Bytes); err != nil || !parsed.Equal(old) {
+		t.Error("backup isn't the old certificate")
+	}
+}
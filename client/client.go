@@ -2,29 +2,50 @@
 package client
 
 import (
+	"crypto/x509"
+	"encoding/pem"
 	"io/ioutil"
 	"net"
 	"net/url"
 	"sync"
 	"time"
 
+	"github.com/makeworld-the-better-one/amfora/logger"
 	"github.com/makeworld-the-better-one/go-gemini"
-	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
 )
 
 var (
 	certCache   = make(map[string][][]byte)
 	certCacheMu = &sync.RWMutex{}
+	certGen     uint64 // Changed with certCacheMu whenever the certificate of a host changes
 
-	fetchClient *gemini.Client
+	fetchClient *geminiClient
+	torClient   *geminiClient // Always connects through Tor
 )
 
 func Init() {
-	fetchClient = &gemini.Client{
-		ConnectTimeout: 10 * time.Second, // Default is 15
-		ReadTimeout:    time.Duration(viper.GetInt("a-general.page_max_time")) * time.Second,
+	fetchClient = &geminiClient{
+		connectTimeout: 10 * time.Second,
+		readTimeout:    time.Duration(viper.GetInt("a-general.page_max_time")) * time.Second,
+		dial:           dial,
 	}
+	torClient = &geminiClient{
+		connectTimeout: 30 * time.Second, // Tor connections are slower to set up
+		readTimeout:    time.Duration(viper.GetInt("a-general.page_max_time")) * time.Second,
+		dial:           dialTor,
+	}
+	initDNS()
+}
+
+// clientFor returns the client to use for fetching the URL. Hosts that
+// require Tor always use it, even when the connection is to a Gemini proxy.
+func clientFor(u string) *geminiClient {
+	parsed, err := url.Parse(u)
+	if err == nil && RequiresTor(parsed.Hostname()) {
+		return torClient
+	}
+	return fetchClient
 }
 
 func clientCert(host string) ([]byte, []byte) {
@@ -35,15 +56,7 @@ func clientCert(host string) ([]byte, []byte) {
 		return pair[0], pair[1]
 	}
 
-	// Expand paths starting with ~/
-	certPath, err := homedir.Expand(viper.GetString("auth.certs." + host))
-	if err != nil {
-		certPath = viper.GetString("auth.certs." + host)
-	}
-	keyPath, err := homedir.Expand(viper.GetString("auth.keys." + host))
-	if err != nil {
-		keyPath = viper.GetString("auth.keys." + host)
-	}
+	certPath, keyPath := certPaths(host)
 	if certPath == "" && keyPath == "" {
 		certCacheMu.Lock()
 		certCache[host] = [][]byte{nil, nil}
@@ -78,23 +91,61 @@ func HasClientCert(host string) bool {
 	return cert != nil
 }
 
-func fetch(u string, c *gemini.Client) (*gemini.Response, error) {
+// CertGeneration returns a number that changes whenever the client certificate
+// used for a host changes, so information about the certificates can be cached.
+func CertGeneration() uint64 {
+	certCacheMu.RLock()
+	defer certCacheMu.RUnlock()
+	return certGen
+}
+
+// ClientCertName returns the common name of the client certificate for a host,
+// which is usually the name of the identity. It returns an empty string if
+// there is no certificate, or it has no common name.
+func ClientCertName(host string) string {
+	cert, _ := clientCert(host)
+	block, _ := pem.Decode(cert)
+	if block == nil {
+		return ""
+	}
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return ""
+	}
+	return parsed.Subject.CommonName
+}
+
+// certFor returns the client certificate and key to send to the host. The
+// tab certificate is used if there is one, see FetchWithCert. Hosts it isn't
+// for get no certificate.
+func certFor(host string, tc *TabCert) ([]byte, []byte) {
+	if tc != nil {
+		if !tc.HasCertFor(host) {
+			return nil, nil
+		}
+		return tc.cert, tc.key
+	}
+	return clientCert(host)
+}
+
+// fetch fetches the URL with the client, see FetchWithCert for tc.
+func fetch(u string, c *geminiClient, tc *TabCert) (*gemini.Response, error) {
 	parsed, _ := url.Parse(u)
-	cert, key := clientCert(parsed.Host)
+	cert, key := certFor(parsed.Host, tc)
 
-	var res *gemini.Response
-	var err error
 	if cert != nil {
-		res, err = c.FetchWithCert(u, cert, key)
-	} else {
-		res, err = c.Fetch(u)
+		logger.Debugf("Using a client certificate for %s", parsed.Host)
 	}
+	start := time.Now()
+	res, err := c.fetch("", u, cert, key)
+	recordFetch(u, "", start, res, err, tc.isPrivate())
 	if err != nil {
 		return nil, err
 	}
 
 	ok := handleTofu(parsed.Hostname(), parsed.Port(), res.Cert)
 	if !ok {
+		logger.Warnf("TOFU check failed for %s", parsed.Host)
 		return res, ErrTofu
 	}
 
@@ -103,21 +154,47 @@ func fetch(u string, c *gemini.Client) (*gemini.Response, error) {
 
 // Fetch returns response data and an error.
 // The error text is human friendly and should be displayed.
+//
+// The connection rules for the URL's host are followed, so it may
+// be blocked or fetched through a proxy.
 func Fetch(u string) (*gemini.Response, error) {
-	return fetch(u, fetchClient)
+	return FetchWithCert(u, nil)
 }
 
-func fetchWithProxy(proxyHostname, proxyPort, u string, c *gemini.Client) (*gemini.Response, error) {
+// FetchWithCert is the same as Fetch, but the tab certificate is sent instead
+// of the client certificate for the host, if it's for the host. Otherwise no
+// certificate is sent. If it's nil, the one for the host is sent like with Fetch.
+func FetchWithCert(u string, tc *TabCert) (*gemini.Response, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	rule, proxy := HostRule(parsed.Hostname())
+	switch rule {
+	case RuleBlock:
+		if !tc.isPrivate() {
+			logger.Infof("Fetch %s blocked by connection rules", u)
+		}
+		return nil, ErrBlocked
+	case RuleProxy:
+		proxyHostname, proxyPort, err := net.SplitHostPort(proxy)
+		if err != nil {
+			// No port in the proxy
+			proxyHostname = proxy
+			proxyPort = "1965"
+		}
+		return fetchWithProxy(proxyHostname, proxyPort, u, clientFor(u), tc)
+	}
+	return fetch(u, clientFor(u), tc)
+}
+
+func fetchWithProxy(proxyHostname, proxyPort, u string, c *geminiClient, tc *TabCert) (*gemini.Response, error) {
 	parsed, _ := url.Parse(u)
-	cert, key := clientCert(parsed.Host)
+	cert, key := certFor(parsed.Host, tc)
 
-	var res *gemini.Response
-	var err error
-	if cert != nil {
-		res, err = c.FetchWithHostAndCert(net.JoinHostPort(proxyHostname, proxyPort), u, cert, key)
-	} else {
-		res, err = c.FetchWithHost(net.JoinHostPort(proxyHostname, proxyPort), u)
-	}
+	start := time.Now()
+	res, err := c.fetch(net.JoinHostPort(proxyHostname, proxyPort), u, cert, key)
+	recordFetch(u, net.JoinHostPort(proxyHostname, proxyPort), start, res, err, tc.isPrivate())
 	if err != nil {
 		return nil, err
 	}
@@ -125,6 +202,7 @@ func fetchWithProxy(proxyHostname, proxyPort, u string, c *gemini.Client) (*gemi
 	// Only associate the returned cert with the proxy
 	ok := handleTofu(proxyHostname, proxyPort, res.Cert)
 	if !ok {
+		logger.Warnf("TOFU check failed for proxy %s", net.JoinHostPort(proxyHostname, proxyPort))
 		return res, ErrTofu
 	}
 
@@ -133,5 +211,11 @@ func fetchWithProxy(proxyHostname, proxyPort, u string, c *gemini.Client) (*gemi
 
 // FetchWithProxy is the same as Fetch, but uses a proxy.
 func FetchWithProxy(proxyHostname, proxyPort, u string) (*gemini.Response, error) {
-	return fetchWithProxy(proxyHostname, proxyPort, u, fetchClient)
+	return FetchWithProxyAndCert(proxyHostname, proxyPort, u, nil)
+}
+
+// FetchWithProxyAndCert is the same as FetchWithProxy, but the tab
+// certificate is sent, like with FetchWithCert.
+func FetchWithProxyAndCert(proxyHostname, proxyPort, u string, tc *TabCert) (*gemini.Response, error) {
+	return fetchWithProxy(proxyHostname, proxyPort, u, clientFor(u), tc)
 }
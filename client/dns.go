@@ -0,0 +1,174 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/logger"
+	"github.com/spf13/viper"
+)
+
+// The DNS server used to look up hosts can be set in the config, to get
+// around a system resolver that's broken or blocks Gemini hosts. It can be a
+// regular DNS server, or a DNS-over-HTTPS (DoH) URL. Connections through Tor
+// don't use it, since Tor looks up the host itself.
+
+var (
+	resolver *net.Resolver // nil to use the system's
+	dnsErr   error         // Set if the server in the config can't be used
+)
+
+// dohClient makes the DoH requests.
+var dohClient = &http.Client{Timeout: 10 * time.Second}
+
+// dohMaxSize is the largest a DNS message can be. Longer responses are errors.
+const dohMaxSize = 65535
+
+// initDNS sets the resolver from the config.
+func initDNS() {
+	resolver, dnsErr = newResolver(viper.GetString("dns.server"))
+	if dnsErr != nil {
+		logger.Errorf("DNS: %v", dnsErr)
+	}
+}
+
+// parseDNSServer returns the address of the DNS server, with the default
+// port added if needed, or the URL if it's a DoH server.
+func parseDNSServer(server string) (addr string, doh bool, err error) {
+	if strings.Contains(server, "://") {
+		u, err := url.Parse(server)
+		if err != nil {
+			return "", false, fmt.Errorf("invalid DNS server URL %s: %w", server, err)
+		}
+		if u.Scheme != "https" || u.Host == "" {
+			return "", false, fmt.Errorf("invalid DNS server URL %s: DNS-over-HTTPS needs an https URL", server)
+		}
+		return u.String(), true, nil
+	}
+
+	if net.ParseIP(server) != nil {
+		// No port, and an IPv6 address can't be split
+		return net.JoinHostPort(server, "53"), false, nil
+	}
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		host, port = server, "53"
+	}
+	if net.ParseIP(host) == nil {
+		return "", false, fmt.Errorf("invalid DNS server %s: it must be an IP address, or an https URL", server)
+	}
+	return net.JoinHostPort(host, port), false, nil
+}
+
+// newResolver returns a resolver that uses the server, or nil for an empty
+// string, to use the system's resolver.
+func newResolver(server string) (*net.Resolver, error) {
+	if server == "" {
+		return nil, nil
+	}
+	addr, doh, err := parseDNSServer(server)
+	if err != nil {
+		return nil, err
+	}
+	if doh {
+		return &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+				return &dohConn{ctx: ctx, url: addr}, nil
+			},
+		}, nil
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}, nil
+}
+
+// dohConn is what the Go resolver uses as a connection to the DNS server,
+// for a DoH server. It's used like a TCP connection, so each DNS message
+// starts with its length. Each message written is sent in its own request,
+// and the response can then be read.
+type dohConn struct {
+	ctx      context.Context
+	url      string
+	deadline time.Time
+	wbuf     []byte
+	rbuf     bytes.Buffer
+}
+
+func (c *dohConn) Write(b []byte) (int, error) {
+	c.wbuf = append(c.wbuf, b...)
+	if len(c.wbuf) < 2 {
+		return len(b), nil
+	}
+	size := int(c.wbuf[0])<<8 | int(c.wbuf[1])
+	if len(c.wbuf) < 2+size {
+		return len(b), nil
+	}
+	msg := c.wbuf[2 : 2+size]
+	c.wbuf = c.wbuf[2+size:]
+
+	resp, err := c.query(msg)
+	if err != nil {
+		return 0, err
+	}
+	c.rbuf.Write([]byte{byte(len(resp) >> 8), byte(len(resp))})
+	c.rbuf.Write(resp)
+	return len(b), nil
+}
+
+// query sends the DNS message to the server, and returns its response.
+func (c *dohConn) query(msg []byte) ([]byte, error) {
+	ctx := c.ctx
+	if !c.deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, c.deadline)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(msg))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	res, err := dohClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DNS server responded with %s", res.Status)
+	}
+	resp, err := ioutil.ReadAll(io.LimitReader(res.Body, dohMaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) > dohMaxSize {
+		return nil, errors.New("DNS server response is too large")
+	}
+	return resp, nil
+}
+
+func (c *dohConn) Read(b []byte) (int, error) {
+	return c.rbuf.Read(b)
+}
+
+func (c *dohConn) Close() error                       { return nil }
+func (c *dohConn) LocalAddr() net.Addr                { return nil }
+func (c *dohConn) RemoteAddr() net.Addr               { return nil }
+func (c *dohConn) SetDeadline(t time.Time) error      { c.deadline = t; return nil }
+func (c *dohConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *dohConn) SetWriteDeadline(t time.Time) error { c.deadline = t; return nil }
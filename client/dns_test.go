@@ -0,0 +1,103 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestParseDNSServer(t *testing.T) {
+	tests := []struct {
+		server string
+		addr   string
+		doh    bool
+		err    bool
+	}{
+		{"9.9.9.9", "9.9.9.9:53", false, false},
+		{"9.9.9.9:5353", "9.9.9.9:5353", false, false},
+		{"2620:fe::fe", "[2620:fe::fe]:53", false, false},
+		{"[2620:fe::fe]:53", "[2620:fe::fe]:53", false, false},
+		{"https://1.1.1.1/dns-query", "https://1.1.1.1/dns-query", true, false},
+		{"https://dns.example.com/dns-query", "https://dns.example.com/dns-query", true, false},
+		{"dns.example.com", "", false, true},
+		{"http://1.1.1.1/dns-query", "", false, true},
+		{"tls://1.1.1.1", "", false, true},
+		{"https:///dns-query", "", false, true},
+	}
+	for _, tt := range tests {
+		addr, doh, err := parseDNSServer(tt.server)
+		if (err != nil) != tt.err {
+			t.Errorf("%s: got error %v", tt.server, err)
+			continue
+		}
+		if addr != tt.addr || doh != tt.doh {
+			t.Errorf("%s: got %q, %v, want %q, %v", tt.server, addr, doh, tt.addr, tt.doh)
+		}
+	}
+}
+
+// dohHandler answers A queries with 192.0.2.1, and has no other records.
+func dohHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/dns-message" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		var p dnsmessage.Parser
+		h, err := p.Start(body)
+		if err != nil {
+			t.Errorf("invalid query: %v", err)
+			return
+		}
+		q, err := p.Question()
+		if err != nil {
+			t.Errorf("invalid question: %v", err)
+			return
+		}
+
+		b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: h.ID, Response: true, RecursionAvailable: true})
+		b.EnableCompression()
+		b.StartQuestions() //nolint:errcheck
+		b.Question(q)      //nolint:errcheck
+		b.StartAnswers()   //nolint:errcheck
+		if q.Type == dnsmessage.TypeA {
+			b.AResource(dnsmessage.ResourceHeader{ //nolint:errcheck
+				Name:  q.Name,
+				Class: dnsmessage.ClassINET,
+				TTL:   60,
+			}, dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}})
+		}
+		resp, err := b.Finish()
+		if err != nil {
+			t.Errorf("couldn't build response: %v", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(resp) //nolint:errcheck
+	}
+}
+
+func TestDoHResolver(t *testing.T) {
+	server := httptest.NewTLSServer(dohHandler(t))
+	defer server.Close()
+	oldClient := dohClient
+	dohClient = server.Client()
+	defer func() { dohClient = oldClient }()
+
+	r, err := newResolver(server.URL + "/dns-query")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrs, err := r.LookupHost(context.Background(), "gemini.example.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 1 || addrs[0] != "192.0.2.1" {
+		t.Errorf("got %v, want [192.0.2.1]", addrs)
+	}
+}
@@ -0,0 +1,217 @@
+package client
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/makeworld-the-better-one/go-gemini"
+)
+
+// geminiClient makes Gemini requests. It's used instead of the client from
+// go-gemini so the TLS config can be set for each host, like the TLS versions
+// allowed. The responses are the same.
+type geminiClient struct {
+	connectTimeout time.Duration
+	readTimeout    time.Duration
+	dial           func(*net.Dialer, string) (net.Conn, error)
+}
+
+// ErrInvalidHeader is returned when the server's response header isn't valid.
+var ErrInvalidHeader = errors.New("the server sent an invalid response header")
+
+// responseBody is the body of a response, after the header. It closes the
+// connection, and can set a read deadline on it, see SetReadTimeout.
+type responseBody struct {
+	r    *bufio.Reader
+	conn net.Conn
+}
+
+func (b *responseBody) Read(p []byte) (int, error)        { return b.r.Read(p) }
+func (b *responseBody) Close() error                      { return b.conn.Close() }
+func (b *responseBody) SetReadDeadline(t time.Time) error { return b.conn.SetReadDeadline(t) }
+
+// SetReadTimeout sets how long there is to read the rest of the response,
+// from now. A timeout of zero removes it. Responses that weren't fetched over
+// the network, like from a filter or plugin, are left as is.
+func SetReadTimeout(res *gemini.Response, timeout time.Duration) error {
+	if res == nil {
+		return nil
+	}
+	body, ok := res.Body.(interface{ SetReadDeadline(time.Time) error })
+	if !ok {
+		return nil
+	}
+	if timeout == 0 {
+		return body.SetReadDeadline(time.Time{})
+	}
+	return body.SetReadDeadline(time.Now().Add(timeout))
+}
+
+// tlsConfig returns the TLS config for connecting to the host. The server
+// certificate isn't verified against CAs, TOFU is used instead.
+func tlsConfig(host string, certPEM, keyPEM []byte) (*tls.Config, error) {
+	conf := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true, //nolint:gosec // TOFU is used instead
+		MinVersion:         minTLSVersion(host),
+		MaxVersion:         tls.VersionTLS13, // The highest version is always preferred
+	}
+	if certPEM != nil {
+		pair, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{pair}
+	}
+	return conf, nil
+}
+
+// fetch requests the URL from the server at the address, which is the host
+// of the URL unless it's a proxy. The client certificate is sent if it's not nil.
+func (c *geminiClient) fetch(address, u string, certPEM, keyPEM []byte) (*gemini.Response, error) {
+	if len(u) > gemini.URLMaxLength {
+		return nil, errors.New("the URL is too long")
+	}
+	if address == "" {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			return nil, err
+		}
+		if parsed.Port() == "" {
+			address = net.JoinHostPort(parsed.Hostname(), "1965")
+		} else {
+			address = parsed.Host
+		}
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := tlsConfig(host, certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	rawConn, err := c.dial(&net.Dialer{Timeout: c.connectTimeout}, address)
+	if err != nil {
+		return nil, err
+	}
+	conn := tls.Client(rawConn, conf)
+	conn.SetDeadline(time.Now().Add(c.connectTimeout)) //nolint:errcheck
+	if err := conn.Handshake(); err != nil {
+		conn.Close()
+		if conf.MinVersion > tls.VersionTLS12 {
+			return nil, fmt.Errorf("TLS error, the server may not support %s required by the config: %w",
+				TLSVersionName(conf.MinVersion), err)
+		}
+		return nil, fmt.Errorf("TLS error: %w", err)
+	}
+	state := conn.ConnectionState()
+	tlsVersionsMu.Lock()
+	tlsVersions[address] = state.Version
+	tlsVersionsMu.Unlock()
+
+	cert := state.PeerCertificates[0]
+	if err := checkServerCert(cert, host); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if c.readTimeout > 0 {
+		conn.SetDeadline(time.Now().Add(c.readTimeout)) //nolint:errcheck
+	} else {
+		// No limit, only the connection had one
+		conn.SetDeadline(time.Time{}) //nolint:errcheck
+	}
+	if _, err := io.WriteString(conn, u+"\r\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	r := bufio.NewReader(conn)
+	status, meta, err := readHeader(r)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &gemini.Response{
+		Status: status,
+		Meta:   meta,
+		Body:   &responseBody{r: r, conn: conn},
+		Cert:   cert,
+	}, nil
+}
+
+// checkServerCert returns an error if the certificate is expired, or isn't
+// for the host. Whether it's trusted is checked later with TOFU.
+func checkServerCert(cert *x509.Certificate, host string) error {
+	now := time.Now()
+	if now.Before(cert.NotBefore) {
+		return errors.New("the server certificate isn't valid yet")
+	}
+	if now.After(cert.NotAfter) {
+		return errors.New("the server certificate is expired")
+	}
+	err := cert.VerifyHostname(host)
+	if err != nil && len(cert.DNSNames) == 0 && len(cert.IPAddresses) == 0 &&
+		matchHostname(cert.Subject.CommonName, host) {
+		// Many Gemini certificates only have the host as their common name,
+		// which isn't checked by VerifyHostname
+		err = nil
+	}
+	if err != nil {
+		return fmt.Errorf("the server certificate isn't for this host: %w", err)
+	}
+	return nil
+}
+
+// matchHostname returns true if the name from a certificate, which can have a
+// wildcard like "*.example.com", matches the host.
+func matchHostname(name, host string) bool {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if name == host {
+		return true
+	}
+	if !strings.HasPrefix(name, "*.") {
+		return false
+	}
+	i := strings.Index(host, ".")
+	return i > 0 && host[i:] == name[1:]
+}
+
+// readHeader reads the status and meta of a response.
+func readHeader(r *bufio.Reader) (int, string, error) {
+	// Two digits, a space, up to 1024 bytes of meta, and CRLF
+	line, err := r.ReadSlice('\n')
+	if err != nil && !errors.Is(err, bufio.ErrBufferFull) {
+		return 0, "", fmt.Errorf("couldn't read the response header: %w", err)
+	}
+	if err != nil || len(line) > 2+1+1024+2 {
+		return 0, "", ErrInvalidHeader
+	}
+	header := strings.TrimSuffix(strings.TrimSuffix(string(line), "\n"), "\r")
+	if len(header) < 2 {
+		return 0, "", ErrInvalidHeader
+	}
+	status, err := strconv.Atoi(header[:2])
+	if err != nil || status < 10 {
+		return 0, "", ErrInvalidHeader
+	}
+	meta := header[2:]
+	if meta != "" {
+		if meta[0] != ' ' {
+			return 0, "", ErrInvalidHeader
+		}
+		meta = meta[1:]
+	}
+	return status, meta, nil
+}
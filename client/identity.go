@@ -0,0 +1,169 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/logger"
+	"github.com/spf13/viper"
+)
+
+// Identities are the client certificates set in the "auth" section of the
+// config. When a server asks for a certificate, one can be used for it until
+// Amfora is closed, or a temporary one can be made. Neither is saved anywhere.
+
+// How long temporary certificates are valid for. They're thrown away on exit,
+// so this only has to be longer than any session.
+const temporaryCertValidity = 30 * 24 * time.Hour
+
+// Hosts that are using a temporary certificate.
+var temporaryCerts = make(map[string]bool)
+
+// Identity is a client certificate from the config.
+type Identity struct {
+	Host string // The host it's set for in the config
+	Name string // The common name of the certificate, or the host if it has none
+}
+
+// Identities returns the client certificates set in the config, sorted by name.
+// Ones that can't be loaded are skipped.
+func Identities() []Identity {
+	var ids []Identity
+	for host := range viper.GetStringMapString("auth.certs") {
+		if !HasClientCert(host) || IsTemporaryCert(host) {
+			continue
+		}
+		name := ClientCertName(host)
+		if name == "" {
+			name = host
+		}
+		ids = append(ids, Identity{Host: host, Name: name})
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if ids[i].Name == ids[j].Name {
+			return ids[i].Host < ids[j].Host
+		}
+		return ids[i].Name < ids[j].Name
+	})
+	return ids
+}
+
+// UseIdentity makes requests to host use the client certificate of the
+// identity, until Amfora is closed.
+func UseIdentity(host string, id Identity) {
+	cert, key := clientCert(id.Host)
+	certCacheMu.Lock()
+	certCache[host] = [][]byte{cert, key}
+	delete(temporaryCerts, host)
+	certGen++
+	certCacheMu.Unlock()
+	logger.Infof("Using the identity %q for %s", id.Name, host)
+}
+
+// UseTemporaryCert makes a new client certificate that's only used for
+// requests to host, until Amfora is closed.
+func UseTemporaryCert(host string) error {
+	cert, key, err := newTemporaryCert()
+	if err != nil {
+		return err
+	}
+	certCacheMu.Lock()
+	certCache[host] = [][]byte{cert, key}
+	temporaryCerts[host] = true
+	certGen++
+	certCacheMu.Unlock()
+	logger.Infof("Using a temporary client certificate for %s", host)
+	return nil
+}
+
+// TabCert is a client certificate that's used for the requests from a tab,
+// instead of the ones for each host. A certificate is only sent to the host
+// it was chosen for, and other hosts get none, so it isn't sent to every
+// site followed from there. It can also have no certificate, so none are sent.
+type TabCert struct {
+	Name      string // Shown to the user
+	Host      string // The host the certificate is sent to
+	Temporary bool
+	cert, key []byte
+	private   bool
+}
+
+// NoCert is a TabCert that doesn't send any certificate.
+var NoCert = &TabCert{Name: "None"}
+
+// PrivateCert is the TabCert of private tabs. Like NoCert it doesn't send any
+// certificate, and the URLs fetched with it aren't logged or shown on about:network.
+var PrivateCert = &TabCert{Name: "None", private: true}
+
+// isPrivate returns true if the TabCert is PrivateCert.
+func (tc *TabCert) isPrivate() bool {
+	return tc != nil && tc.private
+}
+
+// HasCertFor returns true if a certificate is sent to the host with the TabCert.
+func (tc *TabCert) HasCertFor(host string) bool {
+	return tc.cert != nil && tc.Host == host
+}
+
+// IdentityTabCert returns a TabCert for the identity, that's sent to host.
+func IdentityTabCert(id Identity, host string) *TabCert {
+	cert, key := clientCert(id.Host)
+	return &TabCert{Name: id.Name, Host: host, cert: cert, key: key}
+}
+
+// TemporaryTabCert returns a TabCert with a new certificate, like the ones
+// made by UseTemporaryCert, that's sent to host.
+func TemporaryTabCert(host string) (*TabCert, error) {
+	cert, key, err := newTemporaryCert()
+	if err != nil {
+		return nil, err
+	}
+	return &TabCert{Name: "Temporary ID", Host: host, Temporary: true, cert: cert, key: key}, nil
+}
+
+// IsTemporaryCert returns true if host is using a certificate made by UseTemporaryCert.
+func IsTemporaryCert(host string) bool {
+	certCacheMu.RLock()
+	defer certCacheMu.RUnlock()
+	return temporaryCerts[host]
+}
+
+// newTemporaryCert returns a new self-signed certificate and its key, PEM encoded.
+// It has no name, so it can't be linked to any other identity.
+func newTemporaryCert() ([]byte, []byte, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{},
+		NotBefore:    now.Add(-time.Hour), // In case the server's clock is behind
+		NotAfter:     now.Add(temporaryCertValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDer, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}),
+		nil
+}
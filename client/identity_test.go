@@ -0,0 +1,69 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+)
+
+func TestNewTemporaryCert(t *testing.T) {
+	cert, key, err := newTemporaryCert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pair, err := tls.X509KeyPair(cert, key)
+	if err != nil {
+		t.Fatalf("the certificate and key don't make a pair: %v", err)
+	}
+	parsed, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Subject.CommonName != "" {
+		t.Errorf("common name = %q, want none", parsed.Subject.CommonName)
+	}
+
+	cert2, _, err := newTemporaryCert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(cert) == string(cert2) {
+		t.Error("two temporary certificates are the same")
+	}
+}
+
+func TestTabCertHost(t *testing.T) {
+	tc, err := TemporaryTabCert("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert, _ := certFor("example.com", tc); cert == nil {
+		t.Error("the tab certificate isn't sent to the host it was chosen for")
+	}
+	if cert, _ := certFor("example.org", tc); cert != nil {
+		t.Error("the tab certificate is sent to another host")
+	}
+	if cert, _ := certFor("example.com", NoCert); cert != nil {
+		t.Error("a certificate is sent with NoCert")
+	}
+}
+
+func TestCertGeneration(t *testing.T) {
+	defer func() {
+		certCacheMu.Lock()
+		delete(certCache, "example.com")
+		delete(temporaryCerts, "example.com")
+		certCacheMu.Unlock()
+	}()
+
+	gen := CertGeneration()
+	if err := UseTemporaryCert("example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if CertGeneration() == gen {
+		t.Error("the generation didn't change after using a temporary certificate")
+	}
+	if !IsTemporaryCert("example.com") {
+		t.Error("the host isn't using the temporary certificate")
+	}
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/logger"
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+)
+
+// Misfin is a protocol for sending messages, like email for Gemini.
+// The sender is identified by their client certificate.
+// See gemini://misfin.org/ for the specification.
+
+const misfinPort = "1958"
+
+// The max length of a Misfin request, including the CRLF
+const misfinMaxRequest = 2048
+
+var (
+	ErrNoMisfinIdentity = errors.New("no Misfin identity is set in the config, see the misfin section")
+	ErrMisfinTooLong    = errors.New("the message is too long, Misfin requests can be at most 2048 bytes")
+	ErrMisfinAddress    = errors.New("invalid Misfin address, it should look like misfin://mailbox@example.com")
+)
+
+// MisfinAddress returns the mailbox address of a misfin: URL,
+// like "mailbox@example.com".
+func MisfinAddress(u string) (string, error) {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Scheme != "misfin" || parsed.User == nil || parsed.User.Username() == "" ||
+		parsed.Hostname() == "" {
+		return "", ErrMisfinAddress
+	}
+	return parsed.User.Username() + "@" + parsed.Host, nil
+}
+
+// misfinIdentity loads the client certificate from the "misfin" section of the config.
+func misfinIdentity() (tls.Certificate, error) {
+	certPath, err := homedir.Expand(viper.GetString("misfin.cert"))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	keyPath, err := homedir.Expand(viper.GetString("misfin.key"))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if certPath == "" || keyPath == "" {
+		return tls.Certificate{}, ErrNoMisfinIdentity
+	}
+	return tls.LoadX509KeyPair(certPath, keyPath)
+}
+
+// MisfinIdentityName returns the common name of the Misfin identity certificate,
+// or an empty string if there isn't one.
+func MisfinIdentityName() string {
+	cert, err := misfinIdentity()
+	if err != nil || len(cert.Certificate) == 0 {
+		return ""
+	}
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return ""
+	}
+	return parsed.Subject.CommonName
+}
+
+// SendMisfin sends the message to the mailbox of the misfin: URL, using the
+// identity certificate from the config. The status and meta of the response are
+// returned. The server certificate is checked with TOFU, like for Gemini.
+func SendMisfin(u, message string) (int, string, error) {
+	addr, err := MisfinAddress(u)
+	if err != nil {
+		return 0, "", err
+	}
+	request := "misfin://" + addr + " " + message + "\r\n"
+	if len(request) > misfinMaxRequest {
+		return 0, "", ErrMisfinTooLong
+	}
+	identity, err := misfinIdentity()
+	if err != nil {
+		return 0, "", err
+	}
+
+	parsed, _ := url.Parse(u)
+	host, port := parsed.Hostname(), parsed.Port()
+	if port == "" {
+		port = misfinPort
+	}
+
+	rawConn, err := dial(&net.Dialer{Timeout: 10 * time.Second}, net.JoinHostPort(host, port))
+	if err != nil {
+		return 0, "", err
+	}
+	conn := tls.Client(rawConn, &tls.Config{
+		ServerName:         host,
+		Certificates:       []tls.Certificate{identity},
+		InsecureSkipVerify: true, //nolint:gosec // TOFU is used instead
+		MinVersion:         minTLSVersion(host),
+		MaxVersion:         tls.VersionTLS13,
+	})
+	defer conn.Close()
+	if timeout := time.Duration(viper.GetInt("a-general.page_max_time")) * time.Second; timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout)) //nolint:errcheck
+	}
+
+	if err := conn.Handshake(); err != nil {
+		return 0, "", err
+	}
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return 0, "", errors.New("the server sent no certificate")
+	}
+	if !handleTofu(host, port, certs[0]) {
+		logger.Warnf("TOFU check failed for Misfin server %s", net.JoinHostPort(host, port))
+		return 0, "", ErrTofu
+	}
+
+	if _, err := conn.Write([]byte(request)); err != nil {
+		return 0, "", err
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil && line == "" {
+		return 0, "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	parts := strings.SplitN(line, " ", 2)
+	status, err := strconv.Atoi(parts[0])
+	if err != nil || len(parts[0]) != 2 {
+		return 0, "", fmt.Errorf("invalid response from the server: %q", line)
+	}
+	meta := ""
+	if len(parts) == 2 {
+		meta = parts[1]
+	}
+	logger.Infof("Misfin message to %s: %d %s", addr, status, meta)
+	return status, meta, nil
+}
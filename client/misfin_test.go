@@ -0,0 +1,23 @@
+package client
+
+import "testing"
+
+var misfinAddressTests = []struct {
+	u        string
+	expected string
+	err      error
+}{
+	{"misfin://alice@example.com", "alice@example.com", nil},
+	{"misfin://alice@example.com:1959/", "alice@example.com:1959", nil},
+	{"misfin://example.com", "", ErrMisfinAddress},
+	{"gemini://alice@example.com", "", ErrMisfinAddress},
+}
+
+func TestMisfinAddress(t *testing.T) {
+	for _, tt := range misfinAddressTests {
+		actual, err := MisfinAddress(tt.u)
+		if actual != tt.expected || err != tt.err {
+			t.Errorf("MisfinAddress(%q): expected %q, %v, actual %q, %v", tt.u, tt.expected, tt.err, actual, err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/logger"
+	"github.com/makeworld-the-better-one/go-gemini"
+)
+
+// The number of requests kept for about:network.
+const maxRequests = 100
+
+// Request is a request made by the client, as shown on about:network.
+type Request struct {
+	URL      string
+	Via      string // The proxy the request went through, if any
+	Start    time.Time
+	Status   int // Zero if the request failed
+	Meta     string
+	Err      error
+	Header   time.Duration // How long it took to get the response header
+	Duration time.Duration // How long it took until the last of the body was read
+	Bytes    int64         // The size of the body that has been read so far
+}
+
+var (
+	requests   []*Request // Oldest first
+	requestsMu sync.Mutex
+)
+
+// countingBody counts the bytes read from a response body for its Request.
+type countingBody struct {
+	io.ReadCloser
+	req *Request
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		requestsMu.Lock()
+		b.req.Bytes += int64(n)
+		b.req.Duration = time.Since(b.req.Start)
+		requestsMu.Unlock()
+	}
+	return n, err
+}
+
+// SetReadDeadline is used by SetReadTimeout.
+func (b *countingBody) SetReadDeadline(t time.Time) error {
+	if body, ok := b.ReadCloser.(interface{ SetReadDeadline(time.Time) error }); ok {
+		return body.SetReadDeadline(t)
+	}
+	return nil
+}
+
+// recordFetch logs the result of a request, and adds it to the requests shown
+// on about:network. The via string is the proxy used, if any. Requests from
+// private tabs are logged without their URL, and aren't added.
+//
+// The body of the response is wrapped so that the bytes read from it are counted.
+func recordFetch(u, via string, start time.Time, res *gemini.Response, err error, private bool) {
+	req := &Request{
+		URL:    u,
+		Via:    via,
+		Start:  start,
+		Err:    err,
+		Header: time.Since(start),
+	}
+	req.Duration = req.Header
+
+	logged := u
+	if private {
+		logged = "from a private tab"
+	} else if via != "" {
+		logged += " via " + via
+	}
+	took := req.Header.Round(time.Millisecond)
+	if err != nil {
+		logger.Warnf("Fetch %s failed after %s: %v", logged, took, err)
+	} else {
+		logger.Infof("Fetch %s: %d %s (%s)", logged, res.Status, res.Meta, took)
+		req.Status = res.Status
+		req.Meta = res.Meta
+		if res.Body != nil && !private {
+			res.Body = &countingBody{res.Body, req}
+		}
+	}
+	if private {
+		return
+	}
+
+	requestsMu.Lock()
+	defer requestsMu.Unlock()
+	if len(requests) >= maxRequests {
+		requests = requests[1:]
+	}
+	requests = append(requests, req)
+}
+
+// Requests returns the most recent requests, newest first.
+func Requests() []Request {
+	requestsMu.Lock()
+	defer requestsMu.Unlock()
+	reqs := make([]Request, len(requests))
+	for i, req := range requests {
+		reqs[len(requests)-1-i] = *req
+	}
+	return reqs
+}
+
+// ClearRequests forgets the requests that have been made.
+func ClearRequests() {
+	requestsMu.Lock()
+	defer requestsMu.Unlock()
+	requests = nil
+}
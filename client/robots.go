@@ -0,0 +1,177 @@
+package client
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/makeworld-the-better-one/go-gemini"
+)
+
+// Automated fetching, like checking links, follows the robots.txt of each
+// capsule, as described in the Gemini robots.txt companion specification.
+
+// How long a capsule's robots.txt is used before it's fetched again
+const robotsTTL = time.Hour
+
+// The most of a robots.txt that's read
+const maxRobotsSize = 64 * 1024
+
+// robotsRules are the rules of a robots.txt for one user agent. Paths in
+// disallow can't be fetched, unless a longer path in allow says they can.
+type robotsRules struct {
+	allow    []string
+	disallow []string
+}
+
+type robotsEntry struct {
+	ready   chan struct{} // Closed once rules is set
+	rules   robotsRules
+	fetched time.Time
+}
+
+var (
+	robotsMu    sync.Mutex
+	robotsCache = make(map[string]*robotsEntry) // Keyed by host and port
+)
+
+// parseRobots returns the rules of the robots.txt for the first of the user
+// agents that has a group in it. If none do, the rules for "*" are used.
+func parseRobots(r io.Reader, agents ...string) robotsRules {
+	groups := make(map[string]*robotsRules)
+	var current []*robotsRules
+	inAgents := false // The last line was a User-agent line
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i != -1 {
+			line = line[:i]
+		}
+		i := strings.IndexByte(line, ':')
+		if i == -1 {
+			continue
+		}
+		field := strings.ToLower(strings.TrimSpace(line[:i]))
+		value := strings.TrimSpace(line[i+1:])
+
+		switch field {
+		case "user-agent":
+			if !inAgents {
+				current = nil
+			}
+			inAgents = true
+			agent := strings.ToLower(value)
+			if groups[agent] == nil {
+				groups[agent] = &robotsRules{}
+			}
+			current = append(current, groups[agent])
+		case "allow", "disallow":
+			inAgents = false
+			if value == "" {
+				// An empty Disallow allows everything
+				continue
+			}
+			for _, g := range current {
+				if field == "allow" {
+					g.allow = append(g.allow, value)
+				} else {
+					g.disallow = append(g.disallow, value)
+				}
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	for _, agent := range agents {
+		if g, ok := groups[strings.ToLower(agent)]; ok {
+			return *g
+		}
+	}
+	if g, ok := groups["*"]; ok {
+		return *g
+	}
+	return robotsRules{}
+}
+
+// allowed returns true if the rules allow fetching the path.
+func (r robotsRules) allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	longest := func(prefixes []string) int {
+		n := -1
+		for _, p := range prefixes {
+			if strings.HasPrefix(path, p) && len(p) > n {
+				n = len(p)
+			}
+		}
+		return n
+	}
+	d := longest(r.disallow)
+	return d == -1 || longest(r.allow) >= d
+}
+
+// fetchRobots fetches the robots.txt of the host. If there isn't one, or it
+// can't be fetched, there are no rules.
+func fetchRobots(host string, agents []string) robotsRules {
+	res, err := Fetch("gemini://" + host + "/robots.txt")
+	if err != nil {
+		return robotsRules{}
+	}
+	defer res.Body.Close()
+	if res.Status != gemini.StatusSuccess || !strings.HasPrefix(res.Meta, "text/plain") {
+		return robotsRules{}
+	}
+	return parseRobots(io.LimitReader(res.Body, maxRobotsSize), agents...)
+}
+
+// RobotsAllowed returns true if the robots.txt of the Gemini URL's capsule
+// allows fetching it, for the first of the user agents it has rules for.
+// The "*" user agent is always used if none match. Automated fetching should
+// pass one of the virtual user agents from the specification, like "researcher".
+//
+// The robots.txt is fetched the first time, and then cached for a while.
+// URLs that aren't Gemini URLs, or that can't be parsed, are always allowed.
+func RobotsAllowed(u string, agents ...string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Scheme != "gemini" {
+		return true
+	}
+	host := strings.ToLower(parsed.Host)
+	if parsed.Port() == "" {
+		host += ":1965"
+	}
+	key := host + " " + strings.ToLower(strings.Join(agents, " "))
+
+	robotsMu.Lock()
+	entry, ok := robotsCache[key]
+	if !ok || (isClosed(entry.ready) && time.Since(entry.fetched) > robotsTTL) {
+		entry = &robotsEntry{ready: make(chan struct{})}
+		robotsCache[key] = entry
+		robotsMu.Unlock()
+
+		entry.rules = fetchRobots(host, agents)
+		entry.fetched = time.Now()
+		close(entry.ready)
+	} else {
+		robotsMu.Unlock()
+		// Another request could still be fetching it
+		<-entry.ready
+	}
+
+	return entry.rules.allowed(parsed.EscapedPath())
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRobotsAllowed(t *testing.T) {
+	robots := `# Comments are ignored
+User-agent: archiver
+User-agent: indexer
+Disallow: /
+
+User-agent: researcher
+Disallow: /private/
+Allow: /private/public/
+
+User-agent: *
+Disallow: /cgi-bin/ # Not for anyone
+`
+	tests := []struct {
+		name   string
+		agents []string
+		path   string
+		want   bool
+	}{
+		{"all disallowed", []string{"indexer"}, "/page.gmi", false},
+		{"group with two agents", []string{"archiver"}, "/", false},
+		{"allowed path", []string{"researcher"}, "/page.gmi", true},
+		{"disallowed path", []string{"researcher"}, "/private/page.gmi", false},
+		{"longer allow", []string{"researcher"}, "/private/public/page.gmi", true},
+		{"only the matching group", []string{"researcher"}, "/cgi-bin/x", true},
+		{"first matching agent", []string{"amfora", "researcher"}, "/private/", false},
+		{"fallback to star", []string{"webproxy"}, "/cgi-bin/x", false},
+		{"star allowed", []string{"webproxy"}, "/private/", true},
+		{"empty path", []string{"indexer"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := parseRobots(strings.NewReader(robots), tt.agents...)
+			if got := rules.allowed(tt.path); got != tt.want {
+				t.Errorf("allowed(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	empty := parseRobots(strings.NewReader("User-agent: *\nDisallow:\n"), "researcher")
+	if !empty.allowed("/") {
+		t.Error("an empty Disallow should allow everything")
+	}
+}
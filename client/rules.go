@@ -0,0 +1,100 @@
+package client
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/spf13/viper"
+)
+
+// Rule is how connections to a host are made, as set in the
+// connection-rules section of the config.
+type Rule int
+
+const (
+	RuleNone    Rule = iota // No rule, connect like normal
+	RuleBlock               // Never connect to the host
+	RuleConfirm             // Ask the user before connecting
+	RuleTor                 // Only connect through Tor
+	RuleProxy               // Send all requests through a Gemini proxy
+)
+
+// ErrBlocked is returned when fetching a URL whose host is blocked, in the
+// config or with BlockHost.
+var ErrBlocked = errors.New("connecting to this host is blocked")
+
+// blocklistMu protects config.BlocklistStore, since viper is not thread-safe.
+var blocklistMu = sync.RWMutex{}
+
+// blocklisted returns true if the host was blocked with BlockHost. Entries
+// can also be wildcards, like "*.example.com", if the file is edited by hand.
+func blocklisted(host string) bool {
+	blocklistMu.RLock()
+	defer blocklistMu.RUnlock()
+	return config.HasHost(config.BlocklistStore.GetStringSlice("hosts"), host)
+}
+
+// BlockHost adds the host to the blocklist, and saves it. Blocked hosts
+// have the RuleBlock rule, whatever the connection-rules section says.
+func BlockHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if blocklisted(host) {
+		return nil
+	}
+
+	blocklistMu.Lock()
+	defer blocklistMu.Unlock()
+
+	hosts := config.BlocklistStore.GetStringSlice("hosts")
+	config.BlocklistStore.Set("hosts", append(hosts, host))
+	return config.BlocklistStore.WriteConfig()
+}
+
+// IsBlocked returns true if connecting to the host is blocked.
+func IsBlocked(host string) bool {
+	rule, _ := HostRule(host)
+	return rule == RuleBlock
+}
+
+// hostValue returns the string set for the host in the config section.
+// The host itself is looked up first, and then wildcards matching its
+// parent domains, like "*.example.com".
+func hostValue(section, host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return ""
+	}
+
+	value := strings.TrimSpace(viper.GetString(section + "." + host))
+	for parent := host; value == ""; {
+		i := strings.Index(parent, ".")
+		if i == -1 {
+			return ""
+		}
+		parent = parent[i+1:]
+		value = strings.TrimSpace(viper.GetString(section + ".*." + parent))
+	}
+	return value
+}
+
+// HostRule returns the connection rule for the host. For RuleProxy, the
+// address of the proxy is returned as well.
+func HostRule(host string) (Rule, string) {
+	if host != "" && blocklisted(strings.TrimSuffix(strings.ToLower(host), ".")) {
+		return RuleBlock, ""
+	}
+	value := hostValue("connection-rules", host)
+	switch strings.ToLower(value) {
+	case "":
+		return RuleNone, ""
+	case "block":
+		return RuleBlock, ""
+	case "confirm":
+		return RuleConfirm, ""
+	case "tor":
+		return RuleTor, ""
+	}
+	return RuleProxy, value
+}
@@ -0,0 +1,33 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/spf13/viper"
+)
+
+func TestHostRuleBlocklist(t *testing.T) {
+	config.BlocklistStore.Set("hosts", []string{"bad.example.com", "*.spam.example"})
+	viper.Set("connection-rules.bad.example.com", "tor")
+	defer func() {
+		config.BlocklistStore.Set("hosts", []string{})
+		viper.Set("connection-rules.bad.example.com", "")
+	}()
+
+	tests := []struct {
+		host string
+		want Rule
+	}{
+		{"bad.example.com", RuleBlock}, // The blocklist comes first
+		{"BAD.example.com.", RuleBlock},
+		{"a.spam.example", RuleBlock},
+		{"example.com", RuleNone},
+		{"spam.example", RuleNone},
+	}
+	for _, tt := range tests {
+		if got, _ := HostRule(tt.host); got != tt.want {
+			t.Errorf("HostRule(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
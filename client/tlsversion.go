@@ -0,0 +1,67 @@
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	// The TLS version negotiated with each address, for the latest connection
+	tlsVersions   = make(map[string]uint16)
+	tlsVersionsMu = &sync.RWMutex{}
+)
+
+var tlsVersionNames = map[uint16]string{
+	tls.VersionTLS10: "1.0",
+	tls.VersionTLS11: "1.1",
+	tls.VersionTLS12: "1.2",
+	tls.VersionTLS13: "1.3",
+}
+
+// TLSVersionName returns a human readable name for the TLS version, like "TLS 1.3".
+func TLSVersionName(version uint16) string {
+	if name, ok := tlsVersionNames[version]; ok {
+		return "TLS " + name
+	}
+	return fmt.Sprintf("Unknown (0x%04x)", version)
+}
+
+// parseTLSVersion parses a version like "1.3". It returns 0 if the version
+// isn't valid.
+func parseTLSVersion(s string) uint16 {
+	s = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "tls")
+	s = strings.TrimSpace(s)
+	for version, name := range tlsVersionNames {
+		if name == s {
+			return version
+		}
+	}
+	return 0
+}
+
+// minTLSVersion returns the lowest TLS version allowed for the host.
+func minTLSVersion(host string) uint16 {
+	if version := parseTLSVersion(hostValue("tls-exceptions", host)); version != 0 {
+		return version
+	}
+	if version := parseTLSVersion(viper.GetString("a-general.tls_min_version")); version != 0 {
+		return version
+	}
+	return tls.VersionTLS12
+}
+
+// TLSVersion returns the TLS version used for the latest connection to the
+// host and port, or 0 if it's not known.
+func TLSVersion(host, port string) uint16 {
+	if port == "" {
+		port = "1965"
+	}
+	tlsVersionsMu.RLock()
+	defer tlsVersionsMu.RUnlock()
+	return tlsVersions[net.JoinHostPort(host, port)]
+}
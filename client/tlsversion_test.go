@@ -0,0 +1,164 @@
+package client
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// geminiServer starts a server for 127.0.0.1 that responds to each request
+// with a "20 text/gemini" header, using TLS versions from min to max.
+func geminiServer(t *testing.T, min, max uint16) net.Listener {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: priv}},
+		MinVersion:   min,
+		MaxVersion:   max,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if _, err := bufio.NewReader(conn).ReadString('\n'); err == nil {
+					conn.Write([]byte("20 text/gemini\r\n")) //nolint:errcheck
+				}
+			}()
+		}
+	}()
+	return ln
+}
+
+func TestFetchTLSVersion(t *testing.T) {
+	defer viper.Reset()
+	c := &geminiClient{
+		connectTimeout: 5 * time.Second,
+		readTimeout:    5 * time.Second,
+		dial: func(d *net.Dialer, address string) (net.Conn, error) {
+			return d.Dial("tcp", address)
+		},
+	}
+
+	tls11 := geminiServer(t, tls.VersionTLS11, tls.VersionTLS11)
+	defer tls11.Close()
+	tls12 := geminiServer(t, tls.VersionTLS12, tls.VersionTLS12)
+	defer tls12.Close()
+	tls13 := geminiServer(t, tls.VersionTLS12, tls.VersionTLS13)
+	defer tls13.Close()
+
+	tests := []struct {
+		min       string
+		exception string // For 127.0.0.1
+		ln        net.Listener
+		version   uint16 // Zero if the connection should fail
+	}{
+		{"1.2", "", tls13, tls.VersionTLS13},
+		{"1.2", "", tls12, tls.VersionTLS12},
+		{"1.3", "", tls12, 0},
+		{"1.3", "", tls13, tls.VersionTLS13},
+		{"1.2", "", tls11, 0},
+		{"1.2", "1.1", tls11, tls.VersionTLS11},
+		{"1.3", "1.2", tls12, tls.VersionTLS12},
+	}
+	for i, tt := range tests {
+		viper.Set("a-general.tls_min_version", tt.min)
+		viper.Set("tls-exceptions.127.0.0.1", tt.exception)
+		address := tt.ln.Addr().String()
+		res, err := c.fetch(address, "gemini://127.0.0.1/", nil, nil)
+		if tt.version == 0 {
+			if err == nil {
+				res.Body.Close()
+				t.Errorf("%d: the connection should have failed", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		res.Body.Close()
+		host, port, _ := net.SplitHostPort(address)
+		if got := TLSVersion(host, port); got != tt.version || res.Status != 20 {
+			t.Errorf("%d: got %s and status %d, want %s", i, TLSVersionName(got), res.Status, TLSVersionName(tt.version))
+		}
+	}
+}
+
+func TestFetchNoReadTimeout(t *testing.T) {
+	// A page_max_time of zero means there's no limit
+	c := &geminiClient{
+		connectTimeout: 5 * time.Second,
+		readTimeout:    0,
+		dial: func(d *net.Dialer, address string) (net.Conn, error) {
+			return d.Dial("tcp", address)
+		},
+	}
+	ln := geminiServer(t, tls.VersionTLS12, tls.VersionTLS13)
+	defer ln.Close()
+
+	res, err := c.fetch(ln.Addr().String(), "gemini://127.0.0.1/", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.Status != 20 {
+		t.Errorf("got status %d, want 20", res.Status)
+	}
+	if _, err := ioutil.ReadAll(res.Body); err != nil {
+		t.Errorf("reading the body: %v", err)
+	}
+}
+
+func TestReadHeader(t *testing.T) {
+	tests := []struct {
+		header string
+		status int
+		meta   string
+		ok     bool
+	}{
+		{"20 text/gemini\r\n", 20, "text/gemini", true},
+		{"51 Not found\n", 51, "Not found", true},
+		{"20\r\n", 20, "", true},
+		{"2 text/gemini\r\n", 0, "", false},
+		{"20text/gemini\r\n", 0, "", false},
+		{"ab text/gemini\r\n", 0, "", false},
+		{"20 text/gemini", 0, "", false},
+		{"20 " + string(make([]byte, 1025)) + "\r\n", 0, "", false},
+	}
+	for i, tt := range tests {
+		status, meta, err := readHeader(bufio.NewReader(strings.NewReader(tt.header)))
+		if (err == nil) != tt.ok || status != tt.status || meta != tt.meta {
+			t.Errorf("%d: got %d %q %v", i, status, meta, err)
+		}
+	}
+}
@@ -44,6 +44,13 @@ func expiryKey(domain string, port string) string {
 	return strings.ReplaceAll(strings.TrimSuffix(domain, "."), ".", "/") + "/expiry" + ":" + port
 }
 
+func addedKey(domain string, port string) string {
+	if port == "1965" || port == "" {
+		return strings.ReplaceAll(strings.TrimSuffix(domain, "."), ".", "/") + "/added"
+	}
+	return strings.ReplaceAll(strings.TrimSuffix(domain, "."), ".", "/") + "/added" + ":" + port
+}
+
 func loadTofuEntry(domain string, port string) (string, time.Time, error) {
 	tofuStoreMu.RLock()
 	defer tofuStoreMu.RUnlock()
@@ -81,6 +88,11 @@ func saveTofuEntry(domain, port string, cert *x509.Certificate) {
 	tofuStoreMu.Lock()
 	defer tofuStoreMu.Unlock()
 
+	oldID := tofuStore.GetString(idKey(domain, port))
+	if oldID != certID(cert) && oldID != origCertID(cert) {
+		// A different cert is being trusted, not just an update of the stored data
+		tofuStore.Set(addedKey(domain, port), time.Now().UTC())
+	}
 	tofuStore.Set(idKey(domain, port), certID(cert))
 	tofuStore.Set(expiryKey(domain, port), cert.NotAfter.UTC())
 	tofuStore.WriteConfig() //nolint:errcheck // Not an issue if it's not saved, only cached data
@@ -136,3 +148,42 @@ func GetExpiry(domain, port string) time.Time {
 
 	return tofuStore.GetTime(expiryKey(domain, port))
 }
+
+// GetTrustedSince returns when the stored cert for the given host was first trusted.
+// The time will be empty (zero) if it isn't known, for example for certs
+// stored by older versions of Amfora.
+func GetTrustedSince(domain, port string) time.Time {
+	tofuStoreMu.RLock()
+	defer tofuStoreMu.RUnlock()
+
+	return tofuStore.GetTime(addedKey(domain, port))
+}
+
+// GetFingerprint returns the stored fingerprint for the given host,
+// or an empty string if there is none.
+func GetFingerprint(domain, port string) string {
+	id, _, err := loadTofuEntry(domain, port)
+	if err != nil {
+		return ""
+	}
+	return id
+}
+
+// CertFingerprint returns the fingerprint of the cert, as it would be
+// stored in the TOFU database.
+func CertFingerprint(cert *x509.Certificate) string {
+	return certID(cert)
+}
+
+// ClearTofu forgets all the trusted server certificates, so the next ones
+// seen are trusted instead. Keys can't be removed from viper, so they're set
+// to empty values, which are treated like entries that don't exist.
+func ClearTofu() error {
+	tofuStoreMu.Lock()
+	defer tofuStoreMu.Unlock()
+
+	for _, key := range tofuStore.AllKeys() {
+		tofuStore.Set(key, "")
+	}
+	return tofuStore.WriteConfig()
+}
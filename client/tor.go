@@ -0,0 +1,93 @@
+package client
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/spf13/viper"
+	"golang.org/x/net/proxy"
+)
+
+// ErrNoTor is returned when connecting to a host that requires Tor, like
+// an onion service, without a Tor proxy set.
+var ErrNoTor = errors.New("a Tor proxy must be set in the config to connect to this host")
+
+// IsOnion returns true if the host is a Tor onion service.
+func IsOnion(host string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(strings.ToLower(host), "."), ".onion")
+}
+
+// RequiresTor returns true if the host can only be connected to through Tor.
+func RequiresTor(host string) bool {
+	if IsOnion(host) {
+		return true
+	}
+	rule, _ := HostRule(host)
+	return rule == RuleTor
+}
+
+// UsesTor returns true if connections to the host go through the Tor proxy.
+func UsesTor(host string) bool {
+	if viper.GetString("tor.proxy") == "" {
+		return false
+	}
+	return viper.GetBool("tor.all") || RequiresTor(host)
+}
+
+// TorProxy returns the address of the Tor SOCKS proxy to use for the host,
+// or an empty string if the connection should be direct.
+// ErrNoTor is returned for hosts that require Tor when there is no proxy to use.
+func TorProxy(host string) (string, error) {
+	if UsesTor(host) {
+		return viper.GetString("tor.proxy"), nil
+	}
+	if RequiresTor(host) {
+		return "", ErrNoTor
+	}
+	return "", nil
+}
+
+// dialTor makes a connection through the Tor proxy. The hostname is passed
+// to the proxy as is, so it's resolved by Tor instead of leaking through
+// local DNS.
+func dialTor(dialer *net.Dialer, address string) (net.Conn, error) {
+	torAddr := viper.GetString("tor.proxy")
+	if torAddr == "" {
+		return nil, ErrNoTor
+	}
+	socks, err := proxy.SOCKS5("tcp", torAddr, nil, dialer)
+	if err != nil {
+		return nil, err
+	}
+	return socks.Dial("tcp", address)
+}
+
+// dial is used by the Gemini client to make connections, so they can
+// be blocked or sent through Tor depending on the host. Direct connections
+// look up the host with the DNS server from the config, see dns.go.
+func dial(dialer *net.Dialer, address string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	if rule, _ := HostRule(host); rule == RuleBlock {
+		return nil, ErrBlocked
+	}
+	torAddr, err := TorProxy(host)
+	if err != nil {
+		return nil, err
+	}
+	if torAddr == "" {
+		if dnsErr != nil {
+			return nil, dnsErr
+		}
+		if resolver != nil {
+			d := *dialer
+			d.Resolver = resolver
+			return d.Dial("tcp", address)
+		}
+		return dialer.Dial("tcp", address)
+	}
+	return dialTor(dialer, address)
+}
@@ -5,15 +5,21 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strings"
+	"sync"
 
 	"code.rocketnine.space/tslocum/cview"
 	"github.com/gdamore/tcell/v2"
 	"github.com/makeworld-the-better-one/amfora/cache"
+	"github.com/makeworld-the-better-one/amfora/logger"
+	"github.com/makeworld-the-better-one/amfora/termimg"
+	"github.com/mattn/go-runewidth"
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/rkoesters/xdg/basedir"
 	"github.com/rkoesters/xdg/userdirs"
@@ -27,6 +33,12 @@ var configPath string
 var NewTabPath string
 var CustomNewTab bool
 
+// Where translations of the interface are, see the i18n package
+var LocalesDir string
+
+// Where the user's own about pages are, like work.gmi for about:work
+var AboutDir string
+
 var TofuStore = viper.New()
 var tofuDBDir string
 var tofuDBPath string
@@ -34,8 +46,20 @@ var tofuDBPath string
 // Bookmarks
 var BkmkStore = viper.New() // TOML API for old bookmarks file
 var bkmkDir string
-var OldBkmkPath string // Old bookmarks file that used TOML format
-var BkmkPath string    // New XBEL (XML) bookmarks file, see #68
+var OldBkmkPath string     // Old bookmarks file that used TOML format
+var BkmkPath string        // New XBEL (XML) bookmarks file, see #68
+var GemtextBkmkPath string // Gemtext bookmarks file, used instead if "a-general.bookmarks_format" is "gemtext"
+
+// Where the last check for a new release is saved
+var UpdateCheckPath string
+
+// Hosts that redirects are always followed from, added from the redirect prompt
+var RedirectStore = viper.New()
+var redirectPath string
+
+// Hosts blocked from inside Amfora, see client.BlockHost
+var BlocklistStore = viper.New()
+var blocklistPath string
 
 var DownloadsDir string
 var TempDownloadsDir string
@@ -44,6 +68,25 @@ var TempDownloadsDir string
 var subscriptionDir string
 var SubscriptionPath string
 
+// Where the open tabs are saved when Amfora crashes
+var CrashSessionPath string
+
+// Where the scroll positions of recently viewed pages are saved
+var ScrollPath string
+
+// Where what was typed in the bottom bar is saved
+var InputHistoryPath string
+
+// Reading list, and the directory for the saved copies of its pages
+var ReadingListPath string
+var ReadingListDir string
+
+// Permanent redirects that were followed, so they're known before connecting
+var RedirectCachePath string
+
+// Where snapshots of pages are archived
+var ArchiveDir string
+
 // Command for opening HTTP(S) URLs in the browser, from "a-general.http" in config.
 var HTTPCommand []string
 
@@ -55,10 +98,74 @@ type MediaHandler struct {
 
 var MediaHandlers = make(map[string]MediaHandler)
 
+// MediaFilter is a command that converts responses of some mediatype into
+// text that Amfora can display.
+type MediaFilter struct {
+	Cmd    []string
+	Output string // The mediatype of the command's output, like "text/gemini", or empty to keep it the same
+}
+
+// HostMediaFilter is a MediaFilter that's only used for pages from some hosts.
+type HostMediaFilter struct {
+	MediaFilter
+	Hosts []string
+	Types []string // Mediatypes, or just types like "text"
+}
+
+// Filters from the "mediatype-filters" config section, by mediatype.
+// Keys can also be just a type, like "image".
+var MediaFilters = make(map[string]MediaFilter)
+
+// Filters from the "mediatype-filters" config section that have hosts,
+// in the order they're in. They're used before MediaFilters.
+var HostMediaFilters []HostMediaFilter
+
+// ContentRule changes gemtext pages from some hosts before they're rendered,
+// see the "content-rules" config section.
+type ContentRule struct {
+	Hosts        []string
+	Hide         []*regexp.Regexp // Lines that match are hidden
+	LinkText     []LinkTextRule
+	Preformatted bool // Text lines are shown as preformatted text
+}
+
+// LinkTextRule replaces matches of the pattern in the text of links.
+type LinkTextRule struct {
+	Pattern *regexp.Regexp
+	Replace string // Can use $1 for submatches, like regexp.ReplaceAllString
+}
+
+// Rules from the "content-rules" config section, in the order they're in.
+var ContentRules []ContentRule
+
+// HasHost returns true if the host is one of the hosts, or a subdomain of a
+// wildcard one, like "*.example.com". The hosts should be lowercase.
+func HasHost(hosts []string, host string) bool {
+	host = strings.TrimSuffix(host, ".")
+	for _, h := range hosts {
+		if h == host || (strings.HasPrefix(h, "*.") && strings.HasSuffix(host, h[1:])) {
+			return true
+		}
+	}
+	return false
+}
+
+// The commands and arguments of the plugins from the "plugins" config section.
+// See the plugins package.
+var Plugins [][]string
+
 // Controlled by "a-general.scrollbar" in config
 // Defaults to ScrollBarAuto on an invalid value
 var ScrollBar cview.ScrollBarVisibility
 
+// Whether screen reader mode is on, which makes the interface
+// easier for screen readers to read. See "a-general.screen_reader".
+var ScreenReader bool
+
+// Controlled by "a-general.image_protocol" in config
+// None means the terminal can't display images, see "a-general.image_fallback"
+var ImageProtocol termimg.Protocol
+
 func Init() error {
 
 	// *** Set paths ***
@@ -86,6 +193,12 @@ func Init() error {
 	}
 	configPath = filepath.Join(configDir, "config.toml")
 
+	// Translations of the interface
+	LocalesDir = filepath.Join(configDir, "locales")
+
+	// Gemtext files that are shown as about pages
+	AboutDir = filepath.Join(configDir, "about")
+
 	// Search for a custom new tab
 	NewTabPath = filepath.Join(configDir, "newtab.gmi")
 	CustomNewTab = false
@@ -113,6 +226,17 @@ func Init() error {
 	}
 	OldBkmkPath = filepath.Join(bkmkDir, "bookmarks.toml")
 	BkmkPath = filepath.Join(bkmkDir, "bookmarks.xml")
+	GemtextBkmkPath = filepath.Join(bkmkDir, "bookmarks.gmi")
+	UpdateCheckPath = filepath.Join(bkmkDir, "update-check.json")
+	redirectPath = filepath.Join(bkmkDir, "redirects.toml")
+	blocklistPath = filepath.Join(bkmkDir, "blocklist.toml")
+	CrashSessionPath = filepath.Join(bkmkDir, "crashed-tabs.json")
+	ScrollPath = filepath.Join(bkmkDir, "scroll.json")
+	InputHistoryPath = filepath.Join(bkmkDir, "input-history.json")
+	ReadingListPath = filepath.Join(bkmkDir, "reading-list.json")
+	RedirectCachePath = filepath.Join(bkmkDir, "permanent-redirects.json")
+	ReadingListDir = filepath.Join(bkmkDir, "reading-list")
+	ArchiveDir = filepath.Join(bkmkDir, "archive")
 
 	// Feeds dir and path
 	if runtime.GOOS == "windows" {
@@ -140,6 +264,7 @@ func Init() error {
 	f, err := os.OpenFile(configPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
 	if err == nil {
 		// Config file doesn't exist yet, write the default one
+		logger.Infof("Writing the default config to %s", configPath)
 		_, err = f.Write(defaultConf)
 		if err != nil {
 			f.Close()
@@ -163,6 +288,17 @@ func Init() error {
 	}
 	// OldBkmkPath isn't created because it shouldn't be there anyway
 
+	// Redirects, stored beside bookmarks
+	f, err = os.OpenFile(redirectPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+	if err == nil {
+		f.Close()
+	}
+	// Same for the blocklist
+	f, err = os.OpenFile(blocklistPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+	if err == nil {
+		f.Close()
+	}
+
 	// Feeds
 	err = os.MkdirAll(subscriptionDir, 0755)
 	if err != nil {
@@ -171,11 +307,31 @@ func Init() error {
 
 	// *** Setup vipers ***
 
-	TofuStore.SetConfigFile(tofuDBPath)
-	TofuStore.SetConfigType("toml")
-	err = TofuStore.ReadInConfig()
-	if err != nil {
-		return err
+	// Each is a file that can be slow to read on some disks, so they're read at once
+	stores := []struct {
+		v    *viper.Viper
+		path string
+	}{
+		{TofuStore, tofuDBPath},
+		{RedirectStore, redirectPath},
+		{BlocklistStore, blocklistPath},
+	}
+	storeErrs := make([]error, len(stores))
+	var wg sync.WaitGroup
+	for i := range stores {
+		stores[i].v.SetConfigFile(stores[i].path)
+		stores[i].v.SetConfigType("toml")
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			storeErrs[i] = stores[i].v.ReadInConfig()
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range storeErrs {
+		if err != nil {
+			return err
+		}
 	}
 
 	BkmkStore.SetConfigFile(OldBkmkPath)
@@ -190,19 +346,54 @@ func Init() error {
 
 	viper.SetDefault("a-general.home", "gemini://gemini.circumlunar.space")
 	viper.SetDefault("a-general.auto_redirect", false)
+	viper.SetDefault("a-general.cross_host_redirects", "follow")
 	viper.SetDefault("a-general.http", "default")
 	viper.SetDefault("a-general.search", "gemini://geminispace.info/search")
 	viper.SetDefault("a-general.color", true)
 	viper.SetDefault("a-general.ansi", true)
 	viper.SetDefault("a-general.bullets", true)
+	viper.SetDefault("a-general.bullet", "\u2022")
+	viper.SetDefault("a-general.quote_marker", ">")
+	viper.SetDefault("a-general.heading_markers", []string{"#", "##", "###"})
 	viper.SetDefault("a-general.show_link", false)
+	viper.SetDefault("a-general.link_scheme", false)
+	viper.SetDefault("a-general.link_markers", "numbers")
+	viper.SetDefault("a-general.compact_links", false)
+	viper.SetDefault("a-general.underline_links", false)
+	viper.SetDefault("a-general.link_destination", "full")
 	viper.SetDefault("a-general.left_margin", 0.15)
 	viper.SetDefault("a-general.max_width", 100)
+	viper.SetDefault("a-general.tables", false)
+	viper.SetDefault("a-general.bidi", true)
+	viper.SetDefault("a-general.ambiguous_width", 0)
+	viper.SetDefault("a-general.justify", false)
+	viper.SetDefault("a-general.table_borders", true)
+	viper.SetDefault("a-general.pre_alt_text", "hidden")
+	viper.SetDefault("a-general.wrap_pre", false)
 	viper.SetDefault("a-general.downloads", "")
 	viper.SetDefault("a-general.temp_downloads", "")
 	viper.SetDefault("a-general.page_max_size", 2097152)
+	viper.SetDefault("a-general.stream_max_size", 52428800)
 	viper.SetDefault("a-general.page_max_time", 10)
+	viper.SetDefault("a-general.auto_retries", 0)
+	viper.SetDefault("a-general.tls_min_version", "1.2")
+	viper.SetDefault("a-general.security_indicator", true)
+	viper.SetDefault("a-general.status_format", "{url}")
+	viper.SetDefault("a-general.language", "")
+	viper.SetDefault("a-general.screen_reader", false)
+	viper.SetDefault("a-general.zen_mode", false)
+	viper.SetDefault("a-general.single_tab", false)
+	viper.SetDefault("a-general.pipe_rendered", false)
+	viper.SetDefault("a-general.editing_mode", "emacs")
+	viper.SetDefault("a-general.clear_on_exit", []string{})
+	viper.SetDefault("a-general.bookmarks_format", "xbel")
+	viper.SetDefault("a-general.update_check", "")
+	viper.SetDefault("auth.expiry_warning", 14)
 	viper.SetDefault("a-general.scrollbar", "auto")
+	viper.SetDefault("a-general.image_protocol", "auto")
+	viper.SetDefault("a-general.image_fallback", "blocks")
+	viper.SetDefault("a-general.log_file", "")
+	viper.SetDefault("a-general.log_level", "info")
 	viper.SetDefault("keybindings.bind_reload", []string{"R", "Ctrl-R"})
 	viper.SetDefault("keybindings.bind_home", "Backspace")
 	viper.SetDefault("keybindings.bind_bookmarks", "Ctrl-B")
@@ -250,21 +441,75 @@ func Init() error {
 	viper.SetDefault("keybindings.bind_copy_target_url", "c")
 	viper.SetDefault("keybindings.bind_beginning", []string{"Home", "g"})
 	viper.SetDefault("keybindings.bind_end", []string{"End", "G"})
+	viper.SetDefault("keybindings.bind_preview_image", "i")
+	viper.SetDefault("keybindings.bind_cert_info", "K")
+	viper.SetDefault("keybindings.bind_input_editor", "Ctrl-O")
+	viper.SetDefault("keybindings.bind_view_as", "V")
+	viper.SetDefault("keybindings.bind_page_info", "I")
+	viper.SetDefault("keybindings.bind_copy_heading_url", "Alt-c")
+	viper.SetDefault("keybindings.bind_wrap_pre", "W")
+	viper.SetDefault("keybindings.bind_read_later", "L")
+	viper.SetDefault("keybindings.bind_reading_list", "Ctrl-L")
+	viper.SetDefault("keybindings.bind_archive", "A")
+	viper.SetDefault("keybindings.bind_diff", "D")
+	viper.SetDefault("keybindings.bind_stop", "Ctrl-K")
+	viper.SetDefault("keybindings.bind_follow", "F")
+	viper.SetDefault("keybindings.bind_zen", "Z")
+	viper.SetDefault("keybindings.bind_parent", "-")
+	viper.SetDefault("keybindings.bind_root", "_")
+	viper.SetDefault("keybindings.bind_next_page", "]")
+	viper.SetDefault("keybindings.bind_prev_page", "[")
+	viper.SetDefault("keybindings.bind_mark_read", "m")
+	viper.SetDefault("keybindings.bind_mark_feed_read", "M")
+	viper.SetDefault("keybindings.bind_show_read", "H")
+	viper.SetDefault("keybindings.bind_next_unread", "n")
+	viper.SetDefault("keybindings.bind_peek", "P")
+	viper.SetDefault("keybindings.bind_split", "|")
+	viper.SetDefault("keybindings.bind_split_below", "\\")
+	viper.SetDefault("keybindings.bind_split_focus", "Alt-o")
+	viper.SetDefault("keybindings.bind_follow_in_pane", "Alt-Enter")
+	viper.SetDefault("keybindings.bind_pager", "v")
+	viper.SetDefault("keybindings.bind_pre_block", "p")
+	viper.SetDefault("keybindings.bind_pipe", "Ctrl-P")
+	viper.SetDefault("keybindings.bind_block_host", "B")
+	viper.SetDefault("keybindings.bind_new_private_tab", "Ctrl-N")
+	viper.SetDefault("keybindings.bind_tab_identity", "Alt-i")
+	viper.SetDefault("keybindings.bind_record_macro", "Q")
+	viper.SetDefault("keybindings.bind_play_macro", "Alt-q")
+	viper.SetDefault("keybindings.bind_finder", "Ctrl-G")
+	viper.SetDefault("keybindings.bind_jump_back", "Alt-,")
+	viper.SetDefault("keybindings.bind_jump_forward", "Alt-.")
+	viper.SetDefault("keybindings.bind_go_to_mark", "`")
+	viper.SetDefault("keybindings.bind_follow_text", "t")
 	viper.SetDefault("keybindings.shift_numbers", "")
 	viper.SetDefault("url-handlers.other", "off")
+	viper.SetDefault("misfin.cert", "")
+	viper.SetDefault("misfin.key", "")
+	viper.SetDefault("tor.proxy", "127.0.0.1:9050")
+	viper.SetDefault("tor.all", false)
+	viper.SetDefault("dns.server", "")
 	viper.SetDefault("cache.max_size", 0)
 	viper.SetDefault("cache.max_pages", 20)
 	viper.SetDefault("cache.timeout", 1800)
+	viper.SetDefault("cache.max_disk_pages", 200)
 	viper.SetDefault("subscriptions.popup", true)
 	viper.SetDefault("subscriptions.update_interval", 1800)
 	viper.SetDefault("subscriptions.workers", 3)
+	viper.SetDefault("subscriptions.host_workers", 1)
+	viper.SetDefault("subscriptions.host_delay", 1)
 	viper.SetDefault("subscriptions.entries_per_page", 20)
+	viper.SetDefault("subscriptions.open_unread_max", 20)
+	viper.SetDefault("subscriptions.show_read", true)
+	viper.SetDefault("subscriptions.group_by", "feed")
 
 	viper.SetConfigFile(configPath)
 	viper.SetConfigType("toml")
-	err = viper.ReadInConfig()
-	if err != nil {
-		return err
+	if !useDefaults {
+		err = viper.ReadInConfig()
+		if err != nil {
+			return parseError(err)
+		}
+		logger.Infof("Loaded config from %s", configPath)
 	}
 
 	// Setup the key bindings
@@ -291,17 +536,20 @@ func Init() error {
 		di, err := os.Stat(dDir)
 		if err == nil {
 			if !di.IsDir() {
-				return fmt.Errorf("downloads path specified is not a directory: %s", dDir)
+				return keyError("a-general.downloads", expectedDir,
+					fmt.Errorf("downloads path specified is not a directory: %s", dDir))
 			}
 		} else if os.IsNotExist(err) {
 			// Try to create path
 			err = os.MkdirAll(dDir, 0755)
 			if err != nil {
-				return fmt.Errorf("downloads path could not be created: %s", dDir)
+				return keyError("a-general.downloads", expectedDir,
+					fmt.Errorf("downloads path could not be created: %s", dDir))
 			}
 		} else {
 			// Some other error
-			return fmt.Errorf("couldn't access downloads directory: %s", dDir)
+			return keyError("a-general.downloads", expectedDir,
+				fmt.Errorf("couldn't access downloads directory: %s", dDir))
 		}
 		DownloadsDir = dDir
 	}
@@ -321,37 +569,52 @@ func Init() error {
 		di, err := os.Stat(dDir)
 		if err == nil {
 			if !di.IsDir() {
-				return fmt.Errorf("temp downloads path specified is not a directory: %s", dDir)
+				return keyError("a-general.temp_downloads", expectedDir,
+					fmt.Errorf("temp downloads path specified is not a directory: %s", dDir))
 			}
 		} else if os.IsNotExist(err) {
 			// Try to create path
 			err = os.MkdirAll(dDir, 0755)
 			if err != nil {
-				return fmt.Errorf("temp downloads path could not be created: %s", dDir)
+				return keyError("a-general.temp_downloads", expectedDir,
+					fmt.Errorf("temp downloads path could not be created: %s", dDir))
 			}
 		} else {
 			// Some other error
-			return fmt.Errorf("couldn't access temp downloads directory: %s", dDir)
+			return keyError("a-general.temp_downloads", expectedDir,
+				fmt.Errorf("couldn't access temp downloads directory: %s", dDir))
 		}
 		TempDownloadsDir = dDir
 	}
 
+	logger.Debugf("Downloads dir: %s, temp downloads dir: %s", DownloadsDir, TempDownloadsDir)
+
 	// Setup cache from config
 	cache.SetMaxSize(viper.GetInt("cache.max_size"))
 	cache.SetMaxPages(viper.GetInt("cache.max_pages"))
 	cache.SetTimeout(viper.GetInt("cache.timeout"))
+	logger.Debugf("Cache: max size %d, max pages %d, timeout %ds", viper.GetInt("cache.max_size"),
+		viper.GetInt("cache.max_pages"), viper.GetInt("cache.timeout"))
+	cache.SetDisk(filepath.Join(tofuDBDir, "pages"), viper.GetInt("cache.max_disk_pages"))
 
 	// Setup theme
 	configTheme := viper.Sub("theme")
 	if configTheme != nil {
+		// A built-in theme, that the other colors are set on top of
+		if err := setBuiltinTheme(configTheme.GetString("base")); err != nil {
+			return keyError("theme.base", "", err)
+		}
 		for k, v := range configTheme.AllSettings() {
+			if k == "base" {
+				continue
+			}
 			colorStr, ok := v.(string)
 			if !ok {
-				return fmt.Errorf(`value for "%s" is not a string: %v`, k, v)
+				return keyError("theme."+k, expectedColor, fmt.Errorf("value is not a string: %v", v))
 			}
 			color := tcell.GetColor(strings.ToLower(colorStr))
 			if color == tcell.ColorDefault {
-				return fmt.Errorf(`invalid color format for "%s": %s`, k, colorStr)
+				return keyError("theme."+k, expectedColor, fmt.Errorf("invalid color format: %s", colorStr))
 			}
 			SetColor(k, color)
 		}
@@ -377,28 +640,146 @@ func Init() error {
 	}
 	err = viper.UnmarshalKey("mediatype-handlers", &rawMediaHandlers)
 	if err != nil {
-		return fmt.Errorf("couldn't parse mediatype-handlers section in config: %w", err)
+		return keyError("mediatype-handlers", "", fmt.Errorf("couldn't parse it: %w", err))
 	}
 	for _, rawMediaHandler := range rawMediaHandlers {
 		if len(rawMediaHandler.Cmd) == 0 {
-			return fmt.Errorf("empty cmd array in mediatype-handlers section")
+			return keyError("mediatype-handlers", expectedCmd, errors.New("empty cmd array"))
 		}
 		if len(rawMediaHandler.Types) == 0 {
-			return fmt.Errorf("empty types array in mediatype-handlers section")
+			return keyError("mediatype-handlers", "", errors.New("empty types array"))
 		}
 
 		for _, typ := range rawMediaHandler.Types {
 			if _, ok := MediaHandlers[typ]; ok {
-				return fmt.Errorf("multiple mediatype-handlers defined for %v", typ)
+				return keyError("mediatype-handlers", "", fmt.Errorf("multiple mediatype-handlers defined for %v", typ))
 			}
 			MediaHandlers[typ] = MediaHandler{
 				Cmd:      rawMediaHandler.Cmd,
 				NoPrompt: rawMediaHandler.NoPrompt,
 				Stream:   rawMediaHandler.Stream,
 			}
+			logger.Debugf("Mediatype handler for %s: %v", typ, rawMediaHandler.Cmd)
+		}
+	}
+
+	var rawMediaFilters []struct {
+		Cmd    []string `mapstructure:"cmd"`
+		Types  []string `mapstructure:"types"`
+		Hosts  []string `mapstructure:"hosts"`
+		Output string   `mapstructure:"output"`
+	}
+	err = viper.UnmarshalKey("mediatype-filters", &rawMediaFilters)
+	if err != nil {
+		return keyError("mediatype-filters", "", fmt.Errorf("couldn't parse it: %w", err))
+	}
+	for _, rawMediaFilter := range rawMediaFilters {
+		if len(rawMediaFilter.Cmd) == 0 {
+			return keyError("mediatype-filters", expectedCmd, errors.New("empty cmd array"))
+		}
+		if len(rawMediaFilter.Hosts) > 0 {
+			// Filters for hosts change pages that can already be displayed,
+			// so by default they're for text, and output the same mediatype
+			if len(rawMediaFilter.Types) == 0 {
+				rawMediaFilter.Types = []string{"text"}
+			}
+			if rawMediaFilter.Output != "" && !strings.HasPrefix(rawMediaFilter.Output, "text/") {
+				return keyError("mediatype-filters", "",
+					fmt.Errorf("output must be a text mediatype, not %v", rawMediaFilter.Output))
+			}
+			hosts := make([]string, len(rawMediaFilter.Hosts))
+			for i := range rawMediaFilter.Hosts {
+				hosts[i] = strings.ToLower(rawMediaFilter.Hosts[i])
+			}
+			HostMediaFilters = append(HostMediaFilters, HostMediaFilter{
+				MediaFilter: MediaFilter{
+					Cmd:    rawMediaFilter.Cmd,
+					Output: rawMediaFilter.Output,
+				},
+				Hosts: hosts,
+				Types: rawMediaFilter.Types,
+			})
+			logger.Debugf("Mediatype filter for %v on %v: %v, output %s", rawMediaFilter.Types, hosts,
+				rawMediaFilter.Cmd, rawMediaFilter.Output)
+			continue
+		}
+
+		if len(rawMediaFilter.Types) == 0 {
+			return keyError("mediatype-filters", "", errors.New("empty types array"))
+		}
+		if rawMediaFilter.Output == "" {
+			rawMediaFilter.Output = "text/gemini"
+		}
+		if !strings.HasPrefix(rawMediaFilter.Output, "text/") {
+			return keyError("mediatype-filters", "",
+				fmt.Errorf("output must be a text mediatype, not %v", rawMediaFilter.Output))
+		}
+
+		for _, typ := range rawMediaFilter.Types {
+			if _, ok := MediaFilters[typ]; ok {
+				return keyError("mediatype-filters", "", fmt.Errorf("multiple mediatype-filters defined for %v", typ))
+			}
+			MediaFilters[typ] = MediaFilter{
+				Cmd:    rawMediaFilter.Cmd,
+				Output: rawMediaFilter.Output,
+			}
+			logger.Debugf("Mediatype filter for %s: %v, output %s", typ, rawMediaFilter.Cmd, rawMediaFilter.Output)
 		}
 	}
 
+	var rawContentRules []struct {
+		Hosts        []string   `mapstructure:"hosts"`
+		Hide         []string   `mapstructure:"hide"`
+		LinkText     [][]string `mapstructure:"link_text"`
+		Preformatted bool       `mapstructure:"preformatted"`
+	}
+	err = viper.UnmarshalKey("content-rules", &rawContentRules)
+	if err != nil {
+		return keyError("content-rules", "", fmt.Errorf("couldn't parse it: %w", err))
+	}
+	for _, rawRule := range rawContentRules {
+		if len(rawRule.Hosts) == 0 {
+			return keyError("content-rules", "", errors.New("empty hosts array"))
+		}
+		rule := ContentRule{Preformatted: rawRule.Preformatted}
+		for _, host := range rawRule.Hosts {
+			rule.Hosts = append(rule.Hosts, strings.ToLower(host))
+		}
+		for _, pattern := range rawRule.Hide {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return keyError("content-rules", "", fmt.Errorf("invalid hide pattern: %w", err))
+			}
+			rule.Hide = append(rule.Hide, re)
+		}
+		for _, pair := range rawRule.LinkText {
+			if len(pair) != 2 {
+				return keyError("content-rules", "",
+					fmt.Errorf("link_text must have a pattern and a replacement, not %v", pair))
+			}
+			re, err := regexp.Compile(pair[0])
+			if err != nil {
+				return keyError("content-rules", "", fmt.Errorf("invalid link_text pattern: %w", err))
+			}
+			rule.LinkText = append(rule.LinkText, LinkTextRule{Pattern: re, Replace: pair[1]})
+		}
+		ContentRules = append(ContentRules, rule)
+	}
+
+	var rawPlugins []struct {
+		Cmd []string `mapstructure:"cmd"`
+	}
+	err = viper.UnmarshalKey("plugins", &rawPlugins)
+	if err != nil {
+		return keyError("plugins", "", fmt.Errorf("couldn't parse it: %w", err))
+	}
+	for _, rawPlugin := range rawPlugins {
+		if len(rawPlugin.Cmd) == 0 {
+			return keyError("plugins", expectedCmd, errors.New("empty cmd array"))
+		}
+		Plugins = append(Plugins, rawPlugin.Cmd)
+	}
+
 	// Parse scrollbar options
 	switch viper.GetString("a-general.scrollbar") {
 	case "never":
@@ -409,5 +790,21 @@ func Init() error {
 		ScrollBar = cview.ScrollBarAuto
 	}
 
+	ScreenReader = viper.GetBool("a-general.screen_reader")
+	if ScreenReader {
+		// It would be read out as part of each line
+		ScrollBar = cview.ScrollBarNever
+	}
+
+	ImageProtocol = termimg.ParseProtocol(viper.GetString("a-general.image_protocol"))
+
+	// Width of ambiguous characters, used for both wrapping and drawing the screen
+	switch viper.GetInt("a-general.ambiguous_width") {
+	case 1:
+		runewidth.DefaultCondition.EastAsianWidth = false
+	case 2:
+		runewidth.DefaultCondition.EastAsianWidth = true
+	}
+
 	return nil
 }
@@ -0,0 +1,58 @@
+package config
+
+import "testing"
+
+func TestHasHost(t *testing.T) {
+	hosts := []string{"example.com", "*.example.org"}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"example.com", true},
+		{"example.com.", true},
+		{"sub.example.com", false},
+		{"example.org", false},
+		{"sub.example.org", true},
+		{"a.b.example.org", true},
+		{"notexample.org", false},
+	}
+	for _, tt := range tests {
+		if got := HasHost(hosts, tt.host); got != tt.want {
+			t.Errorf("HasHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestKeyLine(t *testing.T) {
+	toml := `[a-general]
+home = "gemini://example.com"
+
+[theme]
+# A comment
+bg = "blu"
+"tab_num" = "red"
+
+[[plugins]]
+cmd = ['a']
+
+[[plugins]] # Another one
+cmd = []
+`
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"a-general.home", 2},
+		{"theme.bg", 6},
+		{"theme.tab_num", 7},
+		{"theme.base", 0},
+		{"plugins", 9},
+		{"plugins.cmd", 10},
+		{"missing", 0},
+	}
+	for _, tt := range tests {
+		if got := keyLine(toml, tt.key); got != tt.want {
+			t.Errorf("keyLine(%q) = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+}
@@ -19,10 +19,22 @@ home = "gemini://gemini.circumlunar.space"
 # Follow up to 5 Gemini redirects without prompting.
 # A prompt is always shown after the 5th redirect and for redirects to protocols other than Gemini.
 # If set to false, a prompt will be shown before following redirects.
+# The prompt lets you always follow redirects from the current host instead, those
+# hosts are stored in redirects.toml, beside your bookmarks.
 auto_redirect = false
 
+# What to do with Gemini redirects to another host, which auto_redirect doesn't tell apart.
+# "follow": treat them like other redirects, as set by auto_redirect
+# "same_domain": follow redirects within the same domain without asking, like from
+#   example.com to www.example.com, and always ask for the others
+# "ask": always ask, even if auto_redirect is on or redirects from the host are
+#   always followed
+cross_host_redirects = "follow"
+
 # What command to run to open a HTTP(S) URL.
 # Set to "default" to try to guess the browser, or set to "off" to not open HTTP(S) URLs.
+# Set to "reader" to display web pages inside Amfora, like the reader mode of a browser:
+# the main content of the page is found and converted to gemtext.
 # If a command is set, than the URL will be added (in quotes) to the end of the command.
 # A space will be prepended to the URL.
 #
@@ -45,20 +57,96 @@ search = "gemini://geminispace.info/search"
 color = true
 
 # Whether ANSI color codes from the page content should be rendered
+# This can be changed for specific hosts in the [ansi] section below
 ansi = true
 
 # Whether to replace list asterisks with unicode bullets
 bullets = true
 
+# The bullet used for lists, and for links when link_markers is "bullets".
+# Screen reader mode always uses "*". List items that are nested, by starting with
+# more spaces or markers like "* * item", are indented further and use ◦ and ▪.
+bullet = "•"
+
+# What's shown before quote lines. It can be empty.
+quote_marker = ">"
+
+# What's shown before level 1, 2, and 3 headings, instead of the # characters.
+# Set them to ["", "", ""] to show nothing, or something like ["█", "▌", "▏"].
+heading_markers = ["#", "##", "###"]
+
 # Whether to show link after link text
 show_link = false
 
+# Whether to show the scheme of links that don't go to Gemini pages after their text,
+# like "[gopher]" or "[https]"
+link_scheme = false
+
+# What's shown before each link. "numbers", "bullets", "none", and "on_demand" are the only
+# valid values. Links can still be followed with the number keys when their numbers aren't shown.
+# "on_demand" shows nothing, until bind_follow is pressed to show the numbers.
+link_markers = "numbers"
+
+# Whether links that are one after another are shown like a menu, without the blank
+# lines between them, and with their numbers lined up. Useful for index pages on
+# small terminals.
+compact_links = false
+
+# Whether to underline links
+underline_links = false
+
+# What the bottom bar shows when a link is selected. "full", "raw", and "off" are the only
+# valid values. "full" shows the full URL the link goes to, even if it's written as a
+# relative link in the page. "raw" shows the link as it's written, and "off" doesn't
+# change the bottom bar.
+link_destination = "full"
+
 # A number from 0 to 1, indicating what percentage of the terminal width the left margin should take up.
 left_margin = 0.15
 
-# The max number of columns to wrap a page's text to. Preformatted blocks are not wrapped.
+# The max number of columns to wrap a page's text to. Preformatted blocks are not wrapped,
+# unless wrap_pre is enabled.
 max_width = 100
 
+# Whether to soft-wrap preformatted lines that are wider than the screen, instead of
+# scrolling horizontally to see them. Continuation lines start with a dim arrow.
+# Blocks with ANSI codes are never wrapped. This can be switched for each page with
+# bind_wrap_pre, see below.
+wrap_pre = false
+
+# Where the alt text of preformatted blocks is shown, which authors use to describe
+# what's in them. "above", "below", and "hidden" are the only valid values. Screen
+# reader mode shows it above the block, unless it's set to "below".
+pre_alt_text = "hidden"
+
+# Whether to detect simple text tables and align their columns.
+# Only tables with a header row, then a separator row like "---" or "|---|---|",
+# and the same number of columns in every row are aligned.
+# Pipe tables (like in Markdown) are aligned everywhere, and tables with columns
+# separated by tabs or multiple spaces are aligned outside of preformatted blocks.
+# Preformatted blocks with alt text are left alone.
+# Tables are never wrapped, scroll horizontally to see wide ones.
+tables = false
+
+# Whether to draw borders around the cells of aligned tables.
+table_borders = true
+
+# Whether to reorder right-to-left text like Arabic and Hebrew, so it displays correctly.
+# Set this to false if your terminal already handles bidirectional text itself,
+# like Konsole or mlterm, otherwise the text will appear reversed.
+bidi = true
+
+# How many columns wide "ambiguous" characters are, like some Greek and Cyrillic
+# letters, symbols, and box-drawing characters. Set this to 2 if East Asian pages
+# look misaligned, or 1 if lines are wrapping too early.
+# 0 means it's guessed from your locale, or the RUNEWIDTH_EASTASIAN environment variable.
+ambiguous_width = 0
+
+# Whether to justify regular text, so that every line of a paragraph except
+# the last one fills the full width. Works best along with hyphenation, see the
+# [hyphenation] section below.
+justify = false
+
 # 'downloads' is the path to a downloads folder.
 # An empty value means the code will find the default downloads folder for your system.
 # If the path does not exist it will be created.
@@ -68,16 +156,151 @@ downloads = ''
 # Max size for displayable content in bytes - after that size a download window pops up
 page_max_size = 2097152  # 2 MiB
 # Max time it takes to load a page in seconds - after that a download window pops up
+# For pages that are displayed as they load, you can choose to keep loading them instead,
+# with no max time. That's useful for streams that never end, like chats.
 page_max_time = 10
+# Max size in bytes for pages that are kept loading - after that they stop, and what
+# was loaded stays on screen
+stream_max_size = 52428800  # 50 MiB
+
+# When loading a page fails with an error that might be temporary, like a timeout or
+# a refused connection, you're asked whether to try again.
+# Set this to retry automatically that many times first, waiting longer before each
+# retry: 1 second, then 2, then 4, and so on.
+auto_retries = 0
+
+# The lowest TLS version servers are allowed to use, "1.2" or "1.3".
+# TLS 1.3 is always used if the server supports it, setting this to "1.3" requires it.
+# Exceptions for specific hosts can be set in the [tls-exceptions] section.
+tls_min_version = "1.2"
+
+# Whether to show details about the connection of the current page next to the bottom bar.
+# It shows the TLS version, "ID" if a client certificate is used, and how long the
+# server certificate has been trusted, like "5mo". "Tor" is always shown if the page was
+# loaded through Tor.
+security_indicator = true
+
+# What the bottom bar shows when it isn't being used for something else.
+# These placeholders are replaced, and any other text is shown as is:
+#   {url}        the URL of the page
+#   {title}      the first heading of the page
+#   {scroll}     how far down the page is scrolled, like "40%"
+#   {load_time}  how long the page took to load
+#   {identity}   the name of the client certificate used for the page, if any
+#   {tab}        the number of the current tab
+#   {tabs}       how many tabs are open
+# For example: "[{tab}/{tabs}] {title} - {url} ({scroll})"
+status_format = "{url}"
+
+# The language of the interface, like "de" or "pt-BR". By default it's the language
+# of your system, from the LANG environment variable.
+# Translations are TOML files in the "locales" directory next to this config file,
+# named after their language, like "locales/de.toml". Each line is some English text
+# and its translation, and text that isn't translated stays in English:
+#   "Go back in the history" = "Im Verlauf zurückgehen"
+language = ""
+
+# Make the interface easier for terminal screen readers to read.
+# Borders are drawn with spaces, bullets and tables use plain characters,
+# there's no scrollbar, popup text is aligned to the left, and page loads
+# and errors are announced in the bottom bar. Popups are still shown over
+# the page, rather than as lines of text after it.
+screen_reader = false
+
+# Start in zen mode, where the tab row and the bottom bar are hidden so only the page
+# is on screen. They're shown when they're needed, like when typing a URL, while a page
+# is loading, or for a moment after switching tabs. bind_zen switches it on and off.
+zen_mode = false
+
+# Only ever have one tab, and no tab row. Opening a link in a new tab opens it in
+# the current one instead, and closing the tab quits. For when you'd rather use
+# the windows of your terminal or tmux than tabs.
+single_tab = false
+
+# Whether bind_pipe sends the page as it's shown, instead of the page as the server sent it.
+# The shown page is plain text, without colors.
+pipe_rendered = false
+
+# The editing keys of the bottom bar. "emacs" has the keys of most shells, like Ctrl-A, Ctrl-E,
+# Ctrl-W, Alt-B, and Alt-F. "vi" starts in insert mode, and Esc switches to normal mode, which
+# has the motions and commands of vi, with counts and registers. k and j go through the history
+# there, and Esc leaves the bar. The + and * registers are the system clipboard.
+editing_mode = "emacs"
+
+# Browsing data to clear when Amfora exits, for shared computers. The names are the same
+# as on about:clear: "history", "cache", "tofu", "inputs", or "all". Bookmarks, subscriptions,
+# and the reading list are never cleared.
+# clear_on_exit = ["history", "cache"]
+clear_on_exit = []
+
+# How bookmarks are stored. "xbel" keeps them in bookmarks.xml, which other browsers can import.
+# "gemtext" keeps them in bookmarks.gmi, a regular gemtext file that's shown as it is on
+# about:bookmarks, so it can be published, and edited by hand to add headings and text.
+# Amfora adds new bookmarks to the end of it. If the file doesn't exist yet, it's made
+# from the bookmarks in bookmarks.xml. Both files are beside the other Amfora data.
+bookmarks_format = "xbel"
+
+# A Gemini URL of a version file to check for new releases of Amfora, at most once a day.
+# The file has the latest version on a line, like v1.9.0, and can link to a changelog.
+# If there's a newer release, a notice asks whether to open the changelog, until it's
+# dismissed. It's empty by default, so no requests are made.
+update_check = ""
 
 # When a scrollbar appears. "never", "auto", and "always" are the only valid values.
 # "auto" means the scrollbar only appears when the page is longer than the window.
 scrollbar = "auto"
 
+# Which terminal graphics protocol to use to preview images, instead of bringing
+# up the download prompt. "auto", "kitty", "iterm2", "sixel", and "off" are the only
+# valid values. "auto" guesses the protocol from environment variables like TERM,
+# and falls back to "off". Sixel support can't always be detected, set it manually
+# if your terminal supports it.
+image_protocol = "auto"
+
+# How to preview images when image_protocol is "off" or no protocol was detected.
+# "blocks" draws the image with colored block characters, "ascii" uses plain text
+# characters, and "off" disables previews. When enabled, a "Preview" button is added
+# to the download prompt for images.
+image_fallback = "blocks"
+
+# A file to write debug logs to, like network requests, cache events, and errors.
+# Logs are added to the end of the file. Empty means nothing is logged.
+# The --log FILE command line flag overrides this.
+log_file = ""
+
+# The least important messages to log: "debug", "info", "warn", or "error".
+log_level = "info"
+
+
+[ansi]
+# Override the ansi setting above for specific hosts, for example to turn off
+# colors that clash with your theme, or keep ANSI art on a site you like.
+# "example.com" = false
+
+
+[hyphenation]
+# Hyphenate words at the end of lines in regular text, for pages in the given
+# language. Set the language code equal to the path of a hyphenation pattern
+# file, like the hyph-*.pat.txt files from the hyph-utf8 project, or the
+# hyph_*.dic files used by LibreOffice. The files must be UTF-8 encoded.
+# The language comes from the lang parameter of the page, and "default" is used
+# for pages that don't have one.
+# Note the use of single quotes for values, so that backslashes will not be escaped.
+# en = '/usr/share/hyphen/hyph_en_US.dic'
+# default = '/usr/share/hyphen/hyph_en_US.dic'
+
 
 [auth]
 # Authentication settings
 # Note the use of single quotes for values, so that backslashes will not be escaped.
+#
+# When a site asks for a client certificate, you can choose to use one of the
+# certificates below for it, or a temporary certificate made just for that site.
+# Either choice lasts until Amfora is closed, and temporary certificates are never saved.
+
+# Warn at startup when a client certificate below expires within this many days.
+# about:certificates lists them all, and can renew them. Set to 0 to never warn.
+expiry_warning = 14
 
 [auth.certs]
 # Client certificates
@@ -90,7 +313,20 @@ scrollbar = "auto"
 # "example.com" = 'mycert.key'
 
 
+[misfin]
+# Misfin is a protocol for sending messages, like email for Gemini.
+# Following a misfin:// link asks for a message and sends it, using this client
+# certificate as your identity. Its common name is the name you send as.
+# Set url-handlers.misfin below to open misfin links with another program instead.
+# Note the use of single quotes for values, so that backslashes will not be escaped.
+# cert = 'misfin.crt'
+# key = 'misfin.key'
+
+
 [keybindings]
+# The keys can also be changed while Amfora is running, on about:keys.
+# Changes made there are written to this section.
+#
 # If you have a non-US keyboard, use bind_tab1 through bind_tab0 to
 # setup the shift-number bindings: Eg, for US keyboards (the default):
 # bind_tab1 = "!"
@@ -166,6 +402,84 @@ scrollbar = "auto"
 # bind_copy_target_url
 # bind_beginning: moving to beginning of page (top left)
 # bind_end: same but the for the end (bottom left)
+# bind_preview_image: preview the selected link as an image, see image_protocol above
+# bind_cert_info: show the server certificate of the current page
+# bind_input_editor: when a page asks for input, write it in your text editor instead
+#   ($VISUAL or $EDITOR). The saved text is sent once the editor is closed.
+# bind_view_as: render the current page as gemtext, Markdown, plain text, or ANSI art, for when the
+#   server sent the wrong mediatype
+# bind_page_info: show information about the current page, like its mediatype, size,
+#   load time, cache status, and connection
+# bind_copy_heading_url: copy the URL of the current page with a fragment for the heading
+#   of the part of the page being viewed, like gemini://example.com/page.gmi#some-heading.
+#   Opening a URL with a fragment scrolls to that heading.
+# bind_wrap_pre: switch soft-wrapping of preformatted text on or off for the current page,
+#   see wrap_pre above
+# bind_read_later: save the current page to the reading list, with a copy of it
+# bind_reading_list: view the reading list, also at about:reading
+# bind_archive: save a snapshot of the current page to the archive, see about:archive
+# bind_diff: after reloading a page, show what changed since the version before
+# bind_stop: stop loading the current page, keeping what was loaded so far
+# bind_follow: show the link numbers, and follow a link by typing its number
+# bind_follow_text: follow a link by typing some of its text. The links that match are
+#   highlighted, and the link is followed once it's the only one. Enter follows the
+#   one that matches best.
+# bind_zen: hide the tab row and the bottom bar, or show them again, see zen_mode above
+# bind_parent: go up one directory, like from gemini://example.com/dir/page.gmi
+#   to gemini://example.com/dir/
+# bind_root: go to the root of the current capsule, like gemini://example.com/
+# bind_next_page, bind_prev_page: follow the link to the next or previous page, on pages
+#   that are split up, like gemlog archives and stories. Links are found by their text,
+#   like "Next", "Older posts", "« Newer", or "→".
+# bind_mark_read: on the subscriptions page, mark the selected entry as read, or unread.
+#   On other pages, press a letter after it to set a mark where the page is scrolled to.
+# bind_mark_feed_read: on the subscriptions page, mark all entries of the selected
+#   entry's feed as read
+# bind_show_read: on the subscriptions page, hide or show the entries that were read
+# bind_next_unread: on the subscriptions page, select the next unread entry
+# bind_peek: show the start of the page the selected link goes to in a popup, without
+#   leaving the current page. Press Enter in the popup to open the link, or Esc to close it.
+# bind_split, bind_split_below: split the view into two panes, side by side or one above
+#   the other. The other pane opens the current page in a new tab, and each pane keeps its
+#   own history. Pressing the key again closes the other pane, its tab stays open.
+# bind_split_focus: move between the panes of a split view
+# bind_follow_in_pane: open the selected link in the other pane, and stay on the current
+#   one, like a two-pane file manager. If the view isn't split, it's split side by side.
+# bind_pager: show the current page as plain text in your pager, set by the PAGER
+#   environment variable. The default is "less", or "more" on Windows.
+# bind_pre_block: save, copy, or open in your text editor the preformatted text on the
+#   screen, like a script or a config file. Saved files are named after the alt text if
+#   it's a file name, like "config.toml".
+# bind_pipe: run a shell command with the current page as its input, like "wc -w" or
+#   "grep gemini". Its output is shown, if there is any. See pipe_rendered above.
+# bind_block_host: block the host of the selected link, or of the current page. Pages
+#   from it won't be loaded, and links to it are marked. See the connection-rules section.
+# bind_new_private_tab: open a private tab, which has its number in parentheses. Pages
+#   loaded in it aren't cached, don't run the page_loaded hook, and their scroll positions
+#   aren't remembered. It isn't restored after a crash, and client certificates are never
+#   sent from it. Links opened in new tabs from it open in private tabs too.
+# bind_tab_identity: choose the client certificate the current tab sends to the host of
+#   the current page, instead of the ones for each host. Other hosts get none from the
+#   tab. Tabs can use a stored identity, a temporary one, or none, so a site can be used
+#   as different identities in different tabs. Links opened in new tabs from it use the
+#   same identity.
+# bind_record_macro: start recording a macro, then press a letter or number to save it
+#   under. Every key pressed is recorded, until this is pressed again. Macros are kept
+#   until Amfora quits.
+# bind_play_macro: press a letter or number after it to play the macro saved under it.
+#   Pressing this twice plays the last macro that was played again. Each key waits for
+#   the page the one before it loaded.
+# bind_finder: go to an open tab, a bookmark, a subscription or one of its entries, or a
+#   page from the history, by typing some of the letters of its name or URL. Starting the
+#   search with "t:", "b:", "s:", or "h:" only searches tabs, bookmarks, subscriptions,
+#   or history. Up and Down choose, and Enter goes to it.
+# bind_jump_back, bind_jump_forward: go back and forward through the places the tab
+#   jumped from, like the jump list of vim. Places are added before going to another page,
+#   to the top or bottom of the page, or to a heading. Jumping to another page loads it,
+#   like following a link. vim uses Ctrl-O and Ctrl-I, but Ctrl-O is bind_input_editor
+#   and terminals send Ctrl-I as Tab.
+# bind_go_to_mark: press a letter after it to scroll back to the mark set under it with
+#   bind_mark_read. Each tab keeps the marks of its pages until it's closed.
 
 [url-handlers]
 # Allows setting the commands to run for various URL schemes.
@@ -254,6 +568,76 @@ other = 'off'
 # 3. Catch-all: "*"
 
 
+# [[mediatype-filters]] section
+# ---------------------------------
+#
+# Filters are commands that convert pages into text that Amfora can display.
+# The response is piped to the command, and its output is displayed like a regular page.
+# This allows displaying formats that aren't supported by Amfora itself.
+#
+# "output" is the mediatype of what the command outputs, and sets how it is rendered.
+# It can be "text/gemini" (the default), "text/markdown", "text/plain", or "text/x-ansi"
+# for text with ANSI colors.
+#
+# To display HTML pages by converting them to gemtext:
+#
+# [[mediatype-filters]]
+# cmd = ['html2gmi']
+# types = ["text/html"]
+#
+# Like with handlers, the subtype can be omitted to filter the entire type.
+# The COLUMNS environment variable is set to the width of the page, and AMFORA_URL
+# is set to the URL of the page. To display images as ANSI art:
+#
+# [[mediatype-filters]]
+# cmd = ['sh', '-c', 'chafa --size "${COLUMNS}x" -']
+# types = ["image"]
+# output = "text/x-ansi"
+#
+# Filters are used instead of mediatype-handlers for the types they cover.
+# There is no catch-all filter.
+#
+# Filters can also be used only for some hosts, to change pages that can already be
+# displayed, like to translate them or fix their markup. A filter with hosts is used
+# for text pages from those hosts, or for the types it has, and outputs the same
+# mediatype as the page, unless output is set. Hosts can start with "*." to include
+# all their subdomains. Filters with hosts are used before the ones without them.
+#
+# [[mediatype-filters]]
+# cmd = ['sed', 's/colour/color/g']
+# hosts = ["example.com", "*.example.org"]
+# types = ["text/gemini"]
+
+
+# [[content-rules]] section
+# ---------------------------------
+#
+# Content rules change how gemtext pages from some hosts are displayed, like user
+# stylesheets do for websites. The page itself isn't changed, so saving or piping it
+# still uses what the server sent. Hosts can start with "*." to include all their
+# subdomains, and all the rules for a host are used, in order.
+#
+# "hide" is a list of regular expressions, and lines that match any of them are hidden,
+# like boilerplate headers and footers.
+#
+# "link_text" is a list of pairs of a regular expression and what to replace it with,
+# which change the text of links. $1 can be used for the first submatch, and so on.
+# If the text ends up empty, the URL is shown instead.
+#
+# [[content-rules]]
+# hosts = ["example.com"]
+# hide = ['^Powered by ', '^Copyright ']
+# link_text = [['^Back to (.*)', '← $1']]
+#
+# "preformatted" shows all the text lines of the page as preformatted text, which
+# is useful for sites with ASCII art that forget to use preformatted blocks. Links
+# still work.
+#
+# [[content-rules]]
+# hosts = ["*.art.example.org"]
+# preformatted = true
+
+
 [cache]
 # Options for page cache - which is only for text pages
 # Increase the cache size to speed up browsing at the expense of memory
@@ -265,6 +649,11 @@ max_pages = 30 # The maximum number of pages the cache will store
 # How long a page will stay in cache, in seconds.
 timeout = 1800 # 30 mins
 
+# Pages removed from the cache to keep it inside the limits above are saved to disk,
+# so going back to them doesn't load them again. This is how many are saved, and
+# zero turns it off. They're removed when Amfora quits.
+max_disk_pages = 200
+
 [proxies]
 # Allows setting a Gemini proxy for different schemes.
 # The settings are similar to the url-handlers section above.
@@ -277,6 +666,75 @@ timeout = 1800 # 30 mins
 # the url-handlers section.
 #
 # Note that HTTP and HTTPS are treated as separate protocols here.
+#
+# Gemini URLs can be sent through a proxy as well, by setting "gemini".
+# The "other" key sets a proxy for all schemes that don't have one set,
+# except for gemini, http, and https. Set a scheme to "off" to not use the
+# proxy for it.
+
+
+[tor]
+# Connections to .onion hosts are always made through Tor, using its SOCKS proxy.
+# Host names are resolved by Tor, so they don't leak through your DNS server.
+# Set the proxy to an empty string to disable Tor support.
+proxy = "127.0.0.1:9050"
+
+# Send all connections through Tor, not just ones to .onion hosts.
+# This includes Gemini proxies, and HTTP(S) pages viewed in reader mode.
+all = false
+
+
+[dns]
+# The DNS server used to look up hosts, instead of the system's. This can get around
+# a DNS server that's broken, or blocks Gemini hosts. It can be the IP address of a
+# DNS server, with an optional port, or the https URL of a DNS-over-HTTPS server.
+# The host of the URL is looked up with the system's DNS server, so an IP address
+# avoids that. Connections through Tor don't use it, as Tor looks up hosts itself.
+# Leave it empty to use the system's DNS server.
+#
+# Examples:
+#   server = "9.9.9.9"
+#   server = "[2620:fe::fe]:53"
+#   server = "https://1.1.1.1/dns-query"
+server = ""
+
+
+[tls-exceptions]
+# The lowest TLS version allowed for specific hosts, overriding the
+# tls_min_version setting above. Wildcards like "*.example.com" can be used.
+# This can also allow "1.0" or "1.1" for old servers, which aren't secure, and
+# aren't allowed by the Gemini spec.
+#
+# Examples:
+#   "old.example.com" = "1.1"
+#   "*.example.org" = "1.2"
+
+
+[connection-rules]
+# Rules for connecting to specific hosts. A rule can be set for a single host,
+# or for all subdomains of one with a wildcard, like "*.example.com".
+# The possible values are:
+#
+#   "block"   - Never connect to the host
+#   "confirm" - Ask before connecting to the host, once per session
+#   "tor"     - Only connect to the host through Tor, see the [tor] section above
+#
+# Any other value is used as the address of a Gemini proxy, and all requests for
+# the host are sent through it, like in the [proxies] section.
+# Port 1965 is assumed if no port is specified.
+#
+# Examples:
+#   "bad.example.com" = "block"
+#   "*.example.org" = "confirm"
+#   "private.example.net" = "tor"
+#   "gopher.example.com" = "proxy.example.com:1965"
+#
+# Note these rules apply to connections made by Amfora itself, not to URLs
+# opened in other programs, like a web browser.
+#
+# Hosts can also be blocked from inside Amfora, see bind_block_host. They're saved
+# to blocklist.toml, next to the bookmarks. Links to blocked hosts are marked with
+# [blocked] on pages.
 
 
 [subscriptions]
@@ -297,9 +755,68 @@ update_interval = 1800 # 30 mins
 # update times. Any value below 1 will be corrected to 1.
 workers = 3
 
-# The number of subscription updates displayed per page.
+# How many of those can be for the same host, and how long to wait between
+# starting requests to the same host, in seconds. These keep updates from
+# hammering servers that host many feeds. Any value of host_workers below 1
+# will be corrected to 1, and host_delay can be 0 to not wait.
+host_workers = 1
+host_delay = 1
+
+# How entries are grouped on the subscriptions page.
+# "feed" has a section for each feed or page, and "day" one for each day.
+# The entries of a section can be hidden or shown, and sections with unread
+# entries start out shown. "none" is one list of all entries, split up into
+# pages of entries_per_page entries.
+group_by = "feed"
+
+# The number of subscription updates displayed per page, if group_by is "none".
 entries_per_page = 20
 
+# Entries are unread until they're opened. The subscriptions page has a link
+# to open the unread entries in new tabs in the background, newest first.
+# This is the most that are opened at once, set it to 0 to open all of them.
+open_unread_max = 20
+
+# Whether entries that were read are listed on the subscriptions page.
+# bind_show_read switches this while Amfora is open.
+show_read = true
+
+
+[hooks]
+# Commands that are run when things happen, to use Amfora with other tools, like ones
+# for notifications, logging, or archiving. Like with mediatype-handlers, each one is
+# an array of the command and its arguments. They run in the background, and their
+# output is ignored, but failures are logged.
+#
+# Details are in environment variables: AMFORA_EVENT is always the name of the hook,
+# and AMFORA_URL is the URL it's about. The others are listed below.
+#
+# When a page is loaded from the network. AMFORA_MEDIATYPE is the mediatype it was
+# displayed as, and the page as the server sent it is the command's input.
+# page_loaded = ['sh', '-c', 'cat >> ~/gemini-log.txt']
+#
+# When a download is done, or a page was saved. AMFORA_PATH is where it was saved.
+# download_finished = ['notify-send', 'Amfora', 'Download finished']
+#
+# When a subscription has new entries, or a subscribed page changed. AMFORA_COUNT is
+# how many there are, and their URLs are the command's input, one on each line.
+# new_entries = ['sh', '-c', 'notify-send "$AMFORA_COUNT new entries" "$AMFORA_URL"']
+#
+# When a bookmark is added. AMFORA_NAME is its name.
+# bookmark_added = ['sh', '-c', 'echo "$AMFORA_URL" >> ~/bookmarks-backup.txt']
+
+
+# Plugins are programs that add commands, keys, URL schemes, and about pages to Amfora.
+# Each one is started when Amfora starts, and talks to it over stdin and stdout.
+# See PLUGINS.md in the Amfora repo for how to write one, and about:plugins for the
+# plugins that are running.
+#
+# Add a section like this for each plugin, with an array of the command and its
+# arguments, like with mediatype-handlers.
+#
+# [[plugins]]
+# cmd = ['amfora-translate', '--to', 'en']
+
 
 [theme]
 # This section is for changing the COLORS used in Amfora.
@@ -320,6 +837,14 @@ entries_per_page = 20
 #   bkmk = bookmark
 #   modal = a popup window/box in the middle of the screen
 
+# Instead of setting the colors yourself, you can start from a built-in theme.
+# Any colors set below change that theme. The built-in themes are:
+#   default        the usual colors
+#   high-contrast  white and bright colors on black
+#   deuteranopia   for red-green color blindness, using blues, oranges, and yellows
+#   protanopia     like deuteranopia, but without dark reds
+base = "default"
+
 # EXAMPLES:
 # hdg_1 = "green"
 # hdg_2 = "#5f0000"
@@ -339,12 +864,20 @@ entries_per_page = 20
 # hdg_3
 # amfora_link: A link that Amfora supports viewing. For now this is only gemini://
 # foreign_link: HTTP(S), Gopher, etc
+# relative_link: Links to the same site, like "/page.gmi". Uses the amfora_link color if not set
+# cross_host_link: gemini:// links to a different host than the current page. Uses amfora_link if not set
+# gopher_link: Uses foreign_link if not set
+# http_link: HTTP(S) links. Uses foreign_link if not set
 # link_number: The silver number that appears to the left of a link
 # regular_text: Normal gemini text, and plaintext documents
 # quote_text
 # preformatted_text
 # list_text
 
+# diff_added: Lines added in diffs, like the one shown by bind_diff
+# diff_removed: Lines removed in diffs
+# diff_hunk: The @@ line at the start of each part of a diff
+
 # btn_bg: The bg color for all modal buttons
 # btn_text: The text color for all modal buttons
 
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/logger"
+	"github.com/spf13/viper"
+)
+
+// Error is a problem with a value in the config file. Line and Key say where
+// it is, if that's known, and Expected is what the value should look like.
+type Error struct {
+	Path     string // The config file
+	Line     int    // 0 if it's not known
+	Key      string // Like "theme.bg", or a section like "plugins". Empty if it's not known
+	Expected string // Empty if there's nothing more to say than Err
+	Err      error
+}
+
+func (e *Error) Error() string {
+	s := e.Err.Error()
+	if e.Key != "" {
+		s = e.Key + ": " + s
+	}
+	if e.Line > 0 {
+		s = fmt.Sprintf("line %d: %s", e.Line, s)
+	}
+	return s
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// What some of the values in the config should look like
+const (
+	expectedColor = `a color name like "red", or a hex code like "#ff0000"`
+	expectedDir   = "a path to a folder, which is made if it doesn't exist"
+	expectedCmd   = "a command and its arguments, like cmd = ['program', '--flag']"
+)
+
+// useDefaults is true if the config file should be ignored, see UseDefaults.
+var useDefaults bool
+
+// The colors before the theme from the config is set
+var defaultTheme = copyTheme()
+
+func copyTheme() map[string]tcell.Color {
+	themeMu.RLock()
+	defer themeMu.RUnlock()
+	c := make(map[string]tcell.Color, len(theme))
+	for k, v := range theme {
+		c[k] = v
+	}
+	return c
+}
+
+// The position at the start of TOML parse errors, like "(12, 5): "
+var tomlPosRe = regexp.MustCompile(`^\((\d+), \d+\): `)
+
+// parseError returns an Error for an error from reading the config file,
+// with the line it's on if it couldn't be parsed.
+func parseError(err error) error {
+	var parseErr viper.ConfigParseError
+	if !errors.As(err, &parseErr) {
+		return err
+	}
+	// Remove viper's prefix, the error is already known to be from the config
+	msg := strings.TrimPrefix(parseErr.Error(), "While parsing config: ")
+	e := &Error{
+		Path:     configPath,
+		Expected: "valid TOML, see the default config for examples",
+	}
+	if m := tomlPosRe.FindStringSubmatch(msg); m != nil {
+		e.Line, _ = strconv.Atoi(m[1])
+		msg = msg[len(m[0]):]
+	}
+	e.Err = errors.New(msg) //nolint:goerr113
+	return e
+}
+
+// keyError returns an Error for the value of the key in the config, with the
+// line it's on. The key can also be a section, like "plugins".
+func keyError(key, expected string, err error) error {
+	e := &Error{Path: configPath, Key: key, Expected: expected, Err: err}
+	if b, rerr := ioutil.ReadFile(configPath); rerr == nil {
+		e.Line = keyLine(string(b), key)
+	}
+	return e
+}
+
+// keyLine returns the line the key or section is on in the TOML file, or 0 if
+// it isn't in it. Only the first line of sections that are arrays of tables,
+// like [[plugins]], is found.
+func keyLine(toml, key string) int {
+	section, name := "", key
+	if i := strings.LastIndexByte(key, '.'); i != -1 {
+		section, name = key[:i], key[i+1:]
+	}
+
+	current := ""
+	sectionLine := 0 // Where the key is a section instead
+	n := 0
+	scanner := bufio.NewScanner(strings.NewReader(toml))
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			current = strings.TrimLeft(line, "[")
+			if i := strings.IndexByte(current, ']'); i != -1 {
+				current = current[:i]
+			}
+			current = strings.TrimSpace(current)
+			if current == key && sectionLine == 0 {
+				sectionLine = n
+			}
+			continue
+		}
+		if current != section {
+			continue
+		}
+		if i := strings.IndexByte(line, '='); i != -1 && strings.Trim(strings.TrimSpace(line[:i]), `"'`) == name {
+			return n
+		}
+	}
+	return sectionLine
+}
+
+// UseDefaults loads the default config instead of the config file, after
+// Init returned an error because of the config file. Everything else Init
+// does is done again.
+func UseDefaults() error {
+	logger.Warnf("Ignoring the config file, and using the default config")
+	useDefaults = true
+
+	viper.Reset()
+	themeMu.Lock()
+	theme = make(map[string]tcell.Color, len(defaultTheme))
+	for k, v := range defaultTheme {
+		theme[k] = v
+	}
+	themeMu.Unlock()
+	MediaHandlers = make(map[string]MediaHandler)
+	MediaFilters = make(map[string]MediaFilter)
+	HostMediaFilters = nil
+	ContentRules = nil
+	Plugins = nil
+
+	return Init()
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"sort"
 	"strings"
 
 	"code.rocketnine.space/tslocum/cview"
@@ -61,6 +62,46 @@ const (
 	CmdCopyTargetURL
 	CmdBeginning
 	CmdEnd
+	CmdPreviewImage
+	CmdCertInfo
+	CmdInputEditor
+	CmdViewAs
+	CmdPageInfo
+	CmdCopyHeading
+	CmdWrapPre
+	CmdReadLater
+	CmdReadingList
+	CmdArchive
+	CmdDiff
+	CmdStop
+	CmdFollow
+	CmdZen
+	CmdParent
+	CmdRoot
+	CmdNextPage
+	CmdPrevPage
+	CmdMarkRead
+	CmdMarkFeedRead
+	CmdShowRead
+	CmdNextUnread
+	CmdPeek
+	CmdSplit
+	CmdSplitBelow
+	CmdSplitFocus
+	CmdFollowInPane
+	CmdPager
+	CmdPreBlock
+	CmdPipe
+	CmdBlockHost
+	CmdNewPrivateTab
+	CmdTabIdentity
+	CmdRecordMacro
+	CmdPlayMacro
+	CmdFinder
+	CmdJumpBack
+	CmdJumpForward
+	CmdGoToMark
+	CmdFollowText
 )
 
 type keyBinding struct {
@@ -103,24 +144,31 @@ func keyBindingToString(kb keyBinding) (string, bool) {
 // Used by the help panel so bindable keys display with their
 // bound values rather than hardcoded defaults.
 func GetKeyBinding(cmd Command) string {
-	var s string = ""
+	var keys []string
 	for kb, c := range bindings {
 		if c == cmd {
 			t, ok := keyBindingToString(kb)
 			if ok {
-				s += t + ", "
+				keys = append(keys, t)
 			}
 		}
 	}
-
-	if len(s) > 0 {
-		return s[:len(s)-2]
-	}
-	return s
+	// The map has no order, but the keys should always be shown the same way
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
 }
 
 // Parse a single keybinding string and add it to the binding map
 func parseBinding(cmd Command, binding string) {
+	if kb, ok := parseKey(binding); ok {
+		bindings[kb] = cmd
+	}
+	// Bad keybinding!  Quietly ignore...
+}
+
+// parseKey turns a key from the config, like "Alt-c", into a keyBinding.
+// It returns false if it's not a valid key.
+func parseKey(binding string) (keyBinding, bool) {
 	var k tcell.Key
 	var m tcell.ModMask = 0
 	var r rune = 0
@@ -134,81 +182,159 @@ func parseBinding(cmd Command, binding string) {
 		k = tcell.KeyRune
 		r = []rune(binding)[0]
 	} else if len(binding) == 0 {
-		return
+		return keyBinding{}, false
 	} else if binding == "Space" {
 		k = tcell.KeyRune
 		r = ' '
 	} else {
 		var ok bool
 		k, ok = tcellKeys[binding]
-		if !ok { // Bad keybinding!  Quietly ignore...
-			return
+		if !ok {
+			return keyBinding{}, false
 		}
 		if strings.HasPrefix(binding, "Ctrl") {
 			m += tcell.ModCtrl
 		}
 	}
 
-	bindings[keyBinding{k, m, r}] = cmd
+	return keyBinding{k, m, r}, true
+}
+
+// Keybindings of plugin commands, to the names of the commands.
+var pluginBindings = make(map[keyBinding]string)
+
+// AddPluginBinding binds the key to the plugin command with the given name.
+// It returns false if the key isn't valid, or is already used by Amfora or
+// another plugin.
+func AddPluginBinding(binding, name string) bool {
+	kb, ok := parseKey(binding)
+	if !ok {
+		return false
+	}
+	if _, used := bindings[kb]; used {
+		return false
+	}
+	if _, used := pluginBindings[kb]; used {
+		return false
+	}
+	pluginBindings[kb] = name
+	return true
+}
+
+// TranslatePluginKeyEvent returns the name of the plugin command the key is
+// bound to, if there is one.
+func TranslatePluginKeyEvent(e *tcell.EventKey) (string, bool) {
+	k := e.Key()
+	var name string
+	var ok bool
+	if k == tcell.KeyRune {
+		name, ok = pluginBindings[keyBinding{k, e.Modifiers(), e.Rune()}]
+	} else {
+		name, ok = pluginBindings[keyBinding{k, e.Modifiers(), 0}]
+	}
+	return name, ok
+}
+
+// The names of the commands in the keybindings section of the config
+var configBindings = map[Command]string{
+	CmdLink1:         "keybindings.bind_link1",
+	CmdLink2:         "keybindings.bind_link2",
+	CmdLink3:         "keybindings.bind_link3",
+	CmdLink4:         "keybindings.bind_link4",
+	CmdLink5:         "keybindings.bind_link5",
+	CmdLink6:         "keybindings.bind_link6",
+	CmdLink7:         "keybindings.bind_link7",
+	CmdLink8:         "keybindings.bind_link8",
+	CmdLink9:         "keybindings.bind_link9",
+	CmdLink0:         "keybindings.bind_link0",
+	CmdBottom:        "keybindings.bind_bottom",
+	CmdEdit:          "keybindings.bind_edit",
+	CmdHome:          "keybindings.bind_home",
+	CmdBookmarks:     "keybindings.bind_bookmarks",
+	CmdAddBookmark:   "keybindings.bind_add_bookmark",
+	CmdSave:          "keybindings.bind_save",
+	CmdReload:        "keybindings.bind_reload",
+	CmdBack:          "keybindings.bind_back",
+	CmdForward:       "keybindings.bind_forward",
+	CmdMoveUp:        "keybindings.bind_moveup",
+	CmdMoveDown:      "keybindings.bind_movedown",
+	CmdMoveLeft:      "keybindings.bind_moveleft",
+	CmdMoveRight:     "keybindings.bind_moveright",
+	CmdPgup:          "keybindings.bind_pgup",
+	CmdPgdn:          "keybindings.bind_pgdn",
+	CmdNewTab:        "keybindings.bind_new_tab",
+	CmdCloseTab:      "keybindings.bind_close_tab",
+	CmdNextTab:       "keybindings.bind_next_tab",
+	CmdPrevTab:       "keybindings.bind_prev_tab",
+	CmdQuit:          "keybindings.bind_quit",
+	CmdHelp:          "keybindings.bind_help",
+	CmdSub:           "keybindings.bind_sub",
+	CmdAddSub:        "keybindings.bind_add_sub",
+	CmdCopyPageURL:   "keybindings.bind_copy_page_url",
+	CmdCopyTargetURL: "keybindings.bind_copy_target_url",
+	CmdBeginning:     "keybindings.bind_beginning",
+	CmdEnd:           "keybindings.bind_end",
+	CmdPreviewImage:  "keybindings.bind_preview_image",
+	CmdCertInfo:      "keybindings.bind_cert_info",
+	CmdInputEditor:   "keybindings.bind_input_editor",
+	CmdViewAs:        "keybindings.bind_view_as",
+	CmdPageInfo:      "keybindings.bind_page_info",
+	CmdCopyHeading:   "keybindings.bind_copy_heading_url",
+	CmdWrapPre:       "keybindings.bind_wrap_pre",
+	CmdReadLater:     "keybindings.bind_read_later",
+	CmdReadingList:   "keybindings.bind_reading_list",
+	CmdArchive:       "keybindings.bind_archive",
+	CmdDiff:          "keybindings.bind_diff",
+	CmdStop:          "keybindings.bind_stop",
+	CmdFollow:        "keybindings.bind_follow",
+	CmdZen:           "keybindings.bind_zen",
+	CmdParent:        "keybindings.bind_parent",
+	CmdRoot:          "keybindings.bind_root",
+	CmdNextPage:      "keybindings.bind_next_page",
+	CmdPrevPage:      "keybindings.bind_prev_page",
+	CmdMarkRead:      "keybindings.bind_mark_read",
+	CmdMarkFeedRead:  "keybindings.bind_mark_feed_read",
+	CmdShowRead:      "keybindings.bind_show_read",
+	CmdNextUnread:    "keybindings.bind_next_unread",
+	CmdPeek:          "keybindings.bind_peek",
+	CmdSplit:         "keybindings.bind_split",
+	CmdSplitBelow:    "keybindings.bind_split_below",
+	CmdSplitFocus:    "keybindings.bind_split_focus",
+	CmdFollowInPane:  "keybindings.bind_follow_in_pane",
+	CmdPager:         "keybindings.bind_pager",
+	CmdPreBlock:      "keybindings.bind_pre_block",
+	CmdPipe:          "keybindings.bind_pipe",
+	CmdBlockHost:     "keybindings.bind_block_host",
+	CmdNewPrivateTab: "keybindings.bind_new_private_tab",
+	CmdTabIdentity:   "keybindings.bind_tab_identity",
+	CmdRecordMacro:   "keybindings.bind_record_macro",
+	CmdPlayMacro:     "keybindings.bind_play_macro",
+	CmdFinder:        "keybindings.bind_finder",
+	CmdJumpBack:      "keybindings.bind_jump_back",
+	CmdJumpForward:   "keybindings.bind_jump_forward",
+	CmdGoToMark:      "keybindings.bind_go_to_mark",
+	CmdFollowText:    "keybindings.bind_follow_text",
+}
+
+// This is split off to allow shift_numbers to override bind_tab[1-90]
+// (This is needed for older configs so that the default bind_tab values
+// aren't used)
+var configTabNBindings = map[Command]string{
+	CmdTab1: "keybindings.bind_tab1",
+	CmdTab2: "keybindings.bind_tab2",
+	CmdTab3: "keybindings.bind_tab3",
+	CmdTab4: "keybindings.bind_tab4",
+	CmdTab5: "keybindings.bind_tab5",
+	CmdTab6: "keybindings.bind_tab6",
+	CmdTab7: "keybindings.bind_tab7",
+	CmdTab8: "keybindings.bind_tab8",
+	CmdTab9: "keybindings.bind_tab9",
+	CmdTab0: "keybindings.bind_tab0",
 }
 
 // Generate the bindings map from the TOML configuration file.
 // Called by config.Init()
 func KeyInit() {
-	configBindings := map[Command]string{
-		CmdLink1:         "keybindings.bind_link1",
-		CmdLink2:         "keybindings.bind_link2",
-		CmdLink3:         "keybindings.bind_link3",
-		CmdLink4:         "keybindings.bind_link4",
-		CmdLink5:         "keybindings.bind_link5",
-		CmdLink6:         "keybindings.bind_link6",
-		CmdLink7:         "keybindings.bind_link7",
-		CmdLink8:         "keybindings.bind_link8",
-		CmdLink9:         "keybindings.bind_link9",
-		CmdLink0:         "keybindings.bind_link0",
-		CmdBottom:        "keybindings.bind_bottom",
-		CmdEdit:          "keybindings.bind_edit",
-		CmdHome:          "keybindings.bind_home",
-		CmdBookmarks:     "keybindings.bind_bookmarks",
-		CmdAddBookmark:   "keybindings.bind_add_bookmark",
-		CmdSave:          "keybindings.bind_save",
-		CmdReload:        "keybindings.bind_reload",
-		CmdBack:          "keybindings.bind_back",
-		CmdForward:       "keybindings.bind_forward",
-		CmdMoveUp:        "keybindings.bind_moveup",
-		CmdMoveDown:      "keybindings.bind_movedown",
-		CmdMoveLeft:      "keybindings.bind_moveleft",
-		CmdMoveRight:     "keybindings.bind_moveright",
-		CmdPgup:          "keybindings.bind_pgup",
-		CmdPgdn:          "keybindings.bind_pgdn",
-		CmdNewTab:        "keybindings.bind_new_tab",
-		CmdCloseTab:      "keybindings.bind_close_tab",
-		CmdNextTab:       "keybindings.bind_next_tab",
-		CmdPrevTab:       "keybindings.bind_prev_tab",
-		CmdQuit:          "keybindings.bind_quit",
-		CmdHelp:          "keybindings.bind_help",
-		CmdSub:           "keybindings.bind_sub",
-		CmdAddSub:        "keybindings.bind_add_sub",
-		CmdCopyPageURL:   "keybindings.bind_copy_page_url",
-		CmdCopyTargetURL: "keybindings.bind_copy_target_url",
-		CmdBeginning:     "keybindings.bind_beginning",
-		CmdEnd:           "keybindings.bind_end",
-	}
-	// This is split off to allow shift_numbers to override bind_tab[1-90]
-	// (This is needed for older configs so that the default bind_tab values
-	// aren't used)
-	configTabNBindings := map[Command]string{
-		CmdTab1: "keybindings.bind_tab1",
-		CmdTab2: "keybindings.bind_tab2",
-		CmdTab3: "keybindings.bind_tab3",
-		CmdTab4: "keybindings.bind_tab4",
-		CmdTab5: "keybindings.bind_tab5",
-		CmdTab6: "keybindings.bind_tab6",
-		CmdTab7: "keybindings.bind_tab7",
-		CmdTab8: "keybindings.bind_tab8",
-		CmdTab9: "keybindings.bind_tab9",
-		CmdTab0: "keybindings.bind_tab0",
-	}
 	tcellKeys = make(map[string]tcell.Key)
 	bindings = make(map[keyBinding]Command)
 
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/spf13/viper"
+)
+
+// Key bindings can be changed while Amfora is running, on about:keys.
+// The changes are written to the keybindings section of the config file,
+// without touching the rest of it.
+
+// ErrDefaultConfig is returned when changing the config file while the
+// default config is used, because the file has an error.
+var ErrDefaultConfig = errors.New("the config file has an error, so the default config is used and it can't be changed")
+
+// ErrShiftNumbers is returned when changing the keys of the tab commands while
+// the old shift_numbers option is set, which overrides them.
+var ErrShiftNumbers = errors.New("shift_numbers is set in the keybindings section of the config, " +
+	"remove it to change the keys for tabs")
+
+// BindingName returns the name of the command in the keybindings section of
+// the config, like "bind_reload".
+func BindingName(cmd Command) (string, bool) {
+	name, ok := configBindings[cmd]
+	if !ok {
+		name, ok = configTabNBindings[cmd]
+	}
+	return strings.TrimPrefix(name, "keybindings."), ok
+}
+
+// BindableCommands returns all the commands that keys can be bound to, in
+// the order of their names.
+func BindableCommands() []Command {
+	cmds := make([]Command, 0, len(configBindings)+len(configTabNBindings))
+	for c := range configBindings {
+		cmds = append(cmds, c)
+	}
+	for c := range configTabNBindings {
+		cmds = append(cmds, c)
+	}
+	sort.Slice(cmds, func(i, j int) bool {
+		a, _ := BindingName(cmds[i])
+		b, _ := BindingName(cmds[j])
+		return a < b
+	})
+	return cmds
+}
+
+// KeyBindings returns the keys bound to the command, as they're written in
+// the config.
+func KeyBindings(cmd Command) []string {
+	var keys []string
+	for kb, c := range bindings {
+		if c == cmd {
+			if s, ok := keyBindingToString(kb); ok {
+				keys = append(keys, s)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// KeyEventString returns the key of the event as it's written in the config,
+// like "Ctrl-R". It returns false if the key can't be bound.
+func KeyEventString(e *tcell.EventKey) (string, bool) {
+	kb := keyBinding{e.Key(), e.Modifiers(), 0}
+	if e.Key() == tcell.KeyRune {
+		kb.r = e.Rune()
+	}
+	s, ok := keyBindingToString(kb)
+	if !ok {
+		return "", false
+	}
+	// It has to be read back the same way, or the binding won't work
+	if parsed, ok := parseKey(s); !ok || parsed != kb {
+		return "", false
+	}
+	return s, true
+}
+
+// KeyCommand returns what the key is bound to: an Amfora command, or the
+// name of a plugin command. The command is CmdInvalid if it's not bound to
+// one, and the name is empty if it's not bound to a plugin command.
+func KeyCommand(key string) (Command, string) {
+	kb, ok := parseKey(key)
+	if !ok {
+		return CmdInvalid, ""
+	}
+	if cmd, ok := bindings[kb]; ok {
+		return cmd, ""
+	}
+	return CmdInvalid, pluginBindings[kb]
+}
+
+// tomlKeys returns the keys as a TOML value.
+func tomlKeys(keys []string) string {
+	if len(keys) == 1 {
+		return strconv.Quote(keys[0])
+	}
+	quoted := make([]string, len(keys))
+	for i := range keys {
+		quoted[i] = strconv.Quote(keys[i])
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// setTOMLValue returns the TOML file with the key in the section set to the
+// value. A line that already sets it is replaced, even if the value takes up
+// multiple lines. Otherwise it's added to the end of the section, which is
+// added to the end of the file if it isn't there.
+func setTOMLValue(toml, section, key, value string) string {
+	lines := strings.Split(strings.TrimRight(toml, "\n"), "\n")
+	line := key + " = " + value
+
+	current := ""
+	sectionEnd := -1 // The last line of the section that isn't blank or a comment
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "[") {
+			current = strings.TrimSpace(strings.SplitN(strings.TrimLeft(trimmed, "["), "]", 2)[0])
+			if current == section {
+				sectionEnd = i
+			}
+			continue
+		}
+		if current != section {
+			continue
+		}
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			sectionEnd = i
+		}
+		eq := strings.IndexByte(trimmed, '=')
+		if eq == -1 || strings.Trim(strings.TrimSpace(trimmed[:eq]), `"'`) != key {
+			continue
+		}
+		// Found it, remove the lines of arrays that go over multiple lines
+		end := i
+		if v := strings.TrimSpace(trimmed[eq+1:]); strings.HasPrefix(v, "[") {
+			for end < len(lines)-1 && !strings.Contains(lines[end], "]") {
+				end++
+			}
+		}
+		lines = append(lines[:i+1], lines[end+1:]...)
+		lines[i] = line
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	if sectionEnd == -1 {
+		lines = append(lines, "", "["+section+"]", line)
+	} else {
+		lines = append(lines[:sectionEnd+1], append([]string{line}, lines[sectionEnd+1:]...)...)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// SetKeyBindings binds the keys to the command, instead of the ones it has,
+// and saves them to the config file. Keys are written like in the config.
+func SetKeyBindings(cmd Command, keys []string) error {
+	name, ok := BindingName(cmd)
+	if !ok {
+		return fmt.Errorf("keys can't be bound to command %d", cmd)
+	}
+	if useDefaults {
+		return ErrDefaultConfig
+	}
+	if _, tab := configTabNBindings[cmd]; tab && viper.GetString("keybindings.shift_numbers") != "" {
+		return ErrShiftNumbers
+	}
+
+	data, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(configPath)
+	if err != nil {
+		return err
+	}
+	toml := setTOMLValue(string(data), "keybindings", name, tomlKeys(keys))
+	if err := ioutil.WriteFile(configPath, []byte(toml), fi.Mode().Perm()); err != nil {
+		return err
+	}
+
+	if keys == nil {
+		keys = []string{} // nil would use the default keys
+	}
+	viper.Set("keybindings."+name, keys)
+	KeyInit()
+	return nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import "testing"
+
+func TestSetTOMLValue(t *testing.T) {
+	tests := []struct {
+		name string
+		toml string
+		want string
+	}{
+		{
+			"replace",
+			"[a-general]\nhome = \"x\"\n\n[keybindings]\nbind_reload = \"R\"\nbind_quit = \"q\"\n",
+			"[a-general]\nhome = \"x\"\n\n[keybindings]\nbind_reload = [\"R\", \"Ctrl-R\"]\nbind_quit = \"q\"\n",
+		},
+		{
+			"replace multi-line array",
+			"[keybindings]\nbind_reload = [\n  \"R\",\n  \"r\",\n]\nbind_quit = \"q\"\n",
+			"[keybindings]\nbind_reload = [\"R\", \"Ctrl-R\"]\nbind_quit = \"q\"\n",
+		},
+		{
+			"same key in another section",
+			"[keybindings]\nbind_quit = \"q\"\n# bind_reload = \"R\"\n\n[other]\nbind_reload = 1\n",
+			"[keybindings]\nbind_quit = \"q\"\nbind_reload = [\"R\", \"Ctrl-R\"]\n# bind_reload = \"R\"\n" +
+				"\n[other]\nbind_reload = 1\n",
+		},
+		{
+			"empty section",
+			"[keybindings]\n# bind_reload = \"R\"\n",
+			"[keybindings]\nbind_reload = [\"R\", \"Ctrl-R\"]\n# bind_reload = \"R\"\n",
+		},
+		{
+			"no section",
+			"[a-general]\nhome = \"x\"\n",
+			"[a-general]\nhome = \"x\"\n\n[keybindings]\nbind_reload = [\"R\", \"Ctrl-R\"]\n",
+		},
+	}
+	for _, tt := range tests {
+		got := setTOMLValue(tt.toml, "keybindings", "bind_reload", tomlKeys([]string{"R", "Ctrl-R"}))
+		if got != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
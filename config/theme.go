@@ -63,6 +63,28 @@ var theme = map[string]tcell.Color{
 	"quote_text":        tcell.ColorWhite,
 	"preformatted_text": tcell.Color229, // xterm:Wheat1, #ffffaf
 	"list_text":         tcell.ColorWhite,
+
+	"diff_added":   tcell.ColorGreen,
+	"diff_removed": tcell.ColorRed,
+	"diff_hunk":    tcell.ColorTeal,
+}
+
+// Theme keys that have no color by default. Until they're set, the color
+// of the key they map to is used instead.
+var fallbackColors = map[string]string{
+	"relative_link":   "amfora_link",
+	"cross_host_link": "amfora_link",
+	"gopher_link":     "foreign_link",
+	"http_link":       "foreign_link",
+}
+
+// lookupColor returns the color for the key, using fallbackColors if needed.
+// themeMu must be locked for reading.
+func lookupColor(key string) tcell.Color {
+	if c, ok := theme[key]; ok {
+		return c
+	}
+	return theme[fallbackColors[key]]
 }
 
 func SetColor(key string, color tcell.Color) {
@@ -75,7 +97,7 @@ func SetColor(key string, color tcell.Color) {
 func GetColor(key string) tcell.Color {
 	themeMu.RLock()
 	defer themeMu.RUnlock()
-	return theme[key].TrueColor()
+	return lookupColor(key).TrueColor()
 }
 
 // GetColorString returns a string that can be used in a cview color tag,
@@ -84,5 +106,5 @@ func GetColor(key string) tcell.Color {
 func GetColorString(key string) string {
 	themeMu.RLock()
 	defer themeMu.RUnlock()
-	return fmt.Sprintf("#%06x", theme[key].TrueColor().Hex())
+	return fmt.Sprintf("#%06x", lookupColor(key).TrueColor().Hex())
 }
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Built-in themes, which can be picked with the "base" key of the theme section
+// of the config. Each one only has the colors that are different from the default
+// theme, and colors set in the config are applied on top of it.
+var builtinThemes = map[string]map[string]string{
+	// White and bright colors on black, and modals that stand out from pages
+	// by their borders and text instead of their background
+	"high-contrast": {
+		"bg":              "#000000",
+		"tab_num":         "#ffff00",
+		"tab_divider":     "#ffffff",
+		"bottombar_label": "#000000",
+		"bottombar_text":  "#000000",
+		"bottombar_bg":    "#ffffff",
+		"scrollbar":       "#ffffff",
+
+		"btn_bg":   "#ffffff",
+		"btn_text": "#000000",
+
+		"dl_choice_modal_bg":      "#000000",
+		"dl_choice_modal_text":    "#ffffff",
+		"dl_modal_bg":             "#000000",
+		"dl_modal_text":           "#ffffff",
+		"info_modal_bg":           "#000000",
+		"info_modal_text":         "#ffffff",
+		"error_modal_bg":          "#000000",
+		"error_modal_text":        "#ffff00",
+		"yesno_modal_bg":          "#000000",
+		"yesno_modal_text":        "#ffffff",
+		"tofu_modal_bg":           "#000000",
+		"tofu_modal_text":         "#ffff00",
+		"subscription_modal_bg":   "#000000",
+		"subscription_modal_text": "#ffffff",
+
+		"input_modal_bg":         "#000000",
+		"input_modal_text":       "#ffffff",
+		"input_modal_field_bg":   "#ffffff",
+		"input_modal_field_text": "#000000",
+
+		"bkmk_modal_bg":         "#000000",
+		"bkmk_modal_text":       "#ffffff",
+		"bkmk_modal_label":      "#ffff00",
+		"bkmk_modal_field_bg":   "#ffffff",
+		"bkmk_modal_field_text": "#000000",
+
+		"hdg_1":             "#ffff00",
+		"hdg_2":             "#00ffff",
+		"hdg_3":             "#ffffff",
+		"amfora_link":       "#00ffff",
+		"foreign_link":      "#ff87ff",
+		"link_number":       "#ffffff",
+		"regular_text":      "#ffffff",
+		"quote_text":        "#ffffff",
+		"preformatted_text": "#ffffff",
+		"list_text":         "#ffffff",
+
+		"diff_added":   "#00ff00",
+		"diff_removed": "#ff8787",
+		"diff_hunk":    "#00ffff",
+	},
+
+	// For red-green color blindness, where greens are hard to tell from reds.
+	// The colors are from the Okabe-Ito palette, which uses blues, oranges, and
+	// yellows instead.
+	"deuteranopia": {
+		"tab_num":         "#0072b2",
+		"bottombar_label": "#0072b2",
+
+		"dl_modal_bg":    "#a35f00",
+		"error_modal_bg": "#8c3c00",
+		"tofu_modal_bg":  "#8c3c00",
+		"input_modal_bg": "#0072b2",
+
+		"hdg_1":        "#e69f00",
+		"hdg_2":        "#56b4e9",
+		"hdg_3":        "#f0e442",
+		"amfora_link":  "#56b4e9",
+		"foreign_link": "#cc79a7",
+
+		"diff_added":   "#56b4e9",
+		"diff_removed": "#e69f00",
+		"diff_hunk":    "#cc79a7",
+	},
+
+	// Like deuteranopia, but reds also look dark, so brighter colors are
+	// used in their place.
+	"protanopia": {
+		"tab_num":         "#0072b2",
+		"bottombar_label": "#0072b2",
+
+		"dl_modal_bg":    "#a35f00",
+		"error_modal_bg": "#6b4f00",
+		"tofu_modal_bg":  "#6b4f00",
+		"input_modal_bg": "#0072b2",
+
+		"hdg_1":        "#f0e442",
+		"hdg_2":        "#56b4e9",
+		"hdg_3":        "#e69f00",
+		"amfora_link":  "#56b4e9",
+		"foreign_link": "#e69f00",
+
+		"diff_added":   "#56b4e9",
+		"diff_removed": "#f0e442",
+		"diff_hunk":    "#cc79a7",
+	},
+}
+
+// builtinThemeNames returns the names of the built-in themes, sorted.
+func builtinThemeNames() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// setBuiltinTheme sets the colors of the built-in theme with the given name.
+// The default theme is called "default", and doesn't change anything.
+func setBuiltinTheme(name string) error {
+	if name == "" || name == "default" {
+		return nil
+	}
+	colors, ok := builtinThemes[name]
+	if !ok {
+		return fmt.Errorf(`unknown theme base "%s", it can be one of: default, %s`,
+			name, strings.Join(builtinThemeNames(), ", "))
+	}
+	for k, v := range colors {
+		SetColor(k, tcell.GetColor(v))
+	}
+	return nil
+}
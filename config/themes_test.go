@@ -0,0 +1,23 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestBuiltinThemes(t *testing.T) {
+	for name, colors := range builtinThemes {
+		for k, v := range colors {
+			if _, ok := theme[k]; !ok {
+				t.Errorf("theme %s sets unknown key %s", name, k)
+			}
+			if tcell.GetColor(v) == tcell.ColorDefault {
+				t.Errorf("theme %s has invalid color %s for %s", name, v, k)
+			}
+		}
+	}
+	if err := setBuiltinTheme("no-such-theme"); err == nil {
+		t.Error("unknown theme didn't return an error")
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+)
+
+// configErrorText returns the text that explains the error in the config file.
+func configErrorText(e *config.Error) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Amfora couldn't load its config file:\n  %s\n\n", e.Path)
+
+	var where []string
+	if e.Line > 0 {
+		where = append(where, fmt.Sprintf("Line %d", e.Line))
+	}
+	if e.Key != "" {
+		where = append(where, e.Key)
+	}
+	if len(where) > 0 {
+		fmt.Fprintf(&b, "  %s\n", strings.Join(where, ", "))
+	}
+	fmt.Fprintf(&b, "  %s\n", e.Err)
+	if e.Expected != "" {
+		fmt.Fprintf(&b, "\n  Expected %s.\n", e.Expected)
+	}
+	return b.String()
+}
+
+// configErrorScreen shows what's wrong with the config file, and asks whether
+// to continue with the default config instead. It returns true if the user
+// wants to. Errors that aren't about a value in the config file can't be
+// fixed by that, and are only shown, like when stdin isn't a terminal.
+func configErrorScreen(err error) bool {
+	var cerr *config.Error
+	if !errors.As(err, &cerr) {
+		fmt.Fprintf(os.Stderr, "Config error: %v\n", err)
+		return false
+	}
+	fmt.Fprint(os.Stderr, configErrorText(cerr))
+	if !isStdinEmpty() {
+		// Input is piped in, there's no one to ask
+		return false
+	}
+
+	fmt.Fprint(os.Stderr, "\nPress Enter to continue with the default config, or type q and press Enter to quit and fix it. ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	return !strings.EqualFold(strings.TrimSpace(line), "q")
+}
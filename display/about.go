@@ -17,8 +17,16 @@ func aboutInit(version, commit, builtBy string) {
 
 => about:bookmarks
 => about:subscriptions
+=> about:reading
+=> about:archive
+=> about:certificates
+=> about:clear
+=> about:keys
+=> about:link-check
 => about:manage-subscriptions
 => about:newtab
+=> about:network
+=> about:plugins
 => about:version
 => about:license
 => about:thanks
@@ -34,7 +42,7 @@ func aboutInit(version, commit, builtBy string) {
 }
 
 func createAboutPage(url string, content string) structs.Page {
-	renderContent, links := renderer.RenderGemini(content, textWidth(), false)
+	renderContent, links := renderer.RenderGemini(content, textWidth(), false, renderer.ANSIEnabled(""), "")
 	return structs.Page{
 		Raw:       content,
 		Content:   renderContent,
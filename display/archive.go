@@ -0,0 +1,186 @@
+package display
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/archive"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+const archiveTimeFormat = "2006-01-02 15:04:05"
+
+// archivePage saves a snapshot of the tab's page to the archive.
+func archivePage(t *tab) {
+	p := t.page
+	if !t.hasContent() || t.isAnAboutPage() {
+		return
+	}
+
+	header := p.Meta
+	body := p.Body
+	if body == nil {
+		body = []byte(p.Raw)
+	}
+	if header == "" {
+		// Not from a response, like a page from a file
+		mediatype := p.RawMediatype
+		if mediatype == "" {
+			mediatype = string(p.Mediatype)
+		}
+		params := make(map[string]string)
+		if p.Lang != "" {
+			params["lang"] = p.Lang
+		}
+		header = mediatype
+		if formatted := mime.FormatMediaType(mediatype, params); formatted != "" {
+			header = formatted
+		}
+	}
+
+	mt := p.Mediatype
+	if p.ViewedAs != "" {
+		mt = p.ViewedAs
+	}
+	s, err := archive.Save(archive.Snapshot{
+		URL:       p.URL,
+		Header:    "20 " + header, // Pages are only made from successful responses
+		Mediatype: string(mt),
+		Lang:      p.Lang,
+	}, body)
+	if err != nil {
+		Error("Archive Error", i18n.T("The page couldn't be archived: %v", err))
+		return
+	}
+	Info(i18n.T("Archived the page, at %s.\n\nSee about:archive for all archived pages.",
+		s.Time.Format(archiveTimeFormat)))
+}
+
+// archiveQueryURL returns the about:archive URL for viewing the snapshots
+// of the URL, or one of them if the ID isn't empty.
+func archiveQueryURL(u, id string) string {
+	q := url.Values{}
+	q.Set("url", u)
+	if id != "" {
+		q.Set("id", id)
+	}
+	return "about:archive?" + q.Encode()
+}
+
+// archivePageRaw returns the gemtext of about:archive, or the list of
+// snapshots for one URL if it isn't empty.
+func archivePageRaw(u string) (string, error) {
+	var sb strings.Builder
+	if u == "" {
+		latest, err := archive.Latest()
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString("# Archive\n\n")
+		fmt.Fprintf(&sb, "Snapshots of pages, newest first. Press %s on a page to archive it.\n\n",
+			strings.Split(config.GetKeyBinding(config.CmdArchive), ",")[0])
+		if len(latest) == 0 {
+			sb.WriteString("No pages have been archived yet.\n")
+		}
+		for _, s := range latest {
+			fmt.Fprintf(&sb, "=> %s %s\n", archiveQueryURL(s.URL, ""), s.URL)
+			fmt.Fprintf(&sb, "Last archived %s\n\n", s.Time.Format(archiveTimeFormat))
+		}
+		return sb.String(), nil
+	}
+
+	snapshots, err := archive.Snapshots(u)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprintf(&sb, "# Archive of %s\n\n", u)
+	fmt.Fprintf(&sb, "=> %s Current version\n", u)
+	sb.WriteString("=> about:archive All archived pages\n\n")
+	if len(snapshots) == 0 {
+		sb.WriteString("This page hasn't been archived.\n")
+	} else {
+		sb.WriteString("## Snapshots\n\n")
+	}
+	for _, s := range snapshots {
+		fmt.Fprintf(&sb, "=> %s %s\n", archiveQueryURL(u, s.ID), s.Time.Format(archiveTimeFormat))
+		fmt.Fprintf(&sb, "=> %s&remove Remove\n\n", archiveQueryURL(u, s.ID))
+	}
+	return sb.String(), nil
+}
+
+// Archive displays about:archive on the tab, or handles one of its queries.
+// It returns the URL to add to the history, and whether there is one.
+func Archive(t *tab, u string) (string, bool) {
+	var q url.Values
+	if i := strings.IndexByte(u, '?'); i != -1 {
+		var err error
+		q, err = url.ParseQuery(u[i+1:])
+		if err != nil {
+			Error("URL Error", "Invalid query string: "+err.Error())
+			return "", false
+		}
+	}
+	pageURL := q.Get("url")
+	id := q.Get("id")
+
+	if id != "" {
+		if _, ok := q["remove"]; ok {
+			err := archive.Remove(pageURL, id)
+			Archive(t, archiveQueryURL(pageURL, "")) // Reload
+			if err != nil {
+				Error("Archive Error", err.Error())
+			}
+			return "", false
+		}
+		return showSnapshot(t, u, pageURL, id)
+	}
+
+	raw, err := archivePageRaw(pageURL)
+	if err != nil {
+		Error("Archive Error", err.Error())
+		return "", false
+	}
+	content, links := renderer.RenderGemini(raw, textWidth(), false, renderer.ANSIEnabled(""), "")
+	page := structs.Page{
+		Raw:       raw,
+		Content:   content,
+		Links:     links,
+		URL:       u,
+		TermWidth: termW,
+		Mediatype: structs.TextGemini,
+	}
+	setPage(t, &page)
+	t.applyBottomBar()
+	return u, true
+}
+
+// showSnapshot displays a snapshot from the archive. It's shown with the
+// page's own URL, so that relative links work.
+func showSnapshot(t *tab, u, pageURL, id string) (string, bool) {
+	var raw string
+	s, body, err := archive.Get(pageURL, id)
+	if err == nil {
+		raw, err = renderer.DecodeBody(s.Meta(), body)
+	}
+	if err != nil {
+		Error("Archive Error", "The snapshot couldn't be opened: "+err.Error())
+		return "", false
+	}
+	page := structs.Page{
+		Raw:       raw,
+		URL:       s.URL,
+		TermWidth: -1, // Render it
+		Mediatype: structs.Mediatype(s.Mediatype),
+		Lang:      s.Lang,
+		MadeAt:    s.Time,
+	}
+	setPage(t, &page)
+	t.barText = s.URL + " (archived " + s.Time.Format(archiveTimeFormat) + ")"
+	t.applyBottomBar()
+	return u, true
+}
@@ -0,0 +1,60 @@
+package display
+
+import (
+	"net/url"
+
+	"github.com/makeworld-the-better-one/amfora/cache"
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// blockHost asks whether to block the host of the selected link, or of the
+// tab's page if no link is selected, and blocks it. The pages of all the tabs
+// and the cache are rendered again, so links to the host are marked.
+//
+// It should be called in a goroutine.
+func blockHost(t *tab) {
+	defer RecoverCrash()
+	if !t.hasContent() {
+		Info("There's no host to block.")
+		return
+	}
+	u := t.page.URL
+	if t.page.Mode == structs.ModeLinkSelect {
+		var err error
+		u, err = resolveRelLink(t, t.page.URL, t.page.Selected)
+		if err != nil {
+			Error("URL Error", err.Error())
+			return
+		}
+	}
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Hostname() == "" {
+		Info("There's no host to block.")
+		return
+	}
+	host := parsed.Hostname()
+	if client.IsBlocked(host) {
+		Info(i18n.T("%s is already blocked.", escapeMeta(host)))
+		return
+	}
+
+	if !YesNo(i18n.T("Block %s?\nPages from it won't be loaded, and links to it will be marked.", escapeMeta(host))) {
+		return
+	}
+	if err := client.BlockHost(host); err != nil {
+		Error("Blocklist Error", "Couldn't save the blocklist: "+escapeMeta(err.Error()))
+		return
+	}
+
+	App.QueueUpdateDraw(func() {
+		cache.MarkUnrendered()
+		for _, tab := range tabs {
+			if tab.hasContent() {
+				tab.page.TermWidth = -1 // Force it to be rendered again
+			}
+		}
+		reformatTabs()
+	})
+}
@@ -124,15 +124,18 @@ func openBkmkModal(name string, exists bool) (string, bkmkAction) {
 
 // Bookmarks displays the bookmarks page on the current tab.
 func Bookmarks(t *tab) {
-	bkmkPageRaw := "# Bookmarks\r\n\r\n"
-
-	// Gather bookmarks
-	names, urls := bookmarks.All()
-	for i := range names {
-		bkmkPageRaw += fmt.Sprintf("=> %s %s\r\n", urls[i], names[i])
+	bkmkPageRaw, ok := bookmarks.Gemtext()
+	if !ok {
+		bkmkPageRaw = "# Bookmarks\r\n\r\n"
+
+		// Gather bookmarks
+		names, urls := bookmarks.All()
+		for i := range names {
+			bkmkPageRaw += fmt.Sprintf("=> %s %s\r\n", urls[i], names[i])
+		}
 	}
 	// Render and display
-	content, links := renderer.RenderGemini(bkmkPageRaw, textWidth(), false)
+	content, links := renderer.RenderGemini(bkmkPageRaw, textWidth(), false, renderer.ANSIEnabled(""), "")
 	page := structs.Page{
 		Raw:       bkmkPageRaw,
 		Content:   content,
@@ -149,6 +152,7 @@ func Bookmarks(t *tab) {
 // It is the high-level way of doing it. It should be called in a goroutine.
 // It can also be called to edit an existing bookmark.
 func addBookmark() {
+	defer RecoverCrash()
 	t := tabs[curTab]
 	p := t.page
 
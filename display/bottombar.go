@@ -0,0 +1,258 @@
+package display
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/logger"
+	"github.com/spf13/viper"
+)
+
+// What's typed in the bottom bar is saved, and can be brought back with Up
+// and Down, even in a later session. Nothing typed in private tabs is saved.
+// The bar also has the editing keys of readline, the library most shells use,
+// or the ones of vi, see vi.go.
+
+// The number of lines of input that are kept.
+const maxInputHistory = 500
+
+var (
+	inputHistory    []string // Oldest first
+	inputHistoryPos = -1     // The line shown from inputHistory, or -1 if it's what's being typed
+	inputDraft      string   // What was being typed before going through the history
+)
+
+func loadInputHistory() {
+	data, err := ioutil.ReadFile(config.InputHistoryPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warnf("Couldn't read the input history: %v", err)
+		}
+		return
+	}
+	if err := json.Unmarshal(data, &inputHistory); err != nil {
+		logger.Warnf("Couldn't read the input history: %v", err)
+		inputHistory = nil
+	}
+}
+
+func saveInputHistory() {
+	data, err := json.Marshal(inputHistory)
+	if err == nil {
+		err = ioutil.WriteFile(config.InputHistoryPath, data, 0600)
+	}
+	if err != nil {
+		logger.Warnf("Couldn't save the input history: %v", err)
+	}
+}
+
+// addInputHistory adds the line to the end of the history, removing it from
+// where it was before.
+func addInputHistory(line string) {
+	inputHistoryPos = -1
+	if line == "" || tabs[curTab].private {
+		return
+	}
+	for i := range inputHistory {
+		if inputHistory[i] == line {
+			inputHistory = append(inputHistory[:i], inputHistory[i+1:]...)
+			break
+		}
+	}
+	if len(inputHistory) >= maxInputHistory {
+		inputHistory = inputHistory[len(inputHistory)-maxInputHistory+1:]
+	}
+	inputHistory = append(inputHistory, line)
+	saveInputHistory()
+}
+
+// clearInputHistory is for the "inputs" clearable, see clear.go.
+func clearInputHistory() error {
+	App.QueueUpdate(func() {
+		inputHistory = nil
+		inputHistoryPos = -1
+	})
+	if err := os.Remove(config.InputHistoryPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// browseInputHistory returns the line of the history before the one shown,
+// or after it if older is false. It returns false if there isn't one.
+func browseInputHistory(current string, older bool) (string, bool) {
+	pos := inputHistoryPos
+	switch {
+	case older && pos == -1 && len(inputHistory) > 0:
+		inputDraft = current
+		pos = len(inputHistory) - 1
+	case older && pos > 0:
+		pos--
+	case !older && pos != -1:
+		pos++
+	default:
+		return "", false
+	}
+	inputHistoryPos = pos
+	if pos >= len(inputHistory) {
+		inputHistoryPos = -1
+		return inputDraft, true
+	}
+	return inputHistory[pos], true
+}
+
+// isWordChar returns true for the characters that make up words, for moving
+// by words like readline.
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// wordBack returns where the word before pos starts. If onlySpace is true,
+// words are only separated by whitespace.
+func wordBack(text string, pos int, onlySpace bool) int {
+	inWord := func(r rune) bool {
+		if onlySpace {
+			return !unicode.IsSpace(r)
+		}
+		return isWordChar(r)
+	}
+	// Skip what's between the words, and then the word
+	for pos > 0 {
+		r, size := utf8.DecodeLastRuneInString(text[:pos])
+		if inWord(r) {
+			break
+		}
+		pos -= size
+	}
+	for pos > 0 {
+		r, size := utf8.DecodeLastRuneInString(text[:pos])
+		if !inWord(r) {
+			break
+		}
+		pos -= size
+	}
+	return pos
+}
+
+// wordForward returns where the word after pos ends.
+func wordForward(text string, pos int) int {
+	for pos < len(text) {
+		r, size := utf8.DecodeRuneInString(text[pos:])
+		if isWordChar(r) {
+			break
+		}
+		pos += size
+	}
+	for pos < len(text) {
+		r, size := utf8.DecodeRuneInString(text[pos:])
+		if !isWordChar(r) {
+			break
+		}
+		pos += size
+	}
+	return pos
+}
+
+// editLine does what the key does in readline, with the text and the cursor
+// at pos, a byte offset. It returns the new text and cursor, and false if the
+// key isn't one of these:
+//
+//	Ctrl-A, Ctrl-E    Move to the start or end
+//	Alt-B, Alt-F      Move back or forward a word
+//	Ctrl-W            Delete the word before the cursor, up to whitespace
+//	Alt-Backspace     Delete the word before the cursor
+//	Alt-D             Delete the word after the cursor
+//	Ctrl-U, Ctrl-K    Delete everything before or after the cursor
+func editLine(text string, pos int, event *tcell.EventKey) (string, int, bool) {
+	if pos < 0 || pos > len(text) {
+		pos = len(text)
+	}
+	alt := event.Modifiers()&tcell.ModAlt != 0
+
+	//nolint:exhaustive
+	switch event.Key() {
+	case tcell.KeyCtrlA:
+		return text, 0, true
+	case tcell.KeyCtrlE:
+		return text, len(text), true
+	case tcell.KeyCtrlW:
+		start := wordBack(text, pos, true)
+		return text[:start] + text[pos:], start, true
+	case tcell.KeyCtrlU:
+		return text[pos:], 0, true
+	case tcell.KeyCtrlK:
+		return text[:pos], pos, true
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if alt {
+			start := wordBack(text, pos, false)
+			return text[:start] + text[pos:], start, true
+		}
+	case tcell.KeyRune:
+		if !alt {
+			break
+		}
+		switch event.Rune() {
+		case 'b':
+			return text, wordBack(text, pos, false), true
+		case 'f':
+			return text, wordForward(text, pos), true
+		case 'd':
+			return text[:pos] + text[wordForward(text, pos):], pos, true
+		}
+	}
+	return text, pos, false
+}
+
+// bottomBarKey handles the keys for the history and editing, when the
+// bottom bar has focus.
+func bottomBarKey(event *tcell.EventKey) *tcell.EventKey {
+	if following {
+		// Only numbers are typed
+		return event
+	}
+	if viper.GetString("a-general.editing_mode") == "vi" {
+		wasNormal := barVi.normal
+		text, pos, result := barVi.key(bottomBar.GetText(), bottomBar.GetCursorPosition(), event)
+		if barVi.normal != wasNormal {
+			updateIndicator(tabs[curTab])
+		}
+		switch result {
+		case viDone:
+			if text != bottomBar.GetText() {
+				bottomBar.SetText(text)
+			}
+			bottomBar.SetCursorPosition(pos)
+			return nil
+		case viOlder:
+			event = tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+		case viNewer:
+			event = tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+		case viCancel:
+			return event // Esc leaves the bar
+		}
+	}
+
+	//nolint:exhaustive
+	switch event.Key() {
+	case tcell.KeyUp, tcell.KeyDown:
+		if line, ok := browseInputHistory(bottomBar.GetText(), event.Key() == tcell.KeyUp); ok {
+			bottomBar.SetText(line) // The cursor is placed at the end
+		}
+		return nil
+	}
+
+	text, pos, ok := editLine(bottomBar.GetText(), bottomBar.GetCursorPosition(), event)
+	if !ok {
+		return event
+	}
+	if text != bottomBar.GetText() {
+		bottomBar.SetText(text)
+	}
+	bottomBar.SetCursorPosition(pos)
+	return nil
+}
@@ -0,0 +1,78 @@
+package display
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestEditLine(t *testing.T) {
+	const text = "gemini://example.com/some path"
+	tests := []struct {
+		key     tcell.Key
+		r       rune
+		mod     tcell.ModMask
+		pos     int
+		want    string
+		wantPos int
+	}{
+		{tcell.KeyCtrlA, 0, tcell.ModCtrl, 10, text, 0},
+		{tcell.KeyCtrlE, 0, tcell.ModCtrl, 10, text, len(text)},
+		{tcell.KeyCtrlW, 0, tcell.ModCtrl, len(text), "gemini://example.com/some ", 26},
+		{tcell.KeyCtrlW, 0, tcell.ModCtrl, 26, "path", 0},
+		{tcell.KeyCtrlU, 0, tcell.ModCtrl, 9, "example.com/some path", 0},
+		{tcell.KeyCtrlK, 0, tcell.ModCtrl, 9, "gemini://", 9},
+		{tcell.KeyRune, 'b', tcell.ModAlt, len(text), text, 26},
+		{tcell.KeyRune, 'b', tcell.ModAlt, 21, text, 17},
+		{tcell.KeyRune, 'f', tcell.ModAlt, 0, text, 6},
+		{tcell.KeyRune, 'f', tcell.ModAlt, 6, text, 16},
+		{tcell.KeyRune, 'd', tcell.ModAlt, 20, "gemini://example.com path", 20},
+		{tcell.KeyBackspace2, 0, tcell.ModAlt, 25, "gemini://example.com/ path", 21},
+	}
+	for _, tt := range tests {
+		got, pos, ok := editLine(text, tt.pos, tcell.NewEventKey(tt.key, tt.r, tt.mod))
+		if !ok || got != tt.want || pos != tt.wantPos {
+			t.Errorf("editLine(%q, %d, %v %q) = %q, %d, %v, want %q, %d",
+				text, tt.pos, tt.key, tt.r, got, pos, ok, tt.want, tt.wantPos)
+		}
+	}
+
+	// Keys that aren't for editing are typed as usual
+	for _, e := range []*tcell.EventKey{
+		tcell.NewEventKey(tcell.KeyRune, 'b', tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone),
+	} {
+		if _, _, ok := editLine(text, 5, e); ok {
+			t.Errorf("editLine handled %v %q", e.Key(), e.Rune())
+		}
+	}
+}
+
+func TestBrowseInputHistory(t *testing.T) {
+	inputHistory = []string{"one", "two"}
+	inputHistoryPos = -1
+	defer func() {
+		inputHistory = nil
+		inputHistoryPos = -1
+	}()
+
+	steps := []struct {
+		older bool
+		want  string
+		ok    bool
+	}{
+		{false, "", false},
+		{true, "two", true},
+		{true, "one", true},
+		{true, "", false},
+		{false, "two", true},
+		{false, "typed", true},
+		{false, "", false},
+	}
+	for i, s := range steps {
+		got, ok := browseInputHistory("typed", s.older)
+		if got != s.want || ok != s.ok {
+			t.Errorf("step %d: got %q, %v, want %q, %v", i, got, ok, s.want, s.ok)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package display
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/makeworld-the-better-one/amfora/client"
+)
+
+// fingerprint formats the SHA-256 hash of the data like "AB:CD:EF...".
+func fingerprint(data []byte) string {
+	sum := sha256.Sum256(data)
+	parts := make([]string, len(sum))
+	for i := range sum {
+		parts[i] = fmt.Sprintf("%02X", sum[i])
+	}
+	return strings.Join(parts, ":")
+}
+
+// connHost returns the host and port that the URL is fetched from. When a
+// proxy is used, they are the proxy's, and its address is returned too.
+func connHost(parsed *url.URL) (string, string, string) {
+	proxy := urlProxy(parsed)
+	if proxy == "" {
+		return parsed.Hostname(), parsed.Port(), ""
+	}
+	host, port, err := net.SplitHostPort(proxy)
+	if err != nil {
+		// No port in the proxy
+		return proxy, "", proxy
+	}
+	return host, port, proxy
+}
+
+// certInfo displays the details of the server cert for the tab's page,
+// using both the cert the page was fetched with and the TOFU database.
+func certInfo(t *tab) {
+	parsed, err := url.Parse(t.page.URL)
+	if err != nil || t.isAnAboutPage() || parsed.Host == "" {
+		Info("The current page wasn't loaded from a server, so there is no certificate to show.")
+		return
+	}
+
+	host, port, proxy := connHost(parsed)
+	if proxy == "" && parsed.Scheme != "gemini" {
+		Info("Certificates can only be shown for pages loaded over Gemini.")
+		return
+	}
+
+	var sb strings.Builder
+	if proxy != "" {
+		fmt.Fprintf(&sb, "Certificate of the proxy %s\n\n", escapeMeta(proxy))
+	} else {
+		fmt.Fprintf(&sb, "Certificate of %s\n\n", escapeMeta(parsed.Host))
+	}
+
+	cert := t.page.Cert
+	if cert == nil {
+		sb.WriteString("The certificate the page was loaded with isn't available.\n")
+	} else {
+		fmt.Fprintf(&sb, "Subject: %s\n", escapeMeta(cert.Subject.String()))
+		if cert.Issuer.String() == cert.Subject.String() {
+			sb.WriteString("Issuer: Self-signed\n")
+		} else {
+			fmt.Fprintf(&sb, "Issuer: %s\n", escapeMeta(cert.Issuer.String()))
+		}
+		fmt.Fprintf(&sb, "Valid from %s until %s (%s)\n",
+			cert.NotBefore.Format("2006-01-02"),
+			cert.NotAfter.Format("2006-01-02"),
+			humanize.Time(cert.NotAfter),
+		)
+		if t.page.TLSVersion != 0 {
+			fmt.Fprintf(&sb, "Connection: %s\n", client.TLSVersionName(t.page.TLSVersion))
+		}
+		fmt.Fprintf(&sb, "SHA-256 fingerprint:\n%s\n", fingerprint(cert.Raw))
+	}
+
+	sb.WriteString("\n")
+	stored := client.GetFingerprint(host, port)
+	if stored == "" {
+		sb.WriteString("This host isn't in the TOFU database.")
+	} else {
+		since := client.GetTrustedSince(host, port)
+		if since.IsZero() {
+			sb.WriteString("First trusted: Unknown\n")
+		} else {
+			fmt.Fprintf(&sb, "First trusted: %s (%s)\n", since.Local().Format("2006-01-02"), humanize.Time(since))
+		}
+		switch {
+		case cert == nil:
+		case client.CertFingerprint(cert) == stored:
+			sb.WriteString("The certificate matches the one in the TOFU database.")
+		default:
+			sb.WriteString("The certificate doesn't match the one in the TOFU database, " +
+				"it has been replaced since the page was loaded.")
+		}
+	}
+
+	Info(strings.TrimSpace(sb.String()))
+}
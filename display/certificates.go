@@ -0,0 +1,146 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/makeworld-the-better-one/go-gemini"
+	"github.com/spf13/viper"
+)
+
+// certWarningPeriod returns how long before a client certificate expires it's
+// warned about, from the config. Zero means there are no warnings.
+func certWarningPeriod() time.Duration {
+	days := viper.GetInt("auth.expiry_warning")
+	if days < 0 {
+		days = 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// certName returns how a client certificate is named on about:certificates.
+func certName(c client.ConfiguredCert) string {
+	if c.Name == "" {
+		return c.Host
+	}
+	return c.Name + " (" + c.Host + ")"
+}
+
+// certificatesPageRaw returns the gemtext of about:certificates, which lists
+// the client certificates in the config. The ones expiring within the warning
+// period are listed first, with links to renew them.
+func certificatesPageRaw(certs []client.ConfiguredCert, warning time.Duration) string {
+	var expiring, others strings.Builder
+	for _, c := range certs {
+		switch {
+		case c.Err != nil:
+			fmt.Fprintf(&others, "* %s\nCouldn't be loaded: %v\n\n", certName(c), c.Err)
+		case warning > 0 && c.ExpiresWithin(warning):
+			verb := "Expires"
+			if time.Now().After(c.NotAfter) {
+				verb = "Expired"
+			}
+			fmt.Fprintf(&expiring, "* ⚠ %s\n%s %s, on %s\n", certName(c), verb,
+				humanize.Time(c.NotAfter), c.NotAfter.Format("2006-01-02"))
+			fmt.Fprintf(&expiring, "=> about:certificates?renew=%s Renew\n\n", gemini.QueryEscape(c.Host))
+		default:
+			fmt.Fprintf(&others, "* %s\nValid until %s\n", certName(c), c.NotAfter.Format("2006-01-02"))
+			fmt.Fprintf(&others, "=> about:certificates?renew=%s Renew\n\n", gemini.QueryEscape(c.Host))
+		}
+	}
+
+	raw := "# Client Certificates\n\n"
+	if len(certs) == 0 {
+		return raw + "No client certificates are set in the auth section of the config.\n"
+	}
+	if expiring.Len() > 0 {
+		raw += "## Expiring Soon\n\n" + expiring.String()
+		if others.Len() > 0 {
+			raw += "## Others\n\n"
+		}
+	}
+	raw += others.String()
+	raw += "Renewing a certificate keeps its name and key, and makes it valid for as long as it was before, " +
+		"starting now. The old certificate is kept next to it, with \".old\" added to the file name.\n"
+	return raw
+}
+
+// Certificates displays about:certificates on the tab, or renews a certificate
+// for "about:certificates?renew=HOST". It returns the URL to add to the history,
+// and whether there is one.
+func Certificates(t *tab, u string) (string, bool) {
+	if query := strings.TrimPrefix(u, "about:certificates?"); query != u {
+		host, err := gemini.QueryUnescape(strings.TrimPrefix(query, "renew="))
+		if err != nil || !strings.HasPrefix(query, "renew=") {
+			Error("URL Error", "Invalid query string.")
+			return "", false
+		}
+		// The modal waits for an answer, so it can't be shown from here
+		go renewCert(t, host)
+		return "", false
+	}
+
+	raw := certificatesPageRaw(client.ConfiguredCerts(), certWarningPeriod())
+	content, links := renderer.RenderGemini(raw, textWidth(), false, renderer.ANSIEnabled(""), "")
+	page := structs.Page{
+		Raw:       raw,
+		Content:   content,
+		Links:     links,
+		URL:       "about:certificates",
+		TermWidth: termW,
+		Mediatype: structs.TextGemini,
+	}
+	setPage(t, &page)
+	t.applyBottomBar()
+	return u, true
+}
+
+// renewCert renews the client certificate for the host, if the user confirms
+// it. It must be called in a goroutine.
+func renewCert(t *tab, host string) {
+	defer RecoverCrash()
+	if !YesNo(i18n.T("Renew the client certificate for %s?", escapeMeta(host))) {
+		return
+	}
+	notAfter, err := client.RenewCert(host)
+	App.QueueUpdateDraw(func() {
+		if isValidTab(t) && t.page.URL == "about:certificates" {
+			Certificates(t, "about:certificates") // Reload
+		}
+		if err != nil {
+			Error("Certificate Error", i18n.T("The certificate couldn't be renewed: %v", err))
+		} else {
+			Info(i18n.T("The certificate is now valid until %s.", notAfter.Format("2006-01-02")))
+		}
+	})
+}
+
+// WarnExpiringCerts shows a notice in the bottom bar if any client
+// certificates in the config expire soon.
+func WarnExpiringCerts() {
+	defer RecoverCrash()
+	warning := certWarningPeriod()
+	if warning == 0 {
+		return
+	}
+	n := 0
+	for _, c := range client.ConfiguredCerts() {
+		if c.ExpiresWithin(warning) {
+			n++
+		}
+	}
+	if n == 0 {
+		return
+	}
+	msg := "A client certificate expires soon, see about:certificates"
+	if n > 1 {
+		msg = fmt.Sprintf("%d client certificates expire soon, see about:certificates", n)
+	}
+	showNotice("Notice", msg)
+}
@@ -0,0 +1,166 @@
+package display
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/cache"
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+	"github.com/makeworld-the-better-one/amfora/logger"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+)
+
+// Browsing data can be cleared from about:clear, or by typing ":clear" in
+// the bottom bar, optionally followed by what to clear, like ":clear cache".
+
+// clearable is a kind of browsing data that can be cleared.
+type clearable struct {
+	name  string // Used in about:clear?name and ":clear name"
+	title string
+	desc  string
+	clear func() error
+}
+
+var clearables = []clearable{
+	{
+		name:  "history",
+		title: "History",
+		desc: "The back and forward history of open tabs, remembered scroll positions, " +
+			"the network log, and tabs saved after a crash.",
+		clear: clearHistory,
+	},
+	{
+		name:  "cache",
+		title: "Cache",
+		desc:  "Pages stored in memory and on disk, and permanent redirects stored on disk.",
+		clear: func() error {
+			cache.ClearPages()
+			cache.ClearRedirs()
+			return nil
+		},
+	},
+	{
+		name:  "tofu",
+		title: "Trusted certificates",
+		desc: "The server certificates that are trusted, see about:certificates. " +
+			"The next certificate of each server will be trusted without warning.",
+		clear: client.ClearTofu,
+	},
+	{
+		name:  "inputs",
+		title: "Input history",
+		desc:  "What was typed in the bottom bar, like URLs and searches.",
+		clear: clearInputHistory,
+	},
+}
+
+// clearHistory clears the history of open tabs, and the other data that
+// records the pages that were visited.
+func clearHistory() error {
+	App.QueueUpdate(func() {
+		for _, t := range tabs {
+			if len(t.history.urls) > 0 {
+				t.history.urls = []string{t.history.urls[t.history.pos]}
+				t.history.pos = 0
+			}
+		}
+	})
+	scrollMu.Lock()
+	scrollPositions = nil
+	scrollMu.Unlock()
+	saveScrollPositions()
+	client.ClearRequests()
+
+	if err := os.Remove(config.CrashSessionPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// clearOnExit clears the browsing data set in "a-general.clear_on_exit".
+// There's no one to ask or show errors to, so they're only logged.
+func clearOnExit() {
+	for _, name := range viper.GetStringSlice("a-general.clear_on_exit") {
+		found := false
+		for _, c := range clearables {
+			if name != "all" && name != c.name {
+				continue
+			}
+			found = true
+			if err := c.clear(); err != nil {
+				logger.Errorf("Couldn't clear %s on exit: %v", c.name, err)
+			}
+		}
+		if !found {
+			logger.Warnf("Unknown browsing data in clear_on_exit: %s", name)
+		}
+	}
+}
+
+// clearPageRaw returns the gemtext of about:clear.
+func clearPageRaw() string {
+	raw := "# Clear Browsing Data\n\nChoose what to clear, you'll be asked to confirm first. " +
+		"You can also type :clear in the bottom bar, followed by a name like \"cache\" or \"all\".\n\n"
+	for _, c := range clearables {
+		raw += "=> about:clear?" + c.name + " " + c.title + "\n" + c.desc + "\n\n"
+	}
+	raw += "=> about:clear?all Everything above\n"
+	return raw
+}
+
+// ClearPage displays about:clear on the tab.
+func ClearPage(t *tab) {
+	raw := clearPageRaw()
+	content, links := renderer.RenderGemini(raw, textWidth(), false, renderer.ANSIEnabled(""), "")
+	page := structs.Page{
+		Raw:       raw,
+		Content:   content,
+		Links:     links,
+		URL:       "about:clear",
+		TermWidth: termW,
+		Mediatype: structs.TextGemini,
+	}
+	setPage(t, &page)
+	t.applyBottomBar()
+}
+
+// clearData asks to confirm, and then clears the browsing data with the name,
+// or all of it for "all".
+//
+// It should be called in a goroutine.
+func clearData(name string) {
+	defer RecoverCrash()
+	var chosen []clearable
+	var titles []string
+	for _, c := range clearables {
+		if name == "all" || name == c.name {
+			chosen = append(chosen, c)
+			titles = append(titles, strings.ToLower(c.title))
+		}
+	}
+	if len(chosen) == 0 {
+		names := make([]string, 0, len(clearables)+1)
+		for _, c := range clearables {
+			names = append(names, c.name)
+		}
+		Error("Clear Error", i18n.T("There's nothing called \"%s\" to clear. Use one of: %s",
+			escapeMeta(name), strings.Join(append(names, "all"), ", ")))
+		return
+	}
+
+	if !YesNo(i18n.T("Clear %s?\nThis can't be undone.", strings.Join(titles, ", "))) {
+		return
+	}
+	for _, c := range chosen {
+		if err := c.clear(); err != nil {
+			Error("Clear Error", i18n.T("Couldn't clear %s: %s", strings.ToLower(c.title), escapeMeta(err.Error())))
+			return
+		}
+	}
+	showNotice("Notice", "Cleared "+strings.Join(titles, ", "))
+}
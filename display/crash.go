@@ -0,0 +1,177 @@
+package display
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime/debug"
+	"sync"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/cache"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+	"github.com/makeworld-the-better-one/amfora/logger"
+)
+
+// When Amfora crashes, the open tabs are saved to config.CrashSessionPath,
+// and restoring them is offered on the next start.
+
+type savedTab struct {
+	URLs []string `json:"urls"` // History of the tab
+	Pos  int      `json:"pos"`  // Position in the history
+	Row  int      `json:"row"`  // Scroll position of the page
+}
+
+type savedSession struct {
+	Tabs    []savedTab `json:"tabs"`
+	Current int        `json:"current"`
+}
+
+var (
+	// The screen is stored, because getting it from App could block
+	// if the crash happened while App was locked.
+	crashScreen tcell.Screen
+	crashOnce   sync.Once
+)
+
+// currentSession returns the open tabs. Locks aren't used, because it's
+// called after a crash, when they might never be unlocked.
+func currentSession() savedSession {
+	var s savedSession
+	for i, t := range tabs {
+		if t == nil || t.history == nil || len(t.history.urls) == 0 || t.private {
+			continue
+		}
+		if i == curTab {
+			s.Current = len(s.Tabs)
+		}
+		st := savedTab{
+			URLs: append([]string(nil), t.history.urls...),
+			Pos:  t.history.pos,
+		}
+		if t.page != nil {
+			st.Row = t.page.Row
+		}
+		if st.Pos < 0 || st.Pos >= len(st.URLs) {
+			st.Pos = len(st.URLs) - 1
+		}
+		s.Tabs = append(s.Tabs, st)
+	}
+	return s
+}
+
+// onlyNewTabs returns true if there's nothing worth restoring in the session.
+func (s savedSession) onlyNewTabs() bool {
+	for _, st := range s.Tabs {
+		for _, u := range st.URLs {
+			if u != "about:newtab" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func saveSession() error {
+	s := currentSession()
+	if s.onlyNewTabs() {
+		return nil
+	}
+	data, err := json.Marshal(&s)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(config.CrashSessionPath, data, 0600)
+}
+
+// Crash handles a panic. The open tabs are saved, the terminal is restored,
+// and a report is printed. Then Amfora exits.
+//
+// It's called with the value returned by recover, in the main goroutine.
+// Other goroutines should use RecoverCrash.
+func Crash(r interface{}) {
+	crashOnce.Do(func() {
+		logger.Panic(r)
+		saveErr := saveSession()
+		cache.CloseDisk()
+		if crashScreen != nil {
+			crashScreen.Fini()
+		}
+
+		fmt.Fprintf(os.Stderr, "Amfora crashed: %v\n\n", r)
+		if saveErr != nil {
+			fmt.Fprintf(os.Stderr, "The open tabs couldn't be saved: %v\n\n", saveErr)
+		} else if _, err := os.Stat(config.CrashSessionPath); err == nil {
+			fmt.Fprintf(os.Stderr, "The open tabs were saved, and you can restore them the next time Amfora starts.\n\n")
+		}
+		fmt.Fprintf(os.Stderr, "Please report this at https://github.com/makeworld-the-better-one/amfora/issues\n")
+		fmt.Fprintf(os.Stderr, "and include the details below.\n\n%s", debug.Stack())
+		os.Exit(2)
+	})
+}
+
+// RecoverCrash should be deferred at the start of goroutines,
+// so that panics in them are handled by Crash.
+func RecoverCrash() {
+	if r := recover(); r != nil {
+		Crash(r)
+	}
+}
+
+// OfferRestore asks whether to restore the tabs saved by the last crash,
+// if there are any. It should be called in a goroutine, after the app is set up.
+func OfferRestore() {
+	defer RecoverCrash()
+	data, err := ioutil.ReadFile(config.CrashSessionPath)
+	if err != nil {
+		return
+	}
+	// It's only offered once
+	os.Remove(config.CrashSessionPath)
+
+	var s savedSession
+	if err := json.Unmarshal(data, &s); err != nil || len(s.Tabs) == 0 {
+		logger.Warnf("Couldn't read the saved tabs: %v", err)
+		return
+	}
+
+	prompt := i18n.T("Amfora crashed last time. 1 tab was open, restore it?")
+	if len(s.Tabs) > 1 {
+		prompt = i18n.T("Amfora crashed last time. %d tabs were open, restore them?", len(s.Tabs))
+	}
+	if Choice(prompt,
+		[]string{"Restore", "Discard"}) != "Restore" {
+		return
+	}
+
+	if singleTab && s.Current >= 0 && s.Current < len(s.Tabs) {
+		// Only the tab that was being viewed fits
+		s.Tabs = s.Tabs[s.Current : s.Current+1]
+		s.Current = 0
+	}
+
+	App.QueueUpdateDraw(func() {
+		first := NumTabs()
+		for _, st := range s.Tabs {
+			if len(st.URLs) == 0 || st.Pos < 0 || st.Pos >= len(st.URLs) {
+				continue
+			}
+			NewTab()
+			t := tabs[curTab]
+			t.history.urls = st.URLs
+			t.history.pos = st.Pos
+			go func(t *tab, row int) {
+				defer RecoverCrash()
+				handleURL(t, t.history.urls[t.history.pos])
+				t.page.Row = row
+				t.applyAll()
+				App.Draw()
+			}(t, st.Row)
+		}
+		if s.Current >= 0 && first+s.Current < NumTabs() {
+			SwitchTab(first + s.Current)
+		}
+	})
+}
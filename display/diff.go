@@ -0,0 +1,42 @@
+package display
+
+import (
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// showDiff displays what changed on the tab's page when it was reloaded,
+// as a unified diff at about:diff.
+func showDiff(t *tab) {
+	prev := t.previous
+	if prev == nil || !t.hasContent() || prev.URL != t.page.URL {
+		Info("Reload a page to see what changed since it was last loaded.")
+		return
+	}
+
+	format := func(p *structs.Page) string {
+		if p.MadeAt.IsZero() {
+			return p.URL
+		}
+		return p.URL + " (" + p.MadeAt.Format("2006-01-02 15:04:05") + ")"
+	}
+	diff := renderer.UnifiedDiff(format(prev), format(t.page), prev.Raw, t.page.Raw)
+	if diff == "" {
+		Info("The page didn't change when it was reloaded.")
+		return
+	}
+
+	t.diff = &structs.Page{
+		Raw:       diff,
+		URL:       "about:diff",
+		TermWidth: -1, // Render it
+		Mediatype: structs.TextDiff,
+		MadeAt:    time.Now(),
+	}
+	temp := *t.diff // Copy
+	setPage(t, &temp)
+	t.addToHistory("about:diff")
+	t.applyBottomBar()
+}
@@ -11,7 +11,9 @@ import (
 	"code.rocketnine.space/tslocum/cview"
 	"github.com/gdamore/tcell/v2"
 	"github.com/makeworld-the-better-one/amfora/cache"
+	"github.com/makeworld-the-better-one/amfora/client"
 	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/logger"
 	"github.com/makeworld-the-better-one/amfora/renderer"
 	"github.com/makeworld-the-better-one/amfora/structs"
 	"github.com/makeworld-the-better-one/go-gemini"
@@ -22,12 +24,24 @@ var tabs []*tab // Slice of all the current browser tabs
 var curTab = -1 // What tab is currently visible - index for the tabs slice (-1 means there are no tabs)
 
 // Terminal dimensions
+var screenW int
+var screenH int
+
+// Dimensions of the area pages are shown in. It's the whole terminal, unless
+// the view is split, see split.go
 var termW int
 var termH int
 
 // The user input and URL display bar at the bottom
 var bottomBar = cview.NewInputField()
 
+// Shown to the left of the bottom bar, with details about the connection
+// the current page was loaded over. See indicator.go
+var indicator = cview.NewTextView()
+
+// Holds the indicator and the bottom bar
+var bottomRow = cview.NewFlex()
+
 // When the bottom bar string has a space, this regex decides whether it's
 // a non-encoded URL or a search string.
 // See this comment for details:
@@ -47,6 +61,9 @@ var panels = cview.NewPanels()
 // is held in the page named "0".
 var browser = cview.NewTabbedPanels()
 
+// Whether there's only ever one tab, and no tab row. See "a-general.single_tab".
+var singleTab bool
+
 // Root layout
 var layout = cview.NewFlex()
 
@@ -57,43 +74,56 @@ var reformatMu = sync.Mutex{}
 
 var App = cview.NewApplication()
 
+// Whether the stores and plugins are loaded, see Ready.
+var ready bool
+
 func Init(version, commit, builtBy string) {
 	aboutInit(version, commit, builtBy)
+	renderer.SetBlockedFunc(client.IsBlocked)
+
+	crashScreen = App.GetScreen()
+	loadScrollPositions()
+	loadInputHistory()
+	if err := cache.LoadRedirs(config.RedirectCachePath); err != nil {
+		logger.Warnf("Couldn't load the saved redirects: %v", err)
+	}
 
 	App.EnableMouse(false)
 	App.SetRoot(layout, true)
+	App.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
+		updateStatus()
+		updateZen()
+		return false
+	})
 	App.SetAfterResizeFunc(func(width int, height int) {
 		// Store for calculations
-		termW = width
-		termH = height
-
-		// Make sure the current tab content is reformatted when the terminal size changes
-		go func(t *tab) {
-			reformatMu.Lock() // Only allow one reformat job at a time
-			for i := range tabs {
-				// Overwrite all tabs with a new, differently sized, left margin
-				browser.AddTab(
-					strconv.Itoa(i),
-					makeTabLabel(strconv.Itoa(i+1)),
-					makeContentLayout(tabs[i].view, leftMargin()),
-				)
-				if tabs[i] == t {
-					// Reformat page ASAP, in the middle of loop
-					reformatPageAndSetView(t, t.page)
-				}
-			}
-			App.Draw()
-			reformatMu.Unlock()
-		}(tabs[curTab])
+		screenW = width
+		screenH = height
+		resizePanes()
 	})
 
-	panels.AddPanel("browser", browser, true, true)
+	splitInit()
+	panels.AddPanel("browser", panes, true, true)
 
 	helpInit()
+	finderInit()
+	imageInit()
+	peekInit()
+	if config.ScreenReader {
+		screenReaderInit()
+	}
+	zen = viper.GetBool("a-general.zen_mode")
+	singleTab = viper.GetBool("a-general.single_tab")
+	showReadEntries = viper.GetBool("subscriptions.show_read")
 
 	layout.SetDirection(cview.FlexRow)
 	layout.AddItem(panels, 0, 1, true)
-	layout.AddItem(bottomBar, 1, 1, false)
+	layout.AddItem(bottomRow, 1, 1, false)
+
+	indicator.SetDynamicColors(true)
+	bottomRow.SetDirection(cview.FlexColumn)
+	bottomRow.AddItem(indicator, 0, 0, false)
+	bottomRow.AddItem(bottomBar, 0, 1, false)
 
 	if viper.GetBool("a-general.color") {
 		layout.SetBackgroundColor(config.GetColor("bg"))
@@ -102,6 +132,8 @@ func Init(version, commit, builtBy string) {
 		bottomBar.SetLabelColor(config.GetColor("bottombar_label"))
 		bottomBar.SetFieldBackgroundColor(config.GetColor("bottombar_bg"))
 		bottomBar.SetFieldTextColor(config.GetColor("bottombar_text"))
+		indicator.SetBackgroundColor(config.GetColor("bottombar_text"))
+		indicator.SetTextColor(config.GetColor("bottombar_bg"))
 
 		browser.SetTabBackgroundColor(config.GetColor("bg"))
 		browser.SetTabBackgroundColorFocused(config.GetColor("tab_num"))
@@ -118,6 +150,8 @@ func Init(version, commit, builtBy string) {
 		bottomBar.SetLabelColor(tcell.ColorBlack)
 		bottomBar.SetFieldBackgroundColor(tcell.ColorWhite)
 		bottomBar.SetFieldTextColor(tcell.ColorBlack)
+		indicator.SetBackgroundColor(tcell.ColorBlack)
+		indicator.SetTextColor(tcell.ColorWhite)
 
 		browser.SetTabBackgroundColor(tcell.ColorBlack)
 		browser.SetTabBackgroundColorFocused(tcell.ColorWhite)
@@ -130,8 +164,19 @@ func Init(version, commit, builtBy string) {
 		)
 	}
 
+	bottomBar.SetChangedFunc(followChanged)
+	bottomBar.SetInputCapture(bottomBarKey)
 	bottomBar.SetDoneFunc(func(key tcell.Key) {
 		tab := curTab
+		wasFollowing := following
+		if following {
+			endFollow(tabs[tab])
+		}
+		if key == tcell.KeyEnter || key == tcell.KeyEsc {
+			// Going through the history starts from the end again, in insert mode
+			inputHistoryPos = -1
+			barVi.reset()
+		}
 
 		// Reset func to set the bottomBar back to what it was before
 		// Use for errors.
@@ -141,6 +186,15 @@ func Init(version, commit, builtBy string) {
 			App.SetFocus(tabs[tab].view)
 		}
 
+		if followingText {
+			// Enter follows the link that matches best
+			if key != tcell.KeyEnter || !followBestMatch(tabs[tab], bottomBar.GetText()) {
+				endFollowText(tabs[tab])
+				reset()
+			}
+			return
+		}
+
 		//nolint:exhaustive
 		switch key {
 		case tcell.KeyEnter:
@@ -154,6 +208,19 @@ func Init(version, commit, builtBy string) {
 				reset()
 				return
 			}
+			if !wasFollowing {
+				addInputHistory(query)
+			}
+			if fields := strings.Fields(query); fields[0] == ":clear" {
+				// Clearing browsing data, see clear.go
+				if len(fields) == 1 {
+					URL("about:clear")
+					return
+				}
+				reset()
+				go clearData(fields[1])
+				return
+			}
 			if query[0] == '.' && tabs[tab].hasContent() && !tabs[tab].isAnAboutPage() {
 				// Relative url
 				current, err := url.Parse(tabs[tab].page.URL)
@@ -187,18 +254,16 @@ func Init(version, commit, builtBy string) {
 						return
 					}
 					if i <= len(tabs[tab].page.Links) && i > 0 {
-						// Open new tab and load link
-						oldTab := tab
-						NewTab()
 						// Resolve and follow link manually
-						prevParsed, _ := url.Parse(tabs[oldTab].page.URL)
-						nextParsed, err := url.Parse(tabs[oldTab].page.Links[i-1])
+						prevParsed, _ := url.Parse(tabs[tab].page.URL)
+						nextParsed, err := url.Parse(tabs[tab].page.Links[i-1])
 						if err != nil {
 							Error("URL Error", "link URL could not be parsed")
 							reset()
 							return
 						}
-						URL(prevParsed.ResolveReference(nextParsed).String())
+						// Open new tab and load link
+						openInNewTab(prevParsed.ResolveReference(nextParsed).String())
 						return
 					}
 				} else {
@@ -249,7 +314,7 @@ func Init(version, commit, builtBy string) {
 	// Render the default new tab content ONCE and store it for later
 	// This code is repeated in Reload()
 	newTabContent := getNewTabContent()
-	renderedNewTabContent, newTabLinks := renderer.RenderGemini(newTabContent, textWidth(), false)
+	renderedNewTabContent, newTabLinks := renderer.RenderGemini(newTabContent, textWidth(), false, renderer.ANSIEnabled(""), "")
 	newTabPage = structs.Page{
 		Raw:       newTabContent,
 		Content:   renderedNewTabContent,
@@ -264,6 +329,22 @@ func Init(version, commit, builtBy string) {
 	// Setup map of keys to functions here
 	// Changing tabs, new tab, etc
 	App.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// Macros record all keys, even the ones for modals and the bottom bar
+		if event = macroKey(event); event == nil {
+			return nil
+		}
+		if event = markKey(event); event == nil {
+			return nil
+		}
+
+		if !ready {
+			// Only quitting works until the stores and plugins are loaded, see Ready
+			if event.Key() == tcell.KeyCtrlC || config.TranslateKeyEvent(event) == config.CmdQuit {
+				return event
+			}
+			return nil
+		}
+
 		_, ok := App.GetFocus().(*cview.Button)
 		if ok {
 			// It's focused on a modal right now, nothing should interrupt
@@ -311,7 +392,13 @@ func Init(version, commit, builtBy string) {
 			case config.CmdEdit:
 				// Letter e allows to edit current URL
 				bottomBar.SetLabel("[::b]Edit URL: [::-]")
-				bottomBar.SetText(tabs[curTab].page.URL)
+				if tabs[curTab].page.URL == "about:newtab" {
+					// Nothing worth editing, just start typing like CmdBottom
+					bottomBar.SetText("")
+				} else {
+					// The cursor is placed at the end of the text
+					bottomBar.SetText(tabs[curTab].page.URL)
+				}
 				App.SetFocus(bottomBar)
 				return nil
 			case config.CmdAddSub:
@@ -323,6 +410,27 @@ func Init(version, commit, builtBy string) {
 		// All the keys and operations that can work while a tab IS loading
 		//nolint:exhaustive
 		switch cmd {
+		case config.CmdNewPrivateTab:
+			NewPrivateTab()
+			return nil
+		case config.CmdTabIdentity:
+			go tabIdentity(tabs[curTab])
+			return nil
+		case config.CmdRecordMacro:
+			toggleMacroRecording()
+			return nil
+		case config.CmdPlayMacro:
+			chooseMacroRegister(macroPlayRegister)
+			return nil
+		case config.CmdFinder:
+			Finder()
+			return nil
+		case config.CmdJumpBack:
+			jumpTo(tabs[curTab], -1)
+			return nil
+		case config.CmdJumpForward:
+			jumpTo(tabs[curTab], 1)
+			return nil
 		case config.CmdNewTab:
 			if tabs[curTab].page.Mode == structs.ModeLinkSelect {
 				next, err := resolveRelLink(tabs[curTab], tabs[curTab].page.URL, tabs[curTab].page.Selected)
@@ -330,8 +438,7 @@ func Init(version, commit, builtBy string) {
 					Error("URL Error", err.Error())
 					return nil
 				}
-				NewTab()
-				URL(next)
+				openInNewTab(next)
 			} else {
 				NewTab()
 			}
@@ -353,6 +460,20 @@ func Init(version, commit, builtBy string) {
 		case config.CmdHelp:
 			Help()
 			return nil
+		case config.CmdStop:
+			if stop := tabs[curTab].stopLoad; stop != nil {
+				stop()
+			}
+			return nil
+		case config.CmdZen:
+			toggleZen()
+			return nil
+		case config.CmdSplit, config.CmdSplitBelow:
+			toggleSplit(cmd == config.CmdSplit)
+			return nil
+		case config.CmdSplitFocus:
+			focusOtherPane()
+			return nil
 		}
 
 		if cmd >= config.CmdTab1 && cmd <= config.CmdTab0 {
@@ -373,9 +494,38 @@ func Init(version, commit, builtBy string) {
 // Stop stops the app gracefully.
 // In the future it will handle things like ongoing downloads, etc
 func Stop() {
+	for _, t := range tabs {
+		rememberScroll(t)
+	}
+	saveScrollPositions()
+	clearOnExit()
+	cache.CloseDisk()
 	App.Stop()
 }
 
+// reformatTabs fits the tabs to the size of the page area, after it changed.
+func reformatTabs() {
+	// Make sure the visible tab content is reformatted when the size changes
+	go func(t, other *tab) {
+		defer RecoverCrash()
+		reformatMu.Lock() // Only allow one reformat job at a time
+		for i := range tabs {
+			// Overwrite all tabs with a new, differently sized, left margin
+			browser.AddTab(
+				strconv.Itoa(i),
+				tabLabel(i),
+				makeContentLayout(tabs[i].view, leftMargin()),
+			)
+			if tabs[i] == t || tabs[i] == other {
+				// Reformat page ASAP, in the middle of loop
+				reformatPageAndSetView(tabs[i], tabs[i].page)
+			}
+		}
+		App.Draw()
+		reformatMu.Unlock()
+	}(tabs[curTab], splitTab)
+}
+
 // NewTab opens a new tab and switches to it, displaying the
 // the default empty content because there's no URL.
 func NewTab() {
@@ -384,6 +534,12 @@ func NewTab() {
 	// SetDoneFunc to do link highlighting
 	// Add view to pages and switch to it
 
+	if singleTab && NumTabs() > 0 {
+		// The new tab page is opened in the only tab instead
+		URL("about:newtab")
+		return
+	}
+
 	// Process current tab before making a new one
 	if curTab > -1 {
 		// Turn off link selecting mode in the current tab
@@ -402,10 +558,11 @@ func NewTab() {
 
 	browser.AddTab(
 		strconv.Itoa(curTab),
-		makeTabLabel(strconv.Itoa(curTab+1)),
+		tabLabel(curTab),
 		makeContentLayout(tabs[curTab].view, leftMargin()),
 	)
 	browser.SetCurrentTab(strconv.Itoa(curTab))
+	flashChrome()
 	App.SetFocus(tabs[curTab].view)
 
 	bottomBar.SetLabel("")
@@ -416,6 +573,68 @@ func NewTab() {
 	App.Draw()
 }
 
+// NewPrivateTab opens a new private tab. Pages loaded in it aren't remembered
+// or cached, and client certificates are never sent.
+func NewPrivateTab() {
+	if singleTab {
+		Info("Private tabs can't be opened when single_tab is on.")
+		return
+	}
+	NewTab()
+	tabs[curTab].private = true
+	tabs[curTab].cert = client.PrivateCert
+	browser.AddTab(
+		strconv.Itoa(curTab),
+		tabLabel(curTab),
+		makeContentLayout(tabs[curTab].view, leftMargin()),
+	)
+}
+
+// newBackgroundTab opens the absolute URL in a new tab, without switching to it.
+// The tab is private if the current one is, and uses the same identity.
+func newBackgroundTab(u string) {
+	t := makeNewTab()
+	if curTab > -1 {
+		t.private = tabs[curTab].private
+		t.cert = tabs[curTab].cert
+	}
+	tabs = append(tabs, t)
+	temp := newTabPage // Copy
+	setPage(t, &temp)
+	t.addToHistory("about:newtab")
+	t.history.pos = 0 // Manually set as first page
+
+	i := len(tabs) - 1
+	browser.AddTab(
+		strconv.Itoa(i),
+		tabLabel(i),
+		makeContentLayout(t.view, leftMargin()),
+	)
+	flashChrome()
+	go goURL(t, u)
+}
+
+// openInNewTab opens the absolute URL in a new tab, or in the current tab
+// when there's only one tab. The tab is private if the current one is, and
+// uses the same identity.
+func openInNewTab(u string) {
+	if !singleTab {
+		var prev *tab
+		if curTab > -1 {
+			prev = tabs[curTab]
+		}
+		if prev != nil && prev.private {
+			NewPrivateTab()
+		} else {
+			NewTab()
+			if prev != nil {
+				tabs[curTab].cert = prev.cert
+			}
+		}
+	}
+	URL(u)
+}
+
 // CloseTab closes the current tab and switches to the one to its left.
 func CloseTab() {
 	// Basically the NewTab() func inverted
@@ -433,16 +652,23 @@ func CloseTab() {
 		return
 	}
 
+	rememberScroll(tabs[curTab])
 	tabs = tabs[:len(tabs)-1]
 	browser.RemoveTab(strconv.Itoa(curTab))
 
-	if curTab <= 0 {
+	if splitTab != nil {
+		// The other pane takes up the whole view
+		curTab = tabNumber(splitTab)
+		splitTab = nil
+		resizePanes()
+	} else if curTab <= 0 {
 		curTab = NumTabs() - 1
 	} else {
 		curTab--
 	}
 
 	browser.SetCurrentTab(strconv.Itoa(curTab)) // Go to previous page
+	flashChrome()
 	// Restore previous tab's state
 	tabs[curTab].applyAll()
 
@@ -467,6 +693,13 @@ func SwitchTab(tab int) {
 	if curTab > -1 {
 		// Save bottomBar state
 		tabs[curTab].saveBottomBar()
+
+		if splitTab != nil && tabs[tab] == splitTab {
+			// Focus the other pane, the pages stay where they are
+			splitTab = tabs[curTab]
+			splitFirst = !splitFirst
+			layoutPanes()
+		}
 	}
 
 	curTab = tab % NumTabs()
@@ -477,6 +710,7 @@ func SwitchTab(tab int) {
 	tabs[curTab].applyAll()
 
 	App.SetFocus(tabs[curTab].view)
+	flashChrome()
 
 	// Just in case
 	App.Draw()
@@ -487,7 +721,7 @@ func Reload() {
 		// Re-render new tab, similar to Init()
 		newTabContent := getNewTabContent()
 		tmpTermW := termW
-		renderedNewTabContent, newTabLinks := renderer.RenderGemini(newTabContent, textWidth(), false)
+		renderedNewTabContent, newTabLinks := renderer.RenderGemini(newTabContent, textWidth(), false, renderer.ANSIEnabled(""), "")
 		newTabPage = structs.Page{
 			Raw:       newTabContent,
 			Content:   renderedNewTabContent,
@@ -506,8 +740,14 @@ func Reload() {
 	}
 
 	go func(t *tab) {
+		defer RecoverCrash()
+		old := t.page
 		cache.RemovePage(tabs[curTab].page.URL)
-		handleURL(t, t.page.URL, 0) // goURL is not used bc history shouldn't be added to
+		handleURL(t, t.page.URL) // goURL is not used bc history shouldn't be added to
+		if t.page != old && t.page.URL == old.URL && !t.isAnAboutPage() {
+			// Kept to show what changed, see showDiff
+			t.previous = old
+		}
 		if t == tabs[curTab] {
 			// Display the bottomBar state that handleURL set
 			t.applyBottomBar()
@@ -515,6 +755,19 @@ func Reload() {
 	}(tabs[curTab])
 }
 
+// Ready should be called once the subscriptions, bookmarks, reading list,
+// and plugins are loaded, which happens after the UI appears. It binds the
+// plugin keys, lets keys be used, and loads the URL if it isn't empty.
+func Ready(u string) {
+	App.QueueUpdateDraw(func() {
+		pluginsInit()
+		ready = true
+		if u != "" {
+			URL(u)
+		}
+	})
+}
+
 // URL loads and handles the provided URL for the current tab.
 // It should be an absolute URL.
 func URL(u string) {
@@ -536,7 +789,7 @@ func RenderFromString(str string) {
 }
 
 func renderPageFromString(str string) (*structs.Page, bool) {
-	rendered, links := renderer.RenderGemini(str, textWidth(), false)
+	rendered, links := renderer.RenderGemini(str, textWidth(), false, renderer.ANSIEnabled(""), "")
 	page := &structs.Page{
 		Mediatype: structs.TextGemini,
 		Raw:       str,
@@ -17,6 +17,8 @@ import (
 	"code.rocketnine.space/tslocum/cview"
 	"github.com/gdamore/tcell/v2"
 	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/hooks"
+	"github.com/makeworld-the-better-one/amfora/i18n"
 	"github.com/makeworld-the-better-one/amfora/structs"
 	"github.com/makeworld-the-better-one/amfora/sysopen"
 	"github.com/makeworld-the-better-one/go-gemini"
@@ -83,7 +85,6 @@ func dlInit() {
 		frame.SetTitleColor(tcell.ColorWhite)
 	}
 
-	chm.AddButtons([]string{"Open", "Download", "Cancel"})
 	chm.SetBorder(true)
 	chm.GetFrame().SetTitleAlign(cview.AlignCenter)
 	chm.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
@@ -134,12 +135,20 @@ func getMediaHandler(resp *gemini.Response) config.MediaHandler {
 // dlChoice displays the download choice modal and acts on the user's choice.
 // It should run in a goroutine.
 func dlChoice(text, u string, resp *gemini.Response) {
+	defer RecoverCrash()
 	mediaHandler := getMediaHandler(resp)
 	var choice string
 
 	if mediaHandler.NoPrompt {
 		choice = "Open"
 	} else {
+		dlChoiceModal.ClearButtons()
+		mediatype, _, _ := mime.ParseMediaType(resp.Meta)
+		if strings.HasPrefix(mediatype, "image/") && canPreviewImages() {
+			dlChoiceModal.AddButtons([]string{"Open", "Preview", "Download", "Cancel"})
+		} else {
+			dlChoiceModal.AddButtons([]string{"Open", "Download", "Cancel"})
+		}
 		dlChoiceModal.SetText(text)
 		panels.ShowPanel("dlChoice")
 		panels.SendToFront("dlChoice")
@@ -155,6 +164,14 @@ func dlChoice(text, u string, resp *gemini.Response) {
 		resp.Body.Close() // Only close when the file is downloaded
 		return
 	}
+	if choice == "Preview" {
+		panels.HidePanel("dlChoice")
+		if !showImage(resp) {
+			Error("Preview Error", "The image could not be displayed, it may be in an unsupported format.")
+		}
+		resp.Body.Close()
+		return
+	}
 	if choice == "Open" {
 		panels.HidePanel("dlChoice")
 		App.Draw()
@@ -191,7 +208,7 @@ func open(u string, resp *gemini.Response) {
 			Error("File Opening Error", "Error executing custom command: "+err.Error())
 			return
 		}
-		Info("Opened with " + cmd[0])
+		Info(i18n.T("Opened with %s", cmd[0]))
 		return
 	}
 
@@ -256,6 +273,7 @@ func downloadURL(dir, u string, resp *gemini.Response) string {
 	done := false
 
 	go func(isDone *bool) {
+		defer RecoverCrash()
 		// Update the bar display
 		for !*isDone {
 			dlModal.SetText(bar.String())
@@ -281,6 +299,7 @@ func downloadURL(dir, u string, resp *gemini.Response) string {
 		os.Remove(savePath) // Remove partial file
 		return ""
 	}
+	hooks.Run(hooks.DownloadFinished, map[string]string{"URL": u, "PATH": savePath}, "")
 	dlModal.SetText(fmt.Sprintf("Download complete! File saved to %s.", savePath))
 	dlModal.ClearButtons()
 	dlModal.AddButtons([]string{"Ok"})
@@ -300,6 +319,8 @@ func downloadPage(p *structs.Page) (string, error) {
 
 	if p.Mediatype == structs.TextGemini {
 		savePath, err = downloadNameFromURL(config.DownloadsDir, p.URL, ".gmi")
+	} else if p.Mediatype == structs.TextMarkdown {
+		savePath, err = downloadNameFromURL(config.DownloadsDir, p.URL, ".md")
 	} else {
 		savePath, err = downloadNameFromURL(config.DownloadsDir, p.URL, ".txt")
 	}
@@ -312,6 +333,7 @@ func downloadPage(p *structs.Page) (string, error) {
 		os.Remove(savePath)
 		return "", err
 	}
+	hooks.Run(hooks.DownloadFinished, map[string]string{"URL": p.URL, "PATH": savePath}, "")
 	return savePath, err
 }
 
@@ -0,0 +1,58 @@
+package display
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// editorCommand returns the command and arguments for the user's text editor,
+// taken from $VISUAL or $EDITOR.
+func editorCommand() []string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(env)); len(fields) > 0 {
+			return fields
+		}
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"notepad"}
+	}
+	return []string{"vi"}
+}
+
+// editText opens the text in the user's text editor, suspending the TUI,
+// and returns the text as it was saved. The trailing newline most editors
+// add is removed.
+func editText(text string) (string, error) {
+	f, err := ioutil.TempFile("", "amfora-input-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString(text)
+	f.Close()
+	if err != nil {
+		return "", err
+	}
+
+	editor := editorCommand()
+	App.Suspend(func() {
+		cmd := exec.Command(editor[0], append(editor[1:], f.Name())...) //nolint:gosec
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		err = cmd.Run()
+	})
+	if err != nil {
+		return "", err
+	}
+
+	data, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
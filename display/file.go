@@ -45,6 +45,8 @@ func handleFile(u string) (*structs.Page, bool) {
 		mimetype := mime.TypeByExtension(filepath.Ext(uri.Path))
 		if strings.HasSuffix(u, ".gmi") || strings.HasSuffix(u, ".gemini") {
 			mimetype = "text/gemini"
+		} else if strings.HasSuffix(u, ".md") || strings.HasSuffix(u, ".markdown") {
+			mimetype = "text/markdown"
 		}
 
 		if !strings.HasPrefix(mimetype, "text/") {
@@ -59,7 +61,7 @@ func handleFile(u string) (*structs.Page, bool) {
 		}
 
 		if mimetype == "text/gemini" {
-			rendered, links := renderer.RenderGemini(string(content), textWidth(), false)
+			rendered, links := renderer.RenderGemini(string(content), textWidth(), false, renderer.ANSIEnabled(u), "")
 			page = &structs.Page{
 				Mediatype: structs.TextGemini,
 				URL:       u,
@@ -68,6 +70,17 @@ func handleFile(u string) (*structs.Page, bool) {
 				Links:     links,
 				TermWidth: termW,
 			}
+		} else if mimetype == "text/markdown" {
+			rendered, links := renderer.RenderGemini(renderer.MarkdownToGemtext(string(content)), textWidth(),
+				false, renderer.ANSIEnabled(u), "")
+			page = &structs.Page{
+				Mediatype: structs.TextMarkdown,
+				URL:       u,
+				Raw:       string(content),
+				Content:   rendered,
+				Links:     links,
+				TermWidth: termW,
+			}
 		} else {
 			page = &structs.Page{
 				Mediatype: structs.TextPlain,
@@ -107,7 +120,7 @@ func createDirectoryListing(u string) (*structs.Page, bool) {
 		content += fmt.Sprintf("=> %s%s %s%s\n", f.Name(), separator, f.Name(), separator)
 	}
 
-	rendered, links := renderer.RenderGemini(content, textWidth(), false)
+	rendered, links := renderer.RenderGemini(content, textWidth(), false, renderer.ANSIEnabled(u), "")
 	page = &structs.Page{
 		Mediatype: structs.TextGemini,
 		URL:       u,
@@ -0,0 +1,145 @@
+package display
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/go-gemini"
+	"github.com/spf13/viper"
+)
+
+// hasMediatype returns true if the mediatype is one of the types, which can
+// also be just a type, like "text".
+func hasMediatype(types []string, mediatype string) bool {
+	for _, typ := range types {
+		if typ == mediatype || typ == strings.Split(mediatype, "/")[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// getMediaFilter returns the filter for the response to a request for the URL,
+// from the "mediatype-filters" config section. Filters for the host of the URL
+// come first, then the ones for the mediatype of the response.
+func getMediaFilter(u string, res *gemini.Response) (config.MediaFilter, bool) {
+	if gemini.SimplifyStatus(res.Status) != 20 {
+		return config.MediaFilter{}, false
+	}
+	mediatype, _, err := mime.ParseMediaType(res.Meta)
+	if err != nil {
+		return config.MediaFilter{}, false
+	}
+	if parsed, err := url.Parse(u); err == nil {
+		host := strings.ToLower(parsed.Hostname())
+		for _, f := range config.HostMediaFilters {
+			if hasMediatype(f.Types, mediatype) && config.HasHost(f.Hosts, host) {
+				return f.MediaFilter, true
+			}
+		}
+	}
+	if f, ok := config.MediaFilters[mediatype]; ok {
+		return f, true
+	}
+	f, ok := config.MediaFilters[strings.Split(mediatype, "/")[0]]
+	return f, ok
+}
+
+var errFilterInputTooLarge = errors.New("the page is larger than the max page size")
+
+// maxSizeReader returns errFilterInputTooLarge once more than max bytes
+// were read from r.
+type maxSizeReader struct {
+	r   io.Reader
+	max int64
+}
+
+func (m *maxSizeReader) Read(p []byte) (int, error) {
+	n, err := m.r.Read(p)
+	m.max -= int64(n)
+	if m.max < 0 {
+		return n, errFilterInputTooLarge
+	}
+	return n, err
+}
+
+// filterResponse pipes the body of the response through the filter command,
+// and returns a response that holds its output instead. The body is closed.
+// The input and output are limited to page_max_size, and the output is read
+// completely, so the returned response can't time out or be too large. It has
+// no network connection, so client.SetReadTimeout does nothing to it.
+func filterResponse(f config.MediaFilter, u string, res *gemini.Response) (*gemini.Response, error) {
+	defer res.Body.Close()
+	maxSize := viper.GetInt64("a-general.page_max_size")
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if timeout := time.Duration(viper.GetInt("a-general.page_max_time")) * time.Second; timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		// Zero means there's no limit
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, f.Cmd[0], f.Cmd[1:]...) //nolint:gosec
+	cmd.Env = append(os.Environ(), "COLUMNS="+strconv.Itoa(textWidth()), "AMFORA_URL="+u)
+	// The input is copied to the command by exec, and Wait returns
+	// errFilterInputTooLarge if it's too large
+	cmd.Stdin = &maxSizeReader{r: res.Body, max: maxSize}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	// Read one byte more than the max size, to know if the output was too large
+	out, readErr := ioutil.ReadAll(io.LimitReader(stdout, maxSize+1))
+	if int64(len(out)) > maxSize {
+		cancel()
+		cmd.Wait() //nolint:errcheck
+		return nil, errors.New("the output of the filter is larger than the max page size")
+	}
+	if err := cmd.Wait(); err != nil {
+		if errors.Is(err, errFilterInputTooLarge) {
+			return nil, err
+		}
+		if ctx.Err() != nil {
+			return nil, errors.New("the filter took too long")
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	meta := f.Output
+	if meta == "" {
+		meta = res.Meta
+	}
+	return &gemini.Response{
+		Status: 20,
+		Meta:   meta,
+		Body:   ioutil.NopCloser(bytes.NewReader(out)),
+		Cert:   res.Cert,
+	}, nil
+}
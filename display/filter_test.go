@@ -0,0 +1,57 @@
+package display
+
+import (
+	"errors"
+	"io/ioutil"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/go-gemini"
+	"github.com/spf13/viper"
+)
+
+func TestFilterResponse(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs tr and sh")
+	}
+	viper.Set("a-general.page_max_size", 100)
+	viper.Set("a-general.page_max_time", 10)
+	defer viper.Set("a-general.page_max_size", 2097152)
+
+	f := config.MediaFilter{Cmd: []string{"tr", "a-z", "A-Z"}, Output: "text/plain"}
+	newRes := func(body string) *gemini.Response {
+		return &gemini.Response{Status: 20, Meta: "text/x-test", Body: ioutil.NopCloser(strings.NewReader(body))}
+	}
+
+	res, err := filterResponse(f, "gemini://example.com/", newRes("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The response has no connection to set a timeout on
+	if err := client.SetReadTimeout(res, 0); err != nil {
+		t.Errorf("SetReadTimeout: %v", err)
+	}
+	out, _ := ioutil.ReadAll(res.Body)
+	if string(out) != "HELLO" || res.Meta != "text/plain" {
+		t.Errorf("got %q %q, want %q %q", res.Meta, out, "text/plain", "HELLO")
+	}
+
+	if _, err := filterResponse(f, "gemini://example.com/", newRes(strings.Repeat("a", 101))); err == nil {
+		t.Error("no error for output larger than page_max_size")
+	}
+	// Zero means there's no time limit
+	viper.Set("a-general.page_max_time", 0)
+	if _, err := filterResponse(f, "gemini://example.com/", newRes("hello")); err != nil {
+		t.Errorf("with no page_max_time: %v", err)
+	}
+	viper.Set("a-general.page_max_time", 10)
+
+	f.Cmd = []string{"sh", "-c", "cat > /dev/null"}
+	_, err = filterResponse(f, "gemini://example.com/", newRes(strings.Repeat("a", 101)))
+	if !errors.Is(err, errFilterInputTooLarge) {
+		t.Errorf("got %v for a page larger than page_max_size, want errFilterInputTooLarge", err)
+	}
+}
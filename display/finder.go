@@ -0,0 +1,345 @@
+package display
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/bookmarks"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+	"github.com/makeworld-the-better-one/amfora/subscriptions"
+	"github.com/spf13/viper"
+)
+
+// The finder searches the open tabs, bookmarks, subscriptions, and history
+// at once, as the letters of the search are typed, like fzf. A prefix like
+// "b:" only searches one of them.
+
+// The number of results shown, the best ones
+const maxFinderResults = 200
+
+// finderItem is something the finder can go to.
+type finderItem struct {
+	source string // One of the finderSources
+	title  string
+	url    string
+	tab    int // The tab to switch to, for open tabs
+}
+
+// The sources searched by the finder, in the order they're shown, with
+// the prefixes that only search them.
+var finderSources = []struct {
+	name   string
+	prefix string
+}{
+	{"tab", "t:"},
+	{"bookmark", "b:"},
+	{"subscription", "s:"},
+	{"history", "h:"},
+}
+
+var (
+	finderPanel   = cview.NewFlex()
+	finderInput   = cview.NewInputField()
+	finderList    = cview.NewTextView()
+	finderItems   []finderItem // Everything that can be found, made when the finder is shown
+	finderResults []finderItem
+	finderPos     int // The selected result
+)
+
+// fuzzyMatch returns how well the query matches the text, ignoring case,
+// and false if it doesn't. Each word of the query has to match, which means
+// its letters are in the text in order. Letters that are next to each other,
+// or at the start of words, make the match better.
+func fuzzyMatch(query, text string) (int, bool) {
+	text = strings.ToLower(text)
+	total := 0
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		score, ok := fuzzyWord([]rune(word), []rune(text))
+		if !ok {
+			return 0, false
+		}
+		total += score
+	}
+	return total, true
+}
+
+func fuzzyWord(word, text []rune) (int, bool) {
+	best, found := 0, false
+	// Try each place the first letter is, so the best one is found
+	for start := range text {
+		if text[start] != word[0] {
+			continue
+		}
+		score, last, ok := 0, -1, true
+		for i, j := 0, start; i < len(word); i++ {
+			for j < len(text) && text[j] != word[i] {
+				j++
+			}
+			if j == len(text) {
+				ok = false
+				break
+			}
+			score++
+			if last != -1 && j == last+1 {
+				score += 4
+			}
+			if j == 0 || !(unicode.IsLetter(text[j-1]) || unicode.IsDigit(text[j-1])) {
+				score += 2
+			}
+			last = j
+			j++
+		}
+		if !ok {
+			// It won't match starting any later either
+			break
+		}
+		if !found || score > best {
+			best, found = score, true
+		}
+	}
+	return best, found
+}
+
+// findItems returns the items that match the query, best first. Items that
+// match as well stay in the order they were in. An empty query matches
+// everything.
+func findItems(items []finderItem, query string) []finderItem {
+	query = strings.TrimSpace(query)
+	for _, s := range finderSources {
+		if strings.HasPrefix(query, s.prefix) {
+			source := s.name
+			query = strings.TrimSpace(query[len(s.prefix):])
+			var only []finderItem
+			for _, item := range items {
+				if item.source == source {
+					only = append(only, item)
+				}
+			}
+			items = only
+			break
+		}
+	}
+
+	type scored struct {
+		item  finderItem
+		score int
+	}
+	var results []scored
+	for _, item := range items {
+		if score, ok := fuzzyMatch(query, item.title+" "+item.url); ok {
+			results = append(results, scored{item, score})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+	found := make([]finderItem, len(results))
+	for i := range results {
+		found[i] = results[i].item
+	}
+	return found
+}
+
+// allFinderItems returns everything that can be found, in the order of
+// finderSources. Pages from private tabs aren't in the history.
+func allFinderItems() []finderItem {
+	var items []finderItem
+	for i, t := range tabs {
+		title := pageTitle(t.page)
+		if title == "" {
+			title = t.page.URL
+		}
+		label := strconv.Itoa(i + 1)
+		if t.private {
+			label = "(" + label + ")"
+		}
+		items = append(items, finderItem{"tab", label + " " + title, t.page.URL, i})
+	}
+
+	names, urls := bookmarks.All()
+	for i := range names {
+		items = append(items, finderItem{"bookmark", names[i], urls[i], -1})
+	}
+
+	subURLs := subscriptions.AllURLS()
+	sort.Strings(subURLs)
+	for _, u := range subURLs {
+		items = append(items, finderItem{"subscription", subscriptions.Title(u), u, -1})
+	}
+	for _, e := range subscriptions.GetPageEntries().Entries {
+		title := e.Title
+		if e.Prefix != "" {
+			title = e.Prefix + " - " + title
+		}
+		items = append(items, finderItem{"subscription", title, e.URL, -1})
+	}
+
+	// The pages visited in open tabs, and then the ones with a saved scroll
+	// position from earlier, newest first
+	seen := make(map[string]bool)
+	addHistory := func(u string) {
+		if seen[u] || u == "" || strings.HasPrefix(u, "about:") {
+			return
+		}
+		seen[u] = true
+		items = append(items, finderItem{"history", "", u, -1})
+	}
+	for _, t := range tabs {
+		if t.private {
+			continue
+		}
+		for i := len(t.history.urls) - 1; i >= 0; i-- {
+			addHistory(t.history.urls[i])
+		}
+	}
+	scrollMu.Lock()
+	for i := len(scrollPositions) - 1; i >= 0; i-- {
+		addHistory(scrollPositions[i].URL)
+	}
+	scrollMu.Unlock()
+	return items
+}
+
+// finderText returns the results as shown, with the selected one highlighted.
+func finderText(results []finderItem, selected int) string {
+	if len(results) == 0 {
+		return i18n.T("Nothing matches the search.")
+	}
+	var b strings.Builder
+	for i, item := range results {
+		line := fmt.Sprintf("%-12s  %s", i18n.T(item.source), item.url)
+		if item.title != "" && item.title != item.url {
+			line = fmt.Sprintf("%-12s  %s  %s", i18n.T(item.source), item.title, item.url)
+		}
+		line = cview.Escape(line)
+		if i == selected {
+			line = "[::r]" + line + "[::-]"
+		}
+		b.WriteString(line + "\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// updateFinder shows the results for the search that's typed.
+func updateFinder() {
+	finderResults = findItems(finderItems, finderInput.GetText())
+	if len(finderResults) > maxFinderResults {
+		finderResults = finderResults[:maxFinderResults]
+	}
+	finderPos = 0
+	finderList.SetText(finderText(finderResults, finderPos))
+	finderList.ScrollToBeginning()
+}
+
+// moveFinder selects the result that's n after the selected one.
+func moveFinder(n int) {
+	if len(finderResults) == 0 {
+		return
+	}
+	finderPos = (((finderPos + n) % len(finderResults)) + len(finderResults)) % len(finderResults)
+	finderList.SetText(finderText(finderResults, finderPos))
+
+	// Keep the selected result on the screen
+	row, _ := finderList.GetScrollOffset()
+	_, _, _, height := finderList.GetInnerRect()
+	if finderPos < row {
+		finderList.ScrollTo(finderPos, 0)
+	} else if height > 0 && finderPos >= row+height {
+		finderList.ScrollTo(finderPos-height+1, 0)
+	}
+}
+
+// Finder shows the finder.
+func Finder() {
+	finderItems = allFinderItems()
+	finderInput.SetText("")
+	updateFinder()
+	panels.ShowPanel("finder")
+	panels.SendToFront("finder")
+	App.SetFocus(finderInput)
+}
+
+func closeFinder() {
+	panels.HidePanel("finder")
+	finderItems = nil
+	finderResults = nil
+	App.SetFocus(tabs[curTab].view)
+	App.Draw()
+}
+
+func finderInit() {
+	finderList.SetDynamicColors(true)
+	finderList.SetWrap(false)
+	finderList.SetBackgroundColor(config.GetColor("bg"))
+	finderList.SetTextColor(config.GetColor("regular_text"))
+	finderList.SetPadding(0, 0, 1, 1)
+	finderList.SetScrollBarColor(config.GetColor("scrollbar"))
+
+	finderInput.SetLabel("[::b]" + i18n.T("Go to:") + " [::-]")
+	finderInput.SetPlaceholder(i18n.T("t: tabs, b: bookmarks, s: subscriptions, h: history"))
+	// Like the bottom bar
+	if viper.GetBool("a-general.color") {
+		finderInput.SetBackgroundColor(config.GetColor("bottombar_bg"))
+		finderInput.SetLabelColor(config.GetColor("bottombar_label"))
+		finderInput.SetFieldBackgroundColor(config.GetColor("bottombar_bg"))
+		finderInput.SetFieldTextColor(config.GetColor("bottombar_text"))
+	} else {
+		finderInput.SetBackgroundColor(tcell.ColorWhite)
+		finderInput.SetLabelColor(tcell.ColorBlack)
+		finderInput.SetFieldBackgroundColor(tcell.ColorWhite)
+		finderInput.SetFieldTextColor(tcell.ColorBlack)
+	}
+	finderInput.SetChangedFunc(func(text string) {
+		updateFinder()
+	})
+	finderInput.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		//nolint:exhaustive
+		switch event.Key() {
+		case tcell.KeyUp, tcell.KeyCtrlP, tcell.KeyBacktab:
+			moveFinder(-1)
+			return nil
+		case tcell.KeyDown, tcell.KeyCtrlN, tcell.KeyTab:
+			moveFinder(1)
+			return nil
+		case tcell.KeyPgUp:
+			_, _, _, height := finderList.GetInnerRect()
+			moveFinder(-height)
+			return nil
+		case tcell.KeyPgDn:
+			_, _, _, height := finderList.GetInnerRect()
+			moveFinder(height)
+			return nil
+		}
+		return event
+	})
+	finderInput.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEsc {
+			closeFinder()
+			return
+		}
+		if key != tcell.KeyEnter || len(finderResults) == 0 {
+			return
+		}
+		item := finderResults[finderPos]
+		closeFinder()
+		if item.source == "tab" {
+			if item.tab < NumTabs() {
+				SwitchTab(item.tab)
+			}
+			return
+		}
+		URL(item.url)
+	})
+
+	finderPanel.SetDirection(cview.FlexRow)
+	finderPanel.AddItem(finderInput, 1, 0, true)
+	finderPanel.AddItem(finderList, 0, 1, false)
+
+	panels.AddPanel("finder", finderPanel, true, false)
+}
@@ -0,0 +1,64 @@
+package display
+
+import "testing"
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		query string
+		text  string
+		ok    bool
+	}{
+		{"", "anything", true},
+		{"gmi", "gemini://example.com/index.gmi", true},
+		{"GEMSPEC", "gemini://gemini.circumlunar.space/docs/specification.gmi", true},
+		{"specgem", "gemini://gemini.circumlunar.space/docs/specification.gmi", false},
+		{"spec gem", "gemini://gemini.circumlunar.space/docs/specification.gmi", true},
+		{"xyz", "gemini://example.com", false},
+	}
+	for _, tt := range tests {
+		if _, ok := fuzzyMatch(tt.query, tt.text); ok != tt.ok {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, want %v", tt.query, tt.text, ok, tt.ok)
+		}
+	}
+
+	// Letters together, and at the start of words, are better
+	together, _ := fuzzyMatch("news", "gemini://example.com/news/")
+	apart, _ := fuzzyMatch("news", "gemini://example.com/n/e/w/s")
+	if together <= apart {
+		t.Errorf("together scored %d, apart scored %d", together, apart)
+	}
+}
+
+func TestFindItems(t *testing.T) {
+	items := []finderItem{
+		{"tab", "1 Station", "gemini://station.martinrue.com/", 0},
+		{"bookmark", "Gemini specification", "gemini://gemini.circumlunar.space/docs/specification.gmi", -1},
+		{"bookmark", "Station", "gemini://station.martinrue.com/", -1},
+		{"history", "", "gemini://example.com/stationery", -1},
+	}
+	tests := []struct {
+		query string
+		want  []string // The sources of the results, in order
+	}{
+		{"", []string{"tab", "bookmark", "bookmark", "history"}},
+		// The specification matches too, but not as well
+		{"station", []string{"tab", "bookmark", "history", "bookmark"}},
+		{"b: station", []string{"bookmark", "bookmark"}},
+		{"b: martinrue", []string{"bookmark"}},
+		{"h:", []string{"history"}},
+		{"spec", []string{"bookmark"}},
+		{"t:spec", nil},
+	}
+	for _, tt := range tests {
+		got := findItems(items, tt.query)
+		if len(got) != len(tt.want) {
+			t.Errorf("findItems(%q) returned %d items, want %d: %v", tt.query, len(got), len(tt.want), got)
+			continue
+		}
+		for i := range got {
+			if got[i].source != tt.want[i] {
+				t.Errorf("findItems(%q)[%d] is a %s, want a %s", tt.query, i, got[i].source, tt.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package display
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+)
+
+// Following a link by its number. The link numbers are shown while typing,
+// even when link_markers is "on_demand", and the link is followed as soon as
+// the number can't be the start of a longer one. Links can also be followed
+// by typing some of their text, see startFollowText.
+
+var (
+	following     bool // Whether the bottom bar is being used to follow a link, see startFollow
+	followingText bool // The same for following a link by its text
+	followTexts   []string
+)
+
+// The regions of links in the content of a page, see renderer.RenderGemini,
+// and the style tags inside them.
+var (
+	linkRegionRe = regexp.MustCompile(`\["(\d+)"\](.*?)\[""\]`)
+	styleTagRe   = regexp.MustCompile(`\[[^\[\]]*\]`)
+)
+
+// setLinkNumbers shows or hides the link numbers of the tab's page, if they're
+// only shown on demand.
+func setLinkNumbers(t *tab, show bool) {
+	p := t.page
+	if p.ShowNumbers == show {
+		return
+	}
+	p.ShowNumbers = show
+	if viper.GetString("a-general.link_markers") != "on_demand" ||
+		(p.Mediatype != structs.TextGemini && p.Mediatype != structs.TextMarkdown) {
+		return
+	}
+	p.TermWidth = -1 // Force it to be rendered again
+	reformatPageAndSetView(t, p)
+}
+
+// startFollow shows the link numbers of the tab's page, and starts typing
+// the number of the link to follow in the bottom bar.
+func startFollow(t *tab) {
+	if len(t.page.Links) == 0 {
+		Info("There are no links on this page.")
+		return
+	}
+	setLinkNumbers(t, true)
+	following = true
+	bottomBar.SetLabel("[::b]Follow link: [::-]")
+	bottomBar.SetText("")
+	App.SetFocus(bottomBar)
+}
+
+// endFollow hides the link numbers again, after the bottom bar is done.
+func endFollow(t *tab) {
+	following = false
+	setLinkNumbers(t, false)
+}
+
+// followChanged is called when the text of the bottom bar changes. While
+// following, the link is followed once the number typed is complete.
+func followChanged(text string) {
+	if followingText {
+		followTextChanged(text)
+		return
+	}
+	if !following {
+		return
+	}
+	t := tabs[curTab]
+	n, err := strconv.Atoi(text)
+	if err != nil || n < 1 || n > len(t.page.Links) || n*10 <= len(t.page.Links) {
+		// Not a link number, or more digits could still be typed
+		return
+	}
+	endFollow(t)
+	bottomBar.SetLabel("")
+	t.applyAll()
+	App.SetFocus(t.view)
+	followLink(t, t.page.URL, t.page.Links[n-1])
+}
+
+// linkTexts returns the text of each link in the content of a page, as it's
+// shown. Links that are wrapped onto more lines are joined back together.
+func linkTexts(content string, numLinks int) []string {
+	texts := make([]string, numLinks)
+	for _, m := range linkRegionRe.FindAllStringSubmatch(content, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n >= numLinks {
+			continue
+		}
+		text := strings.TrimSpace(styleTagRe.ReplaceAllString(m[2], ""))
+		if texts[n] != "" {
+			text = texts[n] + " " + text
+		}
+		texts[n] = text
+	}
+	return texts
+}
+
+// matchLinks returns the numbers of the links whose text has every word of
+// the query in it, ignoring case, best first. Links that start with the query
+// are best, then ones with a word that starts with it. Links that match as well
+// stay in the order they're on the page.
+func matchLinks(texts []string, query string) []int {
+	query = strings.ToLower(strings.TrimSpace(query))
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return nil
+	}
+	type scored struct {
+		n     int
+		score int
+	}
+	var results []scored
+	for n, text := range texts {
+		text = strings.ToLower(text)
+		matched := true
+		for _, w := range words {
+			if !strings.Contains(text, w) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		score := 0
+		if strings.HasPrefix(text, query) {
+			score = 2
+		} else if strings.Contains(" "+text, " "+words[0]) {
+			score = 1
+		}
+		results = append(results, scored{n, score})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+	matches := make([]int, len(results))
+	for i := range results {
+		matches[i] = results[i].n
+	}
+	return matches
+}
+
+// startFollowText starts typing some of the text of the link to follow in the
+// bottom bar. The links that match are highlighted.
+func startFollowText(t *tab) {
+	if len(t.page.Links) == 0 {
+		Info("There are no links on this page.")
+		return
+	}
+	t.clearSelected()
+	followingText = true
+	followTexts = linkTexts(t.page.Content, len(t.page.Links))
+	bottomBar.SetLabel("[::b]Follow link text: [::-]")
+	bottomBar.SetText("")
+	App.SetFocus(bottomBar)
+}
+
+// endFollowText stops highlighting the links that match, after the bottom bar
+// is done.
+func endFollowText(t *tab) {
+	followingText = false
+	followTexts = nil
+	t.view.Highlight("")
+}
+
+// followTextChanged highlights the links that match the text typed, and
+// follows the link if it's the only one.
+func followTextChanged(text string) {
+	t := tabs[curTab]
+	matches := matchLinks(followTexts, text)
+	if len(matches) == 1 {
+		followMatch(t, matches[0])
+		return
+	}
+	ids := make([]string, len(matches))
+	for i, n := range matches {
+		ids[i] = strconv.Itoa(n)
+	}
+	t.view.Highlight(ids...)
+	if len(ids) > 0 {
+		t.scrollToHighlight()
+	}
+	if strings.TrimSpace(text) == "" {
+		bottomBar.SetLabel("[::b]Follow link text: [::-]")
+	} else {
+		bottomBar.SetLabel(fmt.Sprintf("[::b]Follow link text (%d): [::-]", len(matches)))
+	}
+}
+
+// followBestMatch follows the link that best matches the text, for Enter.
+// It returns false if no link matches.
+func followBestMatch(t *tab, text string) bool {
+	matches := matchLinks(followTexts, text)
+	if len(matches) == 0 {
+		return false
+	}
+	followMatch(t, matches[0])
+	return true
+}
+
+func followMatch(t *tab, n int) {
+	endFollowText(t)
+	bottomBar.SetLabel("")
+	t.applyAll()
+	App.SetFocus(t.view)
+	followLink(t, t.page.URL, t.page.Links[n])
+}
+
+// followPagination follows the link to the next page of the tab's page, or the
+// previous one if next is false. See renderer.PaginationLink.
+func followPagination(t *tab, next bool) {
+	if t.page.Mediatype != structs.TextGemini || t.isAnAboutPage() {
+		return
+	}
+	u, ok := renderer.PaginationLink(t.page.Raw, next)
+	if !ok {
+		if next {
+			showNotice("Notice", "There's no link to a next page")
+		} else {
+			showNotice("Notice", "There's no link to a previous page")
+		}
+		return
+	}
+	followLink(t, t.page.URL, u)
+}
@@ -0,0 +1,45 @@
+package display
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLinkTexts(t *testing.T) {
+	content := `=> [#8700d7::b][1][-::-] ["0"][#0000ff::u]Gemini[-::-][""]` + "\n" +
+		`   text` + "\n" +
+		`[::b][2][::-] ["1"]A link that's[""]` + "\n" +
+		`    ["1"]wrapped [red[] here[""]`
+	got := linkTexts(content, 2)
+	want := []string{"Gemini", "A link that's wrapped [red here"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestMatchLinks(t *testing.T) {
+	texts := []string{"Gemlog archive", "Older posts", "About me", "My gemlog", "Posts about Gemini"}
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"", nil},
+		{"gem", []int{0, 3, 4}},
+		{"GEMLOG", []int{0, 3}},
+		{"posts", []int{4, 1}},
+		{"about", []int{2, 4}},
+		{"me", []int{2}},
+		{"log", []int{0, 3}},
+		{"about gem", []int{4}},
+		{"xyz", []int{}},
+	}
+	for _, tt := range tests {
+		got := matchLinks(texts, tt.query)
+		if len(got) == 0 && len(tt.want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("matchLinks(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
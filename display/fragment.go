@@ -0,0 +1,134 @@
+package display
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/atotto/clipboard"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// Gemini has no fragments, so they're handled by Amfora. A fragment points to
+// the heading whose text turns into the same fragment, like "#getting-started"
+// for "## Getting Started". Fragments are never sent to the server.
+
+// headingFragment turns heading text into a fragment. Letters and digits are
+// lowercased, spaces and dashes become a single dash, and the rest is removed.
+func headingFragment(text string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.TrimSpace(strings.TrimLeft(text, "#")) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if dash && sb.Len() > 0 {
+				sb.WriteRune('-')
+			}
+			dash = false
+			sb.WriteRune(unicode.ToLower(r))
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			dash = true
+		}
+	}
+	return sb.String()
+}
+
+// headingRow is a heading of a page, and the row it was rendered at.
+type headingRow struct {
+	text string
+	row  int
+}
+
+// pageHeadings returns the headings of the tab's page, in order. Only gemtext
+// and Markdown pages have headings.
+func pageHeadings(t *tab) []headingRow {
+	var raw string
+	switch t.page.Mediatype {
+	case structs.TextGemini:
+		raw = t.page.Raw
+	case structs.TextMarkdown:
+		raw = renderer.MarkdownToGemtext(t.page.Raw)
+	default:
+		return nil
+	}
+
+	// Each heading is the next rendered line that starts the way it's
+	// shown, with the heading marker from the config.
+	rendered := strings.Split(t.view.GetText(true), "\n")
+	var headings []headingRow
+	pre := false
+	row := 0
+	for _, line := range strings.Split(raw, "\n") {
+		if strings.HasPrefix(line, "```") {
+			pre = !pre
+			continue
+		}
+		if pre || !strings.HasPrefix(line, "#") {
+			continue
+		}
+		heading := strings.Join(strings.Fields(line), " ")
+		shown := strings.Join(strings.Fields(renderer.HeadingText(line)), " ")
+		found := -1
+		for i := row; i < len(rendered); i++ {
+			start := strings.Join(strings.Fields(rendered[i]), " ")
+			if start != "" && strings.HasPrefix(shown, start) &&
+				(len(start) == len(shown) || shown[len(start)] == ' ') {
+				found = i
+				row = i + 1
+				break
+			}
+		}
+		if found == -1 {
+			// Rendering changed it too much to be found
+			break
+		}
+		headings = append(headings, headingRow{text: heading, row: found})
+	}
+	return headings
+}
+
+// scrollToFragment scrolls the tab to the heading the fragment points to.
+// It returns false if there is no such heading.
+func scrollToFragment(t *tab, fragment string) bool {
+	fragment = headingFragment(fragment)
+	if fragment == "" {
+		return false
+	}
+	for _, h := range pageHeadings(t) {
+		if headingFragment(h.text) == fragment {
+			t.scrollTo(h.row, 0)
+			return true
+		}
+	}
+	return false
+}
+
+// copyHeadingURL copies the URL of the tab's page, with the fragment for the
+// heading of the part of the page being viewed. That's the last heading above
+// the top of the screen, or the first one on the screen.
+func copyHeadingURL(t *tab) {
+	headings := pageHeadings(t)
+	if len(headings) == 0 || t.isAnAboutPage() {
+		Info("There are no headings on this page to link to.")
+		return
+	}
+	top, _ := t.view.GetScrollOffset()
+	heading := headings[0]
+	for _, h := range headings {
+		if h.row > top {
+			break
+		}
+		heading = h
+	}
+
+	parsed, err := url.Parse(t.page.URL)
+	if err != nil {
+		Error("Copy Error", "The page URL couldn't be parsed.")
+		return
+	}
+	parsed.Fragment = headingFragment(heading.text)
+	if err := clipboard.WriteAll(parsed.String()); err != nil {
+		Error("Copy Error", err.Error())
+	}
+}
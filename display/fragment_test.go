@@ -0,0 +1,22 @@
+package display
+
+import "testing"
+
+var headingFragmentTests = []struct {
+	text     string
+	expected string
+}{
+	{"# Getting Started", "getting-started"},
+	{"### What's new?", "whats-new"},
+	{"## Ünïcode  -- Heading_2", "ünïcode-heading-2"},
+	{"getting-started", "getting-started"},
+	{"#", ""},
+}
+
+func TestHeadingFragment(t *testing.T) {
+	for _, tt := range headingFragmentTests {
+		if actual := headingFragment(tt.text); actual != tt.expected {
+			t.Errorf("headingFragment(%q): expected %q, actual %q", tt.text, tt.expected, actual)
+		}
+	}
+}
@@ -8,14 +8,21 @@ import (
 	"os/exec"
 	"path"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/makeworld-the-better-one/amfora/cache"
 	"github.com/makeworld-the-better-one/amfora/client"
 	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/hooks"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+	"github.com/makeworld-the-better-one/amfora/plugins"
 	"github.com/makeworld-the-better-one/amfora/renderer"
 	"github.com/makeworld-the-better-one/amfora/rr"
 	"github.com/makeworld-the-better-one/amfora/structs"
 	"github.com/makeworld-the-better-one/amfora/subscriptions"
+	"github.com/makeworld-the-better-one/amfora/termimg"
 	"github.com/makeworld-the-better-one/amfora/webbrowser"
 	"github.com/makeworld-the-better-one/go-gemini"
 	"github.com/spf13/viper"
@@ -53,7 +60,7 @@ func handleHTTP(u string, showInfo bool) bool {
 		err = exec.Command(config.HTTPCommand[0], u).Start()
 	}
 	if err != nil {
-		Error("HTTP Error", "Error executing custom browser command: "+err.Error())
+		Error("HTTP Error", i18n.T("Error executing custom browser command: %v", err))
 		return false
 	}
 
@@ -61,6 +68,75 @@ func handleHTTP(u string, showInfo bool) bool {
 	return true
 }
 
+// getProxy returns the Gemini proxy server to use for URLs with the provided
+// scheme, or an empty string if they shouldn't be proxied.
+// The "other" proxy is used for schemes that don't have their own, except for
+// Gemini and HTTP(S).
+func getProxy(scheme string) string {
+	proxy := strings.TrimSpace(viper.GetString("proxies." + scheme))
+	if proxy == "" && scheme != "gemini" && scheme != "http" && scheme != "https" && scheme != "file" {
+		proxy = strings.TrimSpace(viper.GetString("proxies.other"))
+	}
+	if proxy == "off" {
+		return ""
+	}
+	return proxy
+}
+
+// urlProxy returns the Gemini proxy server to use for the URL. It can be set
+// for the host in the connection rules, or for the scheme of the URL.
+func urlProxy(parsed *url.URL) string {
+	if rule, proxy := client.HostRule(parsed.Hostname()); rule == client.RuleProxy {
+		return proxy
+	}
+	return getProxy(parsed.Scheme)
+}
+
+// torified returns true if the page at the URL would be loaded through Tor.
+// When a Gemini proxy is used, the connection to it can go through Tor too.
+func torified(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Scheme == "about" || parsed.Scheme == "file" {
+		return false
+	}
+	if client.UsesTor(parsed.Hostname()) {
+		return true
+	}
+	host, _, proxy := connHost(parsed)
+	return proxy != "" && client.UsesTor(host)
+}
+
+// Hosts the user agreed to connect to in this session, for hosts that
+// have the "confirm" connection rule.
+var confirmedHosts = make(map[string]struct{})
+var confirmedHostsMu = sync.Mutex{}
+
+// checkHostRule returns false if the connection rules don't allow connecting
+// to the host of the URL. The user is asked first if the rule requires it.
+func checkHostRule(parsed *url.URL) bool {
+	host := strings.ToLower(parsed.Hostname())
+	rule, _ := client.HostRule(host)
+	switch rule {
+	case client.RuleBlock:
+		Error("Blocked Host", i18n.T("Connecting to %s is blocked.", escapeMeta(host)))
+		return false
+	case client.RuleConfirm:
+		confirmedHostsMu.Lock()
+		_, ok := confirmedHosts[host]
+		confirmedHostsMu.Unlock()
+		if ok {
+			return true
+		}
+		if !YesNo(i18n.T("Connect to %s?", escapeMeta(host))) {
+			return false
+		}
+		confirmedHostsMu.Lock()
+		confirmedHosts[host] = struct{}{}
+		confirmedHostsMu.Unlock()
+	}
+	return true
+}
+
 // handleOther is used by handleURL.
 // It opens links other than Gemini and HTTP and displays Error modals.
 func handleOther(u string) {
@@ -74,13 +150,13 @@ func handleOther(u string) {
 	}
 	switch handler {
 	case "", "off":
-		Error("URL Error", "Opening "+parsed.Scheme+" URLs is turned off.")
+		Error("URL Error", i18n.T("Opening %s URLs is turned off.", parsed.Scheme))
 	default:
 		// The config has a custom command to execute for URLs
 		fields := strings.Fields(handler)
 		err := exec.Command(fields[0], append(fields[1:], u)...).Start()
 		if err != nil {
-			Error("URL Error", "Error executing custom command: "+err.Error())
+			Error("URL Error", i18n.T("Error executing custom command: %v", err))
 		}
 	}
 	App.Draw()
@@ -126,6 +202,31 @@ func handleAbout(t *tab, u string) (string, bool) {
 		return u, true
 	case "about:about":
 		temp := aboutPage
+		if names := userAboutPages(); len(names) > 0 {
+			raw := aboutPage.Raw + "\n## Your Pages\n\n"
+			for _, name := range names {
+				raw += "=> about:" + name + "\n"
+			}
+			temp = createAboutPage("about:about", raw)
+		}
+		setPage(t, &temp)
+		t.applyBottomBar()
+		return u, true
+	case "about:network":
+		Network(t)
+		return u, true
+	case "about:plugins":
+		Plugins(t)
+		return u, true
+	case "about:clear":
+		ClearPage(t)
+		return u, true
+	case "about:diff":
+		if t.diff == nil {
+			Error("Error", "There's no diff to show, reload a page first.")
+			return "", false
+		}
+		temp := *t.diff // Copy
 		setPage(t, &temp)
 		t.applyBottomBar()
 		return u, true
@@ -133,7 +234,27 @@ func handleAbout(t *tab, u string) (string, bool) {
 
 	if u == "about:subscriptions" || (len(u) > 20 && u[:20] == "about:subscriptions?") {
 		// about:subscriptions?2 views page 2
-		return Subscriptions(t, u), true
+		return Subscriptions(t, u)
+	}
+	if strings.HasPrefix(u, "about:clear?") {
+		// The page stays the same, the data is cleared after it's confirmed
+		go clearData(strings.TrimPrefix(u, "about:clear?"))
+		return "", false
+	}
+	if u == "about:archive" || strings.HasPrefix(u, "about:archive?") {
+		return Archive(t, u)
+	}
+	if u == "about:link-check" || strings.HasPrefix(u, "about:link-check?") {
+		return LinkCheck(t, u)
+	}
+	if u == "about:certificates" || strings.HasPrefix(u, "about:certificates?") {
+		return Certificates(t, u)
+	}
+	if u == "about:keys" || strings.HasPrefix(u, "about:keys?") {
+		return Keys(t, u)
+	}
+	if u == "about:reading" || strings.HasPrefix(u, "about:reading?") {
+		return ReadingList(t, u)
 	}
 	if u == "about:manage-subscriptions" || (len(u) > 27 && u[:27] == "about:manage-subscriptions?") {
 		ManageSubscriptions(t, u)
@@ -143,11 +264,22 @@ func handleAbout(t *tab, u string) (string, bool) {
 		}
 		return "", false
 	}
+	if UserAbout(t, u) {
+		return u, true
+	}
+	if p, ok := plugins.ForAbout(u); ok {
+		// Plugins can take a while to respond, and this may be called by the UI
+		go loadPluginAbout(t, p, u)
+		return u, true
+	}
 
 	Error("Error", "Not a valid 'about:' URL.")
 	return "", false
 }
 
+// fetchURL fetches Gemini URLs that don't go through a proxy. Tests replace it.
+var fetchURL = client.FetchWithCert
+
 // handleURL displays whatever action is needed for the provided URL,
 // and applies it to the current tab.
 // It loads documents, handles errors, brings up a download prompt, etc.
@@ -161,25 +293,53 @@ func handleAbout(t *tab, u string) (string, bool) {
 // The bottomBar is not actually changed in this func, except during loading.
 // The func that calls this one should apply the bottomBar values if necessary.
 //
+// It starts a new load in the tab, so any page that was loading stops.
+func handleURL(t *tab, u string) (string, bool) {
+	// Pages are displayed while they are downloaded, and the user can go
+	// to another page in the meantime. Each load gets an ID, so the old
+	// one knows to stop.
+	return handleURLWithLoad(t, u, 0, atomic.AddUint64(&t.loadID, 1))
+}
+
+// handleURLWithLoad is handleURL, as part of the load with the ID. Requests
+// made for the same load, like after a redirect, input, or choosing a client
+// certificate, use it with the ID they already have.
+//
 // numRedirects is the number of redirects that resulted in the provided URL.
-// It should typically be 0.
-func handleURL(t *tab, u string, numRedirects int) (string, bool) {
+func handleURLWithLoad(t *tab, u string, numRedirects int, loadID uint64) (string, bool) {
 	defer App.Draw() // Just in case
+	defer RecoverCrash()
 
 	// Save for resetting on error
 	oldLable := t.barLabel
 	oldText := t.barText
 
+	if numRedirects == 0 {
+		t.redirects = nil
+	}
+	superseded := func() bool {
+		return atomic.LoadUint64(&t.loadID) != loadID
+	}
+
 	// Custom return function
 	ret := func(s string, b bool) (string, bool) {
+		if superseded() {
+			// Another page is being loaded in the tab, don't change anything
+			return "", false
+		}
 		if !b {
 			// Reset bottomBar if page wasn't loaded
 			t.barLabel = oldLable
 			t.barText = oldText
 		}
 		t.mode = tabModeDone
+		t.partial = false
+		if b {
+			announcePage(t)
+		}
 
 		go func(p *structs.Page) {
+			defer RecoverCrash()
 			if b && t.hasContent() && !t.isAnAboutPage() && viper.GetBool("subscriptions.popup") {
 				// The current page might be an untracked feed, and the user wants
 				// to be notified in such cases.
@@ -196,16 +356,22 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 	}
 
 	t.barLabel = ""
-	bottomBar.SetLabel("")
-
-	App.SetFocus(t.view)
+	if t == tabs[curTab] {
+		// Tabs opened in the background stay there
+		bottomBar.SetLabel("")
+		App.SetFocus(t.view)
+	}
 
 	if strings.HasPrefix(u, "about:") {
 		return ret(handleAbout(t, u))
 	}
 
 	u = normalizeURL(u)
-	u = cache.Redirect(u)
+	if redir := cache.Redirect(u); redir != u {
+		// Permanently redirected before
+		t.redirects = append(t.redirects, u)
+		u = redir
+	}
 
 	parsed, err := url.Parse(u)
 	if err != nil {
@@ -213,7 +379,26 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 		return ret("", false)
 	}
 
-	proxy := strings.TrimSpace(viper.GetString("proxies." + parsed.Scheme))
+	if !checkHostRule(parsed) {
+		return ret("", false)
+	}
+
+	if p, ok := plugins.ForScheme(parsed.Scheme); ok {
+		if t == tabs[curTab] {
+			bottomBar.SetText(i18n.T("Loading..."))
+		}
+		t.barText = i18n.T("Loading...")
+		t.mode = tabModeLoading
+		App.Draw()
+		page, ok := requestPluginPage(p, u)
+		if !ok || !isValidTab(t) || superseded() {
+			return ret("", false)
+		}
+		setPage(t, page)
+		return ret(u, true)
+	}
+
+	proxy := urlProxy(parsed)
 	usingProxy := false
 
 	proxyHostname, proxyPort, err := net.SplitHostPort(proxy)
@@ -224,14 +409,28 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 	}
 
 	if strings.HasPrefix(u, "http") {
-		if proxy == "" || proxy == "off" {
+		if proxy == "" {
 			// No proxy available
+			if httpReaderEnabled() {
+				page, ok := handleHTTPReader(u)
+				if !ok {
+					return ret("", false)
+				}
+				setPage(t, page)
+				return ret(page.URL, true)
+			}
 			handleHTTP(u, true)
 			return ret("", false)
 		}
 		usingProxy = true
 	}
 
+	if parsed.Scheme == "misfin" && viper.GetString("url-handlers.misfin") == "" {
+		// Handled by Amfora unless there's a handler for it
+		go composeMisfin(u)
+		return ret("", false)
+	}
+
 	if strings.HasPrefix(u, "file") {
 		page, ok := handleFile(u)
 		if !ok {
@@ -243,7 +442,7 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 
 	if !strings.HasPrefix(u, "http") && !strings.HasPrefix(u, "gemini") && !strings.HasPrefix(u, "file") {
 		// Not a Gemini URL
-		if proxy == "" || proxy == "off" {
+		if proxy == "" {
 			// No proxy available
 			handleOther(u)
 			return ret("", false)
@@ -251,33 +450,54 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 		usingProxy = true
 	}
 
+	if strings.HasPrefix(u, "gemini") && proxy != "" {
+		// Gemini URLs can go through a proxy too
+		usingProxy = true
+	}
+
 	// Gemini URL, or one with a Gemini proxy available
 
 	// Load page from cache if it exists,
 	// and this isn't a page that was redirected to by the server (indicates dynamic content)
-	if numRedirects == 0 {
+	if numRedirects == 0 && (t.cert == nil || !t.cert.HasCertFor(parsed.Host)) {
+		// Pages loaded with a client certificate aren't cached, but other
+		// tabs may have cached what the page looks like without one
 		page, ok := cache.GetPage(u)
 		if ok {
 			setPage(t, page)
+			t.fromCache = true
 			return ret(u, true)
 		}
 	}
 	// Otherwise download it
-	bottomBar.SetText("Loading...")
-	t.barText = "Loading..." // Save it too, in case the tab switches during loading
+	if t == tabs[curTab] {
+		bottomBar.SetText(i18n.T("Loading..."))
+	}
+	t.barText = i18n.T("Loading...") // Save it too, in case the tab switches during loading
 	t.mode = tabModeLoading
 	App.Draw()
 
+	start := time.Now()
 	var res *gemini.Response
-	if usingProxy {
-		res, err = client.FetchWithProxy(proxyHostname, proxyPort, u)
-	} else {
-		res, err = client.Fetch(u)
-	}
+	for attempts := 1; ; attempts++ {
+		if usingProxy {
+			res, err = client.FetchWithProxyAndCert(proxyHostname, proxyPort, u, t.cert)
+		} else {
+			res, err = fetchURL(u, t.cert)
+		}
 
-	// Loading may have taken a while, make sure tab is still valid
-	if !isValidTab(t) {
-		return ret("", false)
+		// Loading may have taken a while, make sure tab is still valid
+		if !isValidTab(t) {
+			return ret("", false)
+		}
+
+		if !isTransientErr(err) {
+			break
+		}
+		if !retryFetch(t, err, attempts, superseded) {
+			// The user already saw the error when asked to retry, or stopped it
+			return ret("", false)
+		}
 	}
 
 	if errors.Is(err, client.ErrTofu) {
@@ -306,45 +526,199 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 		return ret("", false)
 	}
 
+	// The mediatype sent by the server, if the response goes through a filter
+	var filteredType string
+	if filter, ok := getMediaFilter(u, res); ok {
+		if t == tabs[curTab] {
+			bottomBar.SetText(i18n.T("Filtering..."))
+		}
+		t.barText = i18n.T("Filtering...")
+		App.Draw()
+
+		filteredType, _, _ = mime.ParseMediaType(res.Meta)
+		res, err = filterResponse(filter, u, res)
+		if !isValidTab(t) || superseded() {
+			return ret("", false)
+		}
+		if err != nil {
+			Error("Filter Error", i18n.T("Couldn't filter the page: %s", escapeMeta(err.Error())))
+			return ret("", false)
+		}
+	}
+
+	// The stop key closes the connection, ending pages that are still loading.
+	// The original body is closed, because the RestartReader isn't safe to
+	// close while it's being read from.
+	var stopped int32
+	body := res.Body
+	t.stopLoad = func() {
+		atomic.StoreInt32(&stopped, 1)
+		body.Close()
+	}
+	defer func() {
+		if !superseded() {
+			t.stopLoad = nil
+		}
+	}()
+
 	// Fetch happened successfully, use RestartReader to buffer read data
 	res.Body = rr.NewRestartReader(res.Body)
 
 	if renderer.CanDisplay(res) {
-		page, err := renderer.MakePage(u, res, textWidth(), usingProxy)
+		setConnDetails := func(p *structs.Page) {
+			if filteredType != "" {
+				p.RawMediatype = filteredType
+			}
+			p.TermWidth = termW
+			if usingProxy {
+				p.TLSVersion = client.TLSVersion(proxyHostname, proxyPort)
+			} else {
+				p.TLSVersion = client.TLSVersion(parsed.Hostname(), parsed.Port())
+			}
+			p.Cert = res.Cert
+			p.Redirects = append([]string(nil), t.redirects...)
+			p.LoadTime = time.Since(start)
+		}
+
+		// The partial page being displayed while the page is downloaded, if any.
+		// It's only changed in the UI goroutine, and progress waits for that.
+		var partial *structs.Page
+		progress := func(p *structs.Page) {
+			setConnDetails(p)
+			done := make(chan struct{})
+			App.QueueUpdateDraw(func() {
+				defer close(done)
+				if superseded() || !isValidTab(t) || (partial != nil && t.page != partial) {
+					// The user went somewhere else
+					return
+				}
+				if partial == nil {
+					setPage(t, p)
+					// The page can be scrolled while the rest loads
+					t.partial = true
+					if t == tabs[curTab] {
+						t.applyBottomBar()
+					}
+				} else {
+					replacePage(t, p)
+				}
+				partial = p
+			})
+			<-done
+		}
+		// Returned when the page didn't finish loading. The partial page stays
+		// displayed, but it's not added to the history or cache.
+		errRet := func() (string, bool) {
+			if partial == nil || superseded() || t.page != partial {
+				return ret("", false)
+			}
+			t.stopped = true
+			oldLable = ""
+			oldText = u + " (stopped loading)"
+			return ret("", false)
+		}
+
+		// Pages from gemini:// URLs are still Gemini pages, even through a proxy
+		page, err := renderer.MakePage(u, res, textWidth(), usingProxy && parsed.Scheme != "gemini", progress)
 		// Rendering may have taken a while, make sure tab is still valid
 		if !isValidTab(t) {
 			return ret("", false)
 		}
+		if superseded() || (partial != nil && t.page != partial) {
+			// The user went to another page while this one was loading
+			return "", false
+		}
+
+		streamed := false // Whether the page was kept loading past the limits
+		if partial != nil && (errors.Is(err, renderer.ErrTooLarge) || errors.Is(err, renderer.ErrTimedOut)) {
+			// The page has been shown as it loads, so it could be a stream
+			// that never ends, like a chat. The user can choose to keep it going.
+			choice := Choice("This page is still loading, it may be a stream that never ends.\n"+
+				"What would you like to do?", []string{"Keep loading", "Download", "Stop"})
+			if superseded() || !isValidTab(t) || t.page != partial {
+				return "", false
+			}
+			switch choice {
+			case "Keep loading":
+				streamed = true
+				client.SetReadTimeout(res, 0) //nolint: errcheck
+				res.Body.(*rr.RestartReader).Restart()
+				page, err = renderer.MakeStreamPage(u, res, textWidth(), usingProxy && parsed.Scheme != "gemini", progress)
+				if !isValidTab(t) {
+					return ret("", false)
+				}
+				if superseded() || t.page != partial {
+					return "", false
+				}
+				if errors.Is(err, renderer.ErrTooLarge) {
+					// Even streams stop somewhere, what was loaded stays on screen
+					body.Close()
+					Info("This page stopped loading because it's larger than the max size for streams.")
+					return errRet()
+				}
+			case "Download":
+				client.SetReadTimeout(res, 0) //nolint: errcheck
+				res.Body.(*rr.RestartReader).Restart()
+				go dlChoice("That page is too large. What would you like to do?", u, res)
+				return errRet()
+			default:
+				res.Body.Close()
+				return errRet()
+			}
+		}
+		if atomic.LoadInt32(&stopped) == 1 {
+			// The user stopped loading, what was loaded stays on screen
+			return errRet()
+		}
 
 		if errors.Is(err, renderer.ErrTooLarge) {
 			// Downloading now
 			// Disable read timeout and go back to start
-			res.SetReadTimeout(0) //nolint: errcheck
+			client.SetReadTimeout(res, 0) //nolint: errcheck
 			res.Body.(*rr.RestartReader).Restart()
 			go dlChoice("That page is too large. What would you like to do?", u, res)
-			return ret("", false)
+			return errRet()
 		}
 		if errors.Is(err, renderer.ErrTimedOut) {
 			// Downloading now
 			// Disable read timeout and go back to start
-			res.SetReadTimeout(0) //nolint: errcheck
+			client.SetReadTimeout(res, 0) //nolint: errcheck
 			res.Body.(*rr.RestartReader).Restart()
 			go dlChoice("Loading that page timed out. What would you like to do?", u, res)
-			return ret("", false)
+			return errRet()
 		}
 		if err != nil {
-			Error("Page Error", "Issuing creating page: "+err.Error())
-			return ret("", false)
+			Error("Page Error", i18n.T("Issuing creating page: %v", err))
+			return errRet()
 		}
 
-		page.TermWidth = termW
+		setConnDetails(page)
+		page.Private = t.private
 
-		if !client.HasClientCert(parsed.Host) {
-			// Don't cache pages with client certs
-			go cache.AddPage(page)
+		if !t.usesClientCert(parsed.Host) && !streamed && !t.private {
+			// Don't cache pages with client certs, streams that could be huge,
+			// or pages from private tabs
+			go func() {
+				defer RecoverCrash()
+				cache.AddPage(page)
+			}()
 		}
 
-		setPage(t, page)
+		if partial != nil {
+			done := make(chan struct{})
+			App.QueueUpdateDraw(func() {
+				defer close(done)
+				if !superseded() && isValidTab(t) && t.page == partial {
+					replacePage(t, page)
+				}
+			})
+			<-done
+		} else {
+			setPage(t, page)
+		}
+		if !t.private {
+			hooks.Run(hooks.PageLoaded, map[string]string{"URL": u, "MEDIATYPE": string(page.Mediatype)}, page.Raw)
+		}
 		return ret(u, true)
 	}
 	// Not displayable
@@ -370,32 +744,59 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 				Error("Input Error", "URL for that input would be too long.")
 				return ret("", false)
 			}
-			return ret(handleURL(t, parsed.String(), 0))
+			return ret(handleURLWithLoad(t, parsed.String(), 0, loadID))
 		}
 		return ret("", false)
 	case 30, 31:
 		parsedMeta, err := url.Parse(res.Meta)
 		if err != nil {
-			Error("Redirect Error", "Invalid URL: "+err.Error())
+			Error("Redirect Error", i18n.T("Invalid URL: %v", err))
 			return ret("", false)
 		}
-		redir := parsed.ResolveReference(parsedMeta).String()
+		parsedRedir := parsed.ResolveReference(parsedMeta)
+		redir := parsedRedir.String()
 		// Prompt before redirecting to non-Gemini protocol
 		redirect := false
 		if !strings.HasPrefix(redir, "gemini") {
-			if YesNo("Follow redirect to non-Gemini URL?\n" + redir) {
+			if YesNo(i18n.T("Follow redirect to non-Gemini URL?\n%s", redir)) {
 				redirect = true
 			} else {
 				return ret("", false)
 			}
 		}
 		// Prompt before redirecting
-		autoRedirect := viper.GetBool("a-general.auto_redirect")
-		if redirect || (autoRedirect && numRedirects < 5) || YesNo("Follow redirect?\n"+redir) {
-			if res.Status == gemini.StatusRedirectPermanent {
-				go cache.AddRedir(u, redir)
+		mustAsk, mayFollow := crossHostRedirect(parsed, parsedRedir)
+		hostAllowed := !mustAsk && autoRedirectHost(parsed.Hostname())
+		autoRedirect := !mustAsk && (viper.GetBool("a-general.auto_redirect") || hostAllowed || mayFollow)
+		if !redirect && !(autoRedirect && numRedirects < 5) {
+			buttons := []string{"Yes", "Always for this host", "No"}
+			if hostAllowed || mustAsk || t.private {
+				// Too many redirects in a row, or it has to be asked every time.
+				// Private tabs don't save hosts either.
+				buttons = []string{"Yes", "No"}
 			}
-			return ret(handleURL(t, redir, numRedirects+1))
+			switch Choice(i18n.T("Follow redirect?\n%s", redir), buttons) {
+			case "Yes":
+				redirect = true
+			case "Always for this host":
+				redirect = true
+				if err := addAutoRedirectHost(parsed.Hostname()); err != nil {
+					Error("Redirect Error", i18n.T("Couldn't save the host: %v", err))
+				}
+			}
+		} else {
+			redirect = true
+		}
+		if redirect {
+			if res.Status == gemini.StatusRedirectPermanent && !t.private {
+				go func() {
+					defer RecoverCrash()
+					cache.AddRedir(u, redir)
+				}()
+				offerRedirectUpdate(u, redir)
+			}
+			t.redirects = append(t.redirects, u)
+			return ret(handleURLWithLoad(t, redir, numRedirects+1, loadID))
 		}
 		return ret("", false)
 	case 40:
@@ -411,7 +812,7 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 		Error("Proxy Failure", escapeMeta(res.Meta))
 		return ret("", false)
 	case 44:
-		Error("Slow Down", "You should wait "+escapeMeta(res.Meta)+" seconds before making another request.")
+		Error("Slow Down", i18n.T("You should wait %s seconds before making another request.", escapeMeta(res.Meta)))
 		return ret("", false)
 	case 50:
 		Error("Permanent Failure", escapeMeta(res.Meta))
@@ -428,14 +829,27 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 	case 59:
 		Error("Bad Request", escapeMeta(res.Meta))
 		return ret("", false)
-	case 60:
-		Error("Client Certificate Required", escapeMeta(res.Meta))
-		return ret("", false)
-	case 61:
-		Error("Certificate Not Authorised", escapeMeta(res.Meta))
-		return ret("", false)
-	case 62:
-		Error("Certificate Not Valid", escapeMeta(res.Meta))
+	case 60, 61, 62:
+		if t.private {
+			Error("Certificate Required", "This page needs a client certificate, "+
+				"which private tabs never send. Open it in a regular tab to use one.")
+			return ret("", false)
+		}
+		if t.cert != nil && t.cert.HasCertFor(parsed.Host) {
+			Error("Certificate Required", i18n.T("This tab uses the identity %s, which this page doesn't accept. "+
+				"You can change the identity of the tab.", escapeMeta(t.cert.Name)))
+			return ret("", false)
+		}
+		if t.cert != nil {
+			// The tab sends no certificate to this host
+			Error("Certificate Required", i18n.T("This page needs a client certificate, and this tab doesn't send "+
+				"one to %s. You can change the identity of the tab.", escapeMeta(parsed.Host)))
+			return ret("", false)
+		}
+		if certChoice(parsed.Host, res.Status, res.Meta) {
+			// Try again with the new certificate
+			return ret(handleURLWithLoad(t, u, 0, loadID))
+		}
 		return ret("", false)
 	}
 
@@ -447,11 +861,12 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 	feed, ok := subscriptions.GetFeed(mediatype, filename, res.Body)
 	if ok {
 		go func() {
+			defer RecoverCrash()
 			added := addFeedDirect(u, feed, subscriptions.IsSubscribed(u))
 			if !added {
 				// Otherwise offer download choices
 				// Disable read timeout and go back to start
-				res.SetReadTimeout(0) //nolint: errcheck
+				client.SetReadTimeout(res, 0) //nolint: errcheck
 				res.Body.(*rr.RestartReader).Restart()
 				go dlChoice("That file could not be displayed. What would you like to do?", u, res)
 			}
@@ -459,9 +874,26 @@ func handleURL(t *tab, u string, numRedirects int) (string, bool) {
 		return ret("", false)
 	}
 
+	// Preview images if the terminal supports it
+	if strings.HasPrefix(mediatype, "image/") && config.ImageProtocol != termimg.None {
+		go func() {
+			defer RecoverCrash()
+			if showImage(res) {
+				res.Body.Close()
+				return
+			}
+			// Couldn't be displayed, so offer download choices
+			// Disable read timeout and go back to start
+			client.SetReadTimeout(res, 0) //nolint: errcheck
+			res.Body.(*rr.RestartReader).Restart()
+			dlChoice("That image could not be displayed. What would you like to do?", u, res)
+		}()
+		return ret("", false)
+	}
+
 	// Otherwise offer download choices
 	// Disable read timeout and go back to start
-	res.SetReadTimeout(0) //nolint: errcheck
+	client.SetReadTimeout(res, 0) //nolint: errcheck
 	res.Body.(*rr.RestartReader).Restart()
 	go dlChoice("That file could not be displayed. What would you like to do?", u, res)
 	return ret("", false)
@@ -0,0 +1,37 @@
+package display
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/makeworld-the-better-one/amfora/cache"
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/makeworld-the-better-one/go-gemini"
+)
+
+func TestHandleURLAfterInput(t *testing.T) {
+	const u = "gemini://example.com/search"
+	const withQuery = u + "?amfora"
+
+	defer func(f func(string, *client.TabCert) (*gemini.Response, error)) { fetchURL = f }(fetchURL)
+	fetchURL = func(string, *client.TabCert) (*gemini.Response, error) {
+		return &gemini.Response{Status: 10, Meta: "Search", Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	}
+	// The page after the input is in the cache, so it isn't fetched
+	cache.AddPage(&structs.Page{URL: withQuery, Mediatype: structs.TextGemini, Raw: "# Results",
+		Content: "Results", TermWidth: termW})
+	defer cache.RemovePage(withQuery)
+
+	tb := makeNewTab()
+	defer func(old []*tab, cur int) { tabs, curTab = old, cur }(tabs, curTab)
+	tabs = []*tab{tb}
+	curTab = 0
+
+	go func() { inputCh <- "amfora" }()
+	final, displayed := handleURL(tb, u)
+	if !displayed || final != withQuery {
+		t.Errorf("handleURL after input: got %q %v, want %q true", final, displayed, withQuery)
+	}
+}
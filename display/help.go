@@ -1,6 +1,7 @@
 package display
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
 	"text/tabwriter"
@@ -8,119 +9,303 @@ import (
 	"code.rocketnine.space/tslocum/cview"
 	"github.com/gdamore/tcell/v2"
 	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+	"github.com/makeworld-the-better-one/amfora/plugins"
+	"github.com/spf13/viper"
 )
 
-var helpCells = strings.TrimSpace(
-	"?\tBring up this help. You can scroll!\n" +
-		"Esc\tLeave the help\n" +
-		"Arrow keys, %s(left)/%s(down)/%s(up)/%s(right)\tScroll and move a page.\n" +
-		"%s\tGo up a page in document\n" +
-		"%s\tGo down a page in document\n" +
-		"%s\tGo to top of document\n" +
-		"%s\tGo to bottom of document\n" +
-		"Tab\tNavigate to the next item in a popup.\n" +
-		"Shift-Tab\tNavigate to the previous item in a popup.\n" +
-		"%s\tGo back in the history\n" +
-		"%s\tGo forward in the history\n" +
-		"%s\tOpen bar at the bottom - type a URL, link number, search term.\n" +
-		"\tYou can also type two dots (..) to go up a directory in the URL.\n" +
-		"\tTyping new:N will open link number N in a new tab\n" +
-		"\tinstead of the current one.\n" +
-		"%s\tGo to links 1-10 respectively.\n" +
-		"%s\tEdit current URL\n" +
-		"%s\tCopy current page URL\n" +
-		"%s\tCopy current selected URL\n" +
-		"Enter, Tab\tOn a page this will start link highlighting.\n" +
-		"\tPress Tab and Shift-Tab to pick different links.\n" +
-		"\tPress Enter again to go to one, or Esc to stop.\n" +
-		"%s\tGo to a specific tab. (Default: Shift-NUMBER)\n" +
-		"%s\tGo to the last tab.\n" +
-		"%s\tPrevious tab\n" +
-		"%s\tNext tab\n" +
-		"%s\tGo home\n" +
-		"%s\tNew tab, or if a link is selected,\n" +
-		"\tthis will open the link in a new tab.\n" +
-		"%s\tClose tab. For now, only the right-most tab can be closed.\n" +
-		"%s\tReload a page, discarding the cached version.\n" +
-		"\tThis can also be used if you resize your terminal.\n" +
-		"%s\tView bookmarks\n" +
-		"%s\tAdd, change, or remove a bookmark for the current page.\n" +
-		"%s\tSave the current page to your downloads.\n" +
-		"%s\tView subscriptions\n" +
-		"%s\tAdd or update a subscription\n" +
-		"%s\tQuit\n")
-
-var helpTable = cview.NewTextView()
+// The help is made from the key bindings each time it's shown, so it has the
+// keys from the config, and the ones plugins added. It can be searched, which
+// only shows the lines that have the text in their keys or description.
+
+// helpEntry is a line of the help, and the lines that continue its description.
+// The keys are the ones bound to the command, unless there's a keys func.
+type helpEntry struct {
+	cmd  config.Command // CmdInvalid if the keys aren't for one command
+	keys func() string
+	desc string // Lines after the first are continuation lines
+}
+
+type helpSection struct {
+	title   string
+	entries []helpEntry
+}
+
+// fixed returns keys that can't be changed in the config.
+func fixed(keys string) func() string {
+	return func() string { return keys }
+}
+
+// firstKey returns the first key bound to the command.
+func firstKey(cmd config.Command) string {
+	return strings.Split(config.GetKeyBinding(cmd), ",")[0]
+}
+
+var helpSections = []helpSection{
+	{"Help", []helpEntry{
+		{config.CmdHelp, nil, "Bring up this help. You can scroll!\n" +
+			"The keys can be changed on about:keys."},
+		{config.CmdInvalid, fixed("/"), "Search the help. Enter keeps the results, Esc shows everything again."},
+		{config.CmdInvalid, fixed("Esc"), "Leave the help"},
+	}},
+	{"Moving around", []helpEntry{
+		{config.CmdInvalid, func() string {
+			return fmt.Sprintf("Arrow keys, %s(left)/%s(down)/%s(up)/%s(right)",
+				config.GetKeyBinding(config.CmdMoveLeft), config.GetKeyBinding(config.CmdMoveDown),
+				config.GetKeyBinding(config.CmdMoveUp), config.GetKeyBinding(config.CmdMoveRight))
+		}, "Scroll and move a page."},
+		{config.CmdPgup, nil, "Go up a page in document"},
+		{config.CmdPgdn, nil, "Go down a page in document"},
+		{config.CmdBeginning, nil, "Go to top of document"},
+		{config.CmdEnd, nil, "Go to bottom of document"},
+		{config.CmdInvalid, fixed("Tab"), "Navigate to the next item in a popup."},
+		{config.CmdInvalid, fixed("Shift-Tab"), "Navigate to the previous item in a popup."},
+		{config.CmdBack, nil, "Go back in the history"},
+		{config.CmdForward, nil, "Go forward in the history"},
+		{config.CmdJumpBack, nil, "Go back to where the tab jumped from, like the top of the page\n" +
+			"or another page. It's separate from the history, like vim's jump list."},
+		{config.CmdJumpForward, nil, "Go forward through the places the tab jumped from"},
+		{config.CmdBottom, nil, "Open bar at the bottom - type a URL, link number, search term.\n" +
+			"You can also type two dots (..) to go up a directory in the URL.\n" +
+			"Typing new:N will open link number N in a new tab\n" +
+			"instead of the current one.\n" +
+			"Typing :clear will show the browsing data that can be cleared.\n" +
+			"Up and Down go through what was typed before. The editing keys\n" +
+			"of shells work too: Ctrl-A, Ctrl-E, Ctrl-W, Ctrl-U, Ctrl-K, Alt-B, Alt-F.\n" +
+			"Set editing_mode to \"vi\" in the config for the keys of vi instead."},
+		{config.CmdEdit, nil, "Edit current URL"},
+		{config.CmdFinder, nil, "Go to a tab, bookmark, subscription, or page from the history,\n" +
+			"by typing some of its letters. Start with t:, b:, s:, or h: to only search one."},
+		{config.CmdHome, nil, "Go home"},
+		{config.CmdParent, nil, "Go up one directory from the current page"},
+		{config.CmdRoot, nil, "Go to the root of the current capsule"},
+		{config.CmdNextPage, nil, "Follow the link to the next page, like \"Next\" or \"Older posts\""},
+		{config.CmdPrevPage, nil, "Follow the link to the previous page, like \"Previous\" or \"Newer posts\""},
+	}},
+	{"Links", []helpEntry{
+		{config.CmdInvalid, func() string {
+			return fmt.Sprintf("%s to %s", firstKey(config.CmdLink1), firstKey(config.CmdLink0))
+		}, "Go to links 1-10 respectively."},
+		{config.CmdFollow, nil, "Show the link numbers, and type one to follow that link."},
+		{config.CmdFollowText, nil, "Type some of the text of a link to follow it. Enter follows the\n" +
+			"link that matches best."},
+		{config.CmdInvalid, fixed("Enter, Tab"), "On a page this will start link highlighting.\n" +
+			"Press Tab and Shift-Tab to pick different links.\n" +
+			"Press Enter again to go to one, or Esc to stop."},
+		{config.CmdCopyTargetURL, nil, "Copy current selected URL"},
+		{config.CmdPreviewImage, nil, "Preview the selected link as an image,\n" +
+			"if your terminal supports it."},
+		{config.CmdPeek, nil, "Peek at the selected link: show the start of the page it goes to,\n" +
+			"without leaving this one."},
+	}},
+	{"The current page", []helpEntry{
+		{config.CmdCopyPageURL, nil, "Copy current page URL"},
+		{config.CmdCopyHeading, nil, "Copy a link to the heading of the part of the page being viewed"},
+		{config.CmdPageInfo, nil, "Show information about the current page"},
+		{config.CmdCertInfo, nil, "Show the server certificate of the current page"},
+		{config.CmdViewAs, nil, "View the current page as gemtext, Markdown, plain text,\n" +
+			"or ANSI art, if the server sent the wrong type."},
+		{config.CmdInputEditor, nil, "When a page asks for input, write it in your text editor."},
+		{config.CmdWrapPre, nil, "Soft-wrap preformatted text on the current page, or stop wrapping it."},
+		{config.CmdPager, nil, "Show the current page in your pager, like less."},
+		{config.CmdPreBlock, nil, "Save, copy, or edit the preformatted text on the screen."},
+		{config.CmdPipe, nil, "Run a shell command with the current page as its input."},
+		{config.CmdBlockHost, nil, "Block the host of the selected link, or of the current page."},
+		{config.CmdReload, nil, "Reload a page, discarding the cached version.\n" +
+			"This can also be used if you resize your terminal."},
+		{config.CmdDiff, nil, "After reloading, show what changed on the page."},
+		{config.CmdStop, nil, "Stop loading the page. What has loaded so far stays on screen,\n" +
+			"so this also ends pages that are streamed, like chats."},
+		{config.CmdSave, nil, "Save the current page to your downloads."},
+		{config.CmdArchive, nil, "Archive a snapshot of the current page, see about:archive"},
+	}},
+	{"Tabs and panes", []helpEntry{
+		{config.CmdInvalid, func() string {
+			return fmt.Sprintf("%s to %s", firstKey(config.CmdTab1), firstKey(config.CmdTab9))
+		}, "Go to a specific tab. (Default: Shift-NUMBER)"},
+		{config.CmdTab0, nil, "Go to the last tab."},
+		{config.CmdPrevTab, nil, "Previous tab"},
+		{config.CmdNextTab, nil, "Next tab"},
+		{config.CmdNewTab, nil, "New tab, or if a link is selected,\n" +
+			"this will open the link in a new tab."},
+		{config.CmdNewPrivateTab, nil, "New private tab. Pages in it aren't remembered, and no\n" +
+			"client certificates are sent. Its number is in parentheses."},
+		{config.CmdTabIdentity, nil, "Choose the identity (client certificate) the current tab uses\n" +
+			"for the current site, or go back to the ones for each site."},
+		{config.CmdCloseTab, nil, "Close tab. For now, only the right-most tab can be closed."},
+		{config.CmdZen, nil, "Hide the tab row and the bottom bar, or show them again."},
+		{config.CmdSplit, nil, "Split the view into two panes side by side, or close the other pane."},
+		{config.CmdSplitBelow, nil, "Split the view into two panes one above the other, or close the other pane."},
+		{config.CmdSplitFocus, nil, "Move between the panes of a split view."},
+		{config.CmdFollowInPane, nil, "Open the selected link in the other pane, and stay on this one."},
+	}},
+	{"Bookmarks, reading list, and subscriptions", []helpEntry{
+		{config.CmdBookmarks, nil, "View bookmarks"},
+		{config.CmdAddBookmark, nil, "Add, change, or remove a bookmark for the current page."},
+		{config.CmdReadLater, nil, "Save the current page to the reading list."},
+		{config.CmdReadingList, nil, "View the reading list"},
+		{config.CmdSub, nil, "View subscriptions"},
+		{config.CmdAddSub, nil, "Add or update a subscription"},
+	}},
+	{"On the subscriptions page", []helpEntry{
+		{config.CmdMarkRead, nil, "On the subscriptions page, mark the selected entry as read or unread.\n" +
+			"On other pages, press a letter after it to set a mark."},
+		{config.CmdGoToMark, nil, "Press a letter after it to go to the mark set under it"},
+		{config.CmdMarkFeedRead, nil, "On the subscriptions page, mark the selected entry's feed as read"},
+		{config.CmdShowRead, nil, "On the subscriptions page, hide or show the entries that were read"},
+		{config.CmdNextUnread, nil, "On the subscriptions page, select the next unread entry"},
+	}},
+	{"Other", []helpEntry{
+		{config.CmdRecordMacro, nil, "Record a macro: press a letter or number to save it under,\n" +
+			"then the keys to record. Press this again to stop recording."},
+		{config.CmdPlayMacro, nil, "Play the macro saved under the letter or number pressed next.\n" +
+			"Press this twice to play the last macro again."},
+		{config.CmdQuit, nil, "Quit"},
+	}},
+}
+
+// keyString returns the keys of the entry, as they are when the help is shown.
+func (e helpEntry) keyString() string {
+	if e.keys != nil {
+		return e.keys()
+	}
+	return config.GetKeyBinding(e.cmd)
+}
+
+// pluginHelpSection returns the help for the plugin commands bound to keys.
+func pluginHelpSection() helpSection {
+	s := helpSection{title: "Plugins"}
+	for _, p := range plugins.All() {
+		for _, c := range p.Commands {
+			if _, ok := pluginKeys[p.Name+"/"+c.Name]; !ok {
+				continue
+			}
+			desc := c.Description
+			if desc == "" {
+				desc = c.Name
+			}
+			s.entries = append(s.entries, helpEntry{config.CmdInvalid, fixed(c.Key), p.Name + ": " + desc})
+		}
+	}
+	return s
+}
+
+// helpText returns the help, with only the lines that have the query in
+// their keys or description, ignoring case. Everything is shown if the
+// query is empty.
+func helpText(sections []helpSection, query string) string {
+	query = strings.ToLower(query)
+	var out strings.Builder
+	for _, section := range sections {
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+		for _, e := range section.entries {
+			keys := e.keyString()
+			if keys == "" {
+				keys = i18n.T("(not bound)")
+			}
+			// Only the descriptions are translated, not the keys
+			lines := strings.Split(e.desc, "\n")
+			for i := range lines {
+				lines[i] = i18n.T(lines[i])
+			}
+			if query != "" && !strings.Contains(strings.ToLower(keys+"\n"+strings.Join(lines, "\n")), query) {
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\n", keys, lines[0])
+			for _, line := range lines[1:] {
+				fmt.Fprintf(w, "\t%s\n", line)
+			}
+			fmt.Fprintln(w, "\t")
+		}
+		w.Flush()
+		if buf.Len() == 0 {
+			continue
+		}
+		fmt.Fprintf(&out, "[::b]%s[::-]\n\n%s\n", cview.Escape(i18n.T(section.title)), cview.Escape(buf.String()))
+	}
+	if out.Len() == 0 {
+		return i18n.T("Nothing in the help matches the search.")
+	}
+	return strings.TrimRight(out.String(), "\n")
+}
+
+var (
+	helpPanel  = cview.NewFlex()
+	helpSearch = cview.NewInputField()
+	helpTable  = cview.NewTextView()
+)
+
+// The sections shown, made when the help is
+var shownHelpSections []helpSection
 
 // Help displays the help and keybindings.
 func Help() {
+	shownHelpSections = append(helpSections[:len(helpSections):len(helpSections)], pluginHelpSection())
+	helpSearch.SetText("")
+	helpPanel.ResizeItem(helpSearch, 0, 0)
+	helpTable.SetText(helpText(shownHelpSections, ""))
 	helpTable.ScrollToBeginning()
 	panels.ShowPanel("help")
 	panels.SendToFront("help")
 	App.SetFocus(helpTable)
 }
 
+func closeHelp() {
+	panels.HidePanel("help")
+	App.SetFocus(tabs[curTab].view)
+	App.Draw()
+}
+
 func helpInit() {
-	// Populate help table
+	helpTable.SetDynamicColors(true)
 	helpTable.SetBackgroundColor(config.GetColor("bg"))
 	helpTable.SetTextColor(config.GetColor("regular_text"))
 	helpTable.SetPadding(0, 0, 1, 1)
 	helpTable.SetDoneFunc(func(key tcell.Key) {
 		if key == tcell.KeyEsc || key == tcell.KeyEnter {
-			panels.HidePanel("help")
-			App.SetFocus(tabs[curTab].view)
-			App.Draw()
+			closeHelp()
 		}
 	})
+	helpTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyRune && event.Rune() == '/' {
+			helpPanel.ResizeItem(helpSearch, 1, 0)
+			App.SetFocus(helpSearch)
+			return nil
+		}
+		return event
+	})
 	helpTable.SetScrollBarColor(config.GetColor("scrollbar"))
 
-	tabKeys := fmt.Sprintf("%s to %s", strings.Split(config.GetKeyBinding(config.CmdTab1), ",")[0],
-		strings.Split(config.GetKeyBinding(config.CmdTab9), ",")[0])
-	linkKeys := fmt.Sprintf("%s to %s", strings.Split(config.GetKeyBinding(config.CmdLink1), ",")[0],
-		strings.Split(config.GetKeyBinding(config.CmdLink0), ",")[0])
-
-	helpCells = fmt.Sprintf(helpCells,
-		config.GetKeyBinding(config.CmdMoveLeft),
-		config.GetKeyBinding(config.CmdMoveDown),
-		config.GetKeyBinding(config.CmdMoveUp),
-		config.GetKeyBinding(config.CmdMoveRight),
-		config.GetKeyBinding(config.CmdPgup),
-		config.GetKeyBinding(config.CmdPgdn),
-		config.GetKeyBinding(config.CmdBeginning),
-		config.GetKeyBinding(config.CmdEnd),
-		config.GetKeyBinding(config.CmdBack),
-		config.GetKeyBinding(config.CmdForward),
-		config.GetKeyBinding(config.CmdBottom),
-		linkKeys,
-		config.GetKeyBinding(config.CmdEdit),
-		config.GetKeyBinding(config.CmdCopyPageURL),
-		config.GetKeyBinding(config.CmdCopyTargetURL),
-		tabKeys,
-		config.GetKeyBinding(config.CmdTab0),
-		config.GetKeyBinding(config.CmdPrevTab),
-		config.GetKeyBinding(config.CmdNextTab),
-		config.GetKeyBinding(config.CmdHome),
-		config.GetKeyBinding(config.CmdNewTab),
-		config.GetKeyBinding(config.CmdCloseTab),
-		config.GetKeyBinding(config.CmdReload),
-		config.GetKeyBinding(config.CmdBookmarks),
-		config.GetKeyBinding(config.CmdAddBookmark),
-		config.GetKeyBinding(config.CmdSave),
-		config.GetKeyBinding(config.CmdSub),
-		config.GetKeyBinding(config.CmdAddSub),
-		config.GetKeyBinding(config.CmdQuit),
-	)
-
-	lines := strings.Split(helpCells, "\n")
-	w := tabwriter.NewWriter(helpTable, 0, 8, 2, ' ', 0)
-	for i, line := range lines {
-		if i > 0 && line[0] != '\t' {
-			fmt.Fprintln(w, "\t")
-		}
-		fmt.Fprintln(w, line)
+	helpSearch.SetLabel("[::b]" + i18n.T("Search:") + " [::-]")
+	// Like the bottom bar
+	if viper.GetBool("a-general.color") {
+		helpSearch.SetBackgroundColor(config.GetColor("bottombar_bg"))
+		helpSearch.SetLabelColor(config.GetColor("bottombar_label"))
+		helpSearch.SetFieldBackgroundColor(config.GetColor("bottombar_bg"))
+		helpSearch.SetFieldTextColor(config.GetColor("bottombar_text"))
+	} else {
+		helpSearch.SetBackgroundColor(tcell.ColorWhite)
+		helpSearch.SetLabelColor(tcell.ColorBlack)
+		helpSearch.SetFieldBackgroundColor(tcell.ColorWhite)
+		helpSearch.SetFieldTextColor(tcell.ColorBlack)
 	}
+	helpSearch.SetChangedFunc(func(text string) {
+		// Incremental search, the results change with each key
+		helpTable.SetText(helpText(shownHelpSections, text))
+		helpTable.ScrollToBeginning()
+	})
+	helpSearch.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEsc {
+			helpSearch.SetText("")
+			helpPanel.ResizeItem(helpSearch, 0, 0)
+		}
+		App.SetFocus(helpTable)
+	})
 
-	w.Flush()
+	helpPanel.SetDirection(cview.FlexRow)
+	helpPanel.AddItem(helpSearch, 0, 0, false)
+	helpPanel.AddItem(helpTable, 0, 1, true)
 
-	panels.AddPanel("help", helpTable, true, false)
+	panels.AddPanel("help", helpPanel, true, false)
 }
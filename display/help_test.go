@@ -0,0 +1,44 @@
+package display
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+)
+
+func TestHelpText(t *testing.T) {
+	sections := []helpSection{
+		{"Links", []helpEntry{
+			{config.CmdInvalid, fixed("Tab"), "Select a link"},
+			{config.CmdInvalid, fixed("Ctrl-C"), "Copy the [selected] link\nto the clipboard"},
+		}},
+		{"Tabs", []helpEntry{
+			{config.CmdInvalid, fixed("Ctrl-T"), "New tab"},
+			{config.CmdInvalid, fixed(""), "Close the tab"},
+		}},
+	}
+	tests := []struct {
+		query    string
+		contains []string
+		excludes []string
+	}{
+		{"", []string{"[::b]Links[::-]", "Tab", "Ctrl-T", "(not bound)", "[selected[]"}, nil},
+		{"CLIPBOARD", []string{"Links", "Ctrl-C", "to the clipboard"}, []string{"Tabs", "New tab", "Select a link"}},
+		{"ctrl-t", []string{"Tabs", "New tab"}, []string{"Links", "Close the tab"}},
+		{"nothing", []string{"Nothing in the help matches"}, []string{"Links", "Tabs"}},
+	}
+	for _, tt := range tests {
+		got := helpText(sections, tt.query)
+		for _, s := range tt.contains {
+			if !strings.Contains(got, s) {
+				t.Errorf("helpText(%q) doesn't contain %q:\n%s", tt.query, s, got)
+			}
+		}
+		for _, s := range tt.excludes {
+			if strings.Contains(got, s) {
+				t.Errorf("helpText(%q) contains %q:\n%s", tt.query, s, got)
+			}
+		}
+	}
+}
@@ -2,7 +2,8 @@ package display
 
 // applyHist is a history.go internal function, to load a URL in the history.
 func applyHist(t *tab) {
-	handleURL(t, t.history.urls[t.history.pos], 0) // Load that position in history
+	defer RecoverCrash()
+	handleURL(t, t.history.urls[t.history.pos]) // Load that position in history
 	t.applyAll()
 }
 
@@ -16,6 +17,12 @@ func histForward(t *tab) {
 }
 
 func histBack(t *tab) {
+	if t.stopped && t.history.pos >= 0 && t.history.pos < len(t.history.urls) {
+		// The page that stopped loading isn't in the history, so going back
+		// is going to the page before it
+		go applyHist(t)
+		return
+	}
 	if t.history.pos <= 0 {
 		// First tab in history
 		return
@@ -0,0 +1,144 @@
+package display
+
+import (
+	"net/url"
+
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+)
+
+// certChoice asks the user which client certificate to use for the host,
+// after the server asked for one with status 60, or didn't accept the one
+// that was sent. It returns true if one was picked, and the page should
+// be loaded again.
+func certChoice(host string, status int, meta string) bool {
+	var prompt string
+	switch status {
+	case 60:
+		prompt = "This page requires a client certificate."
+	case 61:
+		prompt = "The certificate used isn't authorised for this page."
+	default:
+		prompt = "The certificate used isn't valid for this page."
+	}
+	if meta != "" {
+		prompt += "\n\n" + escapeMeta(meta)
+	}
+	prompt += "\n\nA temporary certificate is made just for this site, and forgotten when Amfora is closed."
+
+	ids := client.Identities()
+	buttons := []string{"Temporary"}
+	if len(ids) > 0 {
+		buttons = append(buttons, "Stored identity")
+	}
+	buttons = append(buttons, "Cancel")
+
+	switch Choice(prompt, buttons) {
+	case "Temporary":
+		if err := client.UseTemporaryCert(host); err != nil {
+			Error("Certificate Error", "Couldn't make a certificate: "+err.Error())
+			return false
+		}
+		return true
+	case "Stored identity":
+		names := make([]string, 0, len(ids)+1)
+		for _, id := range ids {
+			names = append(names, id.Name)
+		}
+		names = append(names, "Cancel")
+		choice := Choice("Which identity should be used for "+escapeMeta(host)+
+			" until Amfora is closed?", names)
+		for _, id := range ids {
+			if id.Name == choice {
+				client.UseIdentity(host, id)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// usesClientCert returns true if a client certificate is sent to the host
+// from the tab.
+func (t *tab) usesClientCert(host string) bool {
+	if t.cert != nil {
+		return t.cert.HasCertFor(host)
+	}
+	return client.HasClientCert(host)
+}
+
+// tabIdentity asks which identity the tab should use for the host of the
+// current page, and sets it. Other hosts get no certificate from the tab.
+// The current page is loaded again with it.
+//
+// It should be called in a goroutine.
+func tabIdentity(t *tab) {
+	defer RecoverCrash()
+	if t.private {
+		Info("Private tabs never send client certificates.")
+		return
+	}
+
+	// The certificate is only sent to the host of the current page
+	var host string
+	if t.hasContent() && !t.isAnAboutPage() {
+		if parsed, err := url.Parse(t.page.URL); err == nil {
+			host = parsed.Host
+		}
+	}
+
+	current := i18n.T("It's using the certificates set for each site now.")
+	if t.cert != nil {
+		if t.cert.Host != "" {
+			current = i18n.T("It's using the identity \"%s\" for %s now.", escapeMeta(t.cert.Name), escapeMeta(t.cert.Host))
+		} else {
+			current = i18n.T("It's using the identity \"%s\" now.", escapeMeta(t.cert.Name))
+		}
+	}
+	ids := client.Identities()
+	buttons := []string{"Each site's", "None"}
+	prompt := i18n.T("Which identity should this tab use?") + "\n"
+	if host != "" {
+		buttons = append(buttons, "Temporary")
+		for _, id := range ids {
+			buttons = append(buttons, id.Name)
+		}
+		prompt = i18n.T("Which identity should this tab use for %s?\nOther sites won't get a certificate from this tab.",
+			escapeMeta(host)) + "\n"
+	}
+	buttons = append(buttons, "Cancel")
+	choice := Choice(prompt+current, buttons)
+
+	var cert *client.TabCert
+	switch choice {
+	case "", "Cancel":
+		return
+	case "Each site's":
+	case "None":
+		cert = client.NoCert
+	case "Temporary":
+		var err error
+		cert, err = client.TemporaryTabCert(host)
+		if err != nil {
+			Error("Certificate Error", "Couldn't make a certificate: "+escapeMeta(err.Error()))
+			return
+		}
+	default:
+		for _, id := range ids {
+			if id.Name == choice {
+				cert = client.IdentityTabCert(id, host)
+				break
+			}
+		}
+	}
+
+	App.QueueUpdateDraw(func() {
+		if !isValidTab(t) {
+			return
+		}
+		t.cert = cert
+		if t.hasContent() && !t.isAnAboutPage() {
+			go goURL(t, t.page.URL)
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package display
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"strings"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/makeworld-the-better-one/amfora/termimg"
+	"github.com/makeworld-the-better-one/go-gemini"
+	"github.com/spf13/viper"
+)
+
+// Displays images as text, when the terminal doesn't support graphics
+var imageView = cview.NewTextView()
+
+// Images larger than this aren't decoded, since a small file can decode to
+// a huge image that takes up all the memory
+const maxImageSide = 16384
+const maxImagePixels = 50000000
+
+var errImageTooLarge = errors.New("the image is too large")
+
+func imageInit() {
+	imageView.SetDynamicColors(true)
+	imageView.SetWrap(false)
+	imageView.SetBackgroundColor(config.GetColor("bg"))
+	imageView.SetTextColor(config.GetColor("regular_text"))
+	imageView.SetScrollBarVisibility(cview.ScrollBarNever)
+	imageView.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEsc || key == tcell.KeyEnter {
+			panels.HidePanel("image")
+			App.SetFocus(tabs[curTab].view)
+			App.Draw()
+		}
+	})
+	panels.AddPanel("image", imageView, true, false)
+}
+
+// imageFallback returns how images should be previewed as text:
+// "blocks", "ascii", or "off".
+func imageFallback() string {
+	switch strings.ToLower(viper.GetString("a-general.image_fallback")) {
+	case "blocks":
+		if !viper.GetBool("a-general.color") {
+			return "ascii"
+		}
+		return "blocks"
+	case "ascii":
+		return "ascii"
+	default:
+		return "off"
+	}
+}
+
+// canPreviewImages returns true if images can be previewed in some way.
+func canPreviewImages() bool {
+	return config.ImageProtocol != termimg.None || imageFallback() != "off"
+}
+
+// showImageText displays the image inside the TUI, using text.
+func showImageText(img image.Image) {
+	// Leave a row for the message at the bottom
+	rows := screenH - 3
+	if imageFallback() == "ascii" {
+		imageView.SetText(termimg.ASCII(img, screenW, rows))
+	} else {
+		imageView.SetText(termimg.Blocks(img, screenW, rows))
+	}
+	fmt.Fprint(imageView, "\nPress Enter to go back.")
+	imageView.ScrollToBeginning()
+	panels.ShowPanel("image")
+	panels.SendToFront("image")
+	App.SetFocus(imageView)
+	App.Draw()
+}
+
+// showImage decodes the image in the response and displays it using the
+// terminal's graphics protocol. The rest of the UI is suspended until the
+// user presses Enter. If there is no graphics protocol, the image is
+// displayed as text instead.
+//
+// It returns false if the image couldn't be decoded or displayed.
+func showImage(res *gemini.Response) bool {
+	if !canPreviewImages() {
+		return false
+	}
+	img, err := decodeImage(res.Body)
+	if err != nil {
+		return false
+	}
+	if config.ImageProtocol == termimg.None {
+		showImageText(img)
+		return true
+	}
+
+	App.Suspend(func() {
+		// Clear the screen and go to the top left
+		fmt.Print("\x1b[2J\x1b[H")
+		err = termimg.Write(os.Stdout, img, config.ImageProtocol, screenW, screenH-2)
+		if err != nil {
+			return
+		}
+		fmt.Print("\r\n\r\nPress Enter to go back to Amfora.")
+		bufio.NewReader(os.Stdin).ReadString('\n')     //nolint:errcheck
+		termimg.Clear(os.Stdout, config.ImageProtocol) //nolint:errcheck
+	})
+	return err == nil
+}
+
+// decodeImage decodes the image, if it's no larger than the page_max_size
+// config option, and its dimensions are reasonable.
+func decodeImage(r io.Reader) (image.Image, error) {
+	maxSize := viper.GetInt64("a-general.page_max_size")
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, errImageTooLarge
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width > maxImageSide || cfg.Height > maxImageSide || cfg.Width*cfg.Height > maxImagePixels {
+		return nil, errImageTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// previewImage fetches the selected link of the tab and displays it as
+// an image, without leaving the page.
+//
+// It should be called in a goroutine.
+func previewImage(t *tab) {
+	defer RecoverCrash()
+	if !canPreviewImages() {
+		Error("Preview Error", "Image previews are turned off.")
+		return
+	}
+	if t.page.Mode != structs.ModeLinkSelect {
+		Info("Select a link with Tab to preview it.")
+		return
+	}
+
+	u, err := resolveRelLink(t, t.page.URL, t.page.Selected)
+	if err != nil {
+		Error("URL Error", err.Error())
+		return
+	}
+	u = normalizeURL(u)
+	if !strings.HasPrefix(u, "gemini://") {
+		Error("Preview Error", "Only Gemini links can be previewed.")
+		return
+	}
+
+	res, err := client.FetchWithCert(u, t.cert)
+	if errors.Is(err, client.ErrTofu) {
+		res.Body.Close()
+		Error("Preview Error", "The server's certificate has changed. Open the link to review it.")
+		return
+	} else if err != nil {
+		Error("URL Fetch Error", err.Error())
+		return
+	}
+	defer res.Body.Close()
+
+	mediatype, _, _ := mime.ParseMediaType(res.Meta)
+	if res.Status != gemini.StatusSuccess || !strings.HasPrefix(mediatype, "image/") {
+		Error("Preview Error", "That link is not an image.")
+		return
+	}
+	if !showImage(res) {
+		Error("Preview Error", "The image could not be displayed, it may be in an unsupported format.")
+	}
+}
@@ -0,0 +1,36 @@
+package display
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeImage(t *testing.T) {
+	viper.Set("a-general.page_max_size", 1<<20)
+	defer viper.Set("a-general.page_max_size", 2097152)
+
+	if _, err := decodeImage(bytes.NewReader(encodePNG(t, 10, 10))); err != nil {
+		t.Errorf("small image: %v", err)
+	}
+	// A blank image compresses to far less than its decoded size
+	if _, err := decodeImage(bytes.NewReader(encodePNG(t, maxImageSide+1, 1))); err != errImageTooLarge {
+		t.Errorf("wide image: got %v, want errImageTooLarge", err)
+	}
+
+	viper.Set("a-general.page_max_size", 10)
+	if _, err := decodeImage(bytes.NewReader(encodePNG(t, 10, 10))); err != errImageTooLarge {
+		t.Errorf("image over page_max_size: got %v, want errImageTooLarge", err)
+	}
+}
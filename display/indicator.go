@@ -0,0 +1,94 @@
+package display
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/mattn/go-runewidth"
+	"github.com/spf13/viper"
+)
+
+// shortAge formats how long ago the time was in a compact way, like "3d" or "5mo".
+func shortAge(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < 24*time.Hour:
+		return "<1d"
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo", d/(30*24*time.Hour))
+	}
+	return fmt.Sprintf("%dy", d/(365*24*time.Hour))
+}
+
+// connInfo holds the connection details shown in the indicator, and the
+// modes shown before them.
+type connInfo struct {
+	tlsVersion   uint16
+	clientCert   bool
+	trustedSince time.Time
+	tor          bool
+	recording    rune // The register of the macro being recorded
+	viNormal     bool // The bottom bar is in vi's normal mode
+}
+
+// indicatorText returns the text of the indicator for the connection, which is
+// empty if there is nothing to show.
+func indicatorText(c *connInfo) string {
+	parts := make([]string, 0, 6)
+	if c.viNormal {
+		parts = append(parts, "NORMAL")
+	}
+	if c.recording != 0 {
+		parts = append(parts, "REC "+string(c.recording))
+	}
+	if c.tlsVersion != 0 {
+		parts = append(parts, strings.ReplaceAll(client.TLSVersionName(c.tlsVersion), " ", ""))
+	}
+	if c.clientCert {
+		parts = append(parts, "ID")
+	}
+	if !c.trustedSince.IsZero() {
+		parts = append(parts, shortAge(c.trustedSince))
+	}
+	if c.tor {
+		parts = append(parts, "Tor")
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " " + strings.Join(parts, " ") + " "
+}
+
+// updateIndicator shows the connection details of the tab's page to the
+// left of the bottom bar, or hides the indicator if there are none.
+//
+// It shows the TLS version, whether a client cert is used, how long the
+// server cert has been trusted, and whether the page was loaded through Tor.
+// Only the Tor part is shown if the security indicator is turned off. While
+// the bottom bar is in vi's normal mode, or a macro is recorded, that's
+// shown first.
+func updateIndicator(t *tab) {
+	var info connInfo
+	parsed, err := url.Parse(t.page.URL)
+	if t.page.URL != "" && err == nil && !t.isAnAboutPage() && parsed.Host != "" {
+		info.tor = torified(t.page.URL)
+
+		if viper.GetBool("a-general.security_indicator") && t.page.TLSVersion != 0 {
+			host, port, _ := connHost(parsed)
+			info.tlsVersion = t.page.TLSVersion
+			info.clientCert = t.usesClientCert(parsed.Host)
+			info.trustedSince = client.GetTrustedSince(host, port)
+		}
+	}
+
+	info.recording = macroRecording
+	info.viNormal = barVi.normal
+	text := indicatorText(&info)
+	indicator.SetText("[::b]" + text + "[::-]")
+	bottomRow.ResizeItem(indicator, runewidth.StringWidth(text), 0)
+}
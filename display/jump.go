@@ -0,0 +1,85 @@
+package display
+
+// Each tab has a jump list, like vim. Positions are added to it before the
+// tab jumps somewhere else: to another page, to the top or bottom of the page,
+// or to a heading. Going back through it returns to them, without changing the
+// tab's history. Positions are text offsets, like for scroll.go.
+
+// The number of positions each tab's jump list keeps.
+const maxJumps = 100
+
+type jump struct {
+	url    string
+	offset int
+}
+
+type jumpList struct {
+	jumps  []jump // Oldest first
+	pos    int    // Where the tab is in jumps, or len(jumps) if it's not going through it
+	target *jump  // The jump the page being loaded is for, see setPage
+}
+
+// add adds the position to the end of the list, removing it from where it
+// was before.
+func (l *jumpList) add(j jump) {
+	for i := range l.jumps {
+		if l.jumps[i] == j {
+			l.jumps = append(l.jumps[:i], l.jumps[i+1:]...)
+			break
+		}
+	}
+	if len(l.jumps) >= maxJumps {
+		l.jumps = l.jumps[len(l.jumps)-maxJumps+1:]
+	}
+	l.jumps = append(l.jumps, j)
+	l.pos = len(l.jumps)
+}
+
+// move returns the position that's n after the one the tab is at, or before
+// it if n is negative. current is where the tab is now, which is added to the
+// end the first time it goes back, so going forward can return to it. It
+// returns false if there's nowhere to go.
+func (l *jumpList) move(current jump, n int) (jump, bool) {
+	if n < 0 && l.pos >= len(l.jumps) {
+		l.add(current)
+		l.pos = len(l.jumps) - 1
+	}
+	to := l.pos + n
+	if to < 0 || to >= len(l.jumps) {
+		return jump{}, false
+	}
+	l.pos = to
+	return l.jumps[to], true
+}
+
+// currentJump returns the position of the tab.
+func (t *tab) currentJump() jump {
+	return jump{t.page.URL, textOffset(t.view.GetText(true), t.page.Row)}
+}
+
+// addJump adds the position of the tab to its jump list, before it jumps
+// somewhere else.
+func (t *tab) addJump() {
+	if !t.hasContent() {
+		return
+	}
+	t.jumps.add(t.currentJump())
+}
+
+// jumpTo moves the tab n positions through its jump list. Positions on other
+// pages load them, and are added to the tab's history.
+func jumpTo(t *tab, n int) {
+	if !t.hasContent() || t.mode != tabModeDone {
+		return
+	}
+	j, ok := t.jumps.move(t.currentJump(), n)
+	if !ok {
+		return
+	}
+	if j.url == t.page.URL {
+		t.scrollTo(rowAtOffset(t.view.GetText(true), j.offset), 0)
+		return
+	}
+	t.jumps.target = &j
+	URL(j.url)
+}
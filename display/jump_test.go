@@ -0,0 +1,43 @@
+package display
+
+import "testing"
+
+func TestJumpList(t *testing.T) {
+	var l jumpList
+	a, b, c := jump{"a", 0}, jump{"b", 10}, jump{"c", 0}
+	l.add(a)
+	l.add(b)
+
+	tests := []struct {
+		n    int
+		want jump
+		ok   bool
+	}{
+		{1, jump{}, false}, // Not going through the list yet
+		{-1, b, true},      // c is added
+		{-1, a, true},
+		{-1, jump{}, false},
+		{1, b, true},
+		{1, c, true},
+		{1, jump{}, false},
+	}
+	for i, tt := range tests {
+		got, ok := l.move(c, tt.n)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("move %d: got %v %v, want %v %v", i, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	// Adding a position that's already there moves it to the end
+	l.add(a)
+	if len(l.jumps) != 3 || l.jumps[2] != a || l.pos != 3 {
+		t.Errorf("got %v at %d after adding a again", l.jumps, l.pos)
+	}
+
+	for i := 0; i < maxJumps+10; i++ {
+		l.add(jump{"d", i})
+	}
+	if len(l.jumps) != maxJumps || l.jumps[maxJumps-1] != (jump{"d", maxJumps + 9}) {
+		t.Errorf("got %d jumps, ending with %v", len(l.jumps), l.jumps[len(l.jumps)-1])
+	}
+}
@@ -0,0 +1,203 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// about:keys lists the commands that keys can be bound to, with their keys.
+// Following one asks for the new key, and saves it to the config.
+
+// keysPageRaw returns the gemtext of about:keys. The commands are in the
+// sections of the help, and the ones that aren't in it are at the end.
+func keysPageRaw(sections []helpSection) string {
+	var b strings.Builder
+	b.WriteString("# Key Bindings\n\n")
+	b.WriteString("Select a command to change its keys. The changes are saved to the keybindings section " +
+		"of the config file.\n\n")
+
+	keys := func(cmd config.Command) string {
+		if k := config.GetKeyBinding(cmd); k != "" {
+			return k
+		}
+		return "(not bound)"
+	}
+
+	listed := make(map[config.Command]bool)
+	for _, section := range sections {
+		var lines strings.Builder
+		for _, e := range section.entries {
+			name, ok := config.BindingName(e.cmd)
+			if !ok || listed[e.cmd] {
+				continue
+			}
+			listed[e.cmd] = true
+			desc := strings.TrimSuffix(strings.Split(e.desc, "\n")[0], ".")
+			fmt.Fprintf(&lines, "=> about:keys?%s %s: %s\n", name, desc, keys(e.cmd))
+		}
+		if lines.Len() > 0 {
+			fmt.Fprintf(&b, "## %s\n\n%s\n", section.title, lines.String())
+		}
+	}
+
+	var other strings.Builder
+	for _, cmd := range config.BindableCommands() {
+		if listed[cmd] {
+			continue
+		}
+		name, _ := config.BindingName(cmd)
+		fmt.Fprintf(&other, "=> about:keys?%s %s: %s\n", name, name, keys(cmd))
+	}
+	if other.Len() > 0 {
+		fmt.Fprintf(&b, "## Other commands\n\n%s", other.String())
+	}
+	return b.String()
+}
+
+// Keys displays about:keys on the tab, or changes the keys of a command for
+// "about:keys?bind_NAME". It returns the URL to add to the history, and
+// whether there is one.
+func Keys(t *tab, u string) (string, bool) {
+	if name := strings.TrimPrefix(u, "about:keys?"); name != u {
+		for _, cmd := range config.BindableCommands() {
+			if n, _ := config.BindingName(cmd); n == name {
+				// The modals wait for an answer, so they can't be shown from here
+				go editKeys(t, cmd)
+				return "", false
+			}
+		}
+		Error("URL Error", i18n.T("There's no command called %s.", name))
+		return "", false
+	}
+
+	raw := keysPageRaw(helpSections)
+	content, links := renderer.RenderGemini(raw, textWidth(), false, renderer.ANSIEnabled(""), "")
+	page := structs.Page{
+		Raw:       raw,
+		Content:   content,
+		Links:     links,
+		URL:       "about:keys",
+		TermWidth: termW,
+		Mediatype: structs.TextGemini,
+	}
+	setPage(t, &page)
+	t.applyBottomBar()
+	return u, true
+}
+
+// pressKey asks for a key to bind, and returns it as it's written in the
+// config. It returns false if it was cancelled with Esc, or the button.
+// It must be called in a goroutine.
+func pressKey(prompt string) (string, bool) {
+	var key string
+	choiceModal.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc, tcell.KeyEnter, tcell.KeyTab, tcell.KeyBacktab:
+			// For the modal, and can't be bound anyway
+			return event
+		}
+		if k, ok := config.KeyEventString(event); ok {
+			key = k
+			choiceCh <- -1 // Close the modal
+		}
+		return nil
+	})
+	defer choiceModal.SetInputCapture(nil)
+
+	Choice(prompt, []string{"Cancel"})
+	return key, key != ""
+}
+
+// commandName returns how the command is called in modals.
+func commandName(cmd config.Command) string {
+	for _, section := range helpSections {
+		for _, e := range section.entries {
+			if e.cmd == cmd {
+				return "\"" + strings.TrimSuffix(strings.Split(e.desc, "\n")[0], ".") + "\""
+			}
+		}
+	}
+	name, _ := config.BindingName(cmd)
+	return name
+}
+
+// without returns the keys without the key.
+func without(keys []string, key string) []string {
+	var out []string
+	for _, k := range keys {
+		if k != key {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// editKeys asks what to do with the keys of the command, and saves the new
+// ones. It must be called in a goroutine.
+func editKeys(t *tab, cmd config.Command) {
+	defer RecoverCrash()
+	current := config.KeyBindings(cmd)
+	prompt := "There are no keys for " + commandName(cmd) + "."
+	buttons := []string{"Add", "Cancel"}
+	if len(current) > 0 {
+		prompt = "The keys for " + commandName(cmd) + " are " + strings.Join(current, ", ") + "."
+		buttons = []string{"Replace", "Add", "Unbind", "Cancel"}
+	}
+
+	var keys []string
+	taken := config.CmdInvalid // The command the key is taken from
+	var takenKey string
+	choice := Choice(escapeMeta(prompt), buttons)
+	switch choice {
+	case "Replace", "Add":
+		key, ok := pressKey("Press the key to use for " + escapeMeta(commandName(cmd)) + ", or Esc to cancel.")
+		if !ok {
+			return
+		}
+		other, plugin := config.KeyCommand(key)
+		if plugin != "" {
+			App.QueueUpdateDraw(func() {
+				Error("Key Error", i18n.T("%s is used by the plugin command %s.", escapeMeta(key), escapeMeta(plugin)))
+			})
+			return
+		}
+		if other != config.CmdInvalid && other != cmd {
+			if !YesNo(i18n.T("%s is already used for %s. Use it for this instead?",
+				escapeMeta(key), escapeMeta(commandName(other)))) {
+				return
+			}
+			taken = other
+			takenKey = key
+		}
+		keys = []string{key}
+		if choice == "Add" {
+			keys = append(without(current, key), key)
+		}
+	case "Unbind":
+		keys = nil
+	default:
+		return
+	}
+
+	App.QueueUpdateDraw(func() {
+		if taken != config.CmdInvalid {
+			if err := config.SetKeyBindings(taken, without(config.KeyBindings(taken), takenKey)); err != nil {
+				Error("Config Error", "The keys couldn't be saved: "+err.Error())
+				return
+			}
+		}
+		if err := config.SetKeyBindings(cmd, keys); err != nil {
+			Error("Config Error", "The keys couldn't be saved: "+err.Error())
+			return
+		}
+		if isValidTab(t) && t.page.URL == "about:keys" {
+			Keys(t, "about:keys") // Reload
+		}
+	})
+}
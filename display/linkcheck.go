@@ -0,0 +1,275 @@
+package display
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/makeworld-the-better-one/amfora/bookmarks"
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/makeworld-the-better-one/amfora/subscriptions"
+	"github.com/makeworld-the-better-one/go-gemini"
+	"github.com/spf13/viper"
+)
+
+// The link checker fetches the URL of every bookmark and subscription in the
+// background, and lists the ones that failed or moved on about:link-check,
+// so they can be removed or updated. Capsules whose robots.txt disallows
+// researchers, the virtual user agent for this kind of fetching, are skipped.
+
+// checkedLink is a bookmark or subscription that had a problem.
+type checkedLink struct {
+	URL     string
+	Name    string // The bookmark name, empty for subscriptions
+	Problem string
+	MovedTo string // Set if it has permanently moved, instead of Problem
+}
+
+var linkCheck struct {
+	sync.Mutex
+	running  bool
+	started  time.Time
+	finished time.Time
+	total    int
+	checked  int
+	skipped  int // Not Gemini URLs, or disallowed by robots.txt, which aren't checked
+	problems []checkedLink
+}
+
+// linkProblem returns what's wrong with the response to a request for a link,
+// or where it moved to. Both are empty if there's nothing wrong.
+func linkProblem(res *gemini.Response, err error) (problem, movedTo string) {
+	switch {
+	case errors.Is(err, client.ErrTofu):
+		return "The server's certificate changed since the last visit.", ""
+	case err != nil && isTransientErr(err):
+		return "Couldn't connect: " + err.Error(), ""
+	case err != nil:
+		return "Error: " + err.Error(), ""
+	}
+	switch gemini.SimplifyStatus(res.Status) {
+	case 20, 10, 60:
+		// Input and certificates are for the user to deal with, they're not broken
+		return "", ""
+	case 30:
+		if res.Status == gemini.StatusRedirectPermanent {
+			return "", res.Meta
+		}
+		return "", ""
+	case 40:
+		return fmt.Sprintf("Temporary failure (%d): %s", res.Status, res.Meta), ""
+	case 50:
+		return fmt.Sprintf("Permanent failure (%d): %s", res.Status, res.Meta), ""
+	}
+	return fmt.Sprintf("Invalid status code %d", res.Status), ""
+}
+
+// checkLink fetches the URL and returns what's wrong with it, if anything.
+func checkLink(u string) (problem, movedTo string) {
+	res, err := client.Fetch(u)
+	if res != nil {
+		defer res.Body.Close()
+	}
+	problem, movedTo = linkProblem(res, err)
+	if movedTo != "" {
+		// Redirects can be relative
+		if parsed, err := url.Parse(u); err == nil {
+			if next, err := parsed.Parse(movedTo); err == nil {
+				movedTo = next.String()
+			}
+		}
+	}
+	return problem, movedTo
+}
+
+// startLinkCheck starts checking all the bookmarks and subscriptions, if
+// they aren't being checked already.
+func startLinkCheck() {
+	linkCheck.Lock()
+	defer linkCheck.Unlock()
+	if linkCheck.running {
+		return
+	}
+
+	names, urls := bookmarks.All()
+	subs := subscriptions.AllURLS()
+
+	jobs := make(chan checkedLink, len(urls)+len(subs))
+	for i := range urls {
+		jobs <- checkedLink{URL: urls[i], Name: names[i]}
+	}
+	for _, u := range subs {
+		jobs <- checkedLink{URL: u}
+	}
+	close(jobs)
+
+	linkCheck.running = true
+	linkCheck.started = time.Now()
+	linkCheck.total = len(jobs)
+	linkCheck.checked = 0
+	linkCheck.skipped = 0
+	linkCheck.problems = nil
+
+	workers := viper.GetInt("subscriptions.workers")
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer RecoverCrash()
+			defer wg.Done()
+			for link := range jobs {
+				if !strings.HasPrefix(link.URL, "gemini://") || !client.RobotsAllowed(link.URL, "researcher") {
+					linkCheck.Lock()
+					linkCheck.checked++
+					linkCheck.skipped++
+					linkCheck.Unlock()
+					continue
+				}
+				link.Problem, link.MovedTo = checkLink(link.URL)
+				linkCheck.Lock()
+				linkCheck.checked++
+				if link.Problem != "" || link.MovedTo != "" {
+					linkCheck.problems = append(linkCheck.problems, link)
+				}
+				linkCheck.Unlock()
+			}
+		}()
+	}
+	go func() {
+		defer RecoverCrash()
+		wg.Wait()
+		linkCheck.Lock()
+		linkCheck.running = false
+		linkCheck.finished = time.Now()
+		n := len(linkCheck.problems)
+		linkCheck.Unlock()
+		if n == 1 {
+			showNotice("Notice", "The link check is done, 1 link had a problem, see about:link-check")
+		} else {
+			showNotice("Notice", i18n.T("The link check is done, %d links had problems, see about:link-check", n))
+		}
+	}()
+}
+
+// linkCheckPageRaw returns the gemtext of about:link-check.
+func linkCheckPageRaw() string {
+	linkCheck.Lock()
+	defer linkCheck.Unlock()
+
+	raw := "# Link Check\n\n" +
+		"This fetches every bookmark and subscription, to find the ones that are broken or have moved. " +
+		"Only Gemini links are checked, and not ones that the capsule's robots.txt asks automated clients to leave alone.\n\n"
+	switch {
+	case linkCheck.running:
+		raw += fmt.Sprintf("Checking... %d of %d links are done.\n\n=> about:link-check Refresh\n",
+			linkCheck.checked, linkCheck.total)
+	case linkCheck.started.IsZero():
+		return raw + "=> about:link-check?start Check all links\n"
+	default:
+		raw += fmt.Sprintf("The last check finished %s, %d links were checked and %d were skipped.\n\n",
+			humanize.Time(linkCheck.finished), linkCheck.total-linkCheck.skipped, linkCheck.skipped)
+		raw += "=> about:link-check?start Check all links again\n"
+	}
+
+	var failed, moved strings.Builder
+	for _, link := range linkCheck.problems {
+		b := &failed
+		if link.MovedTo != "" {
+			b = &moved
+		}
+		if link.Name != "" {
+			fmt.Fprintf(b, "=> %s Bookmark: %s\n", link.URL, link.Name)
+		} else {
+			fmt.Fprintf(b, "=> %s Subscription: %s\n", link.URL, link.URL)
+		}
+		if link.MovedTo != "" {
+			fmt.Fprintf(b, "=> %s Moved to %s\n", link.MovedTo, link.MovedTo)
+		} else {
+			b.WriteString(link.Problem + "\n")
+		}
+		if link.Name != "" {
+			fmt.Fprintf(b, "=> about:link-check?remove-bookmark=%s Remove the bookmark\n\n", url.QueryEscape(link.URL))
+		} else {
+			fmt.Fprintf(b, "=> about:manage-subscriptions?%s Unsubscribe\n\n", gemini.QueryEscape(link.URL))
+		}
+	}
+	if failed.Len() > 0 {
+		raw += "\n## Failed\n\n" + failed.String()
+	}
+	if moved.Len() > 0 {
+		raw += "\n## Moved\n\n" + moved.String()
+	}
+	if !linkCheck.running && failed.Len() == 0 && moved.Len() == 0 {
+		raw += "\nNo problems were found.\n"
+	}
+	return raw
+}
+
+// removeCheckedBookmark removes a bookmark listed on about:link-check, if
+// the user confirms it. It must be called in a goroutine.
+func removeCheckedBookmark(t *tab, u string) {
+	defer RecoverCrash()
+	name, ok := bookmarks.Get(u)
+	if !ok || !YesNo(i18n.T("Remove the bookmark %s?", escapeMeta(name))) {
+		return
+	}
+	bookmarks.Remove(u)
+	linkCheck.Lock()
+	for i := range linkCheck.problems {
+		if linkCheck.problems[i].URL == u && linkCheck.problems[i].Name != "" {
+			linkCheck.problems = append(linkCheck.problems[:i], linkCheck.problems[i+1:]...)
+			break
+		}
+	}
+	linkCheck.Unlock()
+	App.QueueUpdateDraw(func() {
+		if isValidTab(t) && t.page.URL == "about:link-check" {
+			LinkCheck(t, "about:link-check") // Reload
+		}
+	})
+}
+
+// LinkCheck displays about:link-check on the tab, or handles one of its
+// queries. It returns the URL to add to the history, and whether there is one.
+func LinkCheck(t *tab, u string) (string, bool) {
+	if i := strings.IndexByte(u, '?'); i != -1 {
+		q, err := url.ParseQuery(u[i+1:])
+		if err != nil {
+			Error("URL Error", "Invalid query string: "+err.Error())
+			return "", false
+		}
+		if _, ok := q["start"]; ok {
+			startLinkCheck()
+		}
+		if bkmk := q.Get("remove-bookmark"); bkmk != "" {
+			// The modal waits for an answer, so it can't be shown from here
+			go removeCheckedBookmark(t, bkmk)
+		}
+		LinkCheck(t, "about:link-check") // Reload
+		return "", false
+	}
+
+	raw := linkCheckPageRaw()
+	content, links := renderer.RenderGemini(raw, textWidth(), false, renderer.ANSIEnabled(""), "")
+	page := structs.Page{
+		Raw:       raw,
+		Content:   content,
+		Links:     links,
+		URL:       "about:link-check",
+		TermWidth: termW,
+		Mediatype: structs.TextGemini,
+	}
+	setPage(t, &page)
+	t.applyBottomBar()
+	return u, true
+}
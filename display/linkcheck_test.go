@@ -0,0 +1,36 @@
+package display
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/go-gemini"
+)
+
+func TestLinkProblem(t *testing.T) {
+	tests := []struct {
+		res         *gemini.Response
+		err         error
+		wantProblem bool
+		wantMovedTo string
+	}{
+		{&gemini.Response{Status: 20, Meta: "text/gemini"}, nil, false, ""},
+		{&gemini.Response{Status: 10, Meta: "Search"}, nil, false, ""},
+		{&gemini.Response{Status: 60, Meta: "Certificate required"}, nil, false, ""},
+		{&gemini.Response{Status: 30, Meta: "/elsewhere"}, nil, false, ""},
+		{&gemini.Response{Status: 31, Meta: "/elsewhere"}, nil, false, "/elsewhere"},
+		{&gemini.Response{Status: 51, Meta: "Not found"}, nil, true, ""},
+		{&gemini.Response{Status: 44, Meta: "30"}, nil, true, ""},
+		{&gemini.Response{Status: 99}, nil, true, ""},
+		{nil, fmt.Errorf("fetching: %w", client.ErrTofu), true, ""},
+		{nil, errors.New("connection refused"), true, ""},
+	}
+	for _, tt := range tests {
+		problem, movedTo := linkProblem(tt.res, tt.err)
+		if (problem != "") != tt.wantProblem || movedTo != tt.wantMovedTo {
+			t.Errorf("linkProblem(%v, %v): got %q, %q", tt.res, tt.err, problem, movedTo)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package display
+
+import (
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+)
+
+// Keyboard macros, like in vi. The record key and a letter or number start
+// recording every key that's pressed, until the record key is pressed again.
+// The play key and the same letter press those keys again. Macros are only
+// kept until Amfora quits.
+
+// What the next key is for, after the record or play key
+const (
+	macroNoRegister = iota
+	macroRecordRegister
+	macroPlayRegister
+)
+
+// How long to wait after each key of a macro is played, so what it does can
+// start, like loading a page or showing a modal.
+const macroKeyDelay = 50 * time.Millisecond
+
+var (
+	macroChoosing  = macroNoRegister
+	macroRecording rune // The register being recorded to, or 0
+	macroKeys      []*tcell.EventKey
+	macros         = make(map[rune][]*tcell.EventKey)
+	lastMacro      rune  // The register of the last macro played
+	macroPlaying   int32 // Set to 1 while a macro is played, atomically
+)
+
+// macroKey is called with every key, before anything else handles it. It
+// returns nil if the key was used for a macro, and the key otherwise.
+func macroKey(event *tcell.EventKey) *tcell.EventKey {
+	if macroChoosing != macroNoRegister {
+		choosing := macroChoosing
+		macroChoosing = macroNoRegister
+		tabs[curTab].applyBottomBar()
+
+		r := event.Rune()
+		if choosing == macroPlayRegister && config.TranslateKeyEvent(event) == config.CmdPlayMacro {
+			r = lastMacro
+		} else if event.Key() != tcell.KeyRune || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			// Esc, or any other key, cancels
+			return nil
+		}
+		if choosing == macroRecordRegister {
+			macroRecording = r
+			macroKeys = nil
+			updateIndicator(tabs[curTab])
+		} else {
+			playMacro(r)
+		}
+		return nil
+	}
+
+	if macroRecording != 0 && atomic.LoadInt32(&macroPlaying) == 0 {
+		// Keys played from another macro aren't recorded, only the keys to play it
+		macroKeys = append(macroKeys, tcell.NewEventKey(event.Key(), event.Rune(), event.Modifiers()))
+	}
+	return event
+}
+
+// chooseMacroRegister asks for the letter of the macro to record or play, in
+// the bottom bar. The next key is used by macroKey.
+func chooseMacroRegister(choosing int) {
+	macroChoosing = choosing
+	if choosing == macroRecordRegister {
+		bottomBar.SetLabel("[::b]Record macro: [::-]")
+	} else {
+		bottomBar.SetLabel("[::b]Play macro: [::-]")
+	}
+	bottomBar.SetText("press a letter or number, or Esc to cancel")
+}
+
+// toggleMacroRecording starts recording a macro, or stops the one being
+// recorded and saves it.
+func toggleMacroRecording() {
+	if macroRecording == 0 {
+		chooseMacroRegister(macroRecordRegister)
+		return
+	}
+	// The last key is the one that stopped the recording
+	if len(macroKeys) > 0 {
+		macroKeys = macroKeys[:len(macroKeys)-1]
+	}
+	macros[macroRecording] = macroKeys
+	macroRecording = 0
+	macroKeys = nil
+	updateIndicator(tabs[curTab])
+}
+
+// playMacro presses the keys of the macro saved under the register again.
+// Only one macro is played at a time.
+func playMacro(r rune) {
+	keys, ok := macros[r]
+	if !ok {
+		Info(i18n.T("There's no macro saved under %c.", r))
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&macroPlaying, 0, 1) {
+		return
+	}
+	lastMacro = r
+
+	go func() {
+		defer RecoverCrash()
+		defer atomic.StoreInt32(&macroPlaying, 0)
+		for _, k := range keys {
+			App.QueueEvent(tcell.NewEventKey(k.Key(), k.Rune(), k.Modifiers()))
+			time.Sleep(macroKeyDelay)
+			// The next key may be for the page the last one loaded
+			for tabLoading() {
+				time.Sleep(macroKeyDelay)
+			}
+		}
+	}()
+}
+
+// tabLoading returns true if the current tab is loading a page. It can be
+// called outside of the UI goroutine.
+func tabLoading() bool {
+	ch := make(chan bool, 1)
+	App.QueueUpdate(func() {
+		ch <- tabs[curTab].mode != tabModeDone
+	})
+	return <-ch
+}
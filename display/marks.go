@@ -0,0 +1,88 @@
+package display
+
+import (
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+)
+
+// Marks within a page, like in vi. The mark key and a letter save where the
+// page is scrolled to, and the go to mark key and the same letter scroll back
+// there. Each tab keeps the marks of the pages it visited until it's closed.
+// The mark key is bind_mark_read, which only marks entries as read on the
+// subscriptions page.
+
+// What the next key is for, after the mark or go to mark key
+const (
+	markNone = iota
+	markSet
+	markGo
+)
+
+var markChoosing = markNone
+
+// pageMarks has the text offsets of the marks set on each page, by URL.
+type pageMarks map[string]map[rune]int
+
+// markKey is called with every key, after macroKey. It returns nil if the key
+// was the letter of a mark, and the key otherwise.
+func markKey(event *tcell.EventKey) *tcell.EventKey {
+	if markChoosing == markNone {
+		return event
+	}
+	choosing := markChoosing
+	markChoosing = markNone
+	t := tabs[curTab]
+	t.applyBottomBar()
+
+	r := event.Rune()
+	if event.Key() != tcell.KeyRune || !unicode.IsLetter(r) || t.mode != tabModeDone {
+		// Esc, or any other key, cancels
+		return nil
+	}
+	if choosing == markSet {
+		t.setMark(r)
+	} else {
+		t.goToMark(r)
+	}
+	return nil
+}
+
+// chooseMark asks for the letter of the mark to set or go to, in the bottom
+// bar. The next key is used by markKey.
+func chooseMark(t *tab, choosing int) {
+	if !t.hasContent() {
+		return
+	}
+	markChoosing = choosing
+	if choosing == markSet {
+		bottomBar.SetLabel("[::b]Set mark: [::-]")
+	} else {
+		bottomBar.SetLabel("[::b]Go to mark: [::-]")
+	}
+	bottomBar.SetText("press a letter, or Esc to cancel")
+}
+
+// setMark saves where the page is scrolled to under the letter.
+func (t *tab) setMark(r rune) {
+	if t.marks == nil {
+		t.marks = make(pageMarks)
+	}
+	if t.marks[t.page.URL] == nil {
+		t.marks[t.page.URL] = make(map[rune]int)
+	}
+	t.marks[t.page.URL][r] = textOffset(t.view.GetText(true), t.page.Row)
+}
+
+// goToMark scrolls the page to the mark saved under the letter.
+func (t *tab) goToMark(r rune) {
+	offset, ok := t.marks[t.page.URL][r]
+	if !ok {
+		Info(i18n.T("There's no mark %c on this page. Set one with %s and a letter.", r, firstKey(config.CmdMarkRead)))
+		return
+	}
+	t.addJump()
+	t.scrollTo(rowAtOffset(t.view.GetText(true), offset), 0)
+}
@@ -0,0 +1,53 @@
+package display
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+)
+
+// composeMisfin asks for a message and sends it to the mailbox of the
+// misfin: URL, with the identity from the config.
+//
+// It should be called in a goroutine.
+func composeMisfin(u string) {
+	defer RecoverCrash()
+	addr, err := client.MisfinAddress(u)
+	if err != nil {
+		Error("Misfin Error", err.Error())
+		return
+	}
+	name := client.MisfinIdentityName()
+	if name == "" {
+		Error("Misfin Error", client.ErrNoMisfinIdentity.Error())
+		return
+	}
+
+	message, ok := Input(fmt.Sprintf("Message to %s, from %s", addr, name), false)
+	if !ok || message == "" {
+		return
+	}
+
+	status, meta, err := client.SendMisfin(u, message)
+	if errors.Is(err, client.ErrTofu) {
+		Error("Misfin Error", "The server certificate doesn't match the one saved for it, the message wasn't sent.")
+		return
+	}
+	if err != nil {
+		Error("Misfin Error", err.Error())
+		return
+	}
+
+	switch status / 10 {
+	case 2:
+		Info(i18n.T("Message sent to %s.", escapeMeta(addr)))
+	case 3:
+		Error("Misfin Error", i18n.T("The mailbox has moved to %s, the message wasn't sent.", escapeMeta(meta)))
+	case 6:
+		Error("Misfin Error", i18n.T("The server didn't accept your identity: %d %s", status, escapeMeta(meta)))
+	default:
+		Error("Misfin Error", i18n.T("The message wasn't sent: %d %s", status, escapeMeta(meta)))
+	}
+}
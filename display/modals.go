@@ -9,6 +9,8 @@ import (
 	humanize "github.com/dustin/go-humanize"
 	"github.com/gdamore/tcell/v2"
 	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+	"github.com/makeworld-the-better-one/amfora/logger"
 	"github.com/spf13/viper"
 )
 
@@ -21,24 +23,34 @@ var errorModal = cview.NewModal()
 
 var inputModal = cview.NewModal()
 var inputCh = make(chan string)
-var inputModalText string // The current text of the input field in the modal
+var inputModalText string             // The current text of the input field in the modal
+var inputEditorCh = make(chan string) // Sends the current input text when the user wants to use their editor
+var inputEditable bool                // Whether the current input can be written in the editor
 
 var yesNoModal = cview.NewModal()
 
 // Channel to receive yesNo answer on
 var yesNoCh = make(chan bool)
 
+// Like yesNoModal, but the buttons are set for each question
+var choiceModal = cview.NewModal()
+
+// Channel to receive the index of the chosen button on
+var choiceCh = make(chan int)
+
 func modalInit() {
-	infoModal.AddButtons([]string{"Ok"})
+	infoModal.AddButtons([]string{i18n.T("Ok")})
 
-	errorModal.AddButtons([]string{"Ok"})
+	errorModal.AddButtons([]string{i18n.T("Ok")})
 
-	yesNoModal.AddButtons([]string{"Yes", "No"})
+	// Buttons are checked by their index, so they can be translated
+	yesNoModal.AddButtons([]string{i18n.T("Yes"), i18n.T("No")})
 
 	panels.AddPanel("info", infoModal, false, false)
 	panels.AddPanel("error", errorModal, false, false)
 	panels.AddPanel("input", inputModal, false, false)
 	panels.AddPanel("yesno", yesNoModal, false, false)
+	panels.AddPanel("choice", choiceModal, false, false)
 
 	// Color setup
 	if viper.GetBool("a-general.color") {
@@ -86,6 +98,18 @@ func modalInit() {
 		form = m.GetForm()
 		form.SetButtonBackgroundColorFocused(config.GetColor("btn_text"))
 		form.SetButtonTextColorFocused(config.GetColor("btn_bg"))
+
+		m = choiceModal
+		m.SetBackgroundColor(config.GetColor("yesno_modal_bg"))
+		m.SetTextColor(config.GetColor("yesno_modal_text"))
+		m.SetButtonBackgroundColor(config.GetColor("btn_bg"))
+		m.SetButtonTextColor(config.GetColor("btn_text"))
+		form = m.GetForm()
+		form.SetButtonBackgroundColorFocused(config.GetColor("btn_text"))
+		form.SetButtonTextColorFocused(config.GetColor("btn_bg"))
+		frame = m.GetFrame()
+		frame.SetBorderColor(config.GetColor("yesno_modal_text"))
+		frame.SetTitleColor(config.GetColor("yesno_modal_text"))
 	} else {
 		m := infoModal
 		m.SetBackgroundColor(tcell.ColorBlack)
@@ -132,6 +156,18 @@ func modalInit() {
 		form = m.GetForm()
 		form.SetButtonBackgroundColorFocused(tcell.ColorBlack)
 		form.SetButtonTextColorFocused(tcell.ColorWhite)
+
+		m = choiceModal
+		m.SetBackgroundColor(tcell.ColorBlack)
+		m.SetTextColor(tcell.ColorWhite)
+		m.SetButtonBackgroundColor(tcell.ColorWhite)
+		m.SetButtonTextColor(tcell.ColorBlack)
+		form = m.GetForm()
+		form.SetButtonBackgroundColorFocused(tcell.ColorBlack)
+		form.SetButtonTextColorFocused(tcell.ColorWhite)
+		frame = m.GetFrame()
+		frame.SetBorderColor(tcell.ColorWhite)
+		frame.SetTitleColor(tcell.ColorWhite)
 	}
 
 	// Modal functions that can't be added up above, because they return the wrong type
@@ -157,40 +193,61 @@ func modalInit() {
 	inputModal.SetBorder(true)
 	frame = inputModal.GetFrame()
 	frame.SetTitleAlign(cview.AlignCenter)
-	frame.SetTitle(" Input ")
+	frame.SetTitle(" " + i18n.T("Input") + " ")
 	inputModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-		if buttonLabel == "Send" {
+		if buttonLabel == i18n.T("Send") {
 			inputCh <- inputModalText
 			return
 		}
+		if buttonLabel == i18n.T("Editor") {
+			inputEditorCh <- inputModalText
+			return
+		}
 		// Empty string indicates no input
 		inputCh <- ""
 	})
+	inputModal.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if inputEditable && config.TranslateKeyEvent(event) == config.CmdInputEditor {
+			inputEditorCh <- inputModalText
+			return nil
+		}
+		return event
+	})
 
 	yesNoModal.SetBorder(true)
 	yesNoModal.GetFrame().SetTitleAlign(cview.AlignCenter)
 	yesNoModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
-		if buttonLabel == "Yes" {
+		if buttonIndex == 0 {
 			yesNoCh <- true
 			return
 		}
 		yesNoCh <- false
 	})
 
+	choiceModal.SetBorder(true)
+	choiceModal.GetFrame().SetTitleAlign(cview.AlignCenter)
+	choiceModal.SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+		choiceCh <- buttonIndex
+	})
+
 	bkmkInit()
 	dlInit()
 }
 
 // Error displays an error on the screen in a modal.
 func Error(title, text string) {
+	logger.Errorf("%s: %s", title, text)
+	title = i18n.T(title)
+	text = i18n.T(text)
 	if text == "" {
-		text = "No additional information."
+		text = i18n.T("No additional information.")
 	} else {
 		text = strings.ToUpper(string([]rune(text)[0])) + text[1:]
 		if !strings.HasSuffix(text, ".") && !strings.HasSuffix(text, "!") && !strings.HasSuffix(text, "?") {
 			text += "."
 		}
 	}
+	announceError(strings.TrimSpace(title), text)
 	// Add spaces to title for aesthetic reasons
 	title = " " + strings.TrimSpace(title) + " "
 
@@ -204,7 +261,7 @@ func Error(title, text string) {
 
 // Info displays some info on the screen in a modal.
 func Info(s string) {
-	infoModal.SetText(s)
+	infoModal.SetText(i18n.T(s))
 	panels.ShowPanel("info")
 	panels.SendToFront("info")
 	App.SetFocus(infoModal)
@@ -213,13 +270,22 @@ func Info(s string) {
 
 // Input pulls up a modal that asks for input, and returns the user's input.
 // It returns an bool indicating if the user chose to send input or not.
+//
+// Input that isn't sensitive can also be written in the user's text editor,
+// and is sent as soon as the editor is closed.
 func Input(prompt string, sensitive bool) (string, bool) {
 	// Remove elements and re-add them - to clear input text and keep input in focus
 	inputModal.ClearButtons()
 	inputModal.GetForm().Clear(false)
 
-	inputModal.AddButtons([]string{"Send", "Cancel"})
+	if sensitive {
+		// Sensitive input shouldn't be written to a file
+		inputModal.AddButtons([]string{i18n.T("Send"), i18n.T("Cancel")})
+	} else {
+		inputModal.AddButtons([]string{i18n.T("Send"), i18n.T("Editor"), i18n.T("Cancel")})
+	}
 	inputModalText = ""
+	inputEditable = !sensitive
 
 	if sensitive {
 		// TODO use bullet characters if user wants it once bug is fixed - see NOTES.md
@@ -241,12 +307,25 @@ func Input(prompt string, sensitive bool) (string, bool) {
 	App.SetFocus(inputModal)
 	App.Draw()
 
-	resp := <-inputCh
+	var resp string
+	var err error
+	select {
+	case resp = <-inputCh:
+		panels.HidePanel("input")
+	case text := <-inputEditorCh:
+		panels.HidePanel("input")
+		resp, err = editText(text)
+	}
+	inputEditable = false
 
-	panels.HidePanel("input")
 	App.SetFocus(tabs[curTab].view)
 	App.Draw()
 
+	if err != nil {
+		Error("Editor Error", "Couldn't get input from the editor: "+escapeMeta(err.Error()))
+		return "", false
+	}
+
 	if resp == "" {
 		return "", false
 	}
@@ -271,7 +350,7 @@ func YesNo(prompt string) bool {
 		frame.SetTitleColor(tcell.ColorWhite)
 	}
 	yesNoModal.GetFrame().SetTitle("")
-	yesNoModal.SetText(prompt)
+	yesNoModal.SetText(i18n.T(prompt))
 	panels.ShowPanel("yesno")
 	panels.SendToFront("yesno")
 	App.SetFocus(yesNoModal)
@@ -284,6 +363,31 @@ func YesNo(prompt string) bool {
 	return resp
 }
 
+// Choice displays a modal asking a question, with the provided buttons as answers.
+// It returns the label of the button that was chosen, untranslated.
+func Choice(prompt string, buttons []string) string {
+	labels := make([]string, len(buttons))
+	for i := range buttons {
+		labels[i] = i18n.T(buttons[i])
+	}
+	choiceModal.ClearButtons()
+	choiceModal.AddButtons(labels)
+	choiceModal.SetText(i18n.T(prompt))
+	panels.ShowPanel("choice")
+	panels.SendToFront("choice")
+	App.SetFocus(choiceModal)
+	App.Draw()
+
+	i := <-choiceCh
+	panels.HidePanel("choice")
+	App.SetFocus(tabs[curTab].view)
+	App.Draw()
+	if i < 0 || i >= len(buttons) {
+		return ""
+	}
+	return buttons[i]
+}
+
 // Tofu displays the TOFU warning modal.
 // It returns a bool indicating whether the user wants to continue.
 func Tofu(host string, expiry time.Time) bool {
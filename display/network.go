@@ -0,0 +1,64 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// networkPageRaw returns the gemtext of about:network, which lists the
+// recent requests, newest first.
+func networkPageRaw(reqs []client.Request) string {
+	var sb strings.Builder
+	sb.WriteString("# Network Requests\n\n")
+	if len(reqs) == 0 {
+		sb.WriteString("No requests have been made yet.\n")
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "The last %d requests, newest first. Reload the page to update it.\n\n", len(reqs))
+
+	for _, req := range reqs {
+		fmt.Fprintf(&sb, "=> %s\n", req.URL)
+		details := []string{req.Start.Format("15:04:05")}
+		if req.Via != "" {
+			details = append(details, "via "+req.Via)
+		}
+		if req.Err != nil {
+			details = append(details,
+				fmt.Sprintf("failed after %s: %v", formatLoadTime(req.Header), req.Err))
+		} else {
+			details = append(details, strings.TrimSpace(fmt.Sprintf("%d %s", req.Status, req.Meta)))
+			if req.Duration > req.Header {
+				details = append(details, fmt.Sprintf("header %s, body %s",
+					formatLoadTime(req.Header), formatLoadTime(req.Duration)))
+			} else {
+				details = append(details, "header "+formatLoadTime(req.Header))
+			}
+			details = append(details, humanize.Bytes(uint64(req.Bytes)))
+		}
+		fmt.Fprintf(&sb, "%s\n\n", strings.Join(details, " · "))
+	}
+	return sb.String()
+}
+
+// Network displays the about:network page on the tab.
+func Network(t *tab) {
+	raw := networkPageRaw(client.Requests())
+	content, links := renderer.RenderGemini(raw, textWidth(), false, renderer.ANSIEnabled(""), "")
+	page := structs.Page{
+		Raw:       raw,
+		Content:   content,
+		Links:     links,
+		URL:       "about:network",
+		TermWidth: termW,
+		Mediatype: structs.TextGemini,
+		MadeAt:    time.Now(),
+	}
+	setPage(t, &page)
+	t.applyBottomBar()
+}
@@ -15,12 +15,15 @@ Press the ? key at any time to bring up the help, and see other keybindings. Mos
 
 You can customize this page by creating a gemtext file called newtab.gmi, in Amfora's configuration folder.
 
+You can make your own internal pages too. A gemtext file called work.gmi in the "about" folder inside the configuration folder is shown at about:work, and they can link to each other.
+
 Happy browsing!
 
 ## Internal Pages
 
 => about:bookmarks Bookmarks
 => about:subscriptions Subscriptions
+=> about:reading Reading list
 => about:about All internal pages
 
 ## Learn more about Amfora!
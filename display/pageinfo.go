@@ -0,0 +1,112 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/makeworld-the-better-one/amfora/cache"
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// Names of the ways a page can be rendered, for displaying.
+var mediatypeNames = map[structs.Mediatype]string{
+	structs.TextGemini:   "gemtext",
+	structs.TextMarkdown: "Markdown",
+	structs.TextPlain:    "plain text",
+	structs.TextAnsi:     "ANSI art",
+	structs.TextDiff:     "diff",
+}
+
+// cacheStatus describes whether the tab's page is in the cache.
+func cacheStatus(t *tab) string {
+	cached, ok := cache.GetPage(t.page.URL)
+	switch {
+	case t.fromCache && !t.page.MadeAt.IsZero():
+		return "Loaded from the cache, fetched " + humanize.Time(t.page.MadeAt)
+	case t.fromCache:
+		return "Loaded from the cache"
+	case ok && cached == t.page:
+		return "Stored in the cache"
+	}
+	return "Not cached"
+}
+
+// pageInfo displays information about the tab's page: its mediatype, size,
+// how it was loaded, and a summary of the connection.
+func pageInfo(t *tab) {
+	p := t.page
+	if !t.hasContent() {
+		Info("There is no page to show information about.")
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "URL: %s\n", escapeMeta(p.URL))
+	if title := pageTitle(p); title != "" {
+		fmt.Fprintf(&sb, "Title: %s\n", escapeMeta(title))
+	}
+	if len(p.Redirects) > 0 {
+		sb.WriteString("Redirected from:\n")
+		for _, u := range p.Redirects {
+			fmt.Fprintf(&sb, "  %s\n", escapeMeta(u))
+		}
+	}
+	sb.WriteString("\n")
+
+	mediatype := p.RawMediatype
+	if mediatype == "" {
+		mediatype = string(p.Mediatype)
+	}
+	fmt.Fprintf(&sb, "Type: %s", escapeMeta(mediatype))
+	if p.ViewedAs != "" {
+		fmt.Fprintf(&sb, ", viewed as %s", mediatypeNames[p.Mediatype])
+	} else if name, ok := mediatypeNames[p.Mediatype]; ok && mediatype != string(p.Mediatype) {
+		fmt.Fprintf(&sb, ", shown as %s", name)
+	}
+	sb.WriteString("\n")
+	if p.Charset != "" {
+		fmt.Fprintf(&sb, "Charset: %s\n", escapeMeta(p.Charset))
+	}
+	if p.Lang != "" {
+		fmt.Fprintf(&sb, "Language: %s\n", escapeMeta(p.Lang))
+	}
+	fmt.Fprintf(&sb, "Size: %s\n", humanize.Bytes(uint64(len(p.Raw))))
+	fmt.Fprintf(&sb, "Rendered lines: %d\n", strings.Count(strings.TrimSuffix(p.Content, "\n"), "\n")+1)
+	if p.LoadTime > 0 {
+		fmt.Fprintf(&sb, "Load time: %s\n", formatLoadTime(p.LoadTime))
+	}
+	if !t.isAnAboutPage() && !strings.HasPrefix(p.URL, "file:") {
+		fmt.Fprintf(&sb, "Cache: %s\n", cacheStatus(t))
+	}
+
+	if p.TLSVersion != 0 || p.Cert != nil {
+		sb.WriteString("\n")
+	}
+	if p.TLSVersion != 0 {
+		fmt.Fprintf(&sb, "Connection: %s\n", client.TLSVersionName(p.TLSVersion))
+	}
+	if cert := p.Cert; cert != nil {
+		name := cert.Subject.CommonName
+		if name == "" {
+			name = cert.Subject.String()
+		}
+		issuer := "self-signed"
+		if cert.Issuer.String() != cert.Subject.String() {
+			issuer = "issued by " + cert.Issuer.String()
+		}
+		fmt.Fprintf(&sb, "Certificate: %s, %s\n", escapeMeta(name), escapeMeta(issuer))
+		if time.Now().After(cert.NotAfter) {
+			fmt.Fprintf(&sb, "Expired %s\n", humanize.Time(cert.NotAfter))
+		} else {
+			fmt.Fprintf(&sb, "Valid until %s\n", cert.NotAfter.Format("2006-01-02"))
+		}
+		fmt.Fprintf(&sb, "(%s shows the full certificate)\n",
+			strings.Split(config.GetKeyBinding(config.CmdCertInfo), ",")[0])
+	}
+
+	Info(strings.TrimSpace(sb.String()))
+}
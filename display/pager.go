@@ -0,0 +1,46 @@
+package display
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// pagerCommand returns the command and arguments for the user's pager,
+// taken from $PAGER.
+func pagerCommand() []string {
+	if fields := strings.Fields(os.Getenv("PAGER")); len(fields) > 0 {
+		return fields
+	}
+	if runtime.GOOS == "windows" {
+		return []string{"more"}
+	}
+	return []string{"less"}
+}
+
+// openInPager shows the rendered page of the tab in the user's pager,
+// suspending the TUI until it's closed. The page is sent as plain text.
+//
+// It should be called in a goroutine.
+func openInPager(t *tab) {
+	defer RecoverCrash()
+	if !t.hasContent() {
+		Info("There's no page to show in the pager.")
+		return
+	}
+	text := t.view.GetText(true)
+
+	var err error
+	pager := pagerCommand()
+	App.Suspend(func() {
+		cmd := exec.Command(pager[0], pager[1:]...) //nolint:gosec
+		cmd.Stdin = strings.NewReader(text)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		err = cmd.Run()
+	})
+	if err != nil {
+		Error("Pager Error", "Couldn't run the pager: "+escapeMeta(err.Error()))
+	}
+}
@@ -0,0 +1,170 @@
+package display
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/cache"
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/makeworld-the-better-one/go-gemini"
+)
+
+// Peeking shows the start of the page a link goes to in a popup, so it can
+// be seen without leaving the current page.
+
+var peekView = cview.NewTextView()
+
+// The popup, with peekView in the middle of the screen
+var peekLayout = cview.NewFlex()
+
+// The tab and link being peeked at, so Enter can open it
+var peekTab *tab
+var peekLink string
+
+func peekInit() {
+	peekView.SetDynamicColors(true)
+	peekView.SetRegions(true)
+	peekView.SetWrap(false)
+	peekView.SetBorder(true)
+	peekView.SetBackgroundColor(config.GetColor("bg"))
+	peekView.SetTextColor(config.GetColor("regular_text"))
+	peekView.SetBorderColor(config.GetColor("regular_text"))
+	peekView.SetTitleColor(config.GetColor("regular_text"))
+	peekView.SetScrollBarVisibility(cview.ScrollBarNever)
+	peekView.SetDoneFunc(func(key tcell.Key) {
+		if key != tcell.KeyEsc && key != tcell.KeyEnter {
+			return
+		}
+		panels.HidePanel("peek")
+		App.SetFocus(tabs[curTab].view)
+		if key == tcell.KeyEnter && peekTab == tabs[curTab] {
+			followLink(peekTab, peekTab.page.URL, peekLink)
+		}
+		App.Draw()
+	})
+
+	inner := cview.NewFlex()
+	inner.SetDirection(cview.FlexRow)
+	inner.AddItem(nil, 0, 1, false)
+	inner.AddItem(peekView, 0, 6, true)
+	inner.AddItem(nil, 0, 1, false)
+	peekLayout.AddItem(nil, 0, 1, false)
+	peekLayout.AddItem(inner, 0, 6, true)
+	peekLayout.AddItem(nil, 0, 1, false)
+	panels.AddPanel("peek", peekLayout, true, false)
+}
+
+// peekSize returns the width and height of the text in the popup.
+func peekSize() (int, int) {
+	// See the proportions in peekInit, and take off the border
+	return screenW*6/8 - 2, screenH*6/8 - 2
+}
+
+// firstScreen returns the first lines of the content that fit in the popup.
+func firstScreen(content string, height int) string {
+	lines := strings.Split(content, "\n")
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\r")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// peekContent returns the rendered start of the page at u, from the cache
+// if it's there. For responses that aren't pages, it describes them instead.
+func peekContent(t *tab, u string, width, height int) (string, error) {
+	if p, ok := cache.GetPage(u); ok {
+		if p.Mediatype == structs.TextGemini {
+			content, _ := renderer.RenderGemini(p.Raw, width, false, renderer.ANSIEnabled(u), "")
+			return firstScreen(content, height), nil
+		}
+		return firstScreen(p.Content, height), nil
+	}
+
+	res, err := client.FetchWithCert(u, t.cert)
+	if errors.Is(err, client.ErrTofu) {
+		res.Body.Close()
+		return "", errors.New("the server's certificate has changed, open the link to review it")
+	} else if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	switch gemini.SimplifyStatus(res.Status) {
+	case 10:
+		return "This page asks for input:\n\n" + escapeMeta(res.Meta), nil
+	case 30:
+		return "This page redirects to:\n\n" + escapeMeta(res.Meta), nil
+	case 60:
+		return "This page asks for a client certificate:\n\n" + escapeMeta(res.Meta), nil
+	case 20:
+	default:
+		return fmt.Sprintf("This page can't be loaded, status %d:\n\n%s", res.Status, escapeMeta(res.Meta)), nil
+	}
+
+	if !renderer.CanDisplay(res) {
+		mediatype, _, _ := mime.ParseMediaType(res.Meta)
+		return "This link is a file that can't be displayed, of type " + escapeMeta(mediatype) + ".", nil
+	}
+	p, err := renderer.MakePage(u, res, width, false, nil)
+	if err != nil {
+		if errors.Is(err, renderer.ErrTooLarge) {
+			return "This page is too large to preview.", nil
+		}
+		return "", err
+	}
+	return firstScreen(p.Content, height), nil
+}
+
+// peek fetches the selected link of the tab and shows the start of it in
+// a popup, without leaving the page.
+//
+// It should be called in a goroutine.
+func peek(t *tab) {
+	defer RecoverCrash()
+	if t.page.Mode != structs.ModeLinkSelect {
+		Info("Select a link with Tab to peek at it.")
+		return
+	}
+	link := t.page.Selected
+	u, err := resolveRelLink(t, t.page.URL, link)
+	if err != nil {
+		Error("URL Error", err.Error())
+		return
+	}
+	u = normalizeURL(u)
+	if !strings.HasPrefix(u, "gemini://") {
+		Error("Peek Error", "Only Gemini links can be peeked at.")
+		return
+	}
+
+	width, height := peekSize()
+	content, err := peekContent(t, u, width, height)
+	if err != nil {
+		Error("Peek Error", err.Error())
+		return
+	}
+
+	App.QueueUpdateDraw(func() {
+		if t != tabs[curTab] {
+			return
+		}
+		peekTab = t
+		peekLink = link
+		peekView.SetTitle(" " + cview.Escape(u) + " ")
+		peekView.SetText(content)
+		peekView.ScrollToBeginning()
+		panels.ShowPanel("peek")
+		panels.SendToFront("peek")
+		App.SetFocus(peekView)
+	})
+}
@@ -0,0 +1,153 @@
+package display
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/spf13/viper"
+)
+
+// The most output of a piped command that's shown, so it fits in a modal
+const (
+	maxPipeLines = 20
+	maxPipeBytes = 2000
+)
+
+// How long a piped command can run before it's stopped
+const pipeTimeout = time.Minute
+
+// shellCommand returns a command that runs the command line in the shell.
+// It's in its own process group where that's supported, see runCommand.
+func shellCommand(line string) *exec.Cmd {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", line) //nolint:gosec
+	} else {
+		cmd = exec.Command("sh", "-c", line) //nolint:gosec
+	}
+	setProcessGroup(cmd)
+	return cmd
+}
+
+// runCommand runs the command from shellCommand, and kills it when ctx is
+// done. The processes it started are killed too, otherwise one that's still
+// running could keep the output open, and Wait would wait for it. That's not
+// possible on Windows, only the shell is killed there.
+func runCommand(ctx context.Context, cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			killProcessGroup(cmd.Process)
+		case <-done:
+		}
+	}()
+	err := cmd.Wait()
+	close(done)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// cappedBuffer keeps the first max bytes written to it, and counts the rest
+// without keeping them, so commands with a lot of output don't use up memory.
+type cappedBuffer struct {
+	buf bytes.Buffer
+	max int
+	n   int // All the bytes written
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	c.n += len(p)
+	if room := c.max - c.buf.Len(); room > 0 {
+		if len(p) > room {
+			c.buf.Write(p[:room])
+		} else {
+			c.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+// shortOutput returns the start of the output, up to maxPipeLines lines and
+// maxPipeBytes bytes, and whether it was cut short.
+func shortOutput(out string) (string, bool) {
+	cut := false
+	if len(out) > maxPipeBytes {
+		out = strings.ToValidUTF8(out[:maxPipeBytes], "")
+		cut = true
+	}
+	if lines := strings.SplitN(out, "\n", maxPipeLines+1); len(lines) > maxPipeLines {
+		out = strings.Join(lines[:maxPipeLines], "\n")
+		cut = true
+	}
+	return out, cut
+}
+
+// pipePage asks for a shell command, and runs it with the page of the tab as
+// its input. That's the raw page, or the rendered text if
+// "a-general.pipe_rendered" is set. Its output is shown if there is any.
+// It's stopped if it runs for longer than pipeTimeout.
+//
+// It should be called in a goroutine.
+func pipePage(t *tab) {
+	defer RecoverCrash()
+	if !t.hasContent() {
+		Info("There's no page to pipe to a command.")
+		return
+	}
+	page := t.page
+	input := page.Raw
+	if viper.GetBool("a-general.pipe_rendered") {
+		input = t.view.GetText(true)
+	}
+
+	line, ok := Input("Pipe the page to the command:", false)
+	if !ok || strings.TrimSpace(line) == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pipeTimeout)
+	defer cancel()
+	cmd := shellCommand(line)
+	cmd.Env = append(os.Environ(), "COLUMNS="+strconv.Itoa(textWidth()), "AMFORA_URL="+page.URL)
+	cmd.Stdin = strings.NewReader(input)
+	// One byte more than is shown is kept, so shortOutput knows it was cut
+	out := &cappedBuffer{max: maxPipeBytes + 1}
+	stderr := &cappedBuffer{max: maxPipeBytes}
+	cmd.Stdout = out
+	cmd.Stderr = stderr
+	if err := runCommand(ctx, cmd); err != nil {
+		msg := err.Error()
+		if ctx.Err() != nil {
+			msg = fmt.Sprintf("The command took too long, it was stopped after %s.", pipeTimeout)
+		} else if s := strings.TrimSpace(stderr.buf.String()); s != "" {
+			msg += ": " + s
+		}
+		Error("Command Error", escapeMeta(msg))
+		return
+	}
+
+	text, cut := shortOutput(strings.TrimRight(out.buf.String(), "\r\n"))
+	if text == "" {
+		showNotice("Notice", "The command finished with no output")
+		return
+	}
+	text = cview.Escape(text)
+	if cut || out.n > out.buf.Len() {
+		text += fmt.Sprintf("\n\n(The output was cut short, it was %d bytes.)", out.n)
+	}
+	Info(text)
+}
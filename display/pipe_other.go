@@ -0,0 +1,16 @@
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd
+
+package display
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup does nothing, process groups aren't used on this OS.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills the process, but not the ones it started.
+func killProcessGroup(p *os.Process) {
+	p.Kill() //nolint:errcheck
+}
@@ -0,0 +1,44 @@
+package display
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCappedBuffer(t *testing.T) {
+	b := &cappedBuffer{max: 5}
+	for _, s := range []string{"abc", "defg", "hij"} {
+		if n, err := b.Write([]byte(s)); n != len(s) || err != nil {
+			t.Errorf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+	if b.buf.String() != "abcde" || b.n != 10 {
+		t.Errorf("got %q and %d bytes, want %q and 10 bytes", b.buf.String(), b.n, "abcde")
+	}
+}
+
+func TestRunCommandTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sleep")
+	}
+	// The last two leave a process running after the shell is killed,
+	// which holds the output open
+	for _, line := range []string{"exec sleep 10", "sleep 10 | cat", "sleep 10 & wait"} {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		cmd := shellCommand(line)
+		cmd.Stdin = strings.NewReader("")
+		cmd.Stdout = &cappedBuffer{max: 10}
+		cmd.Stderr = &cappedBuffer{max: 10}
+		start := time.Now()
+		if err := runCommand(ctx, cmd); err != context.DeadlineExceeded {
+			t.Errorf("%s: expected the deadline error, actual %v", line, err)
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Errorf("%s: the command ran past the timeout", line)
+		}
+		cancel()
+	}
+}
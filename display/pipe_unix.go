@@ -0,0 +1,20 @@
+// +build linux darwin freebsd netbsd openbsd
+
+package display
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup makes the command start a new process group, so
+// killProcessGroup can kill the processes it starts too.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills the process, and the others in its process group.
+func killProcessGroup(p *os.Process) {
+	syscall.Kill(-p.Pid, syscall.SIGKILL) //nolint:errcheck
+}
@@ -0,0 +1,202 @@
+package display
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+	"github.com/makeworld-the-better-one/amfora/logger"
+	"github.com/makeworld-the-better-one/amfora/plugins"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/makeworld-the-better-one/go-gemini"
+)
+
+// pluginCommand is a command of a plugin that's bound to a key.
+type pluginCommand struct {
+	plugin *plugins.Plugin
+	name   string
+}
+
+// The commands bound to keys, by the names given to config.AddPluginBinding
+var pluginKeys = make(map[string]pluginCommand)
+
+// pluginsInit binds the keys of the plugins' commands.
+func pluginsInit() {
+	for _, p := range plugins.All() {
+		for _, c := range p.Commands {
+			if c.Key == "" {
+				continue
+			}
+			name := p.Name + "/" + c.Name
+			if !config.AddPluginBinding(c.Key, name) {
+				logger.Warnf("The key %s of the command %s from plugin %s is invalid or already used",
+					c.Key, c.Name, p.Name)
+				continue
+			}
+			pluginKeys[name] = pluginCommand{plugin: p, name: c.Name}
+		}
+	}
+}
+
+// pluginPage makes a page for the URL out of the plugin's response.
+func pluginPage(u string, res plugins.Response) (*structs.Page, error) {
+	mediatype := res.Mediatype
+	if mediatype == "" {
+		mediatype = "text/gemini"
+	}
+	return renderer.MakePage(u, &gemini.Response{
+		Status: 20,
+		Meta:   mediatype,
+		Body:   ioutil.NopCloser(strings.NewReader(res.Body)),
+	}, textWidth(), false, nil)
+}
+
+// requestPluginPage asks the plugin for the page at the URL. Errors are shown
+// to the user, and false is returned.
+func requestPluginPage(p *plugins.Plugin, u string) (*structs.Page, bool) {
+	res, err := p.Request(plugins.Request{Type: "page", URL: u})
+	if err != nil {
+		Error("Plugin Error", i18n.T("Plugin %s: %s", escapeMeta(p.Name), escapeMeta(err.Error())))
+		return nil, false
+	}
+	if res.Error != "" {
+		Error("Plugin Error", escapeMeta(res.Error))
+		return nil, false
+	}
+	if res.Message != "" {
+		Info(escapeMeta(res.Message))
+	}
+	page, err := pluginPage(u, res)
+	if err != nil {
+		Error("Plugin Error", i18n.T("Plugin %s sent a page that can't be displayed: %s",
+			escapeMeta(p.Name), escapeMeta(err.Error())))
+		return nil, false
+	}
+	return page, true
+}
+
+// loadPluginAbout displays the about page from the plugin on the tab.
+// It should be called in a goroutine.
+func loadPluginAbout(t *tab, p *plugins.Plugin, u string) {
+	defer RecoverCrash()
+	page, ok := requestPluginPage(p, u)
+	if !ok {
+		return
+	}
+	App.QueueUpdateDraw(func() {
+		if !isValidTab(t) {
+			return
+		}
+		setPage(t, page)
+		if t == tabs[curTab] {
+			t.applyBottomBar()
+		}
+	})
+}
+
+// runPluginCommand runs the plugin command bound to a key, with the name from
+// config.TranslatePluginKeyEvent, on the tab's page.
+//
+// It should be called in a goroutine.
+func runPluginCommand(t *tab, name string) {
+	defer RecoverCrash()
+	c, ok := pluginKeys[name]
+	if !ok {
+		return
+	}
+	req := plugins.Request{Type: "command", Command: c.name}
+	if t.hasContent() {
+		req.URL = t.page.URL
+		req.Mediatype = string(t.page.Mediatype)
+		req.Raw = t.page.Raw
+	}
+	res, err := c.plugin.Request(req)
+	if err != nil {
+		Error("Plugin Error", i18n.T("Plugin %s: %s", escapeMeta(c.plugin.Name), escapeMeta(err.Error())))
+		return
+	}
+	if res.Error != "" {
+		Error("Plugin Error", escapeMeta(res.Error))
+		return
+	}
+	if res.Message != "" {
+		Info(escapeMeta(res.Message))
+	}
+	switch {
+	case res.URL != "":
+		goURL(t, res.URL)
+	case res.Body != "":
+		// The page is replaced, like with viewAs, it stays at the same URL
+		page, err := pluginPage(req.URL, res)
+		if err != nil {
+			Error("Plugin Error", i18n.T("Plugin %s sent a page that can't be displayed: %s",
+				escapeMeta(c.plugin.Name), escapeMeta(err.Error())))
+			return
+		}
+		App.QueueUpdateDraw(func() {
+			if !isValidTab(t) {
+				return
+			}
+			setPage(t, page)
+			if t == tabs[curTab] {
+				t.applyBottomBar()
+			}
+		})
+	}
+}
+
+// pluginsPageRaw returns the gemtext of about:plugins.
+func pluginsPageRaw() string {
+	var sb strings.Builder
+	sb.WriteString("# Plugins\n\n")
+	if len(plugins.All()) == 0 && len(plugins.Failed()) == 0 {
+		sb.WriteString("No plugins are set in the config. See the plugins section of the config for how to add them.\n")
+		return sb.String()
+	}
+	for _, p := range plugins.All() {
+		fmt.Fprintf(&sb, "## %s\n\n", p.Name)
+		for _, c := range p.Commands {
+			line := "* " + c.Name
+			if _, ok := pluginKeys[p.Name+"/"+c.Name]; ok {
+				line += " (" + c.Key + ")"
+			}
+			if c.Description != "" {
+				line += ": " + c.Description
+			}
+			sb.WriteString(line + "\n")
+		}
+		for _, s := range p.Schemes {
+			fmt.Fprintf(&sb, "* Opens %s: URLs\n", s)
+		}
+		for _, a := range p.About {
+			fmt.Fprintf(&sb, "=> about:%s\n", a)
+		}
+		sb.WriteString("\n")
+	}
+	if failed := plugins.Failed(); len(failed) > 0 {
+		sb.WriteString("## Plugins that couldn't be started\n\n")
+		for _, f := range failed {
+			sb.WriteString("* " + f + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// Plugins displays the about:plugins page on the tab.
+func Plugins(t *tab) {
+	raw := pluginsPageRaw()
+	content, links := renderer.RenderGemini(raw, textWidth(), false, renderer.ANSIEnabled(""), "")
+	page := structs.Page{
+		Raw:       raw,
+		Content:   content,
+		Links:     links,
+		URL:       "about:plugins",
+		TermWidth: termW,
+		Mediatype: structs.TextGemini,
+	}
+	setPage(t, &page)
+	t.applyBottomBar()
+}
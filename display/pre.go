@@ -0,0 +1,152 @@
+package display
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+)
+
+// Preformatted blocks often hold scripts and config files, which can be saved,
+// copied, or opened in the text editor as they are, without the page around them.
+
+// preBlockRows is a preformatted block of a page, and the rows it was
+// rendered at. end is approximate, because lines can be wrapped.
+type preBlockRows struct {
+	block      renderer.PreBlock
+	start, end int
+}
+
+// pagePreBlocks returns the preformatted blocks of the tab's page, in order,
+// and the rows of the ones that could be found. Only gemtext and Markdown
+// pages have them.
+func pagePreBlocks(t *tab) ([]renderer.PreBlock, []preBlockRows) {
+	var raw string
+	switch t.page.Mediatype {
+	case structs.TextGemini:
+		raw = t.page.Raw
+	case structs.TextMarkdown:
+		raw = renderer.MarkdownToGemtext(t.page.Raw)
+	default:
+		return nil, nil
+	}
+	blocks := renderer.GemtextPreBlocks(raw)
+
+	// Preformatted lines are rendered as they are, unless they're wrapped,
+	// so each block starts at the next rendered line that is its first line,
+	// or the start of it. Blocks that are empty or have ANSI codes can't be found.
+	wrapped := viper.GetBool("a-general.wrap_pre") != t.page.ToggleWrap
+	rendered := strings.Split(t.view.GetText(true), "\n")
+	var rows []preBlockRows
+	row := 0
+	for _, block := range blocks {
+		lines := strings.Split(block.Text, "\n")
+		first := -1
+		for i, line := range lines {
+			if strings.TrimSpace(line) != "" {
+				first = i
+				break
+			}
+		}
+		if first == -1 || strings.Contains(block.Text, "\x1b") {
+			break
+		}
+		want := strings.TrimSpace(lines[first])
+		found := -1
+		for i := row; i < len(rendered); i++ {
+			line := strings.TrimSpace(strings.TrimSuffix(rendered[i], "\r"))
+			if line != "" && (line == want || (wrapped && strings.HasPrefix(want, line))) {
+				found = i
+				break
+			}
+		}
+		if found == -1 {
+			// Rendering changed it too much to be found
+			break
+		}
+		start := found - first
+		row = start + len(lines)
+		rows = append(rows, preBlockRows{block: block, start: start, end: row - 1})
+	}
+	return blocks, rows
+}
+
+// viewedPreBlock returns the preformatted block of the part of the page being
+// viewed. That's the block the top of the screen is in, or the first one on
+// the screen. If the page only has one block, it's always used.
+func viewedPreBlock(t *tab) (renderer.PreBlock, bool) {
+	blocks, rows := pagePreBlocks(t)
+	if len(blocks) == 1 {
+		return blocks[0], true
+	}
+	top, _ := t.view.GetScrollOffset()
+	_, _, _, height := t.view.GetInnerRect()
+	for _, r := range rows {
+		if r.end >= top && r.start < top+height {
+			return r.block, true
+		}
+	}
+	return renderer.PreBlock{}, false
+}
+
+// preBlockFileName returns the name to save the block with. The alt text is
+// used if it looks like a file name, like "config.toml".
+func preBlockFileName(alt string) string {
+	if alt != "" && !strings.ContainsAny(alt, " \t/\\") && strings.Contains(strings.Trim(alt, "."), ".") {
+		return alt
+	}
+	return "preformatted.txt"
+}
+
+// savePreBlock saves the block to a file in the downloads folder, and returns
+// the path of it.
+func savePreBlock(block renderer.PreBlock) (string, error) {
+	name, err := getSafeDownloadName(config.DownloadsDir, preBlockFileName(block.Alt), true, 0)
+	if err != nil {
+		return "", err
+	}
+	savePath := filepath.Join(config.DownloadsDir, name)
+	return savePath, ioutil.WriteFile(savePath, []byte(block.Text+"\n"), 0644)
+}
+
+// preBlockActions asks what to do with the preformatted block being viewed,
+// and does it.
+//
+// It should be called in a goroutine.
+func preBlockActions(t *tab) {
+	defer RecoverCrash()
+	block, ok := viewedPreBlock(t)
+	if !ok {
+		Info("There's no preformatted text on the screen.")
+		return
+	}
+
+	prompt := "What would you like to do with this preformatted text?"
+	if block.Alt != "" {
+		prompt = "What would you like to do with the preformatted text \"" + escapeMeta(block.Alt) + "\"?"
+	}
+	switch Choice(prompt, []string{"Save", "Copy", "Edit", "Cancel"}) {
+	case "Save":
+		savePath, err := savePreBlock(block)
+		if err != nil {
+			Error("Download Error", i18n.T("Error saving the preformatted text: %v", err))
+			return
+		}
+		Info(i18n.T("The preformatted text was saved to %s.", savePath))
+	case "Copy":
+		if err := clipboard.WriteAll(block.Text); err != nil {
+			Error("Copy Error", err.Error())
+		}
+	case "Edit":
+		// It's a copy, the changes can be saved somewhere else from the editor
+		if _, err := editText(block.Text); err != nil {
+			Error("Editor Error", "Couldn't open the text editor: "+escapeMeta(err.Error()))
+		}
+	}
+}
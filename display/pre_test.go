@@ -0,0 +1,24 @@
+package display
+
+import "testing"
+
+func TestPreBlockFileName(t *testing.T) {
+	tests := []struct {
+		alt  string
+		want string
+	}{
+		{"", "preformatted.txt"},
+		{"config.toml", "config.toml"},
+		{"install.sh", "install.sh"},
+		{"A diagram of the network", "preformatted.txt"},
+		{"sh", "preformatted.txt"},
+		{".bashrc", "preformatted.txt"},
+		{"../etc/passwd", "preformatted.txt"},
+		{`..\evil.bat`, "preformatted.txt"},
+	}
+	for _, tt := range tests {
+		if got := preBlockFileName(tt.alt); got != tt.want {
+			t.Errorf("preBlockFileName(%q) = %q, want %q", tt.alt, got, tt.want)
+		}
+	}
+}
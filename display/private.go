@@ -5,8 +5,11 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/makeworld-the-better-one/amfora/logger"
 	"github.com/makeworld-the-better-one/amfora/renderer"
 	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/makeworld-the-better-one/amfora/subscriptions"
+	"github.com/spf13/viper"
 )
 
 // This file contains the functions that aren't part of the public API.
@@ -22,6 +25,15 @@ func followLink(t *tab, prev, next string) {
 		}
 		return
 	}
+	if t.isAnAboutPage() {
+		// Relative links between the user's own about pages
+		if about, ok := userAboutLink(next); ok {
+			if final, ok := handleAbout(t, about); ok {
+				t.addToHistory(final)
+			}
+			return
+		}
+	}
 
 	if t.hasContent() && !t.isAnAboutPage() {
 		nextURL, err := resolveRelLink(t, prev, next)
@@ -55,20 +67,33 @@ func reformatPage(p *structs.Page) {
 	// TODO: Setup a renderer.RenderFromMediatype func so this isn't needed
 
 	var rendered string
+	proxied := true
+	if strings.HasPrefix(p.URL, "gemini") ||
+		strings.HasPrefix(p.URL, "about") ||
+		strings.HasPrefix(p.URL, "file") {
+		proxied = false
+	}
+
+	wrapPre := viper.GetBool("a-general.wrap_pre") != p.ToggleWrap
+
 	switch p.Mediatype {
 	case structs.TextGemini:
-		// Links are not recorded because they won't change
-		proxied := true
-		if strings.HasPrefix(p.URL, "gemini") ||
-			strings.HasPrefix(p.URL, "about") ||
-			strings.HasPrefix(p.URL, "file") {
-			proxied = false
-		}
-		rendered, _ = renderer.RenderGemini(p.Raw, textWidth(), proxied)
+		// Links usually won't change, but they're recorded in case the
+		// page is being viewed as a different mediatype, see viewAs
+		rendered, p.Links = renderer.RenderGeminiPage(p.Raw, p.URL, textWidth(), proxied, renderer.ANSIEnabled(p.URL),
+			p.Lang, wrapPre, p.ShowNumbers)
+	case structs.TextMarkdown:
+		rendered, p.Links = renderer.RenderGeminiPage(renderer.MarkdownToGemtext(p.Raw), p.URL, textWidth(), proxied,
+			renderer.ANSIEnabled(p.URL), p.Lang, wrapPre, p.ShowNumbers)
 	case structs.TextPlain:
 		rendered = renderer.RenderPlainText(p.Raw)
+		p.Links = []string{}
 	case structs.TextAnsi:
-		rendered = renderer.RenderANSI(p.Raw)
+		rendered = renderer.RenderANSI(p.Raw, renderer.ANSIEnabled(p.URL))
+		p.Links = []string{}
+	case structs.TextDiff:
+		rendered = renderer.RenderDiff(p.Raw)
+		p.Links = []string{}
 	default:
 		// Rendering this type is not implemented
 		return
@@ -77,16 +102,35 @@ func reformatPage(p *structs.Page) {
 	p.TermWidth = termW
 }
 
+// toggleWrapPre switches soft-wrapping of preformatted text on or off for the
+// tab's page, and renders it again.
+func toggleWrapPre(t *tab) {
+	p := t.page
+	if !t.hasContent() || (p.Mediatype != structs.TextGemini && p.Mediatype != structs.TextMarkdown) {
+		return
+	}
+	p.ToggleWrap = !p.ToggleWrap
+	p.TermWidth = -1 // Force it to be rendered again
+	reformatPageAndSetView(t, p)
+}
+
 // reformatPageAndSetView is for reformatting a page that is already being displayed.
 // setPage should be used when a page is being loaded for the first time.
+//
+// The view stays at the same part of the page, and the selected link stays selected.
 func reformatPageAndSetView(t *tab, p *structs.Page) {
 	if p.TermWidth == termW {
 		// No changes to make
 		return
 	}
+	// Remember where the top of the view is, in a way that doesn't depend on wrapping
+	offset := textOffset(t.view.GetText(true), p.Row)
+
 	reformatPage(p)
 	t.view.SetText(p.Content)
-	t.applyScroll() // Go back to where you were, roughly
+	p.Row = rowAtOffset(t.view.GetText(true), offset)
+	t.applyScroll()
+	t.applySelected()
 
 	App.Draw()
 }
@@ -99,41 +143,113 @@ func setPage(t *tab, p *structs.Page) {
 		return
 	}
 
+	if t.page != p {
+		rememberScroll(t)
+		if t.jumps.target == nil {
+			t.addJump()
+		}
+	}
+
 	// Make sure the page content is fitted to the terminal every time it's displayed
 	reformatPage(p)
 
 	t.page = p
+	t.fromCache = false
+	t.restore = nil
+	t.stopped = false
 
 	// Change page on screen
 	t.view.SetText(p.Content)
 	t.view.Highlight("") // Turn off highlights, other funcs may restore if necessary
 	t.view.ScrollToBeginning()
+	if j := t.jumps.target; j != nil && j.url == p.URL {
+		// Go to where the jump was
+		p.Row = rowAtOffset(t.view.GetText(true), j.offset)
+		t.restore = &scrollRestore{offset: j.offset, row: p.Row}
+		t.view.ScrollTo(p.Row, 0)
+	} else if p.Row == 0 {
+		// Continue where the page was left last time
+		if offset, ok := savedScroll(p.URL); ok {
+			p.Row = rowAtOffset(t.view.GetText(true), offset)
+			t.restore = &scrollRestore{offset: offset, row: p.Row}
+			t.view.ScrollTo(p.Row, 0)
+		}
+	}
+	t.jumps.target = nil
+
 	// Reset page left margin
 	tabNum := tabNumber(t)
 	browser.AddTab(
 		strconv.Itoa(tabNum),
-		makeTabLabel(strconv.Itoa(tabNum+1)),
+		tabLabel(tabNum),
 		makeContentLayout(t.view, leftMargin()),
 	)
 	App.Draw()
 
 	// Setup display
-	App.SetFocus(t.view)
+	if t == tabs[curTab] {
+		App.SetFocus(t.view)
+	}
 
 	// Save bottom bar for the tab - other funcs will apply/display it
 	t.barLabel = ""
 	t.barText = p.URL
 }
 
+// replacePage swaps the tab's page for a more complete version of it, as
+// pages are displayed while they're downloaded. Unlike setPage, the scroll
+// position and selection of the page are kept.
+func replacePage(t *tab, p *structs.Page) {
+	reformatPage(p)
+
+	p.Mode = t.page.Mode
+	p.Selected = t.page.Selected
+	p.SelectedID = t.page.SelectedID
+	row, col := t.view.GetScrollOffset()
+	t.page = p
+	t.view.SetText(p.Content)
+	if t.restore != nil && row == t.restore.row {
+		// More of the page is there, so the saved position can be closer
+		row = rowAtOffset(t.view.GetText(true), t.restore.offset)
+		t.restore.row = row
+	} else {
+		t.restore = nil
+	}
+	p.Row = row
+	t.view.ScrollTo(row, col)
+}
+
 // goURL is like handleURL, but takes care of history and the bottomBar.
 // It should be preferred over handleURL in most cases.
 // It has no return values to be processed.
 //
 // It should be called in a goroutine.
 func goURL(t *tab, u string) {
-	final, displayed := handleURL(t, u, 0)
+	// The fragment is only used here, to scroll to a heading, see fragment.go
+	var fragment string
+	if parsed, err := url.Parse(u); err == nil && parsed.Fragment != "" {
+		fragment = parsed.Fragment
+		parsed.Fragment = ""
+		if t.hasContent() && normalizeURL(parsed.String()) == t.page.URL {
+			// Link to a heading on the same page, no need to load it again
+			t.addJump()
+			scrollToFragment(t, fragment)
+			return
+		}
+	}
+
+	final, displayed := handleURL(t, u)
 	if displayed {
 		t.addToHistory(final)
+		// Visits from private tabs aren't saved
+		if !t.private {
+			if err := subscriptions.MarkRead(final); err != nil {
+				logger.Errorf("Couldn't mark %s as read: %v", final, err)
+			}
+		}
+		if fragment != "" {
+			scrollToFragment(t, fragment)
+		}
 	}
 	if t == tabs[curTab] {
 		// Display the bottomBar state that handleURL set
@@ -0,0 +1,129 @@
+package display
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+	"github.com/makeworld-the-better-one/amfora/readability"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+	"golang.org/x/net/html/charset"
+)
+
+// httpReaderEnabled returns true if HTTP(S) pages should be displayed in
+// Amfora using reader mode, instead of being opened in a browser.
+func httpReaderEnabled() bool {
+	return len(config.HTTPCommand) == 1 && config.HTTPCommand[0] == "reader"
+}
+
+// torProxyURL returns the Tor SOCKS proxy to use for the HTTP request.
+// Requests that don't go through Tor use the proxy from the environment, if any.
+func torProxyURL(req *http.Request) (*url.URL, error) {
+	addr, err := client.TorProxy(req.URL.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	if addr == "" {
+		return http.ProxyFromEnvironment(req)
+	}
+	return &url.URL{Scheme: "socks5", Host: addr}, nil
+}
+
+// handleHTTPReader fetches a HTTP(S) page and converts its main content to
+// gemtext, like the reader mode of a web browser.
+// Plain text pages are displayed as they are.
+func handleHTTPReader(u string) (*structs.Page, bool) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = torProxyURL
+	httpClient := http.Client{
+		Timeout:   time.Duration(viper.GetInt("a-general.page_max_time")) * time.Second,
+		Transport: transport,
+	}
+	resp, err := httpClient.Get(u)
+	if err != nil {
+		if os.IsTimeout(err) {
+			Error("HTTP Error", "The page took too long to load.")
+		} else {
+			Error("HTTP Error", err.Error())
+		}
+		return nil, false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		Error("HTTP Error", i18n.T("The server returned %s.", resp.Status))
+		return nil, false
+	}
+
+	mediatype, params, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediatype != "text/html" && mediatype != "application/xhtml+xml" && mediatype != "text/plain" {
+		Error("HTTP Error", "Reader mode only supports HTML and plain text pages, this page is "+
+			escapeMeta(mediatype)+".")
+		return nil, false
+	}
+
+	// Read one byte more than the max size, to know if the page was too large
+	r, err := charset.NewReader(
+		io.LimitReader(resp.Body, viper.GetInt64("a-general.page_max_size")+1),
+		resp.Header.Get("Content-Type"),
+	)
+	if err != nil {
+		Error("HTTP Error", "Unsupported page encoding: "+err.Error())
+		return nil, false
+	}
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		Error("HTTP Error", err.Error())
+		return nil, false
+	}
+	if int64(len(content)) > viper.GetInt64("a-general.page_max_size") {
+		Error("HTTP Error", "The page is larger than the max page size.")
+		return nil, false
+	}
+
+	// The URL after any redirects, so relative links work
+	finalURL := resp.Request.URL.String()
+
+	if mediatype == "text/plain" {
+		return &structs.Page{
+			Mediatype:    structs.TextPlain,
+			RawMediatype: mediatype,
+			Charset:      params["charset"],
+			URL:          finalURL,
+			Raw:          string(content),
+			Content:      renderer.RenderPlainText(string(content)),
+			Links:        []string{},
+			TermWidth:    termW,
+			MadeAt:       time.Now(),
+		}, true
+	}
+
+	art, err := readability.Convert(bytes.NewReader(content), resp.Request.URL)
+	if err != nil {
+		Error("HTTP Error", "Couldn't convert the page: "+err.Error())
+		return nil, false
+	}
+	rendered, links := renderer.RenderGemini(art.Gemtext, textWidth(), true, renderer.ANSIEnabled(finalURL), art.Lang)
+	return &structs.Page{
+		Mediatype:    structs.TextGemini,
+		RawMediatype: mediatype,
+		Charset:      params["charset"],
+		URL:          finalURL,
+		Lang:         art.Lang,
+		Raw:          art.Gemtext,
+		Content:      rendered,
+		Links:        links,
+		TermWidth:    termW,
+		MadeAt:       time.Now(),
+	}, true
+}
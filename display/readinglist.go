@@ -0,0 +1,148 @@
+package display
+
+import (
+	"fmt"
+	"strings"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/readinglist"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/makeworld-the-better-one/go-gemini"
+)
+
+// readingListPageRaw returns the gemtext of about:reading.
+func readingListPageRaw(items []readinglist.Item) string {
+	var unread, read strings.Builder
+	for _, item := range items {
+		title := item.Title
+		if title == "" {
+			title = item.URL
+		}
+		q := gemini.QueryEscape(item.URL)
+		if item.Read {
+			fmt.Fprintf(&read, "=> %s %s\n", item.URL, title)
+			fmt.Fprintf(&read, "=> about:reading?saved=%s Saved copy, from %s\n", q, humanize.Time(item.Added))
+			fmt.Fprintf(&read, "=> about:reading?unread=%s Mark as unread\n", q)
+			fmt.Fprintf(&read, "=> about:reading?remove=%s Remove\n\n", q)
+		} else {
+			fmt.Fprintf(&unread, "=> %s %s\n", item.URL, title)
+			fmt.Fprintf(&unread, "=> about:reading?saved=%s Saved copy, from %s\n", q, humanize.Time(item.Added))
+			fmt.Fprintf(&unread, "=> about:reading?read=%s Mark as read\n\n", q)
+		}
+	}
+
+	raw := "# Reading List\n\n" +
+		fmt.Sprintf("Pages saved to read later, newest first. Press %s on a page to add it.\n\n",
+			strings.Split(config.GetKeyBinding(config.CmdReadLater), ",")[0]) +
+		"## Unread\n\n"
+	if unread.Len() == 0 {
+		raw += "Nothing to read.\n\n"
+	} else {
+		raw += unread.String()
+	}
+	if read.Len() > 0 {
+		raw += "## Read\n\n" + read.String()
+	}
+	return raw
+}
+
+// ReadingList displays about:reading on the tab, or handles one of its
+// actions, like "about:reading?read=URL". It returns the URL to add to the
+// history, and whether there is one.
+func ReadingList(t *tab, u string) (string, bool) {
+	if query := strings.TrimPrefix(u, "about:reading?"); query != u {
+		parts := strings.SplitN(query, "=", 2)
+		if len(parts) != 2 {
+			Error("URL Error", "Invalid query string.")
+			return "", false
+		}
+		itemURL, err := gemini.QueryUnescape(parts[1])
+		if err != nil {
+			Error("URL Error", "Invalid query string: "+err.Error())
+			return "", false
+		}
+
+		switch parts[0] {
+		case "saved":
+			if !showSavedCopy(t, itemURL) {
+				return "", false
+			}
+			return u, true
+		case "read":
+			err = readinglist.SetRead(itemURL, true)
+		case "unread":
+			err = readinglist.SetRead(itemURL, false)
+		case "remove":
+			err = readinglist.Remove(itemURL)
+		default:
+			Error("URL Error", "Invalid query string.")
+			return "", false
+		}
+		ReadingList(t, "about:reading") // Reload
+		if err != nil {
+			Error("Reading List Error", err.Error())
+		}
+		return "", false
+	}
+
+	raw := readingListPageRaw(readinglist.All())
+	content, links := renderer.RenderGemini(raw, textWidth(), false, renderer.ANSIEnabled(""), "")
+	page := structs.Page{
+		Raw:       raw,
+		Content:   content,
+		Links:     links,
+		URL:       "about:reading",
+		TermWidth: termW,
+		Mediatype: structs.TextGemini,
+	}
+	setPage(t, &page)
+	t.applyBottomBar()
+	return u, true
+}
+
+// showSavedCopy displays the copy of the page that was saved with the reading list.
+// It's shown with the page's own URL, so that relative links work.
+func showSavedCopy(t *tab, u string) bool {
+	item, raw, err := readinglist.Get(u)
+	if err != nil {
+		Error("Reading List Error", "The saved copy couldn't be opened: "+err.Error())
+		return false
+	}
+	page := structs.Page{
+		Raw:       raw,
+		URL:       item.URL,
+		TermWidth: -1, // Render it
+		Mediatype: structs.Mediatype(item.Mediatype),
+		Lang:      item.Lang,
+		MadeAt:    item.Added,
+	}
+	setPage(t, &page)
+	t.barText = item.URL + " (saved copy)"
+	t.applyBottomBar()
+	return true
+}
+
+// readLater adds the tab's page to the reading list.
+func readLater(t *tab) {
+	p := t.page
+	if !t.hasContent() || t.isAnAboutPage() {
+		return
+	}
+	if t.private {
+		Info("Pages in private tabs can't be saved to the reading list.")
+		return
+	}
+	err := readinglist.Add(readinglist.Item{
+		URL:       p.URL,
+		Title:     pageTitle(p),
+		Mediatype: string(p.Mediatype),
+		Lang:      p.Lang,
+	}, p.Raw)
+	if err != nil {
+		Error("Reading List Error", "The page couldn't be saved: "+err.Error())
+		return
+	}
+	Info("Saved to the reading list.")
+}
@@ -0,0 +1,112 @@
+package display
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/makeworld-the-better-one/amfora/bookmarks"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+	"github.com/makeworld-the-better-one/amfora/subscriptions"
+	"github.com/spf13/viper"
+	"golang.org/x/net/publicsuffix"
+)
+
+// redirectStoreMu protects config.RedirectStore, since viper is not thread-safe.
+var redirectStoreMu = sync.RWMutex{}
+
+// autoRedirectHost returns true if redirects from the host are always followed.
+// Hosts are added to the list from the redirect prompt, see addAutoRedirectHost.
+func autoRedirectHost(host string) bool {
+	host = strings.ToLower(host)
+
+	redirectStoreMu.RLock()
+	defer redirectStoreMu.RUnlock()
+
+	for _, h := range config.RedirectStore.GetStringSlice("hosts") {
+		if strings.ToLower(h) == host {
+			return true
+		}
+	}
+	return false
+}
+
+// addAutoRedirectHost adds the host to the list of hosts whose redirects are
+// always followed, and saves the list.
+func addAutoRedirectHost(host string) error {
+	if autoRedirectHost(host) {
+		return nil
+	}
+
+	redirectStoreMu.Lock()
+	defer redirectStoreMu.Unlock()
+
+	hosts := config.RedirectStore.GetStringSlice("hosts")
+	config.RedirectStore.Set("hosts", append(hosts, strings.ToLower(host)))
+	return config.RedirectStore.WriteConfig()
+}
+
+// crossHostRedirect returns whether a redirect must be confirmed, or can be
+// followed without asking, as set by "a-general.cross_host_redirects". Both
+// are false for redirects that stay on the same host and scheme, and when
+// the redirect should be treated like any other.
+func crossHostRedirect(from, to *url.URL) (ask, follow bool) {
+	if strings.EqualFold(from.Hostname(), to.Hostname()) && strings.EqualFold(from.Scheme, to.Scheme) {
+		return false, false
+	}
+	switch viper.GetString("a-general.cross_host_redirects") {
+	case "ask":
+		return true, false
+	case "same_domain":
+		if strings.EqualFold(from.Scheme, to.Scheme) && sameDomain(from.Hostname(), to.Hostname()) {
+			return false, true
+		}
+		return true, false
+	}
+	return false, false
+}
+
+// sameDomain returns true if the hosts are part of the same domain, like
+// "example.com" and "www.example.com", but not "a.co.uk" and "b.co.uk".
+func sameDomain(a, b string) bool {
+	domainA, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(a))
+	if err != nil {
+		return false
+	}
+	domainB, err := publicsuffix.EffectiveTLDPlusOne(strings.ToLower(b))
+	return err == nil && domainA == domainB
+}
+
+// offerRedirectUpdate asks the user whether the bookmark and subscription for
+// a URL that has permanently moved should be changed to use the new URL.
+// Nothing is asked if the URL isn't bookmarked or subscribed to.
+func offerRedirectUpdate(oldURL, newURL string) {
+	_, bookmarked := bookmarks.Get(oldURL)
+	subscribed := subscriptions.IsSubscribed(oldURL)
+
+	var what string
+	switch {
+	case bookmarked && subscribed:
+		what = "your bookmark and subscription"
+	case bookmarked:
+		what = "your bookmark"
+	case subscribed:
+		what = "your subscription"
+	default:
+		return
+	}
+	if !YesNo(i18n.T("This page has permanently moved to:\n%s\n\nUpdate %s to use the new URL?",
+		escapeMeta(newURL), what)) {
+		return
+	}
+
+	if bookmarked {
+		bookmarks.ChangeURL(oldURL, newURL)
+	}
+	if subscribed {
+		if err := subscriptions.Move(oldURL, newURL); err != nil {
+			Error("Subscriptions Error", "Couldn't save the subscription: "+err.Error())
+		}
+	}
+}
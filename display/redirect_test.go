@@ -0,0 +1,33 @@
+package display
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestCrossHostRedirect(t *testing.T) {
+	defer viper.Set("a-general.cross_host_redirects", "follow")
+
+	tests := []struct {
+		policy, from, to string
+		ask, follow      bool
+	}{
+		{"ask", "gemini://example.com/a", "gemini://example.com/b", false, false},
+		{"ask", "gemini://example.com/", "gemini://www.example.com/", true, false},
+		{"same_domain", "gemini://example.com/", "gemini://www.example.com/", false, true},
+		{"same_domain", "gemini://a.co.uk/", "gemini://b.co.uk/", true, false},
+		{"same_domain", "gemini://example.com/", "gemini://example.org/", true, false},
+		{"follow", "gemini://example.com/", "gemini://example.org/", false, false},
+	}
+	for _, tt := range tests {
+		viper.Set("a-general.cross_host_redirects", tt.policy)
+		from, _ := url.Parse(tt.from)
+		to, _ := url.Parse(tt.to)
+		if ask, follow := crossHostRedirect(from, to); ask != tt.ask || follow != tt.follow {
+			t.Errorf("%s: crossHostRedirect(%q, %q) = %v, %v, want %v, %v",
+				tt.policy, tt.from, tt.to, ask, follow, tt.ask, tt.follow)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package display
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/i18n"
+	"github.com/spf13/viper"
+)
+
+// Errors from the network that might go away by trying again.
+var transientErrnos = []syscall.Errno{
+	syscall.ECONNREFUSED,
+	syscall.ECONNRESET,
+	syscall.ECONNABORTED,
+	syscall.ENETUNREACH,
+	syscall.EHOSTUNREACH,
+}
+
+// isTransientErr returns true if the error from fetching a page is one
+// that might go away by trying again, like a timeout or a refused connection.
+func isTransientErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, errno := range transientErrnos {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && (dnsErr.IsTemporary || dnsErr.IsTimeout) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// retryDelay returns how long to wait before retrying, after the provided
+// number of failed attempts. It doubles every time, starting at one second.
+func retryDelay(attempts int) time.Duration {
+	if attempts > 6 {
+		attempts = 6
+	}
+	return time.Second << (attempts - 1)
+}
+
+// How often waiting to retry checks whether another page is being loaded in
+// the tab, or it was closed.
+const retrySupersededInterval = 100 * time.Millisecond
+
+// retryFetch is called when fetching a URL failed with a temporary error,
+// and returns true if the fetch should be tried again. attempts is how many
+// times the URL has been fetched so far. superseded is the one from handleURL.
+//
+// The first few retries are made automatically after waiting, as set by
+// auto_retries in the config. After that the user is asked. Waiting ends
+// early without retrying if the stop key is pressed, or another page is loaded.
+func retryFetch(t *tab, err error, attempts int, superseded func() bool) bool {
+	auto := viper.GetInt("a-general.auto_retries")
+	if attempts <= auto {
+		delay := retryDelay(attempts)
+		text := i18n.T("Failed, retrying in %s (attempt %d of %d)...", delay, attempts+1, auto+1)
+		bottomBar.SetText(text)
+		t.barText = text
+		App.Draw()
+
+		stop := make(chan struct{})
+		var once sync.Once
+		t.stopLoad = func() {
+			once.Do(func() { close(stop) })
+		}
+		ok := waitToRetry(stop, delay, func() bool { return superseded() || !isValidTab(t) })
+		if !superseded() {
+			t.stopLoad = nil
+		}
+		if !ok {
+			return false
+		}
+	} else if !YesNo(i18n.T("%s\n\nThe error might be temporary. Try again? (%d attempts so far)",
+		escapeMeta(err.Error()), attempts)) {
+		return false
+	}
+
+	text := i18n.T("Loading... (attempt %d)", attempts+1)
+	bottomBar.SetText(text)
+	t.barText = text
+	App.Draw()
+	return true
+}
+
+// waitToRetry waits for the delay, and returns false if stop is closed by the
+// stop key in the meantime, or cancelled starts returning true.
+func waitToRetry(stop <-chan struct{}, delay time.Duration, cancelled func() bool) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	// Loading another page doesn't close stop, so it's checked for
+	ticker := time.NewTicker(retrySupersededInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return false
+		case <-timer.C:
+			return !cancelled()
+		case <-ticker.C:
+			if cancelled() {
+				return false
+			}
+		}
+	}
+}
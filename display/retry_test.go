@@ -0,0 +1,74 @@
+package display
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIsTransientErr(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("connection refused"), false}, // Only the text
+		{fmt.Errorf("dial: %w", syscall.ECONNREFUSED), true},
+		{&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.EHOSTUNREACH)}, true},
+		{&net.DNSError{Err: "server misbehaving", IsTemporary: true}, true},
+		{&net.DNSError{Err: "no such host", IsNotFound: true}, false},
+		{fmt.Errorf("couldn't read the response header: %w", os.ErrDeadlineExceeded), true},
+		{fmt.Errorf("TLS error: %w", errors.New("bad certificate")), false},
+	}
+	for i, tt := range tests {
+		if got := isTransientErr(tt.err); got != tt.want {
+			t.Errorf("%d: isTransientErr(%v) = %v, want %v", i, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestWaitToRetry(t *testing.T) {
+	never := func() bool { return false }
+
+	if !waitToRetry(make(chan struct{}), time.Millisecond, never) {
+		t.Error("the wait ended early")
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(stop)
+	}()
+	start := time.Now()
+	if waitToRetry(stop, time.Minute, never) {
+		t.Error("retrying after the stop key was pressed")
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Error("the stop key didn't end the wait")
+	}
+
+	superseded := make(chan struct{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(superseded)
+	}()
+	isSuperseded := func() bool {
+		select {
+		case <-superseded:
+			return true
+		default:
+			return false
+		}
+	}
+	start = time.Now()
+	if waitToRetry(make(chan struct{}), time.Minute, isSuperseded) {
+		t.Error("retrying after another page was loaded")
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Error("loading another page didn't end the wait")
+	}
+}
@@ -0,0 +1,64 @@
+package display
+
+import (
+	"fmt"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+)
+
+// Screen reader mode changes the interface so that terminal screen readers can
+// make sense of it, see "a-general.screen_reader". Borders are drawn with spaces,
+// the text of popups is left-aligned, and page loads and errors are announced in
+// the bottom bar, which screen readers read out when it changes.
+// The renderer uses plain characters for bullets and tables too.
+//
+// Popups are still shown over the page, and the page, tab row, and bottom bar
+// are still separate areas, rather than everything being printed as one
+// sequence of lines to move through. That would need an interface of its own.
+
+// screenReaderInit sets up the interface for screen reader mode.
+func screenReaderInit() {
+	for _, r := range []*rune{
+		&cview.Borders.Horizontal, &cview.Borders.Vertical,
+		&cview.Borders.TopLeft, &cview.Borders.TopRight,
+		&cview.Borders.BottomLeft, &cview.Borders.BottomRight,
+		&cview.Borders.HorizontalFocus, &cview.Borders.VerticalFocus,
+		&cview.Borders.TopLeftFocus, &cview.Borders.TopRightFocus,
+		&cview.Borders.BottomLeftFocus, &cview.Borders.BottomRightFocus,
+	} {
+		*r = ' '
+	}
+	for _, m := range []*cview.Modal{infoModal, errorModal, inputModal, yesNoModal, choiceModal} {
+		m.SetTextAlign(cview.AlignLeft)
+	}
+}
+
+// announcePage announces that the tab's page was loaded, with its title and
+// how many links it has, in the bottom bar.
+func announcePage(t *tab) {
+	if !config.ScreenReader {
+		return
+	}
+	App.QueueUpdateDraw(func() {
+		if !isValidTab(t) || t != tabs[curTab] || !t.hasContent() {
+			return
+		}
+		title := pageTitle(t.page)
+		if title == "" {
+			title = t.page.URL
+		}
+		setNotice(i18n.T("Loaded"), i18n.T("%s, %d links", title, len(t.page.Links)))
+		t.applyBottomBar()
+	})
+}
+
+// announceError announces an error in the bottom bar, as well as in the popup.
+func announceError(title, text string) {
+	if !config.ScreenReader || bottomBar.HasFocus() {
+		return
+	}
+	bottomBar.SetLabel(fmt.Sprintf("[::b]%s: [::-]", i18n.T("Error")))
+	bottomBar.SetText(title + ". " + text)
+}
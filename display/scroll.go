@@ -0,0 +1,111 @@
+package display
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/logger"
+)
+
+// The scroll positions of recently viewed pages are saved, so that going back
+// to a page continues where it was left, even in a later session. Positions are
+// stored as text offsets, see textOffset, so they don't depend on the width of
+// the terminal.
+
+// The number of pages that scroll positions are kept for.
+const maxScrollPositions = 500
+
+type scrollPosition struct {
+	URL    string `json:"url"`
+	Offset int    `json:"offset"`
+}
+
+var (
+	scrollPositions []scrollPosition // Oldest first
+	scrollMu        sync.Mutex
+)
+
+// scrollRestore is a scroll position that was restored on a page that is
+// still being downloaded. The row is updated as more of the page is displayed,
+// unless the user scrolled away from it.
+type scrollRestore struct {
+	offset int
+	row    int
+}
+
+func loadScrollPositions() {
+	data, err := ioutil.ReadFile(config.ScrollPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warnf("Couldn't read scroll positions: %v", err)
+		}
+		return
+	}
+	scrollMu.Lock()
+	defer scrollMu.Unlock()
+	if err := json.Unmarshal(data, &scrollPositions); err != nil {
+		logger.Warnf("Couldn't read scroll positions: %v", err)
+		scrollPositions = nil
+	}
+}
+
+func saveScrollPositions() {
+	scrollMu.Lock()
+	data, err := json.Marshal(scrollPositions)
+	scrollMu.Unlock()
+	if err == nil {
+		err = ioutil.WriteFile(config.ScrollPath, data, 0600)
+	}
+	if err != nil {
+		logger.Warnf("Couldn't save scroll positions: %v", err)
+	}
+}
+
+// removeScrollPosition removes the position for the URL. scrollMu must be locked.
+func removeScrollPosition(u string) {
+	for i := range scrollPositions {
+		if scrollPositions[i].URL == u {
+			scrollPositions = append(scrollPositions[:i], scrollPositions[i+1:]...)
+			return
+		}
+	}
+}
+
+// rememberScroll saves the scroll position of the page the tab is displaying.
+// Pages scrolled to the top are forgotten.
+func rememberScroll(t *tab) {
+	if !t.hasContent() || t.isAnAboutPage() || t.private {
+		return
+	}
+	offset := textOffset(t.view.GetText(true), t.page.Row)
+
+	scrollMu.Lock()
+	defer scrollMu.Unlock()
+	removeScrollPosition(t.page.URL)
+	if offset == 0 {
+		return
+	}
+	if len(scrollPositions) >= maxScrollPositions {
+		scrollPositions = scrollPositions[1:]
+	}
+	scrollPositions = append(scrollPositions, scrollPosition{URL: t.page.URL, Offset: offset})
+}
+
+// savedScroll returns the saved scroll position of the URL.
+func savedScroll(u string) (int, bool) {
+	if strings.HasPrefix(u, "about:") {
+		return 0, false
+	}
+	scrollMu.Lock()
+	defer scrollMu.Unlock()
+	for i := len(scrollPositions) - 1; i >= 0; i-- {
+		if scrollPositions[i].URL == u {
+			return scrollPositions[i].Offset, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,181 @@
+package display
+
+import (
+	"strings"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+)
+
+// Split view shows two tabs at once, side by side or one above the other.
+// The focused pane is the current tab, shown by the browser with the tab row
+// above it, and the other pane shows splitTab. They're normal tabs, so each
+// pane has its own page and history.
+//
+// Switching focus makes the other pane's tab the current one. The pages stay
+// where they are, and the tab row moves above the focused pane.
+
+var splitTab *tab // The tab in the other pane, nil if the view isn't split
+
+var splitVertical bool // Whether the panes are side by side, instead of one above the other
+
+var splitFirst bool // Whether the other pane is left of or above the browser
+
+// Holds the browser, and the other pane when the view is split
+var panes = cview.NewFlex()
+
+// The other pane, with splitTitle above the page
+var splitPane = cview.NewFlex()
+
+// The page layout in splitPane, kept to be replaced
+var splitContent *cview.Flex
+
+// Shows the number of the other pane's tab, in the place of the tab row
+var splitTitle = cview.NewTextView()
+
+const singleTabSplitMsg = "The view can't be split in single tab mode, because each pane is a tab."
+
+func splitInit() {
+	splitPane.SetDirection(cview.FlexRow)
+	if viper.GetBool("a-general.color") {
+		splitTitle.SetBackgroundColor(config.GetColor("bg"))
+		splitTitle.SetTextColor(config.GetColor("tab_num"))
+	} else {
+		splitTitle.SetBackgroundColor(tcell.ColorBlack)
+		splitTitle.SetTextColor(tcell.ColorWhite)
+	}
+	panes.AddItem(browser, 0, 1, true)
+}
+
+// paneSize returns the size of each pane, or the whole terminal if the view
+// isn't split.
+func paneSize() (int, int) {
+	switch {
+	case splitTab == nil:
+		return screenW, screenH
+	case splitVertical:
+		return screenW / 2, screenH
+	default:
+		return screenW, screenH / 2
+	}
+}
+
+// layoutPanes puts the browser and the other pane in place, after the view
+// was split or unsplit, or the focus moved.
+func layoutPanes() {
+	panes.RemoveItem(browser)
+	panes.RemoveItem(splitPane)
+	splitPane.RemoveItem(splitTitle)
+	if splitContent != nil {
+		splitPane.RemoveItem(splitContent)
+		splitContent = nil
+	}
+	if splitTab == nil {
+		panes.AddItem(browser, 0, 1, true)
+		return
+	}
+
+	if splitVertical {
+		panes.SetDirection(cview.FlexColumn)
+	} else {
+		panes.SetDirection(cview.FlexRow)
+	}
+	titleRows := 0
+	if tabRowShown {
+		titleRows = 1
+	}
+	splitTitle.SetText(tabLabel(tabNumber(splitTab)))
+	splitContent = makeContentLayout(splitTab.view, leftMargin())
+	splitPane.AddItem(splitTitle, titleRows, 0, false)
+	splitPane.AddItem(splitContent, 0, 1, false)
+
+	if splitFirst {
+		panes.AddItem(splitPane, 0, 1, false)
+		panes.AddItem(browser, 0, 1, true)
+	} else {
+		panes.AddItem(browser, 0, 1, true)
+		panes.AddItem(splitPane, 0, 1, false)
+	}
+}
+
+// resizePanes updates the panes and the pages in them to the size of the
+// terminal and the split.
+func resizePanes() {
+	termW, termH = paneSize()
+	layoutPanes()
+	reformatTabs()
+}
+
+// toggleSplit splits the view, with the current page opened again in a new
+// tab in the other pane. If the view is already split the other way, the
+// direction changes, and if it's split the same way, the other pane is
+// closed. Its tab stays open.
+func toggleSplit(vertical bool) {
+	switch {
+	case splitTab != nil && splitVertical == vertical:
+		splitTab = nil
+	case splitTab != nil:
+		splitVertical = vertical
+	case singleTab:
+		Info(singleTabSplitMsg)
+		return
+	default:
+		openSplit(tabs[curTab].page.URL, vertical)
+		return
+	}
+	resizePanes()
+	App.Draw()
+}
+
+// openSplit splits the view, with the absolute URL opened in a new tab in the
+// other pane. It must only be called when the view isn't split.
+func openSplit(u string, vertical bool) {
+	newBackgroundTab(u)
+	splitTab = tabs[NumTabs()-1]
+	splitVertical = vertical
+	splitFirst = false
+	resizePanes()
+	App.Draw()
+}
+
+// followInOtherPane opens the selected link of the tab in the other pane,
+// splitting the view side by side if it isn't already. The focus stays where
+// it is, so the page with the links can still be used.
+func followInOtherPane(t *tab) {
+	if t.page.Mode != structs.ModeLinkSelect {
+		Info("Select a link with Tab to open it in the other pane.")
+		return
+	}
+	if singleTab {
+		Info(singleTabSplitMsg)
+		return
+	}
+	next, err := resolveRelLink(t, t.page.URL, t.page.Selected)
+	if err != nil {
+		Error("URL Error", err.Error())
+		return
+	}
+	if splitTab == nil {
+		openSplit(next, true)
+		return
+	}
+	if strings.HasPrefix(next, "about:") {
+		if final, ok := handleAbout(splitTab, next); ok {
+			splitTab.addToHistory(final)
+		}
+		t.applyBottomBar() // The about page set the bar to its own
+		return
+	}
+	go goURL(splitTab, next)
+}
+
+// focusOtherPane makes the tab in the other pane the current one.
+func focusOtherPane() {
+	if splitTab == nil {
+		return
+	}
+	SwitchTab(tabNumber(splitTab))
+}
@@ -0,0 +1,154 @@
+package display
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
+)
+
+// The status format that only shows the URL, which is how the bottom bar
+// always worked. Nothing needs to be updated while drawing for it.
+const defaultStatusFormat = "{url}"
+
+// pageTitle returns the text of the first heading of the page, or an empty
+// string if it has none or isn't gemtext or Markdown.
+func pageTitle(p *structs.Page) string {
+	if p.Mediatype != structs.TextGemini && p.Mediatype != structs.TextMarkdown {
+		return ""
+	}
+	pre := false
+	for _, line := range strings.Split(p.Raw, "\n") {
+		if strings.HasPrefix(line, "```") {
+			pre = !pre
+		} else if !pre && strings.HasPrefix(line, "#") {
+			return strings.TrimSpace(strings.TrimLeft(line, "#"))
+		}
+	}
+	return ""
+}
+
+// scrollPercent returns how far down the view is scrolled, from 0 to 100.
+// Pages that fit on the screen are always at 100.
+func scrollPercent(view *cview.TextView, content string) int {
+	row, _ := view.GetScrollOffset()
+	_, _, _, height := view.GetInnerRect()
+	lines := strings.Count(content, "\n") + 1
+	if lines <= height {
+		return 100
+	}
+	percent := row * 100 / (lines - height)
+	if percent > 100 {
+		return 100
+	}
+	return percent
+}
+
+// formatLoadTime formats how long a page took to load, like "350ms" or "1.2s".
+func formatLoadTime(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+// The {identity} text of hosts that aren't using a tab certificate. The status
+// is made before every draw, so the client certificates aren't parsed each time.
+// The cache is emptied when client.CertGeneration changes.
+var (
+	identityNames   = make(map[string]string)
+	identityNamesMu sync.Mutex
+	identityGen     uint64
+)
+
+// hostIdentity returns the {identity} text for a host, using the cache.
+func hostIdentity(host string) string {
+	identityNamesMu.Lock()
+	defer identityNamesMu.Unlock()
+
+	if gen := client.CertGeneration(); gen != identityGen {
+		identityNames = make(map[string]string)
+		identityGen = gen
+	}
+	if name, ok := identityNames[host]; ok {
+		return name
+	}
+	name := "ID"
+	if client.IsTemporaryCert(host) {
+		name = "Temporary ID"
+	} else if cn := client.ClientCertName(host); cn != "" {
+		name = cn
+	}
+	identityNames[host] = name
+	return name
+}
+
+// statusText returns the status of the tab formatted with "a-general.status_format".
+// Placeholders that don't apply to the page are replaced with nothing.
+func statusText(t *tab) string {
+	format := viper.GetString("a-general.status_format")
+	p := t.page
+
+	pageURL := p.URL
+	if pageURL == "about:newtab" {
+		// The bar has always been empty on new tabs
+		pageURL = ""
+	}
+
+	replacements := make([]string, 0, 14)
+	add := func(placeholder string, value func() string) {
+		if strings.Contains(format, placeholder) {
+			replacements = append(replacements, placeholder, value())
+		}
+	}
+	add("{url}", func() string { return pageURL })
+	add("{title}", func() string { return pageTitle(p) })
+	add("{scroll}", func() string { return strconv.Itoa(scrollPercent(t.view, p.Content)) + "%" })
+	add("{load_time}", func() string { return formatLoadTime(p.LoadTime) })
+	add("{identity}", func() string {
+		parsed, err := url.Parse(p.URL)
+		if err != nil || t.isAnAboutPage() || !t.usesClientCert(parsed.Host) {
+			return ""
+		}
+		if t.cert != nil {
+			return t.cert.Name
+		}
+		return hostIdentity(parsed.Host)
+	})
+	add("{tab}", func() string { return strconv.Itoa(tabNumber(t) + 1) })
+	add("{tabs}", func() string { return strconv.Itoa(NumTabs()) })
+
+	return strings.TrimSpace(strings.NewReplacer(replacements...).Replace(format))
+}
+
+// updateStatus shows the status of the current tab in the bottom bar, if
+// the bar isn't being used for something else, like loading or typing a URL.
+//
+// It's called before every draw, so that things like the scroll position
+// stay up to date.
+func updateStatus() {
+	if viper.GetString("a-general.status_format") == defaultStatusFormat {
+		// Nothing to update, the URL is set in the bottom bar as pages are loaded
+		return
+	}
+	if curTab < 0 || curTab >= len(tabs) {
+		return
+	}
+	t := tabs[curTab]
+	if t.mode != tabModeDone || t.page.Mode != structs.ModeOff ||
+		bottomBar.GetLabel() != "" || bottomBar.HasFocus() {
+		return
+	}
+	if text := statusText(t); bottomBar.GetText() != text {
+		bottomBar.SetText(text)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/gdamore/tcell/v2"
 	"github.com/makeworld-the-better-one/amfora/cache"
 	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/i18n"
 	"github.com/makeworld-the-better-one/amfora/renderer"
 	"github.com/makeworld-the-better-one/amfora/structs"
 	"github.com/makeworld-the-better-one/amfora/subscriptions"
@@ -24,6 +25,10 @@ import (
 // This allows for caching the pages until there's an update.
 var subscriptionPageUpdated = make(map[int]time.Time)
 
+// Whether entries that were read are listed on the subscriptions page.
+// It starts as "subscriptions.show_read", and can be switched with a key.
+var showReadEntries = true
+
 // toLocalDay truncates the provided time to a date only,
 // but converts to the local time first.
 func toLocalDay(t time.Time) time.Time {
@@ -31,8 +36,28 @@ func toLocalDay(t time.Time) time.Time {
 	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
 }
 
-// Subscriptions displays the subscriptions page on the current tab.
-func Subscriptions(t *tab, u string) string {
+// Subscriptions displays the subscriptions page on the current tab, or opens
+// the unread entries for "about:subscriptions?open-unread". It returns the URL
+// to add to the history, and whether there is one.
+func Subscriptions(t *tab, u string) (string, bool) {
+	if u == "about:subscriptions?open-unread" {
+		openUnread()
+		return "", false
+	}
+	if query := strings.TrimPrefix(u, "about:subscriptions?toggle="); query != u {
+		key, err := gemini.QueryUnescape(query)
+		if err != nil {
+			Error("URL Error", "Invalid query string: "+err.Error())
+			return "", false
+		}
+		toggleSection(key)
+		if onSubscriptionsPage(t) {
+			reloadSubscriptions(t)
+			return "", false
+		}
+		return Subscriptions(t, "about:subscriptions")
+	}
+
 	pageN := 0 // Pages are zero-indexed internally
 
 	// Correct URL if query string exists
@@ -63,15 +88,38 @@ func Subscriptions(t *tab, u string) string {
 	}
 	u = correctURL(u)
 
+	// Grouped entries are all on one page
+	groupBy := viper.GetString("subscriptions.group_by")
+	grouped := groupBy == "feed" || groupBy == "day"
+	if grouped {
+		pageN = 0
+		u = "about:subscriptions"
+	}
+
 	// Retrieve cached version if there hasn't been any updates
 	p, ok := cache.GetPage(u)
 	if subscriptionPageUpdated[pageN].After(subscriptions.LastUpdated) && ok {
 		setPage(t, p)
 		t.applyBottomBar()
-		return u
+		return u, true
 	}
 
 	pe := subscriptions.GetPageEntries()
+	unread := 0
+	for _, entry := range pe.Entries {
+		if !entry.Read {
+			unread++
+		}
+	}
+	if !showReadEntries {
+		entries := pe.Entries[:0]
+		for _, entry := range pe.Entries {
+			if !entry.Read {
+				entries = append(entries, entry)
+			}
+		}
+		pe.Entries = entries
+	}
 
 	// Figure out where the entries for this page start, if at all.
 	epp := viper.GetInt("subscriptions.entries_per_page")
@@ -99,57 +147,46 @@ func Subscriptions(t *tab, u string) string {
 
 		rawPage += "You can use Ctrl-X to subscribe to a page, or to an Atom/RSS/JSON feed. See the online wiki for more.\n" +
 			"If you just opened Amfora then updates may appear incrementally. Reload the page to see them.\n\n" +
-			"=> about:manage-subscriptions Manage subscriptions\n\n"
-
-		// curDay represents what day of posts the loop is on.
-		// It only goes backwards in time.
-		// Its initial setting means:
-		// Only display posts older than 26 hours in the future, nothing further in the future.
-		//
-		// 26 hours was chosen because it is the largest timezone difference
-		// currently in the world. Posts may be dated in the future
-		// due to software bugs, where the local user's date is used, but
-		// the UTC timezone is specified. Gemfeed does this at the time of
-		// writing, but will not after #3 gets merged on its repo. Still,
-		// the older version will be used for a while.
-		curDay := toLocalDay(time.Now()).Add(26 * time.Hour)
-
-		for _, entry := range pe.Entries[start:end] { // From new to old
-			// Convert to local time, remove sub-day info
-			pub := toLocalDay(entry.Published)
-
-			if pub.Before(curDay) {
-				// This post is on a new day, add a day header
-				curDay = pub
-				rawPage += fmt.Sprintf("\n## %s\n\n", curDay.Format("Jan 02, 2006"))
-			}
-			if entry.Title == "" || entry.Title == "/" {
-				// Just put author/title
-				// Mainly used for when you're tracking the root domain of a site
-				rawPage += fmt.Sprintf("=>%s %s\n", entry.URL, entry.Prefix)
-			} else {
-				// Include title and dash
-				rawPage += fmt.Sprintf("=>%s %s - %s\n", entry.URL, entry.Prefix, entry.Title)
-			}
+			"=> about:manage-subscriptions Manage subscriptions\n"
+
+		if unread > 0 {
+			rawPage += fmt.Sprintf("=> about:subscriptions?open-unread Open unread entries in new tabs (%d)\n", unread)
 		}
+		showOrHide := "shows"
+		if showReadEntries {
+			showOrHide = "hides"
+		}
+		rawPage += "\n" + fmt.Sprintf(
+			"Unread entries are marked with %s. Select an entry and press %s to mark it as read or unread, "+
+				"or %s to mark its whole feed as read. %s goes to the next unread entry, and %s %s read entries.\n",
+			unreadMarker(), config.GetKeyBinding(config.CmdMarkRead), config.GetKeyBinding(config.CmdMarkFeedRead),
+			config.GetKeyBinding(config.CmdNextUnread), config.GetKeyBinding(config.CmdShowRead),
+			showOrHide,
+		)
 
-		if pageN == 0 && len(pe.Entries) > epp {
-			// First page, and there's more than can fit
-			rawPage += "\n\n=> about:subscriptions?2 Next Page\n"
-		} else if pageN > 0 {
-			// A later page
-			rawPage += fmt.Sprintf(
-				"\n\n=> about:subscriptions?%d Previous Page\n",
-				pageN, // pageN is zero-indexed but the query string is one-indexed
-			)
-			if end != len(pe.Entries) {
-				// There's more
-				rawPage += fmt.Sprintf("=> about:subscriptions?%d Next Page\n", pageN+2)
+		if grouped {
+			rawPage += groupedEntriesRaw(groupEntries(pe.Entries, groupBy), groupBy)
+		} else {
+			rawPage += entriesByDayRaw(pe.Entries[start:end])
+
+			if pageN == 0 && len(pe.Entries) > epp {
+				// First page, and there's more than can fit
+				rawPage += "\n\n=> about:subscriptions?2 Next Page\n"
+			} else if pageN > 0 {
+				// A later page
+				rawPage += fmt.Sprintf(
+					"\n\n=> about:subscriptions?%d Previous Page\n",
+					pageN, // pageN is zero-indexed but the query string is one-indexed
+				)
+				if end != len(pe.Entries) {
+					// There's more
+					rawPage += fmt.Sprintf("=> about:subscriptions?%d Next Page\n", pageN+2)
+				}
 			}
 		}
 	}
 
-	content, links := renderer.RenderGemini(rawPage, textWidth(), false)
+	content, links := renderer.RenderGemini(rawPage, textWidth(), false, renderer.ANSIEnabled(""), "")
 	page := structs.Page{
 		Raw:       rawPage,
 		Content:   content,
@@ -158,13 +195,194 @@ func Subscriptions(t *tab, u string) string {
 		TermWidth: termW,
 		Mediatype: structs.TextGemini,
 	}
-	go cache.AddPage(&page)
+	go func() {
+		defer RecoverCrash()
+		cache.AddPage(&page)
+	}()
 	setPage(t, &page)
 	t.applyBottomBar()
 
 	subscriptionPageUpdated[pageN] = time.Now()
 
-	return u
+	return u, true
+}
+
+// entryLine returns the link line for a subscription entry. The prefix,
+// like the feed title, is left out if withPrefix is false, and the day
+// the entry was published is added if withDate is true.
+func entryLine(entry *subscriptions.PageEntry, withPrefix, withDate bool) string {
+	text := ""
+	if !entry.Read {
+		text = unreadMarker() + " "
+	}
+	if withDate {
+		text += toLocalDay(entry.Published).Format("2006-01-02") + " "
+	}
+	switch {
+	case entry.Title == "" || entry.Title == "/":
+		// Just put author/title
+		// Mainly used for when you're tracking the root domain of a site
+		text += entry.Prefix
+	case withPrefix:
+		// Include title and dash
+		text += entry.Prefix + " - " + entry.Title
+	default:
+		text += entry.Title
+	}
+	return "=>" + entry.URL + " " + text + "\n"
+}
+
+// entriesByDayRaw returns the gemtext of the entries, with a heading for each day.
+func entriesByDayRaw(entries []*subscriptions.PageEntry) string {
+	var b strings.Builder
+
+	// curDay represents what day of posts the loop is on.
+	// It only goes backwards in time.
+	// Its initial setting means:
+	// Only display posts older than 26 hours in the future, nothing further in the future.
+	//
+	// 26 hours was chosen because it is the largest timezone difference
+	// currently in the world. Posts may be dated in the future
+	// due to software bugs, where the local user's date is used, but
+	// the UTC timezone is specified. Gemfeed does this at the time of
+	// writing, but will not after #3 gets merged on its repo. Still,
+	// the older version will be used for a while.
+	curDay := toLocalDay(time.Now()).Add(26 * time.Hour)
+
+	for _, entry := range entries { // From new to old
+		// Convert to local time, remove sub-day info
+		pub := toLocalDay(entry.Published)
+
+		if pub.Before(curDay) {
+			// This post is on a new day, add a day header
+			curDay = pub
+			fmt.Fprintf(&b, "\n## %s\n\n", curDay.Format("Jan 02, 2006"))
+		}
+		b.WriteString(entryLine(entry, true, false))
+	}
+	return b.String()
+}
+
+// subscriptionSection is a group of entries on the subscriptions page, for
+// a feed or a day. See "subscriptions.group_by".
+type subscriptionSection struct {
+	key     string // The feed URL, or the day
+	title   string
+	entries []*subscriptions.PageEntry
+	unread  int
+}
+
+// Whether sections of the subscriptions page were opened or closed, by key.
+// Sections that weren't are open if they have unread entries.
+var sectionOpen = make(map[string]bool)
+
+// isOpen returns whether the entries of the section are shown.
+func (s *subscriptionSection) isOpen() bool {
+	if open, ok := sectionOpen[s.key]; ok {
+		return open
+	}
+	return s.unread > 0
+}
+
+// groupEntries groups the entries by feed or by day, depending on groupBy.
+// The sections are in the order of their newest entry, like the entries.
+func groupEntries(entries []*subscriptions.PageEntry, groupBy string) []*subscriptionSection {
+	var sections []*subscriptionSection
+	byKey := make(map[string]*subscriptionSection)
+	for _, entry := range entries {
+		key := entry.Feed
+		title := entry.Prefix
+		if groupBy == "day" {
+			day := toLocalDay(entry.Published)
+			key = day.Format("2006-01-02")
+			title = day.Format("Jan 02, 2006")
+		}
+		s, ok := byKey[key]
+		if !ok {
+			s = &subscriptionSection{key: key, title: title}
+			byKey[key] = s
+			sections = append(sections, s)
+		}
+		s.entries = append(s.entries, entry)
+		if !entry.Read {
+			s.unread++
+		}
+	}
+	return sections
+}
+
+// groupedEntriesRaw returns the gemtext of the sections. Each one has a link
+// to show or hide its entries.
+func groupedEntriesRaw(sections []*subscriptionSection, groupBy string) string {
+	var b strings.Builder
+	for _, s := range sections {
+		count := "1 entry"
+		if len(s.entries) > 1 {
+			count = fmt.Sprintf("%d entries", len(s.entries))
+		}
+		if s.unread > 0 {
+			count += fmt.Sprintf(", %d unread", s.unread)
+		}
+		action := "Show"
+		if s.isOpen() {
+			action = "Hide"
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n=> about:subscriptions?toggle=%s %s %s\n",
+			s.title, gemini.QueryEscape(s.key), action, count)
+		if !s.isOpen() {
+			continue
+		}
+		for _, entry := range s.entries {
+			// Entries grouped by feed all have the same prefix
+			b.WriteString(entryLine(entry, groupBy == "day", groupBy == "feed"))
+		}
+	}
+	return b.String()
+}
+
+// toggleSection shows or hides the entries of the section with the key,
+// on the subscriptions page.
+func toggleSection(key string) {
+	groupBy := viper.GetString("subscriptions.group_by")
+	for _, s := range groupEntries(subscriptions.GetPageEntries().Entries, groupBy) {
+		if s.key == key {
+			sectionOpen[key] = !s.isOpen()
+		}
+	}
+	// The page has to be made again
+	subscriptionPageUpdated = make(map[int]time.Time)
+}
+
+// openUnread opens the unread subscription entries in new tabs in the
+// background, newest first. At most "subscriptions.open_unread_max" are opened.
+func openUnread() {
+	if singleTab {
+		Info("Entries can't be opened in new tabs, because single_tab is on.")
+		return
+	}
+	max := viper.GetInt("subscriptions.open_unread_max")
+	var urls []string
+	for _, entry := range subscriptions.GetPageEntries().Entries {
+		if entry.Read {
+			continue
+		}
+		if max > 0 && len(urls) >= max {
+			break
+		}
+		urls = append(urls, entry.URL)
+	}
+	if len(urls) == 0 {
+		Info("There are no unread entries.")
+		return
+	}
+	for _, u := range urls {
+		newBackgroundTab(u)
+	}
+	if len(urls) == 1 {
+		showNotice("Notice", "Opened 1 entry in a new tab")
+	} else {
+		showNotice("Notice", i18n.T("Opened %d entries in new tabs", len(urls)))
+	}
 }
 
 // ManageSubscriptions displays the subscription managing page in
@@ -191,7 +409,7 @@ func ManageSubscriptions(t *tab, u string) {
 		)
 	}
 
-	content, links := renderer.RenderGemini(rawPage, textWidth(), false)
+	content, links := renderer.RenderGemini(rawPage, textWidth(), false, renderer.ANSIEnabled(""), "")
 	page := structs.Page{
 		Raw:       rawPage,
 		Content:   content,
@@ -200,7 +418,10 @@ func ManageSubscriptions(t *tab, u string) {
 		TermWidth: termW,
 		Mediatype: structs.TextGemini,
 	}
-	go cache.AddPage(&page)
+	go func() {
+		defer RecoverCrash()
+		cache.AddPage(&page)
+	}()
 	setPage(t, &page)
 	t.applyBottomBar()
 }
@@ -219,7 +440,7 @@ func manageSubscriptionQuery(t *tab, u string) {
 		return
 	}
 	ManageSubscriptions(t, "about:manage-subscriptions") // Reload
-	Info("Unsubscribed from " + sub)
+	Info(i18n.T("Unsubscribed from %s", sub))
 }
 
 // openSubscriptionModal displays the "Add subscription" modal
@@ -301,6 +522,7 @@ func addFeedDirect(u string, feed *gofeed.Feed, tracked bool) bool {
 // addFeed goes through the process of subscribing to the current page/feed.
 // It is the high-level way of doing it. It should be called in a goroutine.
 func addSubscription() {
+	defer RecoverCrash()
 	t := tabs[curTab]
 	p := t.page
 
@@ -326,3 +548,120 @@ func addSubscription() {
 		}
 	}
 }
+
+// unreadMarker returns what unread entries are marked with.
+func unreadMarker() string {
+	if config.ScreenReader {
+		return "*"
+	}
+	return "•"
+}
+
+// onSubscriptionsPage returns true if the tab is showing the subscriptions page.
+func onSubscriptionsPage(t *tab) bool {
+	return t.page.URL == "about:subscriptions" || strings.HasPrefix(t.page.URL, "about:subscriptions?")
+}
+
+// selectedEntry returns the subscription entry of the selected link.
+func selectedEntry(t *tab) (*subscriptions.PageEntry, bool) {
+	if t.page.Mode != structs.ModeLinkSelect {
+		return nil, false
+	}
+	for _, entry := range subscriptions.GetPageEntries().Entries {
+		if entry.URL == t.page.Selected {
+			return entry, true
+		}
+	}
+	return nil, false
+}
+
+// reloadSubscriptions displays the subscriptions page on the tab again, after
+// entries changed. The scroll position is kept, and so is the selected link,
+// or the one that took its place if it's gone.
+func reloadSubscriptions(t *tab) {
+	row, _ := t.view.GetScrollOffset()
+	selected := t.page.Selected
+	selectedID, _ := strconv.Atoi(t.page.SelectedID)
+	wasSelecting := t.page.Mode == structs.ModeLinkSelect
+
+	Subscriptions(t, t.page.URL)
+	t.page.Row = row
+	t.applyScroll()
+	if !wasSelecting || len(t.page.Links) == 0 {
+		return
+	}
+	for i, link := range t.page.Links {
+		if link == selected {
+			t.selectLink(i)
+			return
+		}
+	}
+	if selectedID >= len(t.page.Links) {
+		selectedID = len(t.page.Links) - 1
+	}
+	t.selectLink(selectedID)
+}
+
+// markEntryRead marks the selected entry on the subscriptions page as read,
+// or as unread if it was read. If wholeFeed is true, all the entries of its
+// feed are marked as read instead.
+func markEntryRead(t *tab, wholeFeed bool) {
+	if !onSubscriptionsPage(t) {
+		return
+	}
+	entry, ok := selectedEntry(t)
+	if !ok {
+		showNotice("Notice", "Select an entry first, with Tab")
+		return
+	}
+	var err error
+	switch {
+	case wholeFeed:
+		err = subscriptions.MarkFeedRead(entry.Feed)
+	case entry.Read:
+		err = subscriptions.MarkUnread(entry.URL)
+	default:
+		err = subscriptions.MarkRead(entry.URL)
+	}
+	if err != nil {
+		Error("Save Error", "Error saving the change to disk: "+err.Error())
+	}
+	reloadSubscriptions(t)
+}
+
+// toggleShowRead shows or hides the read entries on the subscriptions page.
+func toggleShowRead(t *tab) {
+	if !onSubscriptionsPage(t) {
+		return
+	}
+	showReadEntries = !showReadEntries
+	// The pages have different entries now
+	subscriptionPageUpdated = make(map[int]time.Time)
+	Subscriptions(t, "about:subscriptions")
+}
+
+// nextUnread selects the next unread entry on the subscriptions page, after
+// the selected link.
+func nextUnread(t *tab) {
+	if !onSubscriptionsPage(t) {
+		return
+	}
+	unread := make(map[string]bool)
+	for _, entry := range subscriptions.GetPageEntries().Entries {
+		if !entry.Read {
+			unread[entry.URL] = true
+		}
+	}
+	start := 0
+	if t.page.Mode == structs.ModeLinkSelect {
+		start, _ = strconv.Atoi(t.page.SelectedID)
+		start++
+	}
+	for i := start; i < len(t.page.Links); i++ {
+		if unread[t.page.Links[i]] {
+			t.selectLink(i)
+			return
+		}
+	}
+	showNotice("Notice", "There are no more unread entries on this page")
+}
@@ -0,0 +1,40 @@
+package display
+
+import (
+	"testing"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/subscriptions"
+)
+
+func TestGroupedEntriesRaw(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2021, 3, d, 12, 0, 0, 0, time.Local) }
+	entries := []*subscriptions.PageEntry{
+		{Prefix: "Gemlog", Title: "Third", URL: "gemini://a/3.gmi", Published: day(3), Feed: "gemini://a/feed"},
+		{Prefix: "Other", Title: "News", URL: "gemini://b/1.gmi", Published: day(3), Feed: "gemini://b/", Read: true},
+		{Prefix: "Gemlog", Title: "Second", URL: "gemini://a/2.gmi", Published: day(2), Feed: "gemini://a/feed", Read: true},
+	}
+
+	byFeed := "\n## Gemlog\n\n" +
+		"=> about:subscriptions?toggle=gemini:%2F%2Fa%2Ffeed Hide 2 entries, 1 unread\n" +
+		"=>gemini://a/3.gmi • 2021-03-03 Third\n" +
+		"=>gemini://a/2.gmi 2021-03-02 Second\n" +
+		"\n## Other\n\n" +
+		"=> about:subscriptions?toggle=gemini:%2F%2Fb%2F Show 1 entry\n"
+	if got := groupedEntriesRaw(groupEntries(entries, "feed"), "feed"); got != byFeed {
+		t.Errorf("By feed: got %q, want %q", got, byFeed)
+	}
+
+	sectionOpen["2021-03-02"] = true
+	defer delete(sectionOpen, "2021-03-02")
+	byDay := "\n## Mar 03, 2021\n\n" +
+		"=> about:subscriptions?toggle=2021-03-03 Hide 2 entries, 1 unread\n" +
+		"=>gemini://a/3.gmi • Gemlog - Third\n" +
+		"=>gemini://b/1.gmi Other - News\n" +
+		"\n## Mar 02, 2021\n\n" +
+		"=> about:subscriptions?toggle=2021-03-02 Hide 1 entry\n" +
+		"=>gemini://a/2.gmi Gemlog - Second\n"
+	if got := groupedEntriesRaw(groupEntries(entries, "day"), "day"); got != byDay {
+		t.Errorf("By day: got %q, want %q", got, byDay)
+	}
+}
@@ -1,16 +1,19 @@
 package display
 
 import (
-	"fmt"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 
 	"code.rocketnine.space/tslocum/cview"
 	"github.com/atotto/clipboard"
 	"github.com/gdamore/tcell/v2"
+	"github.com/makeworld-the-better-one/amfora/client"
 	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/i18n"
 	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/spf13/viper"
 )
 
 type tabMode int
@@ -27,12 +30,25 @@ type tabHistory struct {
 
 // tab hold the information needed for each browser tab.
 type tab struct {
-	page     *structs.Page
-	view     *cview.TextView
-	history  *tabHistory
-	mode     tabMode
-	barLabel string // The bottomBar label for the tab
-	barText  string // The bottomBar text for the tab
+	page      *structs.Page
+	view      *cview.TextView
+	history   *tabHistory
+	mode      tabMode
+	barLabel  string          // The bottomBar label for the tab
+	barText   string          // The bottomBar text for the tab
+	loadID    uint64          // Changed for every page load, see handleURL
+	redirects []string        // URLs that redirected to the page being loaded
+	fromCache bool            // Whether the current page was loaded from the cache
+	restore   *scrollRestore  // The saved scroll position applied to the page being loaded, if any
+	previous  *structs.Page   // The version of the page from before it was reloaded, for showDiff
+	diff      *structs.Page   // The last diff shown, for about:diff
+	stopLoad  func()          // Stops the page that's loading, see handleURL
+	partial   bool            // Whether the page is shown while it's still loading, see handleURL
+	stopped   bool            // Whether the page stopped loading early, so it's not in the history
+	private   bool            // Whether it's a private tab, see NewPrivateTab
+	cert      *client.TabCert // Sent instead of the certificates for hosts, see tabIdentity
+	jumps     jumpList        // See jump.go
+	marks     pageMarks       // See marks.go
 }
 
 // makeNewTab initializes an tab struct with no content.
@@ -95,10 +111,7 @@ func makeNewTab() *tab {
 
 			tabs[tab].view.Highlight("0")
 			tabs[tab].scrollToHighlight()
-			// Display link URL in bottomBar
-			bottomBar.SetLabel("[::b]Link: [::-]")
-			bottomBar.SetText(tabs[tab].page.Links[0])
-			tabs[tab].saveBottomBar()
+			tabs[tab].showLinkDestination(tabs[tab].page.Links[0])
 			tabs[tab].page.Selected = tabs[tab].page.Links[0]
 			tabs[tab].page.SelectedID = "0"
 		}
@@ -116,10 +129,7 @@ func makeNewTab() *tab {
 			}
 			tabs[tab].view.Highlight(strconv.Itoa(index))
 			tabs[tab].scrollToHighlight()
-			// Display link URL in bottomBar
-			bottomBar.SetLabel("[::b]Link: [::-]")
-			bottomBar.SetText(tabs[tab].page.Links[index])
-			tabs[tab].saveBottomBar()
+			tabs[tab].showLinkDestination(tabs[tab].page.Links[index])
 			tabs[tab].page.Selected = tabs[tab].page.Links[index]
 			tabs[tab].page.SelectedID = strconv.Itoa(index)
 		}
@@ -129,12 +139,18 @@ func makeNewTab() *tab {
 		// This was also touched by #222
 		// This also captures any tab-specific events now
 
-		if t.mode != tabModeDone {
-			// Any events that should be caught when the tab is loading is handled in display.go
+		cmd := config.TranslateKeyEvent(event)
+		if t.mode != tabModeDone && !(t.partial && isScrollKey(cmd, event)) {
+			// Any events that should be caught when the tab is loading is handled in display.go.
+			// A page that's shown while it loads can only be scrolled.
 			return nil
 		}
-
-		cmd := config.TranslateKeyEvent(event)
+		if cmd == config.CmdInvalid {
+			if name, ok := config.TranslatePluginKeyEvent(event); ok {
+				go runPluginCommand(&t, name)
+				return nil
+			}
+		}
 
 		// Cmds that aren't single row/column scrolling
 		//nolint:exhaustive
@@ -156,9 +172,9 @@ func makeNewTab() *tab {
 			if t.hasContent() {
 				savePath, err := downloadPage(t.page)
 				if err != nil {
-					Error("Download Error", fmt.Sprintf("Error saving page content: %v", err))
+					Error("Download Error", i18n.T("Error saving page content: %v", err))
 				} else {
-					Info(fmt.Sprintf("Page content saved to %s. ", savePath))
+					Info(i18n.T("Page content saved to %s. ", savePath))
 				}
 			} else {
 				Info("The current page has no content, so it couldn't be downloaded.")
@@ -174,6 +190,85 @@ func makeNewTab() *tab {
 			Subscriptions(&t, "about:subscriptions")
 			tabs[curTab].addToHistory("about:subscriptions")
 			return nil
+		case config.CmdPreviewImage:
+			go previewImage(&t)
+			return nil
+		case config.CmdPeek:
+			go peek(&t)
+			return nil
+		case config.CmdFollowInPane:
+			followInOtherPane(&t)
+			return nil
+		case config.CmdPager:
+			go openInPager(&t)
+			return nil
+		case config.CmdPreBlock:
+			go preBlockActions(&t)
+			return nil
+		case config.CmdPipe:
+			go pipePage(&t)
+			return nil
+		case config.CmdBlockHost:
+			go blockHost(&t)
+			return nil
+		case config.CmdCertInfo:
+			certInfo(&t)
+			return nil
+		case config.CmdViewAs:
+			go viewAs(&t)
+			return nil
+		case config.CmdPageInfo:
+			pageInfo(&t)
+			return nil
+		case config.CmdCopyHeading:
+			copyHeadingURL(&t)
+			return nil
+		case config.CmdWrapPre:
+			toggleWrapPre(&t)
+			return nil
+		case config.CmdFollow:
+			startFollow(&t)
+			return nil
+		case config.CmdFollowText:
+			startFollowText(&t)
+			return nil
+		case config.CmdMarkRead, config.CmdMarkFeedRead:
+			if cmd == config.CmdMarkRead && !onSubscriptionsPage(&t) {
+				chooseMark(&t, markSet)
+				return nil
+			}
+			markEntryRead(&t, cmd == config.CmdMarkFeedRead)
+			return nil
+		case config.CmdGoToMark:
+			chooseMark(&t, markGo)
+			return nil
+		case config.CmdShowRead:
+			toggleShowRead(&t)
+			return nil
+		case config.CmdNextUnread:
+			nextUnread(&t)
+			return nil
+		case config.CmdNextPage, config.CmdPrevPage:
+			followPagination(&t, cmd == config.CmdNextPage)
+			return nil
+		case config.CmdParent, config.CmdRoot:
+			if next, ok := parentURL(t.page.URL, cmd == config.CmdRoot); ok {
+				URL(next)
+			}
+			return nil
+		case config.CmdReadLater:
+			readLater(&t)
+			return nil
+		case config.CmdDiff:
+			showDiff(&t)
+			return nil
+		case config.CmdArchive:
+			archivePage(&t)
+			return nil
+		case config.CmdReadingList:
+			ReadingList(&t, "about:reading")
+			t.addToHistory("about:reading")
+			return nil
 		case config.CmdCopyPageURL:
 			currentURL := tabs[curTab].page.URL
 			err := clipboard.WriteAll(currentURL)
@@ -266,6 +361,7 @@ func makeNewTab() *tab {
 			}
 			return event
 		} else if cmd == config.CmdBeginning {
+			t.addJump()
 			t.page.Row = 0
 			// This is required because cview will also set the column (incorrectly)
 			// if it handles this event itself
@@ -273,6 +369,7 @@ func makeNewTab() *tab {
 			App.Draw()
 			return nil
 		} else if cmd == config.CmdEnd {
+			t.addJump()
 			t.page.Row = height
 			t.applyScroll()
 			App.Draw()
@@ -290,6 +387,24 @@ func makeNewTab() *tab {
 	return &t
 }
 
+// isScrollKey returns true if the key only scrolls the page.
+func isScrollKey(cmd config.Command, event *tcell.EventKey) bool {
+	//nolint:exhaustive
+	switch cmd {
+	case config.CmdMoveUp, config.CmdMoveDown, config.CmdMoveLeft, config.CmdMoveRight,
+		config.CmdPgup, config.CmdPgdn, config.CmdBeginning, config.CmdEnd:
+		return true
+	}
+	if event.Modifiers() != tcell.ModNone {
+		return false
+	}
+	switch event.Key() {
+	case tcell.KeyUp, tcell.KeyDown, tcell.KeyLeft, tcell.KeyRight:
+		return true
+	}
+	return false
+}
+
 // addToHistory adds the given URL to history.
 // It assumes the URL is currently being loaded and displayed on the page.
 func (t *tab) addToHistory(u string) {
@@ -357,7 +472,7 @@ func (t *tab) applyHorizontalScroll() {
 		// Scrolled to the right far enough that no left margin is needed
 		browser.AddTab(
 			strconv.Itoa(i),
-			makeTabLabel(strconv.Itoa(i+1)),
+			tabLabel(i),
 			makeContentLayout(t.view, 0),
 		)
 		t.view.ScrollTo(t.page.Row, t.page.Column-leftMargin())
@@ -365,7 +480,7 @@ func (t *tab) applyHorizontalScroll() {
 		// Left margin is still needed, but is not necessarily at the right size by default
 		browser.AddTab(
 			strconv.Itoa(i),
-			makeTabLabel(strconv.Itoa(i+1)),
+			tabLabel(i),
 			makeContentLayout(t.view, leftMargin()-t.page.Column),
 		)
 	}
@@ -411,6 +526,40 @@ func (t *tab) scrollToHighlight() {
 	t.scrollTo(t.view.GetScrollOffset())
 }
 
+// linkDestination returns what to show in the bottomBar for a selected link
+// on the page, as set by "a-general.link_destination". It returns false if
+// the bottomBar shouldn't change.
+func linkDestination(pageURL, link string) (string, bool) {
+	switch viper.GetString("a-general.link_destination") {
+	case "off":
+		return "", false
+	case "raw":
+		return link, true
+	}
+	// The full URL, so relative links can be checked too
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return link, true
+	}
+	parsed, err := base.Parse(link)
+	if err != nil {
+		return link, true
+	}
+	return parsed.String(), true
+}
+
+// showLinkDestination displays where the selected link goes in the bottomBar,
+// and saves it in the tab.
+func (t *tab) showLinkDestination(link string) {
+	dest, ok := linkDestination(t.page.URL, link)
+	if !ok {
+		return
+	}
+	bottomBar.SetLabel("[::b]Link: [::-]")
+	bottomBar.SetText(dest)
+	t.saveBottomBar()
+}
+
 // saveBottomBar saves the current bottomBar values in the tab.
 func (t *tab) saveBottomBar() {
 	t.barLabel = bottomBar.GetLabel()
@@ -421,6 +570,62 @@ func (t *tab) saveBottomBar() {
 func (t *tab) applyBottomBar() {
 	bottomBar.SetLabel(t.barLabel)
 	bottomBar.SetText(t.barText)
+	if t.mode == tabModeDone && t.barLabel == "" && !bottomBar.HasFocus() {
+		if label, text := takeNotice(); text != "" {
+			bottomBar.SetLabel("[::b]" + label + ": [::-]")
+			bottomBar.SetText(text)
+		}
+	}
+	updateIndicator(t)
+}
+
+// notice is shown in the bottom bar instead of the URL, the next time a tab
+// that's done loading has its bar applied. See showNotice.
+//
+// It's only set in the UI goroutine, but bars are applied from others too,
+// so it's also locked.
+var notice struct {
+	sync.Mutex
+	label string
+	text  string
+}
+
+// setNotice sets the notice. It should be called in the UI goroutine.
+func setNotice(label, msg string) {
+	notice.Lock()
+	notice.label = label
+	notice.text = msg
+	notice.Unlock()
+}
+
+// takeNotice returns the notice, and clears it so it's only shown once.
+func takeNotice() (string, string) {
+	notice.Lock()
+	defer notice.Unlock()
+	label, text := notice.label, notice.text
+	notice.label = ""
+	notice.text = ""
+	return label, text
+}
+
+// showNotice shows a message in the bottom bar, once the current tab is done
+// loading. It stays until the bar is changed, like by loading another page.
+func showNotice(label, msg string) {
+	App.QueueUpdateDraw(func() {
+		setNotice(label, msg)
+		tabs[curTab].applyBottomBar()
+		flashChrome()
+	})
+}
+
+// selectLink selects the link with the index, like Tab does, and scrolls to it.
+func (t *tab) selectLink(i int) {
+	t.page.Mode = structs.ModeLinkSelect
+	t.page.Selected = t.page.Links[i]
+	t.page.SelectedID = strconv.Itoa(i)
+	t.view.Highlight(t.page.SelectedID)
+	t.scrollToHighlight()
+	t.showLinkDestination(t.page.Selected)
 }
 
 // clearSelected turns off any selection that was going on.
@@ -449,8 +654,10 @@ func (t *tab) applySelected() {
 
 		if t.mode == tabModeDone {
 			// Page is not loading so bottomBar can change
-			t.barLabel = "[::b]Link: [::-]"
-			t.barText = t.page.Selected
+			if dest, ok := linkDestination(t.page.URL, t.page.Selected); ok {
+				t.barLabel = "[::b]Link: [::-]"
+				t.barText = dest
+			}
 		}
 	}
 }
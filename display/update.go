@@ -0,0 +1,192 @@
+package display
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/client"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/i18n"
+	"github.com/makeworld-the-better-one/amfora/logger"
+	"github.com/makeworld-the-better-one/go-gemini"
+	"github.com/spf13/viper"
+)
+
+// Amfora can check a version file over Gemini for new releases, at most
+// once a day. It's set by "a-general.update_check", and off by default.
+
+// Where the changelog is, if the version file doesn't link to one
+const defaultChangelogURL = "https://github.com/makeworld-the-better-one/amfora/blob/master/CHANGELOG.md"
+
+// How often the version file is fetched
+const updateCheckInterval = 24 * time.Hour
+
+// updateState is saved to config.UpdateCheckPath between runs.
+type updateState struct {
+	Checked   time.Time `json:"checked"`
+	Latest    string    `json:"latest"`
+	Changelog string    `json:"changelog"`
+	Dismissed string    `json:"dismissed"` // The newest version the user doesn't want to hear about
+}
+
+var versionRe = regexp.MustCompile(`v?(\d+)\.(\d+)\.(\d+)`)
+
+// parseVersion returns the numbers of a version like "v1.8.0".
+func parseVersion(v string) ([3]int, bool) {
+	var nums [3]int
+	m := versionRe.FindStringSubmatch(v)
+	if m == nil {
+		return nums, false
+	}
+	for i := range nums {
+		nums[i], _ = strconv.Atoi(m[i+1])
+	}
+	return nums, true
+}
+
+// newerVersion returns true if version a is newer than b. Versions that
+// can't be parsed are never newer.
+func newerVersion(a, b string) bool {
+	va, ok := parseVersion(a)
+	if !ok {
+		return false
+	}
+	vb, ok := parseVersion(b)
+	if !ok {
+		return false
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			return va[i] > vb[i]
+		}
+	}
+	return false
+}
+
+// parseVersionFile returns the version and changelog URL in a version file.
+// The version is the first one on any line, and the changelog is the first
+// link, either a gemtext link line or a line that's just a URL.
+func parseVersionFile(r io.Reader) (version, changelog string) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "=>") {
+			if fields := strings.Fields(line[2:]); len(fields) > 0 && changelog == "" {
+				changelog = fields[0]
+			}
+			continue
+		}
+		if strings.Contains(line, "://") && !strings.ContainsAny(line, " \t") {
+			if changelog == "" {
+				changelog = line
+			}
+			continue
+		}
+		if m := versionRe.FindString(line); m != "" && version == "" {
+			version = m
+		}
+	}
+	return version, changelog
+}
+
+func readUpdateState() updateState {
+	var s updateState
+	data, err := ioutil.ReadFile(config.UpdateCheckPath)
+	if err == nil {
+		json.Unmarshal(data, &s) //nolint:errcheck
+	}
+	return s
+}
+
+func writeUpdateState(s updateState) {
+	data, err := json.Marshal(s)
+	if err == nil {
+		err = ioutil.WriteFile(config.UpdateCheckPath, data, 0600)
+	}
+	if err != nil {
+		logger.Warnf("Couldn't save the update check: %v", err)
+	}
+}
+
+// fetchVersionFile fetches the version file, and returns the version and
+// changelog URL in it.
+func fetchVersionFile(u string) (string, string, error) {
+	res, err := client.Fetch(u)
+	if err != nil {
+		return "", "", err
+	}
+	defer res.Body.Close()
+	if res.Status != gemini.StatusSuccess {
+		return "", "", fmt.Errorf("status %d %s", res.Status, res.Meta)
+	}
+	version, changelog := parseVersionFile(io.LimitReader(res.Body, 4096))
+	if version == "" {
+		return "", "", errors.New("there's no version in it")
+	}
+	if changelog != "" {
+		// It can be relative to the version file
+		if base, err := url.Parse(u); err == nil {
+			if abs, err := base.Parse(changelog); err == nil {
+				changelog = abs.String()
+			}
+		}
+	}
+	return version, changelog, nil
+}
+
+// CheckForUpdate fetches the version file from the config, if it wasn't
+// fetched in the last day, and asks to see the changelog if there's a release
+// newer than the running version. Each release is only shown until it's
+// dismissed.
+//
+// It should be called in a goroutine.
+func CheckForUpdate(version string) {
+	defer RecoverCrash()
+	u := viper.GetString("a-general.update_check")
+	if u == "" {
+		return
+	}
+
+	s := readUpdateState()
+	if time.Since(s.Checked) > updateCheckInterval {
+		latest, changelog, err := fetchVersionFile(u)
+		if err != nil {
+			logger.Warnf("Couldn't check for a new release at %s: %v", u, err)
+			return
+		}
+		s.Checked = time.Now()
+		s.Latest = latest
+		s.Changelog = changelog
+		writeUpdateState(s)
+	}
+
+	if !newerVersion(s.Latest, version) {
+		return
+	}
+	if s.Dismissed != "" && !newerVersion(s.Latest, s.Dismissed) {
+		return
+	}
+	changelog := s.Changelog
+	if changelog == "" {
+		changelog = defaultChangelogURL
+	}
+	switch Choice(i18n.T("Amfora %s is out, this is %s.", escapeMeta(s.Latest), escapeMeta(version)),
+		[]string{"Changelog", "Later", "Dismiss"}) {
+	case "Changelog":
+		App.QueueUpdateDraw(func() {
+			openInNewTab(changelog)
+		})
+	case "Dismiss":
+		s.Dismissed = s.Latest
+		writeUpdateState(s)
+	}
+}
@@ -0,0 +1,51 @@
+package display
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewerVersion(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"v1.9.0", "v1.8.0", true},
+		{"v1.8.1", "v1.8.0", true},
+		{"v2.0.0", "v1.10.3", true},
+		{"v1.10.0", "v1.9.0", true},
+		{"v1.8.0", "v1.8.0", false},
+		{"v1.7.9", "v1.8.0", false},
+		{"1.9.0", "v1.8.0", true},
+		{"v1.9.0", "unknown", false},
+		{"", "v1.8.0", false},
+	}
+	for _, tt := range tests {
+		if got := newerVersion(tt.a, tt.b); got != tt.want {
+			t.Errorf("newerVersion(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseVersionFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		version   string
+		changelog string
+	}{
+		{"plain", "v1.9.0\n", "v1.9.0", ""},
+		{"gemtext", "# Amfora\n\nLatest: v1.9.0\n=> gemini://example.com/changelog.gmi Changelog\n",
+			"v1.9.0", "gemini://example.com/changelog.gmi"},
+		{"url line", "1.9.0\nhttps://example.com/changes\n", "1.9.0", "https://example.com/changes"},
+		{"empty", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, changelog := parseVersionFile(strings.NewReader(tt.file))
+			if version != tt.version || changelog != tt.changelog {
+				t.Errorf("parseVersionFile() = %q, %q, want %q, %q", version, changelog, tt.version, tt.changelog)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package display
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/renderer"
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// Gemtext files in config.AboutDir are shown as about pages, like work.gmi
+// for about:work. Like newtab.gmi, they're read each time they're opened, so
+// changes show up right away.
+
+// userAboutName returns the name of the user's about page for the URL, which
+// is what's after "about:", without any query. The name can only be for a file
+// directly in config.AboutDir.
+func userAboutName(u string) (string, bool) {
+	name := strings.TrimPrefix(u, "about:")
+	if i := strings.IndexAny(name, "?#"); i != -1 {
+		name = name[:i]
+	}
+	if name == "" || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\:`) {
+		return "", false
+	}
+	return name, true
+}
+
+// userAboutPages returns the names of the user's about pages, sorted.
+func userAboutPages() []string {
+	files, err := ioutil.ReadDir(config.AboutDir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, f := range files {
+		name := strings.TrimSuffix(f.Name(), ".gmi")
+		if !f.Mode().IsRegular() || name == f.Name() {
+			continue
+		}
+		if _, ok := userAboutName(name); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// userAboutLink returns the about URL for a relative link on one of the
+// user's about pages, if it's to another one of them. Links like "work.gmi"
+// and "work" both go to about:work.
+func userAboutLink(link string) (string, bool) {
+	if strings.Contains(link, ":") {
+		// Not relative
+		return "", false
+	}
+	name, ok := userAboutName(strings.TrimPrefix(link, "./"))
+	if !ok {
+		return "", false
+	}
+	name = strings.TrimSuffix(name, ".gmi")
+	if _, err := os.Stat(filepath.Join(config.AboutDir, name+".gmi")); err != nil {
+		return "", false
+	}
+	return "about:" + name, true
+}
+
+// UserAbout displays the user's about page for the URL on the tab. It returns
+// false if there isn't one.
+func UserAbout(t *tab, u string) bool {
+	name, ok := userAboutName(u)
+	if !ok {
+		return false
+	}
+	data, err := ioutil.ReadFile(filepath.Join(config.AboutDir, name+".gmi"))
+	if err != nil {
+		return false
+	}
+	raw := string(data)
+	content, links := renderer.RenderGemini(raw, textWidth(), false, renderer.ANSIEnabled(""), "")
+	page := structs.Page{
+		Raw:       raw,
+		Content:   content,
+		Links:     links,
+		URL:       u,
+		TermWidth: termW,
+		Mediatype: structs.TextGemini,
+	}
+	setPage(t, &page)
+	t.applyBottomBar()
+	return true
+}
@@ -0,0 +1,24 @@
+package display
+
+import "testing"
+
+func TestUserAboutName(t *testing.T) {
+	tests := []struct {
+		u    string
+		name string
+		ok   bool
+	}{
+		{"about:work", "work", true},
+		{"about:work?2#top", "work", true},
+		{"about:", "", false},
+		{"about:../config", "", false},
+		{"about:.hidden", "", false},
+		{`about:a\b`, "", false},
+	}
+	for _, tt := range tests {
+		name, ok := userAboutName(tt.u)
+		if name != tt.name || ok != tt.ok {
+			t.Errorf("userAboutName(%q) = %q, %v, want %q, %v", tt.u, name, ok, tt.name, tt.ok)
+		}
+	}
+}
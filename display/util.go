@@ -3,7 +3,9 @@ package display
 import (
 	"errors"
 	"net/url"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"code.rocketnine.space/tslocum/cview"
 	"github.com/makeworld-the-better-one/go-gemini"
@@ -40,6 +42,15 @@ func makeTabLabel(s string) string {
 	return " " + s + " "
 }
 
+// tabLabel returns the label of the tab at the index. Private tabs have
+// their number in parentheses.
+func tabLabel(i int) string {
+	if i >= 0 && i < len(tabs) && tabs[i].private {
+		return makeTabLabel("(" + strconv.Itoa(i+1) + ")")
+	}
+	return makeTabLabel(strconv.Itoa(i + 1))
+}
+
 // tabNumber gets the index of the tab in the tabs slice. It returns -1
 // if the tab is not in that slice.
 func tabNumber(t *tab) int {
@@ -62,6 +73,74 @@ func isValidTab(t *tab) bool {
 	return tabNumber(t) != -1
 }
 
+// textOffset returns the number of letters and digits in the lines of the
+// text before the provided row. Unlike a row, it stays the same when the text
+// is wrapped to a different width, see rowAtOffset. Other characters aren't
+// counted, because wrapping adds some, like hyphens and the quote marks at the
+// start of wrapped lines.
+func textOffset(text string, row int) int {
+	n := 0
+	for i, line := range strings.Split(text, "\n") {
+		if i >= row {
+			break
+		}
+		n += offsetChars(line)
+	}
+	return n
+}
+
+// rowAtOffset returns the row of the text that contains the character at the
+// provided offset, as returned by textOffset.
+func rowAtOffset(text string, offset int) int {
+	lines := strings.Split(text, "\n")
+	n := 0
+	for i, line := range lines {
+		n += offsetChars(line)
+		if n > offset {
+			return i
+		}
+	}
+	return len(lines) - 1
+}
+
+// offsetChars returns the number of characters in the line counted by textOffset.
+func offsetChars(line string) int {
+	n := 0
+	for _, r := range line {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// parentURL returns the URL of the directory above the page at u, or of the
+// root of its host if root is true. It returns false if there's nowhere to go
+// up to, because u is already at the root or doesn't have a host.
+func parentURL(u string, root bool) (string, bool) {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Host == "" {
+		return "", false
+	}
+	p := "/"
+	if !root {
+		// Both /dir/page.gmi and /dir/sub/ go up to /dir/
+		trimmed := strings.TrimSuffix(parsed.Path, "/")
+		if i := strings.LastIndex(trimmed, "/"); i >= 0 {
+			p = trimmed[:i+1]
+		}
+	}
+	if p == parsed.Path && parsed.RawQuery == "" {
+		return "", false
+	}
+	parsed.Path = p
+	parsed.RawPath = ""
+	parsed.RawQuery = ""
+	parsed.ForceQuery = false
+	parsed.Fragment = ""
+	return parsed.String(), true
+}
+
 func leftMargin() int {
 	return int(float64(termW) * viper.GetFloat64("a-general.left_margin"))
 }
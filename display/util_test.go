@@ -42,3 +42,56 @@ func TestNormalizeURL(t *testing.T) {
 		}
 	}
 }
+
+func TestRowAtOffset(t *testing.T) {
+	narrow := "one two\nthree four\n\nfive six\nseven"
+	wide := "one two three four\n\nfive six seven"
+
+	for row, expected := range []int{0, 0, 2, 2, 2} {
+		actual := rowAtOffset(wide, textOffset(narrow, row))
+		if actual != expected {
+			t.Errorf("rowAtOffset for row %d: expected %d, actual %d", row, expected, actual)
+		}
+	}
+}
+
+func TestRowAtOffsetHyphenated(t *testing.T) {
+	// The same text, with words hyphenated at different places
+	narrow := "> A hyphen-\n> ated para-\n> graph, and\n> an-\n> other\n\nThe end"
+	wide := "> A hyphenated para-\n> graph, and another\n\nThe end"
+
+	for row, expected := range []int{0, 0, 1, 1, 1, 3, 3} {
+		actual := rowAtOffset(wide, textOffset(narrow, row))
+		if actual != expected {
+			t.Errorf("rowAtOffset for row %d: expected %d, actual %d", row, expected, actual)
+		}
+	}
+}
+
+var parentURLTests = []struct {
+	u        string
+	root     bool
+	expected string
+}{
+	{"gemini://example.com/dir/page.gmi", false, "gemini://example.com/dir/"},
+	{"gemini://example.com/dir/sub/", false, "gemini://example.com/dir/"},
+	{"gemini://example.com/dir/", false, "gemini://example.com/"},
+	{"gemini://example.com/page.gmi?query#frag", false, "gemini://example.com/"},
+	{"gemini://example.com/?query", false, "gemini://example.com/"},
+	{"gemini://example.com", false, "gemini://example.com/"},
+	{"gemini://example.com/", false, ""},
+	{"gemini://example.com/dir/sub/page.gmi", true, "gemini://example.com/"},
+	{"gemini://example.com:1966/~user/", true, "gemini://example.com:1966/"},
+	{"gemini://example.com/", true, ""},
+	{"about:bookmarks", false, ""},
+	{"about:bookmarks", true, ""},
+}
+
+func TestParentURL(t *testing.T) {
+	for _, tt := range parentURLTests {
+		actual, ok := parentURL(tt.u, tt.root)
+		if ok != (tt.expected != "") || actual != tt.expected {
+			t.Errorf("parentURL(%s, %v): expected %q, actual %q", tt.u, tt.root, tt.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,472 @@
+package display
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/atotto/clipboard"
+	"github.com/gdamore/tcell/v2"
+)
+
+// The vi editing mode of the bottom bar, used if "a-general.editing_mode" is
+// "vi". The bar starts in insert mode, where keys are typed as usual, and Esc
+// switches to normal mode for the commands and motions of vi. Commands can have
+// a count and a register, like `"a2dw`. The + and * registers are the system
+// clipboard.
+
+// What happened to a key in vi mode
+const (
+	viPass   = iota // It wasn't used, and should be handled as usual
+	viDone          // It was used
+	viOlder         // Show the line before in the history
+	viNewer         // Show the line after in the history
+	viCancel        // Leave the bottom bar
+)
+
+type viState struct {
+	normal    bool
+	pending   []rune // The keys of a command that isn't complete yet, like `"a` or "d2"
+	registers map[rune]string
+	undoText  string
+	undoPos   int
+	canUndo   bool
+}
+
+// The vi mode of the bottom bar
+var barVi viState
+
+// reset goes back to insert mode, for the next time the bar is used.
+func (v *viState) reset() {
+	v.normal = false
+	v.pending = nil
+	v.canUndo = false
+}
+
+// viCommand is a parsed command in normal mode.
+type viCommand struct {
+	register rune
+	count    int
+	op       rune // 'd', 'c', or 'y', or 0 for other commands
+	key      rune // The motion of the operator, or the command
+	arg      rune // The character for f, F, t, T, and r
+}
+
+// The motions that take a character after them
+func viNeedsArg(r rune) bool {
+	return r == 'f' || r == 'F' || r == 't' || r == 'T'
+}
+
+// parseViCommand parses the keys of a command. It returns false if they're
+// not a command, and a nil command if more keys are needed.
+func parseViCommand(keys []rune) (*viCommand, bool) {
+	c := &viCommand{count: 1}
+	i := 0
+	next := func() (rune, bool) {
+		if i >= len(keys) {
+			return 0, false
+		}
+		i++
+		return keys[i-1], true
+	}
+	count := func() int {
+		n := 0
+		for i < len(keys) && unicode.IsDigit(keys[i]) && (n > 0 || keys[i] != '0') {
+			n = n*10 + int(keys[i]-'0')
+			i++
+		}
+		if n == 0 {
+			return 1
+		}
+		return n
+	}
+
+	if len(keys) > 0 && keys[0] == '"' {
+		i++
+		r, ok := next()
+		if !ok {
+			return nil, true
+		}
+		c.register = r
+	}
+	c.count = count()
+	k, ok := next()
+	if !ok {
+		return nil, true
+	}
+	switch {
+	case k == 'd' || k == 'c' || k == 'y':
+		c.op = k
+		c.count *= count()
+		if c.key, ok = next(); !ok {
+			return nil, true
+		}
+		if c.key == k {
+			c.key = '_' // The whole line, like dd
+		} else if !strings.ContainsRune("hl0^$wbeWBEfFtT", c.key) {
+			return nil, false
+		}
+	default:
+		c.key = k
+	}
+	if viNeedsArg(c.key) || (c.op == 0 && c.key == 'r') {
+		if c.arg, ok = next(); !ok {
+			return nil, true
+		}
+	}
+	return c, true
+}
+
+// Classes of characters, for word motions
+func viClass(r rune, big bool) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case big || unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+		return 1
+	}
+	return 2
+}
+
+// viMotion returns where the motion goes from pos, and whether the character
+// there is included when it's used with an operator. It returns false if the
+// motion can't be done.
+func viMotion(line []rune, pos int, key, arg rune, count int) (int, bool, bool) {
+	n := len(line)
+	big := unicode.IsUpper(key)
+	switch key {
+	case 'h':
+		if pos == 0 {
+			return 0, false, false
+		}
+		pos -= count
+		if pos < 0 {
+			pos = 0
+		}
+		return pos, false, true
+	case 'l':
+		pos += count
+		if pos > n {
+			pos = n
+		}
+		return pos, false, true
+	case '0':
+		return 0, false, true
+	case '^':
+		i := 0
+		for i < n-1 && unicode.IsSpace(line[i]) {
+			i++
+		}
+		return i, false, true
+	case '$':
+		if n == 0 {
+			return 0, false, true
+		}
+		return n - 1, true, true
+	case 'w', 'W':
+		for ; count > 0 && pos < n; count-- {
+			c := viClass(line[pos], big)
+			for pos < n && viClass(line[pos], big) == c && c != 0 {
+				pos++
+			}
+			for pos < n && unicode.IsSpace(line[pos]) {
+				pos++
+			}
+		}
+		return pos, false, true
+	case 'b', 'B':
+		for ; count > 0 && pos > 0; count-- {
+			pos--
+			for pos > 0 && unicode.IsSpace(line[pos]) {
+				pos--
+			}
+			c := viClass(line[pos], big)
+			for pos > 0 && viClass(line[pos-1], big) == c {
+				pos--
+			}
+		}
+		return pos, false, true
+	case 'e', 'E':
+		for ; count > 0 && pos < n-1; count-- {
+			pos++
+			for pos < n-1 && unicode.IsSpace(line[pos]) {
+				pos++
+			}
+			c := viClass(line[pos], big)
+			for pos < n-1 && viClass(line[pos+1], big) == c {
+				pos++
+			}
+		}
+		return pos, true, true
+	case 'f', 't':
+		i := pos
+		for ; count > 0; count-- {
+			i++
+			for i < n && line[i] != arg {
+				i++
+			}
+			if i >= n {
+				return pos, false, false
+			}
+		}
+		if key == 't' {
+			i--
+		}
+		return i, true, true
+	case 'F', 'T':
+		i := pos
+		for ; count > 0; count-- {
+			i--
+			for i >= 0 && line[i] != arg {
+				i--
+			}
+			if i < 0 {
+				return pos, false, false
+			}
+		}
+		if key == 'T' {
+			i++
+		}
+		return i, false, true
+	}
+	return pos, false, false
+}
+
+// setRegister saves deleted or yanked text to the register, and to the
+// unnamed one. Uppercase registers add to the lowercase ones.
+func (v *viState) setRegister(r rune, text string) {
+	if v.registers == nil {
+		v.registers = make(map[rune]string)
+	}
+	switch {
+	case r == '+' || r == '*':
+		clipboard.WriteAll(text) //nolint:errcheck
+	case unicode.IsUpper(r):
+		r = unicode.ToLower(r)
+		v.registers[r] += text
+		text = v.registers[r]
+	case r != 0 && r != '"':
+		v.registers[r] = text
+	}
+	v.registers['"'] = text
+}
+
+func (v *viState) getRegister(r rune) string {
+	if r == '+' || r == '*' {
+		text, _ := clipboard.ReadAll()
+		return text
+	}
+	if r == 0 {
+		r = '"'
+	}
+	return v.registers[unicode.ToLower(r)]
+}
+
+// key handles a key in vi mode, with the text of the bar and the cursor at
+// pos, a byte offset. It returns the new text and cursor, and what happened.
+func (v *viState) key(text string, pos int, event *tcell.EventKey) (string, int, int) {
+	if pos < 0 || pos > len(text) {
+		pos = len(text)
+	}
+	line := []rune(text)
+	rpos := len([]rune(text[:pos]))
+	newLine, newPos, result := v.runeKey(line, rpos, event)
+	if result != viDone {
+		return text, pos, result
+	}
+	return string(newLine), len(string(newLine[:newPos])), result
+}
+
+func (v *viState) runeKey(line []rune, pos int, event *tcell.EventKey) ([]rune, int, int) {
+	if !v.normal {
+		if event.Key() == tcell.KeyEsc {
+			v.normal = true
+			v.pending = nil
+			if pos > 0 {
+				pos--
+			}
+			return line, pos, viDone
+		}
+		return line, pos, viPass
+	}
+
+	//nolint:exhaustive
+	switch event.Key() {
+	case tcell.KeyRune:
+	case tcell.KeyEsc:
+		if len(v.pending) > 0 {
+			v.pending = nil
+			return line, pos, viDone
+		}
+		return line, pos, viCancel
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if pos > 0 {
+			pos--
+		}
+		return line, pos, viDone
+	default:
+		v.pending = nil
+		return line, pos, viPass
+	}
+
+	v.pending = append(v.pending, event.Rune())
+	c, ok := parseViCommand(v.pending)
+	if !ok {
+		v.pending = nil
+		return line, pos, viDone
+	}
+	if c == nil {
+		return line, pos, viDone
+	}
+	v.pending = nil
+
+	before := string(line)
+	beforePos := pos
+	line, pos, result := v.run(c, line, pos)
+	if (string(line) != before || !v.normal) && c.key != 'u' {
+		// Insert mode can be undone as one change, like in vi
+		v.undoText, v.undoPos, v.canUndo = before, beforePos, true
+	}
+	if v.normal && pos >= len(line) && len(line) > 0 {
+		// The cursor is on a character in normal mode
+		pos = len(line) - 1
+	}
+	return line, pos, result
+}
+
+// run does the command in normal mode.
+func (v *viState) run(c *viCommand, line []rune, pos int) ([]rune, int, int) {
+	n := len(line)
+	insert := func(at int) ([]rune, int, int) {
+		v.normal = false
+		return line, at, viDone
+	}
+	remove := func(start, end int) []rune {
+		v.setRegister(c.register, string(line[start:end]))
+		return append(line[:start:start], line[end:]...)
+	}
+
+	if c.op != 0 {
+		start, end := 0, n
+		if c.key != '_' {
+			key := c.key
+			if c.op == 'c' && (key == 'w' || key == 'W') && pos < n && !unicode.IsSpace(line[pos]) {
+				// cw changes to the end of the word, like ce
+				key += 'e' - 'w'
+			}
+			to, inclusive, ok := viMotion(line, pos, key, c.arg, c.count)
+			if !ok {
+				return line, pos, viDone
+			}
+			start, end = pos, to
+			if to < pos {
+				start, end = to, pos
+			}
+			if inclusive && end < n {
+				end++
+			}
+		}
+		switch c.op {
+		case 'y':
+			v.setRegister(c.register, string(line[start:end]))
+			return line, start, viDone
+		case 'd':
+			return remove(start, end), start, viDone
+		}
+		line = remove(start, end)
+		return insert(start)
+	}
+
+	switch c.key {
+	case 'i':
+		return insert(pos)
+	case 'a':
+		if n > 0 {
+			pos++
+		}
+		return insert(pos)
+	case 'I':
+		to, _, _ := viMotion(line, pos, '^', 0, 1)
+		return insert(to)
+	case 'A':
+		return insert(n)
+	case 'x', 's':
+		if n == 0 {
+			if c.key == 's' {
+				return insert(0)
+			}
+			return line, pos, viDone
+		}
+		end := pos + c.count
+		if end > n {
+			end = n
+		}
+		line = remove(pos, end)
+		if c.key == 's' {
+			return insert(pos)
+		}
+		return line, pos, viDone
+	case 'X':
+		start := pos - c.count
+		if start < 0 {
+			start = 0
+		}
+		return remove(start, pos), start, viDone
+	case 'D', 'C':
+		line = remove(pos, n)
+		if c.key == 'C' {
+			return insert(pos)
+		}
+		return line, pos, viDone
+	case 'S':
+		line = remove(0, n)
+		return insert(0)
+	case 'Y':
+		v.setRegister(c.register, string(line))
+		return line, pos, viDone
+	case 'p', 'P':
+		paste := []rune(strings.Repeat(v.getRegister(c.register), c.count))
+		if len(paste) == 0 {
+			return line, pos, viDone
+		}
+		at := pos
+		if c.key == 'p' && n > 0 {
+			at++
+		}
+		line = append(line[:at:at], append(paste, line[at:]...)...)
+		return line, at + len(paste) - 1, viDone
+	case 'r':
+		if pos+c.count > n {
+			return line, pos, viDone
+		}
+		for i := pos; i < pos+c.count; i++ {
+			line[i] = c.arg
+		}
+		return line, pos + c.count - 1, viDone
+	case '~':
+		for ; c.count > 0 && pos < n; c.count-- {
+			if unicode.IsUpper(line[pos]) {
+				line[pos] = unicode.ToLower(line[pos])
+			} else {
+				line[pos] = unicode.ToUpper(line[pos])
+			}
+			pos++
+		}
+		return line, pos, viDone
+	case 'u':
+		if !v.canUndo {
+			return line, pos, viDone
+		}
+		undone, undonePos := []rune(v.undoText), v.undoPos
+		v.undoText, v.undoPos = string(line), pos
+		return undone, undonePos, viDone
+	case 'k':
+		return line, pos, viOlder
+	case 'j':
+		return line, pos, viNewer
+	}
+
+	if to, _, ok := viMotion(line, pos, c.key, c.arg, c.count); ok {
+		return line, to, viDone
+	}
+	return line, pos, viDone
+}
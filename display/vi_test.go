@@ -0,0 +1,88 @@
+package display
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// viKeys returns the events for the keys, where "\x1b" is Esc.
+func viKeys(keys string) []*tcell.EventKey {
+	events := make([]*tcell.EventKey, 0, len(keys))
+	for _, r := range keys {
+		if r == '\x1b' {
+			events = append(events, tcell.NewEventKey(tcell.KeyEsc, 0, tcell.ModNone))
+		} else {
+			events = append(events, tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+		}
+	}
+	return events
+}
+
+func TestVi(t *testing.T) {
+	tests := []struct {
+		text    string
+		keys    string // Starting in insert mode, with the cursor at the end
+		want    string
+		wantPos int
+	}{
+		{"one two three", "\x1b0dw", "two three", 0},
+		{"one two three", "\x1b02dw", "three", 0},
+		{"one two three", "\x1b0d2w", "three", 0},
+		{"one two three", "\x1b0cwfour\x1b", "four two three", 3},
+		{"one two three", "\x1bbD", "one two ", 7},
+		{"one two three", "\x1b0fwdt ", "one t three", 5},
+		{"one two three", "\x1b0fty$P", "one two threetwo three", 12},
+		{"one two three", "\x1b0xp", "noe two three", 1},
+		{"one two three", "\x1b0\"ayw$\"ap", "one two threeone ", 16},
+		{"one two three", "\x1b0\"ayw\"Ayw$\"ap", "one two threeone one ", 20},
+		{"one two three", "\x1bddu", "one two three", 12},
+		{"one two three", "\x1b0ifour \x1bu", "one two three", 0},
+		{"one two three", "\x1b03rx", "xxx two three", 2},
+		{"one two three", "\x1b0~~", "ONe two three", 2},
+		{"gemini://example.com/page", "\x1b0wwcwother\x1b", "gemini://other.com/page", 13},
+		{"gemini://example.com/page", "\x1b0WD", "gemini://example.com/pag", 23},
+		{"gemini://example.com/page", "\x1bbdb", "gemini://example.compage", 20},
+		{"gemini://example.com/page", "\x1bbd2b", "gemini://example.page", 17},
+		{"one two", "\x1bccnew", "new", 3},
+		{"one two", "\x1b0Aend", "one twoend", 10},
+	}
+	for _, tt := range tests {
+		var v viState
+		text, pos := tt.text, len(tt.text)
+		for _, e := range viKeys(tt.keys) {
+			var result int
+			text, pos, result = v.key(text, pos, e)
+			if result == viPass {
+				// Typed as usual, in insert mode
+				text = text[:pos] + string(e.Rune()) + text[pos:]
+				pos += len(string(e.Rune()))
+			}
+		}
+		if text != tt.want || pos != tt.wantPos {
+			t.Errorf("%q with %q: got %q at %d, want %q at %d", tt.text, tt.keys, text, pos, tt.want, tt.wantPos)
+		}
+	}
+}
+
+func TestViResults(t *testing.T) {
+	var v viState
+	tests := []struct {
+		key  *tcell.EventKey
+		want int
+	}{
+		{tcell.NewEventKey(tcell.KeyRune, 'k', tcell.ModNone), viPass}, // Insert mode
+		{tcell.NewEventKey(tcell.KeyEsc, 0, tcell.ModNone), viDone},
+		{tcell.NewEventKey(tcell.KeyRune, 'k', tcell.ModNone), viOlder},
+		{tcell.NewEventKey(tcell.KeyRune, 'j', tcell.ModNone), viNewer},
+		{tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone), viDone},
+		{tcell.NewEventKey(tcell.KeyEsc, 0, tcell.ModNone), viDone}, // Cancels d
+		{tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), viPass},
+		{tcell.NewEventKey(tcell.KeyEsc, 0, tcell.ModNone), viCancel},
+	}
+	for i, tt := range tests {
+		if _, _, got := v.key("text", 2, tt.key); got != tt.want {
+			t.Errorf("key %d: got %d, want %d", i, got, tt.want)
+		}
+	}
+}
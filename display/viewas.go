@@ -0,0 +1,62 @@
+package display
+
+import (
+	"github.com/makeworld-the-better-one/amfora/structs"
+)
+
+// Mediatypes a page can be viewed as, by the label of their button.
+var viewAsTypes = map[string]structs.Mediatype{
+	"Gemtext":    structs.TextGemini,
+	"Markdown":   structs.TextMarkdown,
+	"Plain text": structs.TextPlain,
+	"ANSI":       structs.TextAnsi,
+}
+
+// viewAs asks the user which mediatype to render the tab's page as, and
+// renders it again that way. This is for servers that send the wrong
+// mediatype, like text/plain for gemtext.
+//
+// The choice stays with the page in the history and cache, reloading the
+// page goes back to the mediatype the server sent.
+//
+// It should be called in a goroutine.
+func viewAs(t *tab) {
+	defer RecoverCrash()
+	p := t.page
+	if !t.hasContent() || t.isAnAboutPage() {
+		Info("The current page can't be viewed differently.")
+		return
+	}
+
+	buttons := []string{"Gemtext", "Markdown", "Plain text", "ANSI"}
+	if p.ViewedAs != "" {
+		buttons = append(buttons, "Original")
+	}
+	choice := Choice("View this page as:", append(buttons, "Cancel"))
+
+	mediatype, ok := viewAsTypes[choice]
+	if choice == "Original" {
+		mediatype, ok = p.ViewedAs, true
+	}
+	if !ok || mediatype == p.Mediatype || !isValidTab(t) || t.page != p {
+		return
+	}
+
+	switch {
+	case p.ViewedAs == "":
+		p.ViewedAs = p.Mediatype
+	case p.ViewedAs == mediatype:
+		p.ViewedAs = ""
+	}
+	p.Mediatype = mediatype
+	p.TermWidth = -1 // Force it to be rendered again
+	reformatPage(p)
+
+	t.clearSelected()
+	t.view.SetText(p.Content)
+	t.applyScroll()
+	if t == tabs[curTab] {
+		t.applyBottomBar()
+	}
+	App.Draw()
+}
@@ -0,0 +1,63 @@
+package display
+
+import "time"
+
+// Zen mode hides the tab row and the bottom bar, to read without distractions.
+// The tab row is also always hidden in single tab mode, see singleTab.
+// They're still shown while they're needed: the bottom bar while it's being
+// typed in or the page is loading, and both for a moment after switching tabs
+// or when there's a notice.
+
+// How long the hidden parts are shown for after something changes.
+const zenFlash = 2 * time.Second
+
+var zen bool
+
+var (
+	tabRowShown    = true
+	bottomRowShown = true
+	zenShowUntil   time.Time
+)
+
+// toggleZen turns zen mode on or off.
+func toggleZen() {
+	zen = !zen
+	App.Draw()
+}
+
+// flashChrome shows the tab row and the bottom bar for a moment, if they're hidden.
+func flashChrome() {
+	if !zen {
+		return
+	}
+	zenShowUntil = time.Now().Add(zenFlash)
+	time.AfterFunc(zenFlash, func() { App.Draw() })
+}
+
+// updateZen hides or shows the tab row and the bottom bar. It's called before
+// every draw.
+func updateZen() {
+	flashing := time.Now().Before(zenShowUntil)
+	showTabs := !singleTab && (!zen || flashing)
+	showBottom := !zen || flashing || bottomBar.HasFocus() ||
+		(curTab > -1 && tabs[curTab].mode == tabModeLoading)
+
+	if showTabs != tabRowShown {
+		tabRowShown = showTabs
+		if showTabs {
+			browser.ResizeItem(browser.Switcher, 1, 1)
+			splitPane.ResizeItem(splitTitle, 1, 0)
+		} else {
+			browser.ResizeItem(browser.Switcher, 0, 0)
+			splitPane.ResizeItem(splitTitle, 0, 0)
+		}
+	}
+	if showBottom != bottomRowShown {
+		bottomRowShown = showBottom
+		if showBottom {
+			layout.ResizeItem(bottomRow, 1, 1)
+		} else {
+			layout.ResizeItem(bottomRow, 0, 0)
+		}
+	}
+}
@@ -10,10 +10,12 @@ require (
 	github.com/gdamore/tcell/v2 v2.3.3
 	github.com/google/go-cmp v0.5.0 // indirect
 	github.com/makeworld-the-better-one/go-gemini v0.11.0
+	github.com/mattn/go-runewidth v0.0.13
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/mitchellh/mapstructure v1.3.1 // indirect
 	github.com/mmcdole/gofeed v1.1.2
-	github.com/pelletier/go-toml v1.8.0 // indirect
+	github.com/pelletier/go-toml v1.8.0
+	github.com/rivo/uniseg v0.2.0
 	github.com/rkoesters/xdg v0.0.0-20181125232953-edd15b846f9b
 	github.com/schollz/progressbar/v3 v3.8.0
 	github.com/spf13/afero v1.2.2 // indirect
@@ -22,6 +24,7 @@ require (
 	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/spf13/viper v1.7.1
 	github.com/stretchr/testify v1.6.1
+	golang.org/x/net v0.0.0-20201216054612-986b41b23924
 	golang.org/x/text v0.3.6
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
 	gopkg.in/ini.v1 v1.62.0 // indirect
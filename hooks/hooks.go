@@ -0,0 +1,52 @@
+// Package hooks runs the commands set in the hooks section of the config
+// when things happen, like a page loading, so Amfora can be used with other
+// tools, like ones for notifications or archiving.
+package hooks
+
+import (
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/logger"
+	"github.com/spf13/viper"
+)
+
+// Events that commands can be run for. They're the keys of the hooks section.
+const (
+	PageLoaded       = "page_loaded"       // A page was loaded from the network
+	DownloadFinished = "download_finished" // A file or page was saved to the downloads folder
+	NewEntries       = "new_entries"       // A subscription has new entries, or a page changed
+	BookmarkAdded    = "bookmark_added"    // A bookmark was added
+)
+
+// env returns the environment for the command of the event. The event and
+// its details are in variables that start with AMFORA_.
+func env(event string, details map[string]string) []string {
+	vars := make([]string, 0, len(details))
+	for k, v := range details {
+		vars = append(vars, "AMFORA_"+k+"="+v)
+	}
+	sort.Strings(vars)
+	return append(append(os.Environ(), "AMFORA_EVENT="+event), vars...)
+}
+
+// Run runs the command for the event in the background, if one is set, with
+// the details of the event in its environment and stdin as its input.
+// The command's output is discarded, and failures are logged.
+func Run(event string, details map[string]string, stdin string) {
+	args := viper.GetStringSlice("hooks." + event)
+	if len(args) == 0 {
+		return
+	}
+	cmd := exec.Command(args[0], args[1:]...) //nolint:gosec
+	cmd.Env = env(event, details)
+	cmd.Stdin = strings.NewReader(stdin)
+	go func() {
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			logger.Warnf("The %s hook failed: %v: %s", event, err, strings.TrimSpace(string(out)))
+		}
+	}()
+}
@@ -0,0 +1,14 @@
+package hooks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEnv(t *testing.T) {
+	got := env(BookmarkAdded, map[string]string{"URL": "gemini://example.com/", "NAME": "Example"})
+	want := []string{"AMFORA_EVENT=bookmark_added", "AMFORA_NAME=Example", "AMFORA_URL=gemini://example.com/"}
+	if len(got) < len(want) || !reflect.DeepEqual(got[len(got)-len(want):], want) {
+		t.Errorf("env() ends with %q, want %q", got, want)
+	}
+}
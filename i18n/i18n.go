@@ -0,0 +1,141 @@
+// Package i18n translates the text of Amfora's interface.
+//
+// Translations are TOML files in the locales directory of the config
+// directory, named after the language they're for, like "de.toml" or
+// "pt-BR.toml". Each key is the English text, and its value is the translation:
+//
+//	"Go back in the history" = "Im Verlauf zurückgehen"
+//	"Couldn't load the page: %v" = "Die Seite konnte nicht geladen werden: %v"
+//
+// Text that has no translation is shown in English.
+package i18n
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/logger"
+	"github.com/pelletier/go-toml"
+	"golang.org/x/text/language"
+)
+
+// The translations of the English text.
+var translations = make(map[string]string)
+
+// Tag is the language the interface is shown in.
+var Tag = language.English
+
+// T returns the translation of the English text. If there are args, the
+// translation is formatted with them like fmt.Sprintf. Otherwise it's
+// returned as is, so text from elsewhere can be passed safely.
+func T(s string, args ...interface{}) string {
+	if t, ok := translations[s]; ok {
+		s = t
+	}
+	if len(args) == 0 {
+		return s
+	}
+	return fmt.Sprintf(s, args...)
+}
+
+// envLocale returns the language of the user's environment, from the
+// environment variables used on Unix systems. It's empty if none are set,
+// or the locale is C or POSIX, which have no language.
+func envLocale() string {
+	for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		s := os.Getenv(v)
+		// Like "de_DE.UTF-8", "sr_RS@latin", or "C.UTF-8"
+		s = strings.SplitN(s, ".", 2)[0]
+		s = strings.SplitN(s, "@", 2)[0]
+		if s != "" && s != "C" && s != "POSIX" {
+			return strings.ReplaceAll(s, "_", "-")
+		}
+	}
+	return ""
+}
+
+// available returns the languages that there are translations for in dir.
+func available(dir string) map[language.Tag]string {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	tags := make(map[language.Tag]string)
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || filepath.Ext(name) != ".toml" {
+			continue
+		}
+		tag, err := language.Parse(strings.TrimSuffix(name, ".toml"))
+		if err != nil {
+			logger.Warnf("Locale file %s isn't named after a language: %v", name, err)
+			continue
+		}
+		tags[tag] = filepath.Join(dir, name)
+	}
+	return tags
+}
+
+// loadFile returns the translations in the TOML file.
+func loadFile(path string) (map[string]string, error) {
+	tree, err := toml.LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string)
+	for key, value := range tree.ToMap() {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("the translation of %q isn't a string", key)
+		}
+		m[key] = s
+	}
+	return m, nil
+}
+
+// Init picks the language to show the interface in, and loads its translations
+// from dir. locale is the language set in the config. If it's empty, the
+// language of the user's environment is used instead. Only an invalid
+// language in the config is an error.
+//
+// The interface stays in English if there are no translations for the language.
+func Init(locale, dir string) error {
+	fromEnv := locale == ""
+	if fromEnv {
+		locale = envLocale()
+	}
+	if locale == "" {
+		return nil
+	}
+	want, err := language.Parse(locale)
+	if err != nil {
+		if fromEnv {
+			logger.Warnf("Unknown language %q in the environment, using English: %v", locale, err)
+			return nil
+		}
+		return fmt.Errorf("invalid language %q: %w", locale, err)
+	}
+
+	files := available(dir)
+	supported := []language.Tag{language.English} // First, so it's the fallback
+	for tag := range files {
+		supported = append(supported, tag)
+	}
+	_, i, confidence := language.NewMatcher(supported).Match(want)
+	if i == 0 || confidence == language.No {
+		return nil
+	}
+	tag := supported[i]
+
+	m, err := loadFile(files[tag])
+	if err != nil {
+		return fmt.Errorf("%s: %w", files[tag], err)
+	}
+	Tag = tag
+	translations = m
+	logger.Infof("Using the %s translation", tag)
+	return nil
+}
@@ -0,0 +1,112 @@
+package i18n
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+const deToml = `"Yes" = "Ja"
+"Go back in the history. Also works with: %s" = "Im Verlauf zurückgehen. Geht auch mit: %s"
+`
+
+func TestInit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "amfora-locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "de.toml"), []byte(deToml), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		translations = make(map[string]string)
+		Tag = language.English
+	}()
+
+	if err := Init("fr", dir); err != nil {
+		t.Fatal(err)
+	}
+	if Tag != language.English || T("Yes") != "Yes" {
+		t.Errorf("with no French translation, got %v and %q", Tag, T("Yes"))
+	}
+
+	if err := Init("de-AT", dir); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		in   string
+		args []interface{}
+		want string
+	}{
+		{"Yes", nil, "Ja"},
+		{"No", nil, "No"},
+		{"Go back in the history. Also works with: %s", []interface{}{"b"}, "Im Verlauf zurückgehen. Geht auch mit: b"},
+		{"Untranslated %d", []interface{}{3}, "Untranslated 3"},
+		{"gemini://example.com/a%20b", nil, "gemini://example.com/a%20b"},
+	}
+	for _, tt := range tests {
+		if got := T(tt.in, tt.args...); got != tt.want {
+			t.Errorf("T(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEnvLocale(t *testing.T) {
+	for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		defer os.Setenv(v, os.Getenv(v))
+		os.Unsetenv(v)
+	}
+	if got := envLocale(); got != "" {
+		t.Errorf("with no variables set, got %q", got)
+	}
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{"pt_BR.UTF-8", "pt-BR"},
+		{"en_US.UTF-8", "en-US"},
+		{"sr_RS@latin", "sr-RS"},
+		{"C", ""},
+		{"C.UTF-8", ""},
+		{"C.utf8", ""},
+		{"POSIX", ""},
+	}
+	for _, tt := range tests {
+		os.Setenv("LANG", tt.lang)
+		if got := envLocale(); got != tt.want {
+			t.Errorf("LANG=%s: envLocale() = %q, want %q", tt.lang, got, tt.want)
+		}
+	}
+
+	os.Setenv("LANG", "pt_BR.UTF-8")
+	os.Setenv("LC_ALL", "C.UTF-8")
+	if got := envLocale(); got != "pt-BR" {
+		t.Errorf("LC_ALL=C.UTF-8 should be skipped, got %q", got)
+	}
+}
+
+func TestInitEnv(t *testing.T) {
+	for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		defer os.Setenv(v, os.Getenv(v))
+		os.Unsetenv(v)
+	}
+	dir, err := ioutil.TempDir("", "amfora-locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, lang := range []string{"C.UTF-8", "C.utf8", "POSIX", "en_US.UTF-8", "not a locale!"} {
+		os.Setenv("LANG", lang)
+		if err := Init("", dir); err != nil {
+			t.Errorf("LANG=%s: %v", lang, err)
+		}
+	}
+	if err := Init("not a locale!", dir); err == nil {
+		t.Error("an invalid language in the config should be an error")
+	}
+}
@@ -1,20 +1,115 @@
+// Package logger writes leveled logs to a file, for debugging.
+// Nothing is logged until Init is called, so the logging functions
+// can always be used.
 package logger
 
-// For debugging
-
 import (
+	"fmt"
 	"log"
 	"os"
+	"runtime/debug"
+	"strings"
 )
 
+// Level is how important a log message is.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return "UNKNOWN"
+	}
+	return levelNames[l]
+}
+
+// ParseLevel returns the level with the given name, like "debug" or "warn".
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+	if strings.EqualFold(s, "warning") {
+		return LevelWarn, nil
+	}
+	return LevelInfo, fmt.Errorf("invalid log level: %q", s)
+}
+
+// Log is the logger that messages are written to. It's nil if logging is off.
 var Log *log.Logger
 
-func Init() error {
-	f, err := os.Create("debug.log")
+// The lowest level that is logged.
+var minLevel = LevelInfo
+
+var logFile *os.File
+
+// Init starts logging to the file at path, adding to it if it already exists.
+// If logging was already started, the old file is closed.
+func Init(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
 	if err != nil {
 		return err
 	}
-	Log = log.New(f, "", log.LstdFlags)
-	Log.Println("Started Log")
+	if logFile != nil {
+		logFile.Close()
+	}
+	logFile = f
+	Log = log.New(f, "", log.LstdFlags|log.Lmicroseconds)
+	Log.Println("Started log")
 	return nil
 }
+
+// Enabled returns true if logs are being written.
+func Enabled() bool {
+	return Log != nil
+}
+
+// SetLevel sets the lowest level of the messages that are logged.
+// The default is LevelInfo.
+func SetLevel(l Level) {
+	minLevel = l
+}
+
+func logf(l Level, format string, v ...interface{}) {
+	if Log == nil || l < minLevel {
+		return
+	}
+	Log.Printf("%-5s "+format, append([]interface{}{l}, v...)...)
+}
+
+// Debugf logs details that are only useful when tracking down a problem.
+func Debugf(format string, v ...interface{}) {
+	logf(LevelDebug, format, v...)
+}
+
+// Infof logs normal events, like network requests.
+func Infof(format string, v ...interface{}) {
+	logf(LevelInfo, format, v...)
+}
+
+// Warnf logs problems that Amfora could recover from.
+func Warnf(format string, v ...interface{}) {
+	logf(LevelWarn, format, v...)
+}
+
+// Errorf logs errors.
+func Errorf(format string, v ...interface{}) {
+	logf(LevelError, format, v...)
+}
+
+// Panic logs a recovered panic value with the stack trace. It's always
+// logged, no matter the level.
+func Panic(v interface{}) {
+	if Log == nil {
+		return
+	}
+	Log.Printf("%-5s panic: %v\n%s", LevelError, v, debug.Stack())
+}
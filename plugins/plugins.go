@@ -0,0 +1,323 @@
+// Package plugins runs the plugins set in the config. A plugin is a program
+// that Amfora starts, and talks to over its stdin and stdout, with a JSON
+// object on each line. It can add commands, URL schemes, and about pages.
+// See PLUGINS.md for the protocol.
+package plugins
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/logger"
+)
+
+// How long plugins have to register after they're started
+const registerTimeout = 5 * time.Second
+
+// How long plugins have to respond to a request
+const requestTimeout = time.Minute
+
+// How long plugins have to read a request, before they're stopped. It's a
+// var so tests can change it.
+var writeTimeout = 10 * time.Second
+
+// ErrStopped is returned for requests to a plugin that isn't running anymore.
+var ErrStopped = errors.New("the plugin stopped running")
+
+// ErrTimedOut is returned when a plugin took too long to respond.
+var ErrTimedOut = errors.New("the plugin took too long to respond")
+
+// Command is a command a plugin adds, which can be run with a key.
+type Command struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Key         string `json:"key"` // Like keys in the config, empty if it has none
+}
+
+// Request is sent to a plugin, to run a command or get a page.
+type Request struct {
+	ID        int    `json:"id"`
+	Type      string `json:"type"`              // "command" or "page"
+	Command   string `json:"command,omitempty"` // The name of the command to run
+	URL       string `json:"url"`               // The current page, or the page to get
+	Mediatype string `json:"mediatype,omitempty"`
+	Raw       string `json:"raw,omitempty"` // The current page, as the server sent it
+}
+
+// Response is sent by a plugin, to answer a request with the same ID.
+// Any of the fields can be empty.
+type Response struct {
+	ID        int    `json:"id"`
+	Error     string `json:"error"`     // Shown to the user, nothing else is used
+	Message   string `json:"message"`   // Shown to the user
+	URL       string `json:"url"`       // Opened in the tab, for commands
+	Body      string `json:"body"`      // Displayed in the tab
+	Mediatype string `json:"mediatype"` // The mediatype of the body, text/gemini by default
+}
+
+// registration is the first message a plugin sends.
+type registration struct {
+	Type     string    `json:"type"` // Always "register"
+	Name     string    `json:"name"`
+	Commands []Command `json:"commands"`
+	Schemes  []string  `json:"schemes"`
+	About    []string  `json:"about"`
+}
+
+// Plugin is a running plugin.
+type Plugin struct {
+	Name     string
+	Commands []Command
+	Schemes  []string // URL schemes it handles, like "weather"
+	About    []string // About pages it handles, like "weather" for about:weather
+
+	stdin   io.WriteCloser
+	writeMu sync.Mutex // Held while writing to stdin, so requests aren't mixed
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]chan Response // Closed if the plugin stops
+	stopped bool
+}
+
+var (
+	plugins []*Plugin
+	// Errors from plugins that couldn't be started, to show the user
+	failed []string
+)
+
+// Init starts the plugins from the config, and waits for them to register.
+// They're all started at once, so a slow one doesn't hold up the others, but
+// they're kept in the order of the config. Plugins that can't be started are
+// logged, and left out.
+func Init() {
+	started := make([]*Plugin, len(config.Plugins))
+	errs := make([]error, len(config.Plugins))
+	var wg sync.WaitGroup
+	for i := range config.Plugins {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			started[i], errs[i] = start(config.Plugins[i])
+		}(i)
+	}
+	wg.Wait()
+
+	for i, args := range config.Plugins {
+		if errs[i] != nil {
+			msg := fmt.Sprintf("%s: %v", strings.Join(args, " "), errs[i])
+			logger.Errorf("Couldn't start plugin %s", msg)
+			failed = append(failed, msg)
+			continue
+		}
+		logger.Infof("Started plugin %s", started[i].Name)
+		plugins = append(plugins, started[i])
+	}
+}
+
+// start starts the plugin, and reads its registration.
+func start(args []string) (*Plugin, error) {
+	cmd := exec.Command(args[0], args[1:]...) //nolint:gosec
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = ioutil.Discard
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(stdout)
+
+	regCh := make(chan registration, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			errCh <- err
+			return
+		}
+		var reg registration
+		if err := json.Unmarshal(line, &reg); err != nil {
+			errCh <- err
+			return
+		}
+		regCh <- reg
+	}()
+
+	var reg registration
+	select {
+	case reg = <-regCh:
+	case err := <-errCh:
+		cmd.Process.Kill() //nolint:errcheck
+		return nil, fmt.Errorf("couldn't read its registration: %w", err)
+	case <-time.After(registerTimeout):
+		cmd.Process.Kill() //nolint:errcheck
+		return nil, errors.New("it didn't register in time")
+	}
+	if reg.Type != "register" || reg.Name == "" {
+		cmd.Process.Kill() //nolint:errcheck
+		return nil, errors.New(`its first message wasn't a registration with a name`)
+	}
+
+	p := &Plugin{
+		Name:     reg.Name,
+		Commands: reg.Commands,
+		Schemes:  reg.Schemes,
+		About:    reg.About,
+		stdin:    stdin,
+		pending:  make(map[int]chan Response),
+	}
+	go p.read(r)
+	go cmd.Wait() //nolint:errcheck
+	return p, nil
+}
+
+// read passes the responses of the plugin to the requests waiting for them,
+// until it stops.
+func (p *Plugin) read(r *bufio.Reader) {
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			break
+		}
+		var res Response
+		if err := json.Unmarshal(line, &res); err != nil {
+			logger.Warnf("Plugin %s sent an invalid response: %v", p.Name, err)
+			continue
+		}
+		p.mu.Lock()
+		if ch, ok := p.pending[res.ID]; ok {
+			ch <- res
+			delete(p.pending, res.ID)
+		}
+		p.mu.Unlock()
+	}
+
+	logger.Warnf("Plugin %s stopped running", p.Name)
+	p.mu.Lock()
+	p.stopped = true
+	for id, ch := range p.pending {
+		close(ch)
+		delete(p.pending, id)
+	}
+	p.mu.Unlock()
+}
+
+// Request sends the request to the plugin, and returns its response.
+// The ID of the request is set by this function.
+func (p *Plugin) Request(req Request) (Response, error) {
+	ch := make(chan Response, 1)
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return Response{}, ErrStopped
+	}
+	p.nextID++
+	req.ID = p.nextID
+	p.pending[req.ID] = ch
+	p.mu.Unlock()
+
+	// p.mu isn't held while writing, because the plugin might only read the
+	// request after its last response was read, which needs p.mu
+	data, err := json.Marshal(req)
+	if err == nil {
+		err = p.write(append(data, '\n'))
+	}
+	if err != nil {
+		p.mu.Lock()
+		delete(p.pending, req.ID)
+		p.mu.Unlock()
+		return Response{}, err
+	}
+
+	select {
+	case res, ok := <-ch:
+		if !ok {
+			return Response{}, ErrStopped
+		}
+		return res, nil
+	case <-time.After(requestTimeout):
+		p.mu.Lock()
+		delete(p.pending, req.ID)
+		p.mu.Unlock()
+		return Response{}, ErrTimedOut
+	}
+}
+
+// write writes the line to the stdin of the plugin. If the plugin doesn't
+// read it in time, its stdin is closed and it's treated as stopped, so a
+// stuck plugin can't hold up Amfora.
+func (p *Plugin) write(line []byte) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.stdin.Write(line)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(writeTimeout):
+		logger.Warnf("Plugin %s didn't read a request in time, stopping it", p.Name)
+		p.mu.Lock()
+		p.stopped = true
+		p.mu.Unlock()
+		p.stdin.Close() // Ends the write
+		<-done
+		return ErrTimedOut
+	}
+}
+
+// All returns the running plugins, in the order they're in the config.
+func All() []*Plugin {
+	return plugins
+}
+
+// Failed returns the errors of the plugins that couldn't be started.
+func Failed() []string {
+	return failed
+}
+
+// ForScheme returns the plugin that handles URLs with the scheme.
+func ForScheme(scheme string) (*Plugin, bool) {
+	for _, p := range plugins {
+		for _, s := range p.Schemes {
+			if strings.EqualFold(s, scheme) {
+				return p, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ForAbout returns the plugin that handles the about page, like
+// "about:weather". Queries are ignored.
+func ForAbout(u string) (*Plugin, bool) {
+	name := strings.TrimPrefix(u, "about:")
+	if i := strings.IndexAny(name, "?#"); i != -1 {
+		name = name[:i]
+	}
+	for _, p := range plugins {
+		for _, a := range p.About {
+			if a == name {
+				return p, true
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,81 @@
+package plugins
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequest(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	// Answers each request with its own line as the message
+	script := `echo '{"type": "register", "name": "echo", "about": ["echo"]}'
+while read -r line; do
+	id=$(echo "$line" | sed 's/^{"id":\([0-9]*\).*/\1/')
+	echo "{\"id\": $id, \"message\": \"page\"}"
+done`
+	p, err := start([]string{"sh", "-c", script})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.stdin.Close()
+	if p.Name != "echo" || len(p.About) != 1 {
+		t.Errorf("registration = %q %q, want echo [echo]", p.Name, p.About)
+	}
+	for i := 0; i < 2; i++ {
+		res, err := p.Request(Request{Type: "page", URL: "about:echo"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.ID != i+1 || res.Message != "page" {
+			t.Errorf("response %d = %+v", i, res)
+		}
+	}
+}
+
+func TestForAbout(t *testing.T) {
+	plugins = []*Plugin{{Name: "weather", About: []string{"weather"}}}
+	defer func() { plugins = nil }()
+
+	tests := []struct {
+		u  string
+		ok bool
+	}{
+		{"about:weather", true},
+		{"about:weather?london", true},
+		{"about:weathers", false},
+		{"about:bookmarks", false},
+	}
+	for _, tt := range tests {
+		if _, ok := ForAbout(tt.u); ok != tt.ok {
+			t.Errorf("ForAbout(%q) = %v, want %v", tt.u, ok, tt.ok)
+		}
+	}
+}
+
+func TestRequestWriteTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs sh")
+	}
+	writeTimeout = 100 * time.Millisecond
+	defer func() { writeTimeout = 10 * time.Second }()
+
+	// Never reads its stdin
+	p, err := start([]string{"sh", "-c", `echo '{"type": "register", "name": "stuck"}'; sleep 5`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.stdin.Close()
+
+	// Larger than the buffer of the pipe
+	_, err = p.Request(Request{Type: "command", Raw: strings.Repeat("a", 1<<20)})
+	if err != ErrTimedOut {
+		t.Errorf("got %v, want ErrTimedOut", err)
+	}
+	if _, err = p.Request(Request{Type: "command"}); err != ErrStopped {
+		t.Errorf("got %v for a request after that, want ErrStopped", err)
+	}
+}
@@ -0,0 +1,204 @@
+package readability
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// link is a link found inside a block of text. Gemtext can't have links
+// inside text, so they are added as link lines after the block.
+type link struct {
+	url  string
+	text string
+}
+
+// converter turns HTML elements into gemtext lines.
+type converter struct {
+	base   *url.URL
+	lines  []string
+	text   strings.Builder // Text of the current block
+	links  []link          // Links of the current block
+	prefix string          // Added to the start of the current block, like "* "
+	quote  int             // How many blockquotes the converter is inside
+}
+
+// Elements that start a new block of text
+var blockTags = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Blockquote: true, atom.Dd: true,
+	atom.Div: true, atom.Dl: true, atom.Dt: true, atom.Figcaption: true,
+	atom.Figure: true, atom.Header: true, atom.Hr: true, atom.Li: true,
+	atom.Main: true, atom.Ol: true, atom.P: true, atom.Section: true,
+	atom.Table: true, atom.Tr: true, atom.Ul: true,
+}
+
+func (c *converter) line(s string) {
+	c.lines = append(c.lines, s)
+}
+
+// blank adds an empty line, unless there already is one.
+func (c *converter) blank() {
+	if len(c.lines) > 0 && c.lines[len(c.lines)-1] != "" {
+		c.line("")
+	}
+}
+
+// resolve returns the absolute URL for a link on the page.
+func (c *converter) resolve(href string) string {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
+	}
+	if c.base != nil {
+		u = c.base.ResolveReference(u)
+	}
+	if u.Scheme == "javascript" {
+		return ""
+	}
+	return u.String()
+}
+
+// Starts of lines that mean something in gemtext, see escapeLine
+var gemtextSyntax = []string{"=>", "#", "```", "* ", ">"}
+
+// escapeLine adds a space to the start of the line if it would otherwise be
+// read as a link, heading, list item, quote, or preformatted toggle.
+func escapeLine(s string) string {
+	for _, syntax := range gemtextSyntax {
+		if strings.HasPrefix(s, syntax) {
+			return " " + s
+		}
+	}
+	return s
+}
+
+// flush adds the current block of text, and its links.
+func (c *converter) flush() {
+	text := collapse(c.text.String())
+	if text != "" {
+		if c.quote > 0 {
+			c.line("> " + c.prefix + text)
+		} else if c.prefix != "" {
+			c.line(c.prefix + text)
+		} else {
+			c.line(escapeLine(text))
+		}
+	}
+	for _, l := range c.links {
+		c.line("=> " + l.url + " " + l.text)
+	}
+	c.text.Reset()
+	c.links = c.links[:0]
+	c.prefix = ""
+}
+
+// walk converts the node and everything inside it.
+func (c *converter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.text.WriteString(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		c.walkChildren(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		c.flush()
+		c.blank()
+		level := int(n.Data[1] - '0')
+		if level > 3 {
+			level = 3
+		}
+		c.prefix = strings.Repeat("#", level) + " "
+		c.walkChildren(n)
+		c.flush()
+		c.blank()
+	case atom.Pre:
+		c.flush()
+		c.blank()
+		c.line("```")
+		for _, l := range strings.Split(strings.TrimRight(textOf(n), "\n"), "\n") {
+			if strings.HasPrefix(l, "```") {
+				// It would end the preformatted block
+				l = " " + l
+			}
+			c.line(l)
+		}
+		c.line("```")
+		c.blank()
+	case atom.Br:
+		c.flush()
+	case atom.A:
+		start := c.text.Len()
+		c.walkChildren(n)
+		text := collapse(c.text.String()[start:])
+		if href := c.resolve(attr(n, "href")); href != "" && text != "" && !strings.HasPrefix(attr(n, "href"), "#") {
+			c.links = append(c.links, link{href, text})
+		}
+	case atom.Img:
+		if src := c.resolve(attr(n, "src")); src != "" {
+			alt := collapse(attr(n, "alt"))
+			if alt == "" {
+				alt = "Image"
+			}
+			c.links = append(c.links, link{src, alt})
+		}
+	case atom.Li:
+		c.flush()
+		c.prefix = "* "
+		c.walkChildren(n)
+		c.flush()
+	case atom.Blockquote:
+		c.flush()
+		c.blank()
+		c.quote++
+		c.walkChildren(n)
+		c.flush()
+		c.quote--
+		c.blank()
+	case atom.Td, atom.Th:
+		if prevElement(n) != nil {
+			c.text.WriteString(" | ")
+		}
+		c.walkChildren(n)
+	case atom.Tr:
+		c.flush()
+		c.walkChildren(n)
+		c.flush()
+	default:
+		if blockTags[n.DataAtom] {
+			c.flush()
+			if n.DataAtom == atom.P || n.DataAtom == atom.Hr {
+				c.blank()
+			}
+			c.walkChildren(n)
+			c.flush()
+			if n.DataAtom == atom.P {
+				c.blank()
+			}
+		} else {
+			c.walkChildren(n)
+		}
+	}
+}
+
+// prevElement returns the previous sibling that is an element, or nil.
+func prevElement(n *html.Node) *html.Node {
+	for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+		if s.Type == html.ElementNode {
+			return s
+		}
+	}
+	return nil
+}
+
+func (c *converter) walkChildren(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.walk(child)
+	}
+}
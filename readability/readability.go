@@ -0,0 +1,247 @@
+// Package readability extracts the main content of HTML pages, like the reader
+// mode of a web browser, and converts it to gemtext.
+//
+// The content is found using a simplified version of the scoring used by
+// Arc90's Readability: paragraphs give points to the elements containing them,
+// and the element with the most points is used.
+package readability
+
+import (
+	"io"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Article is the result of converting a page.
+type Article struct {
+	Title   string
+	Lang    string // From the lang attribute of the page, can be empty
+	Gemtext string
+}
+
+// Matches the class or id of elements that are unlikely to be content
+var unlikelyRegex = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|extra|foot|header|menu|modal|nav|pager|pagination|popup|related|remark|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe`)
+
+// Matches the class or id of elements that are likely to be content
+var likelyRegex = regexp.MustCompile(`(?i)article|body|column|content|entry|main|page|post|story|text`)
+
+// Elements that are never part of the content
+var removedTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Iframe: true,
+	atom.Form: true, atom.Nav: true, atom.Aside: true, atom.Footer: true,
+	atom.Button: true, atom.Svg: true, atom.Object: true, atom.Embed: true,
+	atom.Input: true, atom.Select: true, atom.Textarea: true, atom.Template: true,
+}
+
+// Convert parses the HTML page and converts its main content to gemtext.
+// base is the URL of the page, used to resolve relative links.
+func Convert(r io.Reader, base *url.URL) (*Article, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	art := &Article{}
+	if root := find(doc, atom.Html); root != nil {
+		art.Lang = attr(root, "lang")
+	}
+	if title := find(doc, atom.Title); title != nil {
+		art.Title = collapse(textOf(title))
+	}
+
+	body := find(doc, atom.Body)
+	if body == nil {
+		body = doc
+	}
+	clean(body)
+
+	c := converter{base: base}
+	if art.Title != "" {
+		c.line("# " + art.Title)
+		c.blank()
+	}
+	c.walk(bestCandidate(body))
+	c.flush()
+	if len(c.lines) > 2 && c.lines[2] == c.lines[0] {
+		// The content started with the same heading as the title
+		c.lines = c.lines[2:]
+	}
+	art.Gemtext = strings.TrimSpace(strings.Join(c.lines, "\n")) + "\n"
+	return art, nil
+}
+
+// find returns the first element with the provided tag, or nil.
+func find(n *html.Node, tag atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == tag {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := find(c, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// textOf returns all the text inside the node.
+func textOf(n *html.Node) string {
+	var sb strings.Builder
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(n)
+	return sb.String()
+}
+
+// collapse replaces runs of whitespace with a single space.
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// clean removes elements that aren't content from the tree.
+func clean(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode {
+			n.RemoveChild(c)
+		} else if c.Type == html.ElementNode {
+			classID := attr(c, "class") + " " + attr(c, "id")
+			if removedTags[c.DataAtom] || hasAttr(c, "hidden") || attr(c, "aria-hidden") == "true" ||
+				(c.DataAtom != atom.Article && c.DataAtom != atom.Main &&
+					unlikelyRegex.MatchString(classID) && !likelyRegex.MatchString(classID)) {
+				n.RemoveChild(c)
+			} else {
+				clean(c)
+			}
+		}
+		c = next
+	}
+}
+
+// classWeight returns a bonus or penalty for the element based on its class and id.
+func classWeight(n *html.Node) float64 {
+	classID := attr(n, "class") + " " + attr(n, "id")
+	weight := 0.0
+	if likelyRegex.MatchString(classID) {
+		weight += 25
+	}
+	if unlikelyRegex.MatchString(classID) {
+		weight -= 25
+	}
+	return weight
+}
+
+// tagWeight returns the starting score of an element based on its tag.
+func tagWeight(n *html.Node) float64 {
+	switch n.DataAtom {
+	case atom.Article, atom.Main:
+		return 10
+	case atom.Div:
+		return 5
+	case atom.Pre, atom.Td, atom.Blockquote:
+		return 3
+	case atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li, atom.Address:
+		return -3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		return -5
+	}
+	return 0
+}
+
+// linkDensity returns how much of the text in the element is inside links.
+func linkDensity(n *html.Node) float64 {
+	total := len(collapse(textOf(n)))
+	if total == 0 {
+		return 0
+	}
+	linked := 0
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			linked += len(collapse(textOf(n)))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(n)
+	return float64(linked) / float64(total)
+}
+
+// bestCandidate returns the element that most likely holds the main content.
+// If nothing stands out, the provided root is returned.
+func bestCandidate(root *html.Node) *html.Node {
+	scores := make(map[*html.Node]float64)
+	addScore := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = tagWeight(n) + classWeight(n)
+		}
+		scores[n] += score
+	}
+
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.DataAtom == atom.P || n.DataAtom == atom.Pre || n.DataAtom == atom.Td) {
+			text := collapse(textOf(n))
+			if len(text) >= 25 {
+				// Longer paragraphs with more commas are more likely to be content
+				score := 1 + float64(strings.Count(text, ",")) + math.Min(float64(len(text))/100, 3)
+				addScore(n.Parent, score)
+				if n.Parent != nil {
+					addScore(n.Parent.Parent, score/2)
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			f(c)
+		}
+	}
+	f(root)
+
+	var best *html.Node
+	bestScore := 0.0
+	for n, score := range scores {
+		score *= 1 - linkDensity(n)
+		if best == nil || score > bestScore {
+			best = n
+			bestScore = score
+		}
+	}
+	if best == nil {
+		return root
+	}
+	return best
+}
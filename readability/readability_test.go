@@ -0,0 +1,85 @@
+package readability
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const testPage = `<!DOCTYPE html>
+<html lang="en">
+<head><title>Test Page</title><script>var x = 1;</script></head>
+<body>
+<nav class="menu"><a href="/">Home</a> <a href="/about">About</a></nav>
+<div class="sidebar"><p>Subscribe to the newsletter, it's great, really, honestly.</p></div>
+<article class="post">
+<h1>Test Page</h1>
+<p>This is the first paragraph, which has a <a href="/link">link</a> in it, and enough text.</p>
+<h2>Section</h2>
+<ul><li>One</li><li>Two</li></ul>
+<blockquote><p>A quote that is long enough to count, with commas, too.</p></blockquote>
+<pre>code
+  block</pre>
+</article>
+<footer>Copyright</footer>
+</body>
+</html>`
+
+const expectedGemtext = `# Test Page
+
+This is the first paragraph, which has a link in it, and enough text.
+=> https://example.com/link link
+
+## Section
+
+* One
+* Two
+
+> A quote that is long enough to count, with commas, too.
+
+` + "```" + `
+code
+  block
+` + "```\n"
+
+func TestConvert(t *testing.T) {
+	base, _ := url.Parse("https://example.com/page")
+	art, err := Convert(strings.NewReader(testPage), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if art.Title != "Test Page" {
+		t.Errorf("expected title %q, actual %q", "Test Page", art.Title)
+	}
+	if art.Lang != "en" {
+		t.Errorf("expected lang %q, actual %q", "en", art.Lang)
+	}
+	if art.Gemtext != expectedGemtext {
+		t.Errorf("expected:\n%s\nactual:\n%s", expectedGemtext, art.Gemtext)
+	}
+}
+
+func TestConvertEscapes(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader("<p>=> not a link</p><p># Not a heading</p>" +
+		"<p>* Not a list</p><p>> Not a quote</p><li># Item</li><pre>```\ncode</pre>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := converter{}
+	c.walk(doc)
+	c.flush()
+
+	expected := []string{" => not a link", " # Not a heading", " * Not a list", " > Not a quote",
+		"* # Item", "```", " ```", "code", "```"}
+	actual := make([]string, 0, len(c.lines))
+	for _, l := range c.lines {
+		if l != "" {
+			actual = append(actual, l)
+		}
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected:\n%s\nactual:\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+}
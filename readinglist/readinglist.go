@@ -0,0 +1,159 @@
+// Package readinglist stores pages saved to read later. A copy of each page
+// is kept on disk, so it can be read even if it changes or goes away.
+package readinglist
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+)
+
+var ErrNotFound = errors.New("page isn't in the reading list")
+
+// Item is a page in the reading list.
+type Item struct {
+	URL       string    `json:"url"`
+	Title     string    `json:"title,omitempty"`
+	Mediatype string    `json:"mediatype"` // The structs.Mediatype of the saved copy
+	Lang      string    `json:"lang,omitempty"`
+	Added     time.Time `json:"added"`
+	Read      bool      `json:"read"`
+}
+
+var (
+	items = make(map[string]*Item)
+	mu    sync.RWMutex
+)
+
+// Init reads the reading list from disk. It should be called after config.Init.
+func Init() error {
+	jsonBytes, err := ioutil.ReadFile(config.ReadingListPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read reading-list.json error: %w", err)
+	}
+	if len(jsonBytes) == 0 {
+		return nil
+	}
+	var list []*Item
+	if err := json.Unmarshal(jsonBytes, &list); err != nil {
+		return fmt.Errorf("reading-list.json is corrupted: %w", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, item := range list {
+		items[item.URL] = item
+	}
+	return nil
+}
+
+// writeJSON saves the reading list to disk. mu must be read-locked.
+func writeJSON() error {
+	list := make([]*Item, 0, len(items))
+	for _, item := range items {
+		list = append(list, item)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Added.Before(list[j].Added) })
+	jsonBytes, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(config.ReadingListPath, jsonBytes, 0666)
+}
+
+// copyPath returns the path of the saved copy of the page.
+func copyPath(u string) string {
+	return filepath.Join(config.ReadingListDir, fmt.Sprintf("%x", sha256.Sum256([]byte(u))))
+}
+
+// Add saves the page to the reading list as unread, with a copy of its content.
+// If it's already there, the copy is updated.
+func Add(item Item, raw string) error {
+	if err := os.MkdirAll(config.ReadingListDir, 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(copyPath(item.URL), []byte(raw), 0666); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	item.Read = false
+	if item.Added.IsZero() {
+		item.Added = time.Now()
+	}
+	items[item.URL] = &item
+	return writeJSON()
+}
+
+// Has returns true if the URL is in the reading list.
+func Has(u string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := items[u]
+	return ok
+}
+
+// All returns the items in the reading list, newest first.
+func All() []Item {
+	mu.RLock()
+	defer mu.RUnlock()
+	list := make([]Item, 0, len(items))
+	for _, item := range items {
+		list = append(list, *item)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Added.After(list[j].Added) })
+	return list
+}
+
+// Get returns the item for the URL, and its saved copy.
+func Get(u string) (Item, string, error) {
+	mu.RLock()
+	item, ok := items[u]
+	mu.RUnlock()
+	if !ok {
+		return Item{}, "", ErrNotFound
+	}
+	raw, err := ioutil.ReadFile(copyPath(u))
+	if err != nil {
+		return Item{}, "", err
+	}
+	return *item, string(raw), nil
+}
+
+// SetRead marks the item for the URL as read or unread.
+func SetRead(u string, read bool) error {
+	mu.Lock()
+	defer mu.Unlock()
+	item, ok := items[u]
+	if !ok {
+		return ErrNotFound
+	}
+	item.Read = read
+	return writeJSON()
+}
+
+// Remove removes the URL and its saved copy from the reading list.
+func Remove(u string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := items[u]; !ok {
+		return ErrNotFound
+	}
+	delete(items, u)
+	if err := os.Remove(copyPath(u)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return writeJSON()
+}
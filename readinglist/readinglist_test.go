@@ -0,0 +1,136 @@
+package readinglist
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+)
+
+// setup points the reading list at a temporary directory, and empties it.
+// The returned function removes the directory.
+func setup(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "amfora-readinglist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.ReadingListPath = filepath.Join(dir, "reading-list.json")
+	config.ReadingListDir = filepath.Join(dir, "reading-list")
+	mu.Lock()
+	items = make(map[string]*Item)
+	mu.Unlock()
+	return func() { os.RemoveAll(dir) }
+}
+
+func TestAddRemove(t *testing.T) {
+	defer setup(t)()
+
+	const u = "gemini://example.com/"
+	if err := Add(Item{URL: u, Title: "Example", Mediatype: "text/gemini"}, "# Example"); err != nil {
+		t.Fatal(err)
+	}
+	if !Has(u) {
+		t.Fatal("Has: the added page isn't in the reading list")
+	}
+	item, raw, err := Get(u)
+	if err != nil || raw != "# Example" || item.Title != "Example" || item.Read || item.Added.IsZero() {
+		t.Errorf("Get: %+v %q %v", item, raw, err)
+	}
+
+	if err := SetRead(u, true); err != nil {
+		t.Fatal(err)
+	}
+	if item, _, _ := Get(u); !item.Read {
+		t.Error("SetRead: the item isn't read")
+	}
+	// Adding it again updates the copy, and makes it unread
+	if err := Add(Item{URL: u, Title: "Example"}, "# Changed"); err != nil {
+		t.Fatal(err)
+	}
+	if item, raw, _ := Get(u); item.Read || raw != "# Changed" {
+		t.Errorf("Add again: got %+v %q", item, raw)
+	}
+
+	if err := Remove(u); err != nil {
+		t.Fatal(err)
+	}
+	if Has(u) {
+		t.Error("Has: the removed page is still in the reading list")
+	}
+	if _, err := os.Stat(copyPath(u)); !os.IsNotExist(err) {
+		t.Errorf("the copy of the removed page is still there: %v", err)
+	}
+	if err := Remove(u); err != ErrNotFound {
+		t.Errorf("Remove again: expected ErrNotFound, actual %v", err)
+	}
+	if err := SetRead(u, true); err != ErrNotFound {
+		t.Errorf("SetRead after removing: expected ErrNotFound, actual %v", err)
+	}
+	if _, _, err := Get(u); err != ErrNotFound {
+		t.Errorf("Get after removing: expected ErrNotFound, actual %v", err)
+	}
+}
+
+func TestAllOrder(t *testing.T) {
+	defer setup(t)()
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	urls := []string{"gemini://example.com/a", "gemini://example.com/b", "gemini://example.com/c"}
+	for i, u := range urls {
+		if err := Add(Item{URL: u, Added: start.Add(time.Duration(i) * time.Hour)}, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	all := All()
+	if len(all) != 3 {
+		t.Fatalf("All: expected 3 items, actual %d", len(all))
+	}
+	for i := range all {
+		if want := urls[len(urls)-1-i]; all[i].URL != want {
+			t.Errorf("All: item %d is %s, expected %s, newest first", i, all[i].URL, want)
+		}
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	defer setup(t)()
+
+	const u = "gemini://example.com/"
+	added := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := Add(Item{URL: u, Title: "Example", Lang: "en", Added: added}, "# Example"); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetRead(u, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Start over, like when Amfora is opened again
+	mu.Lock()
+	items = make(map[string]*Item)
+	mu.Unlock()
+	if err := Init(); err != nil {
+		t.Fatal(err)
+	}
+	item, raw, err := Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Title != "Example" || item.Lang != "en" || !item.Read || !item.Added.Equal(added) || raw != "# Example" {
+		t.Errorf("after Init: got %+v %q", item, raw)
+	}
+
+	// A missing file is an empty reading list, a corrupted one is an error
+	os.Remove(config.ReadingListPath)
+	if err := Init(); err != nil {
+		t.Errorf("Init without a file: %v", err)
+	}
+	if err := ioutil.WriteFile(config.ReadingListPath, []byte("not json"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := Init(); err == nil {
+		t.Error("Init with a corrupted file: expected an error")
+	}
+}
@@ -0,0 +1,202 @@
+package renderer
+
+import (
+	"regexp"
+	"unicode"
+
+	"code.rocketnine.space/tslocum/cview"
+)
+
+// Functions for displaying right-to-left text, like Arabic and Hebrew.
+//
+// Most terminals display characters in the order they are stored, so RTL text
+// would appear reversed. This is a simplified version of the Unicode
+// Bidirectional Algorithm (UAX #9), without support for explicit embeddings
+// or isolates. Each wrapped line is reordered into visual order on its own.
+
+// Matches text escaped by cview.Escape, so it can be unescaped before reordering.
+var escapedRegex = regexp.MustCompile(`(\[[a-zA-Z0-9_,;: \-\."#]+\[*)\[\]`)
+
+// Scripts that are written right-to-left
+var rtlScripts = []*unicode.RangeTable{
+	unicode.Arabic, unicode.Hebrew, unicode.Syriac, unicode.Thaana, unicode.Nko,
+	unicode.Samaritan, unicode.Mandaic, unicode.Adlam, unicode.Hanifi_Rohingya,
+}
+
+// Characters that are swapped with their partner when in right-to-left text
+var mirrored = map[rune]rune{
+	'(': ')', ')': '(', '[': ']', ']': '[', '{': '}', '}': '{', '<': '>', '>': '<',
+	'«': '»', '»': '«', '‹': '›', '›': '‹',
+}
+
+// Bidi character types, simplified
+const (
+	bidiNeutral = iota
+	bidiL       // Strong left-to-right
+	bidiR       // Strong right-to-left
+	bidiNum     // Numbers, which are always displayed left-to-right
+)
+
+func bidiType(r rune) int {
+	switch {
+	case r == '\u200f' || unicode.IsOneOf(rtlScripts, r) && (unicode.IsLetter(r) || unicode.IsMark(r)):
+		return bidiR
+	case unicode.IsDigit(r):
+		return bidiNum
+	case r == '\u200e' || unicode.IsLetter(r) || unicode.IsMark(r):
+		return bidiL
+	}
+	return bidiNeutral
+}
+
+// hasRTL returns true if the string has any right-to-left characters.
+func hasRTL(s string) bool {
+	for _, r := range s {
+		if bidiType(r) == bidiR {
+			return true
+		}
+	}
+	return false
+}
+
+// isRTL returns true if the paragraph direction of the string is right-to-left,
+// which is decided by its first strong character.
+func isRTL(s string) bool {
+	for _, r := range s {
+		switch bidiType(r) {
+		case bidiR:
+			return true
+		case bidiL:
+			return false
+		}
+	}
+	return false
+}
+
+// bidiLevels returns the embedding level of each rune, following the
+// weak, neutral, and implicit rules of the algorithm.
+func bidiLevels(runes []rune, rtl bool) []int {
+	types := make([]int, len(runes))
+	for i, r := range runes {
+		types[i] = bidiType(r)
+	}
+	base := 0
+	sos := bidiL
+	if rtl {
+		base = 1
+		sos = bidiR
+	}
+
+	// W7: numbers after left-to-right text act as left-to-right text
+	prevStrong := sos
+	for i, t := range types {
+		switch t {
+		case bidiL, bidiR:
+			prevStrong = t
+		case bidiNum:
+			if prevStrong == bidiL {
+				types[i] = bidiL
+			}
+		}
+	}
+
+	// N1 and N2: neutrals take the direction of the text around them if it's
+	// the same on both sides, otherwise the paragraph direction
+	strongAt := func(i int) int {
+		if types[i] == bidiNum {
+			return bidiR
+		}
+		return types[i]
+	}
+	for i := 0; i < len(types); i++ {
+		if types[i] != bidiNeutral {
+			continue
+		}
+		end := i
+		for end < len(types) && types[end] == bidiNeutral {
+			end++
+		}
+		before, after := sos, sos
+		if i > 0 {
+			before = strongAt(i - 1)
+		}
+		if end < len(types) {
+			after = strongAt(end)
+		}
+		dir := sos
+		if before == after {
+			dir = before
+		}
+		for j := i; j < end; j++ {
+			types[j] = dir
+		}
+		i = end - 1
+	}
+
+	// I1 and I2: resolve the levels
+	levels := make([]int, len(types))
+	for i, t := range types {
+		switch {
+		case base == 0 && t == bidiR:
+			levels[i] = 1
+		case base == 0 && t == bidiNum:
+			levels[i] = 2
+		case base == 1 && (t == bidiL || t == bidiNum):
+			levels[i] = 2
+		default:
+			levels[i] = base
+		}
+	}
+
+	// L1: trailing whitespace goes back to the paragraph level
+	for i := len(runes) - 1; i >= 0 && unicode.IsSpace(runes[i]); i-- {
+		levels[i] = base
+	}
+	return levels
+}
+
+// reorderBidi returns the line in visual order, for display in a terminal.
+// The line can be escaped with cview.Escape, but must not contain any color
+// tags. rtl is the paragraph direction, see isRTL.
+func reorderBidi(line string, rtl bool) string {
+	if !rtl && !hasRTL(line) {
+		// Nothing to reorder
+		return line
+	}
+
+	runes := []rune(escapedRegex.ReplaceAllString(line, "$1]"))
+	levels := bidiLevels(runes, rtl)
+
+	// L4: mirror characters in right-to-left text
+	for i, r := range runes {
+		if m, ok := mirrored[r]; ok && levels[i]%2 == 1 {
+			runes[i] = m
+		}
+	}
+
+	// L2: reverse any sequence at that level or higher, from the highest level
+	// down to the lowest odd level
+	maxLevel := 0
+	for _, l := range levels {
+		if l > maxLevel {
+			maxLevel = l
+		}
+	}
+	for level := maxLevel; level >= 1; level-- {
+		for i := 0; i < len(runes); i++ {
+			if levels[i] < level {
+				continue
+			}
+			end := i
+			for end < len(runes) && levels[end] >= level {
+				end++
+			}
+			for a, b := i, end-1; a < b; a, b = a+1, b-1 {
+				runes[a], runes[b] = runes[b], runes[a]
+				levels[a], levels[b] = levels[b], levels[a]
+			}
+			i = end
+		}
+	}
+	return cview.Escape(string(runes))
+}
@@ -0,0 +1,22 @@
+package renderer
+
+import "testing"
+
+var reorderBidiTests = []struct {
+	line     string
+	expected string
+}{
+	{"hello world", "hello world"},
+	{"שלום world", "world םולש"},
+	{"abc שלום 123 def", "abc 123 םולש def"},
+	{"שלום (עולם)", "(םלוע) םולש"},
+	{"مرحبا 2021", "2021 ابحرم"},
+}
+
+func TestReorderBidi(t *testing.T) {
+	for _, tt := range reorderBidiTests {
+		if actual := reorderBidi(tt.line, isRTL(tt.line)); actual != tt.expected {
+			t.Errorf("reorderBidi(%q): expected %q, actual %q", tt.line, tt.expected, actual)
+		}
+	}
+}
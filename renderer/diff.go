@@ -0,0 +1,189 @@
+package renderer
+
+import (
+	"fmt"
+	"strings"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/spf13/viper"
+)
+
+// The number of unchanged lines shown around changes in a diff.
+const diffContext = 3
+
+// The largest number of lines compared with each other for a diff. Larger
+// changes are shown as all the old lines removed and the new ones added,
+// so that diffing doesn't use too much memory.
+const maxDiffCells = 4_000_000
+
+// diffOp is a line of a diff, starting with ' ', '-', or '+'.
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// diffLines returns the lines of a and b as a list of unchanged, removed,
+// and added lines, using their longest common subsequence.
+func diffLines(a, b []string) []diffOp {
+	// Lines at the start and end that are the same don't need comparing
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:pre] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	midA := a[pre : len(a)-suf]
+	midB := b[pre : len(b)-suf]
+
+	if len(midA)*len(midB) > maxDiffCells {
+		for _, line := range midA {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range midB {
+			ops = append(ops, diffOp{'+', line})
+		}
+	} else {
+		// lcs[i][j] is the length of the LCS of midA[i:] and midB[j:]
+		w := len(midB) + 1
+		lcs := make([]int32, (len(midA)+1)*w)
+		for i := len(midA) - 1; i >= 0; i-- {
+			for j := len(midB) - 1; j >= 0; j-- {
+				switch {
+				case midA[i] == midB[j]:
+					lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+				case lcs[(i+1)*w+j] >= lcs[i*w+j+1]:
+					lcs[i*w+j] = lcs[(i+1)*w+j]
+				default:
+					lcs[i*w+j] = lcs[i*w+j+1]
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(midA) || j < len(midB) {
+			switch {
+			case i < len(midA) && j < len(midB) && midA[i] == midB[j]:
+				ops = append(ops, diffOp{' ', midA[i]})
+				i++
+				j++
+			case j == len(midB) || (i < len(midA) && lcs[(i+1)*w+j] >= lcs[i*w+j+1]):
+				ops = append(ops, diffOp{'-', midA[i]})
+				i++
+			default:
+				ops = append(ops, diffOp{'+', midB[j]})
+				j++
+			}
+		}
+	}
+
+	for _, line := range a[len(a)-suf:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// UnifiedDiff returns a unified diff of the old and new text, with the names
+// in its header. It returns an empty string if the texts are the same.
+func UnifiedDiff(oldName, newName, old, new string) string {
+	ops := diffLines(strings.Split(old, "\n"), strings.Split(new, "\n"))
+
+	var sb strings.Builder
+	// Line numbers in the old and new text of the current op
+	oldLine, newLine := 1, 1
+	for start := 0; start < len(ops); {
+		// Find the next change
+		if ops[start].kind == ' ' {
+			oldLine++
+			newLine++
+			start++
+			continue
+		}
+		// Include the context before it, and all the changes
+		// closer together than twice the context
+		from := start - diffContext
+		if from < 0 {
+			from = 0
+		}
+		end := start
+		for unchanged := 0; end < len(ops) && unchanged <= 2*diffContext; end++ {
+			if ops[end].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		// Remove extra unchanged lines at the end, leaving the context after
+		for end > start && ops[end-1].kind == ' ' {
+			end--
+		}
+		end += diffContext
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		hunkOld, hunkNew := oldLine-(start-from), newLine-(start-from)
+		var oldN, newN int
+		var hunk strings.Builder
+		for _, op := range ops[from:end] {
+			hunk.WriteByte(op.kind)
+			hunk.WriteString(op.text)
+			hunk.WriteByte('\n')
+			if op.kind != '+' {
+				oldN++
+			}
+			if op.kind != '-' {
+				newN++
+			}
+		}
+		if sb.Len() == 0 {
+			fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", hunkOld, oldN, hunkNew, newN)
+		sb.WriteString(hunk.String())
+
+		// Continue after the hunk
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		start = end
+	}
+	return sb.String()
+}
+
+// RenderDiff colors the lines of a unified diff by whether they were added
+// or removed, using the theme colors.
+func RenderDiff(s string) string {
+	if !viper.GetBool("a-general.color") {
+		return cview.Escape(s)
+	}
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		var key string
+		switch {
+		case strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "):
+			lines[i] = fmt.Sprintf("[::b]%s[::-]", cview.Escape(line))
+			continue
+		case strings.HasPrefix(line, "@@"):
+			key = "diff_hunk"
+		case strings.HasPrefix(line, "+"):
+			key = "diff_added"
+		case strings.HasPrefix(line, "-"):
+			key = "diff_removed"
+		default:
+			key = "regular_text"
+		}
+		lines[i] = fmt.Sprintf("[%s]%s[-]", config.GetColorString(key), cview.Escape(line))
+	}
+	return strings.Join(lines, "\n")
+}
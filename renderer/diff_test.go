@@ -0,0 +1,31 @@
+package renderer
+
+import "testing"
+
+var unifiedDiffTests = []struct {
+	old      string
+	new      string
+	expected string
+}{
+	{"a\nb\nc", "a\nb\nc", ""},
+	{"a\nb\nc", "a\nB\nc", "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"},
+	{"a", "a\nb", "--- old\n+++ new\n@@ -1,1 +1,2 @@\n a\n+b\n"},
+	{
+		"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12",
+		"x\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ny",
+		"--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+x\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+y\n",
+	},
+	{
+		"1\n2\n3\n4\n5\n6\n7",
+		"x\n2\n3\n4\n5\n6\ny",
+		"--- old\n+++ new\n@@ -1,7 +1,7 @@\n-1\n+x\n 2\n 3\n 4\n 5\n 6\n-7\n+y\n",
+	},
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	for _, tt := range unifiedDiffTests {
+		if actual := UnifiedDiff("old", "new", tt.old, tt.new); actual != tt.expected {
+			t.Errorf("UnifiedDiff(%q, %q): expected %q, actual %q", tt.old, tt.new, tt.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package renderer
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+)
+
+// Functions for hyphenating words, using Liang's algorithm like TeX does.
+//
+// Patterns aren't included with Amfora, they are loaded from the files set
+// in the "hyphenation" section of the config, one for each language.
+
+// Words can't be split closer to their start or end than this
+const (
+	hyphenMinLeft  = 2
+	hyphenMinRight = 3
+)
+
+// hyphenator finds the places words can be hyphenated, for a single language.
+type hyphenator struct {
+	// patterns maps the letters of each pattern to its values,
+	// where values[i] is for the gap before letter i.
+	patterns map[string][]int
+	maxLen   int // Length of the longest pattern, in runes
+}
+
+// Hyphenators that have been loaded, by config key.
+// A nil value means the patterns couldn't be loaded.
+var hyphenators = make(map[string]*hyphenator)
+var hyphenatorsMu sync.Mutex
+
+// parsePatterns reads hyphenation patterns, like "a1b" or ".ex3". Both the
+// plain text pattern files from the hyph-utf8 project, and the .dic files
+// used by LibreOffice are supported. They must be encoded as UTF-8.
+func parsePatterns(r io.Reader) (*hyphenator, error) {
+	h := &hyphenator{patterns: make(map[string][]int)}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '%'); i != -1 {
+			// Remove comment
+			line = line[:i]
+		}
+		for _, field := range strings.Fields(line) {
+			if strings.ContainsRune(field, '/') || strings.IndexFunc(field, unicode.IsUpper) != -1 {
+				// Non-standard patterns, or a keyword like the charset
+				continue
+			}
+			letters := make([]rune, 0, len(field))
+			values := []int{0}
+			for _, r := range field {
+				if r >= '0' && r <= '9' {
+					values[len(values)-1] = int(r - '0')
+				} else {
+					letters = append(letters, r)
+					values = append(values, 0)
+				}
+			}
+			if len(letters) == 0 {
+				continue
+			}
+			h.patterns[string(letters)] = values
+			if len(letters) > h.maxLen {
+				h.maxLen = len(letters)
+			}
+		}
+	}
+	return h, scanner.Err()
+}
+
+// points returns the rune indexes in the word where a hyphen can be inserted.
+func (h *hyphenator) points(word string) []int {
+	runes := []rune("." + strings.ToLower(word) + ".")
+	values := make([]int, len(runes)+1)
+	for i := range runes {
+		for j := i + 1; j <= len(runes) && j-i <= h.maxLen; j++ {
+			pat, ok := h.patterns[string(runes[i:j])]
+			if !ok {
+				continue
+			}
+			for k, v := range pat {
+				if v > values[i+k] {
+					values[i+k] = v
+				}
+			}
+		}
+	}
+
+	n := len(runes) - 2 // Length of the word
+	ret := make([]int, 0)
+	for i := hyphenMinLeft; i <= n-hyphenMinRight; i++ {
+		// Odd values allow hyphens, and the leading dot shifts them by one
+		if values[i+1]%2 == 1 {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+// split finds the best place to hyphenate the word at the start of the
+// provided clusters, so the first part and a hyphen fit in avail columns.
+// It returns the number of clusters in the first part, and false if the
+// word can't be hyphenated there.
+func (h *hyphenator) split(clusters []cluster, avail int) (int, bool) {
+	// Only the letters of the word are hyphenated, not any punctuation after it
+	word := make([]rune, 0, len(clusters))
+	for _, c := range clusters {
+		r := []rune(c.s)
+		if len(r) != 1 || !unicode.IsLetter(r[0]) {
+			break
+		}
+		word = append(word, r[0])
+	}
+	if len(word) < hyphenMinLeft+hyphenMinRight {
+		return 0, false
+	}
+
+	best := 0
+	for _, p := range h.points(string(word)) {
+		w := 0
+		for _, c := range clusters[:p] {
+			w += c.w
+		}
+		if w+1 > avail {
+			break
+		}
+		best = p
+	}
+	return best, best > 0
+}
+
+// hyphenatorFor returns the hyphenator for pages in the provided language,
+// or nil if hyphenation isn't set up for it. lang is the lang parameter of
+// the mediatype, and pages without one use the "default" key in the config.
+func hyphenatorFor(lang string) *hyphenator {
+	// Only the first language of a list is used, and more general
+	// languages are tried if there's nothing for a specific one
+	lang = strings.ToLower(strings.TrimSpace(strings.Split(lang, ",")[0]))
+	keys := []string{"default"}
+	if lang != "" {
+		keys = []string{lang}
+		if i := strings.IndexAny(lang, "-_"); i != -1 {
+			keys = append(keys, lang[:i])
+		}
+	}
+
+	hyphenatorsMu.Lock()
+	defer hyphenatorsMu.Unlock()
+
+	for _, key := range keys {
+		if !viper.IsSet("hyphenation." + key) {
+			continue
+		}
+		if h, ok := hyphenators[key]; ok {
+			return h
+		}
+
+		var h *hyphenator
+		path, err := homedir.Expand(viper.GetString("hyphenation." + key))
+		if err == nil {
+			var f *os.File
+			f, err = os.Open(path)
+			if err == nil {
+				h, err = parsePatterns(f)
+				f.Close()
+			}
+		}
+		if err != nil {
+			h = nil
+		}
+		hyphenators[key] = h
+		return h
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package renderer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Patterns from Liang's thesis, enough to hyphenate "hyphenation"
+const testPatterns = `UTF-8
+% comment
+hy3ph he2n hena4 hen5at 1na n2at 1tio 2io o2n`
+
+func TestHyphenatorPoints(t *testing.T) {
+	h, err := parsePatterns(strings.NewReader(testPatterns))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []int{2, 6}
+	if actual := h.points("hyphenation"); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, actual %v", expected, actual)
+	}
+}
+
+func TestWordWrapHyphens(t *testing.T) {
+	h, _ := parsePatterns(strings.NewReader(testPatterns))
+	expected := []string{"a hyphen-", "ation."}
+	if actual := wordWrap("a hyphenation.", 10, h); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %q, actual %q", expected, actual)
+	}
+}
+
+func TestJustify(t *testing.T) {
+	expected := "  one   two  three"
+	if actual := justify("  one two three", 18); actual != expected {
+		t.Errorf("expected %q, actual %q", expected, actual)
+	}
+}
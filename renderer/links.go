@@ -0,0 +1,91 @@
+package renderer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Link is a link line of a gemtext document.
+type Link struct {
+	URL  string
+	Text string // Empty if the link has none
+}
+
+// GemtextLinks returns the links of the gemtext, in the order they're in.
+// Like when rendering, lines in preformatted blocks and links without a URL
+// are skipped.
+func GemtextLinks(s string) []Link {
+	var links []Link
+	pre := false
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.HasPrefix(line, "```") {
+			pre = !pre
+			continue
+		}
+		if pre || !strings.HasPrefix(line, "=>") {
+			continue
+		}
+		line = strings.Trim(line[2:], " \t")
+		if line == "" {
+			continue
+		}
+		var link Link
+		if delim := strings.IndexAny(line, " \t"); delim == -1 {
+			link.URL = line
+		} else {
+			link.URL = line[:delim]
+			link.Text = strings.Trim(line[delim:], " \t")
+		}
+		links = append(links, link)
+	}
+	return links
+}
+
+// Words in the text of links that go to the next or previous page of
+// something split into pages, like the archive of a gemlog or a story.
+// Gemlogs list the newest posts first, so "older" goes to the next page.
+var (
+	nextWords = []string{"next", "older", "forward", "continue", "→", "»", ">>"}
+	prevWords = []string{"previous", "prev", "newer", "←", "«", "<<"}
+)
+
+// Links with longer text than this many words are assumed to be about
+// something else, like a post titled "What's next".
+const maxPaginationWords = 4
+
+// PaginationLink returns the URL of the link in the gemtext that goes to the
+// next page, or the previous one if next is false. The link closest to the
+// end is used, as that's where navigation links usually are.
+func PaginationLink(s string, next bool) (string, bool) {
+	want := nextWords
+	if !next {
+		want = prevWords
+	}
+	links := GemtextLinks(s)
+	for i := len(links) - 1; i >= 0; i-- {
+		words := strings.FieldsFunc(strings.ToLower(links[i].Text), func(r rune) bool {
+			return unicode.IsSpace(r) || r == ':' || r == '.' || r == ',' || r == '(' || r == ')' ||
+				r == '[' || r == ']' || r == '|'
+		})
+		if len(words) == 0 || len(words) > maxPaginationWords {
+			continue
+		}
+		for _, word := range words {
+			if hasWord(want, word) {
+				return links[i].URL, true
+			}
+		}
+	}
+	return "", false
+}
+
+// hasWord returns true if word is in words, or starts with one of the arrows.
+func hasWord(words []string, word string) bool {
+	for _, w := range words {
+		if word == w || (!unicode.IsLetter([]rune(w)[0]) && strings.HasPrefix(word, w)) {
+			return true
+		}
+	}
+	return false
+}
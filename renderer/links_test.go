@@ -0,0 +1,214 @@
+package renderer
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestLinkColor(t *testing.T) {
+	base, _ := url.Parse("gemini://example.com/dir/page.gmi")
+	tests := []struct {
+		link    string
+		base    *url.URL
+		proxied bool
+		want    string
+	}{
+		{"other.gmi", base, false, "relative_link"},
+		{"/root.gmi", nil, false, "relative_link"},
+		{"//example.com/x", base, false, "relative_link"},
+		{"//example.org/x", base, false, "cross_host_link"},
+		{"gemini://example.com/x", base, false, "amfora_link"},
+		{"gemini://EXAMPLE.com/x", base, false, "amfora_link"},
+		{"gemini://example.org/x", base, false, "cross_host_link"},
+		{"gemini://example.org/x", nil, false, "amfora_link"},
+		{"about:bookmarks", base, false, "amfora_link"},
+		{"gopher://example.com/1", base, false, "gopher_link"},
+		{"https://example.com/", base, false, "http_link"},
+		{"mailto:someone@example.com", base, false, "foreign_link"},
+		{"page.html", nil, true, "http_link"},
+		{"gemini://example.com/", nil, true, "foreign_link"},
+	}
+	for _, tt := range tests {
+		if got := linkColor(tt.link, tt.base, tt.proxied); got != tt.want {
+			t.Errorf("linkColor(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestLinkMarkers(t *testing.T) {
+	viper.Set("a-general.color", false)
+	defer viper.Set("a-general.color", true)
+	defer viper.Set("a-general.link_markers", "numbers")
+	defer viper.Set("a-general.underline_links", false)
+	defer viper.Set("a-general.link_scheme", false)
+
+	tests := []struct {
+		markers   string
+		underline bool
+		scheme    bool
+		want      string
+	}{
+		{"numbers", false, false, `[::b][1[][::-]  ["0"]Home[""]`},
+		{"bullets", false, false, "[::b]\u2022[::-] " + `["0"]Home[""]`},
+		{"none", false, false, `["0"]Home[""]`},
+		{"none", true, false, `["0"][::u]Home[::-][""]`},
+		{"none", false, true, `["0"]Home [gopher[][""]`},
+		{"on_demand", false, false, `["0"]Home[""]`},
+	}
+	for _, tt := range tests {
+		viper.Set("a-general.link_markers", tt.markers)
+		viper.Set("a-general.underline_links", tt.underline)
+		viper.Set("a-general.link_scheme", tt.scheme)
+		link := "=> gemini://example.com/ Home"
+		if tt.scheme {
+			link = "=> gopher://example.com/ Home"
+		}
+		got, _ := convertRegularGemini(link, 0, 80, false, "", nil, false)
+		if got != tt.want {
+			t.Errorf("%s, underline %v, scheme %v: got %q, want %q", tt.markers, tt.underline, tt.scheme, got, tt.want)
+		}
+	}
+}
+
+func TestPaginationLink(t *testing.T) {
+	tests := []struct {
+		raw  string
+		next bool
+		want string
+	}{
+		{"=> /1.gmi Previous page\n=> /3.gmi Next page\n", true, "/3.gmi"},
+		{"=> /1.gmi Previous page\n=> /3.gmi Next page\n", false, "/1.gmi"},
+		{"=> /old.gmi Older posts\n", true, "/old.gmi"},
+		{"=> /new.gmi « Newer\n", false, "/new.gmi"},
+		{"=> /ch2.gmi →\n", true, "/ch2.gmi"},
+		{"=> /ch2.gmi Chapter 2 »\n", true, "/ch2.gmi"},
+		{"=> /post.gmi What's next for this capsule and me\n", true, ""},
+		{"=> /next.gmi\n", true, ""},
+		{"```\n=> /3.gmi Next\n```\n", true, ""},
+		{"=> /a.gmi Next\nText\n=> /b.gmi Next\n", true, "/b.gmi"},
+		{"=> /nextcloud.gmi Nextcloud\n", true, ""},
+	}
+	for _, tt := range tests {
+		got, ok := PaginationLink(tt.raw, tt.next)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("PaginationLink(%q, %v): got %q, want %q", tt.raw, tt.next, got, tt.want)
+		}
+	}
+}
+
+func TestCompactLinks(t *testing.T) {
+	viper.Set("a-general.color", false)
+	viper.Set("a-general.compact_links", true)
+	defer viper.Set("a-general.color", true)
+	defer viper.Set("a-general.compact_links", false)
+
+	var page []string
+	for i := 0; i < 10; i++ {
+		page = append(page, "=> gemini://example.com/ Link", "")
+	}
+	page = append(page, "Text", "", "=> gemini://example.com/ Alone")
+
+	got, _ := convertRegularGemini(strings.Join(page, "\n"), 0, 80, false, "", nil, false)
+	lines := strings.Split(got, "\r\n")
+	want := []string{
+		`[::b] [1[][::-] ["0"]Link[""]`,
+		`[::b] [2[][::-] ["1"]Link[""]`,
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d: got %q, want %q", i, lines[i], want[i])
+		}
+	}
+	if want := `[::b][10[][::-] ["9"]Link[""]`; lines[9] != want {
+		t.Errorf("line 9: got %q, want %q", lines[9], want)
+	}
+	// A link on its own isn't changed
+	if want := `[::b][11[][::-]  ["10"]Alone[""]`; lines[len(lines)-1] != want {
+		t.Errorf("last line: got %q, want %q", lines[len(lines)-1], want)
+	}
+	if len(lines) != 14 {
+		t.Errorf("got %d lines, want 14: %q", len(lines), lines)
+	}
+}
+
+func TestMarkers(t *testing.T) {
+	viper.Set("a-general.color", false)
+	viper.Set("a-general.bullets", true)
+	viper.Set("a-general.bullet", "-")
+	viper.Set("a-general.quote_marker", "")
+	viper.Set("a-general.heading_markers", []string{"█", "", "[x]"})
+	defer viper.Set("a-general.color", true)
+	defer viper.Set("a-general.bullet", "•")
+	defer viper.Set("a-general.bullets", true)
+	defer viper.Set("a-general.quote_marker", ">")
+	defer viper.Set("a-general.heading_markers", []string{"#", "##", "###"})
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"# Title", "[::b]█ Title[-::-]"},
+		{"##Part", "[::b]Part[-::-]"},
+		{"### Section", "[::b][x[] Section[-::-]"},
+		{"* Item", " [#ffffff]- Item[-]"},
+		{"> Quote", "[#ffffff::i]Quote[-::-]"},
+	}
+	for _, tt := range tests {
+		got, _ := convertRegularGemini(tt.line, 0, 80, false, "", nil, false)
+		if got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestListItem(t *testing.T) {
+	tests := []struct {
+		text  string
+		level int
+		want  string
+	}{
+		{"Item", 0, "Item"},
+		{" Item", 0, "Item"},
+		{"  Nested", 1, "Nested"},
+		{"\tNested", 1, "Nested"},
+		{"* Nested", 1, "Nested"},
+		{"- Nested", 1, "Nested"},
+		{"* * Deeper", 2, "Deeper"},
+		{"    * Deeper", 3, "Deeper"},
+		{"* * * * * * Too deep", maxListLevel, "Too deep"},
+		{"-1 degrees", 0, "-1 degrees"},
+	}
+	for _, tt := range tests {
+		if level, text := listItem(tt.text); level != tt.level || text != tt.want {
+			t.Errorf("listItem(%q) = %d, %q, want %d, %q", tt.text, level, text, tt.level, tt.want)
+		}
+	}
+
+	viper.Set("a-general.color", false)
+	viper.Set("a-general.bullets", true)
+	defer viper.Set("a-general.color", true)
+	got, _ := convertRegularGemini("* * Nested", 0, 80, false, "", nil, false)
+	if want := "   [#ffffff]◦ Nested[-]"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBlockedLinks(t *testing.T) {
+	viper.Set("a-general.color", false)
+	defer viper.Set("a-general.color", true)
+	SetBlockedFunc(func(host string) bool { return host == "blocked.example" })
+	defer SetBlockedFunc(func(string) bool { return false })
+
+	page := "=> gemini://blocked.example/ A\n=> gemini://example.com/ B"
+	got, _ := convertRegularGemini(page, 0, 80, false, "", nil, false)
+	lines := strings.Split(got, "\r\n")
+	if !strings.Contains(lines[0], "[blocked[]") {
+		t.Errorf("the link to a blocked host isn't marked: %q", lines[0])
+	}
+	if strings.Contains(got[len(lines[0]):], "blocked") {
+		t.Errorf("another link is marked: %q", got)
+	}
+}
@@ -0,0 +1,284 @@
+package renderer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Functions for converting Markdown to gemtext, so it can be rendered
+// with RenderGemini and use the same theme elements.
+//
+// Only the common parts of Markdown are supported. Headings, lists, quotes,
+// code blocks and links are converted to their gemtext equivalents. Inline
+// links can't exist in gemtext, so their text is kept in place and link lines
+// are added after the block they were in. Emphasis has no gemtext equivalent
+// and is left as it was written, which is readable as is.
+
+// Matches an ATX heading, like "## Heading ##"
+var mdHeadingRegex = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+
+// Matches the underline of a setext heading
+var mdSetextRegex = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+
+// Matches a bullet or numbered list item
+var mdListRegex = regexp.MustCompile(`^[ \t]*([-*+]|\d{1,9}[.)])[ \t]+(.*)$`)
+
+// Matches a quote line
+var mdQuoteRegex = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+
+// Matches the start or end of a fenced code block
+var mdFenceRegex = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})[ \t]*([^`]*)$")
+
+// Matches a link reference definition, like "[1]: https://example.com"
+var mdRefDefRegex = regexp.MustCompile(`^ {0,3}\[([^\]]+)\]:[ \t]*<?([^\s>]+)>?`)
+
+// Matches inline links and images, like "[text](url)" or `![alt](url "title")`
+var mdLinkRegex = regexp.MustCompile(`(!?)\[([^\]]*)\]\([ \t]*<?([^\s)>]+)>?(?:[ \t]+"[^"]*")?[ \t]*\)`)
+
+// Matches reference links, like "[text][ref]" or "[text][]"
+var mdRefLinkRegex = regexp.MustCompile(`(!?)\[([^\]]+)\]\[([^\]]*)\]`)
+
+// Matches autolinks, like "<gemini://example.com>"
+var mdAutolinkRegex = regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9+.-]*:[^\s<>]+)>`)
+
+// Matches backslash escapes of punctuation
+var mdEscapeRegex = regexp.MustCompile("\\\\([!-/:-@\\[-`{-~])")
+
+// isMarkdownRule returns true if the line is a thematic break, like "---" or "* * *".
+func isMarkdownRule(line string) bool {
+	line = strings.Join(strings.Fields(line), "")
+	if len(line) < 3 || strings.Trim(line, line[:1]) != "" {
+		return false
+	}
+	return line[0] == '-' || line[0] == '*' || line[0] == '_'
+}
+
+// isIndented returns true if the line starts with a tab or at least four spaces.
+func isIndented(line string) bool {
+	return strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "    ")
+}
+
+// markdownConverter holds the state of a Markdown to gemtext conversion.
+type markdownConverter struct {
+	refs  map[string]string // Link reference definitions, by lowercase label
+	out   []string          // Converted gemtext lines
+	para  []string          // Lines of the current paragraph
+	links []string          // Link lines to add after the current block
+}
+
+// inline converts the links in a line of text, and removes backslash escapes.
+// The link lines are added to c.links.
+func (c *markdownConverter) inline(text string) string {
+	addLink := func(image bool, label, u string) string {
+		if label == "" {
+			label = u
+		}
+		if image {
+			c.links = append(c.links, "=> "+u+" Image: "+label)
+		} else {
+			c.links = append(c.links, "=> "+u+" "+label)
+		}
+		return label
+	}
+
+	text = mdLinkRegex.ReplaceAllStringFunc(text, func(s string) string {
+		m := mdLinkRegex.FindStringSubmatch(s)
+		return addLink(m[1] == "!", m[2], m[3])
+	})
+	text = mdRefLinkRegex.ReplaceAllStringFunc(text, func(s string) string {
+		m := mdRefLinkRegex.FindStringSubmatch(s)
+		ref := m[3]
+		if ref == "" {
+			ref = m[2]
+		}
+		u, ok := c.refs[strings.ToLower(ref)]
+		if !ok {
+			return s
+		}
+		return addLink(m[1] == "!", m[2], u)
+	})
+	text = mdAutolinkRegex.ReplaceAllStringFunc(text, func(s string) string {
+		u := s[1 : len(s)-1]
+		c.links = append(c.links, "=> "+u)
+		return u
+	})
+	return mdEscapeRegex.ReplaceAllString(text, "$1")
+}
+
+// endPara adds the current paragraph as a single line.
+func (c *markdownConverter) endPara() {
+	if len(c.para) > 0 {
+		text := strings.Join(c.para, " ")
+		if strings.HasPrefix(text, "#") || strings.HasPrefix(text, "=>") {
+			// Don't let text like "#hashtag" become a heading or link line
+			text = " " + text
+		}
+		c.out = append(c.out, text)
+		c.para = nil
+	}
+}
+
+// endBlock ends the current paragraph and adds the link lines of the block.
+func (c *markdownConverter) endBlock() {
+	c.endPara()
+	c.out = append(c.out, c.links...)
+	c.links = nil
+}
+
+// MarkdownToGemtext converts Markdown text to gemtext.
+func MarkdownToGemtext(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	c := markdownConverter{refs: make(map[string]string)}
+
+	// Reference definitions can be anywhere in the document
+	for _, line := range lines {
+		if m := mdRefDefRegex.FindStringSubmatch(line); m != nil {
+			c.refs[strings.ToLower(m[1])] = m[2]
+		}
+	}
+
+	fence := ""       // The opening fence of the current fenced code block
+	indented := false // Whether in an indented code block
+	blanks := 0       // Blank lines in an indented code block that haven't been added yet
+	inList := false   // Whether the last line was part of a list
+
+	for _, line := range lines {
+		if fence != "" {
+			if m := mdFenceRegex.FindStringSubmatch(line); m != nil && m[2] == "" &&
+				m[1][0] == fence[0] && len(m[1]) >= len(fence) {
+				c.out = append(c.out, "```")
+				fence = ""
+			} else {
+				c.out = append(c.out, line)
+			}
+			continue
+		}
+
+		if indented {
+			if strings.TrimSpace(line) == "" {
+				blanks++
+				continue
+			}
+			if isIndented(line) {
+				for ; blanks > 0; blanks-- {
+					c.out = append(c.out, "")
+				}
+				c.out = append(c.out, strings.TrimPrefix(strings.TrimPrefix(line, "\t"), "    "))
+				continue
+			}
+			c.out = append(c.out, "```")
+			for ; blanks > 0; blanks-- {
+				c.out = append(c.out, "")
+			}
+			indented = false
+		}
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			c.endBlock()
+			if len(c.out) > 0 && c.out[len(c.out)-1] != "" {
+				c.out = append(c.out, "")
+			}
+			inList = false
+			continue
+		}
+
+		if m := mdFenceRegex.FindStringSubmatch(line); m != nil {
+			c.endBlock()
+			c.out = append(c.out, "```"+strings.TrimSpace(m[2]))
+			fence = m[1]
+			inList = false
+			continue
+		}
+
+		if len(c.para) > 0 {
+			if m := mdSetextRegex.FindStringSubmatch(line); m != nil {
+				level := "#"
+				if m[1][0] == '-' {
+					level = "##"
+				}
+				c.out = append(c.out, level+" "+strings.Join(c.para, " "))
+				c.para = nil
+				c.endBlock()
+				continue
+			}
+		}
+
+		if isMarkdownRule(line) {
+			c.endBlock()
+			c.out = append(c.out, "---")
+			inList = false
+			continue
+		}
+
+		if m := mdHeadingRegex.FindStringSubmatch(line); m != nil {
+			c.endBlock()
+			level := len(m[1])
+			if level > 3 {
+				// Gemtext only has three levels
+				level = 3
+			}
+			c.out = append(c.out, strings.Repeat("#", level)+" "+c.inline(m[2]))
+			inList = false
+			continue
+		}
+
+		if mdRefDefRegex.MatchString(line) {
+			// Already collected, the links are added where they're used
+			continue
+		}
+
+		if m := mdListRegex.FindStringSubmatch(line); m != nil {
+			c.endPara()
+			if m[1] == "-" || m[1] == "*" || m[1] == "+" {
+				c.out = append(c.out, "* "+c.inline(m[2]))
+			} else {
+				// Gemtext doesn't have numbered lists, so the number is kept as text
+				c.out = append(c.out, m[1]+" "+c.inline(m[2]))
+			}
+			inList = true
+			continue
+		}
+
+		if inList && len(c.para) == 0 && (line[0] == ' ' || line[0] == '\t') {
+			// Continuation of a list item
+			c.out[len(c.out)-1] += " " + c.inline(trimmed)
+			continue
+		}
+
+		if len(c.para) == 0 && isIndented(line) {
+			c.endBlock()
+			c.out = append(c.out, "```", strings.TrimPrefix(strings.TrimPrefix(line, "\t"), "    "))
+			indented = true
+			continue
+		}
+
+		if m := mdQuoteRegex.FindStringSubmatch(line); m != nil {
+			c.endPara()
+			c.out = append(c.out, "> "+c.inline(m[1]))
+			inList = false
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "|") {
+			// Table rows stay on their own lines, so they can be aligned
+			c.endPara()
+			c.out = append(c.out, c.inline(trimmed))
+			continue
+		}
+
+		inList = false
+		c.para = append(c.para, c.inline(trimmed))
+		if strings.HasSuffix(line, "  ") || strings.HasSuffix(trimmed, "\\") {
+			// Hard line break
+			c.para[len(c.para)-1] = strings.TrimSuffix(c.para[len(c.para)-1], "\\")
+			c.endPara()
+		}
+	}
+
+	if fence != "" || indented {
+		c.out = append(c.out, "```")
+	}
+	c.endBlock()
+	return strings.TrimRight(strings.Join(c.out, "\n"), "\n") + "\n"
+}
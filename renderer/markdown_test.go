@@ -0,0 +1,31 @@
+package renderer
+
+import "testing"
+
+var markdownTests = []struct {
+	md       string
+	expected string
+}{
+	{"# Title\n\nSome\ntext.\n", "# Title\n\nSome text.\n"},
+	{"Title\n=====\nSub\n---\n", "# Title\n## Sub\n"},
+	{"#### Deep ####\n", "### Deep\n"},
+	{"- one\n+ two\n  more\n1. three\n", "* one\n* two more\n1. three\n"},
+	{"> quoted\n", "> quoted\n"},
+	{"```go\ncode\n```\n", "```go\ncode\n```\n"},
+	{"text\n\n    code\n\n    more\nafter\n", "text\n\n```\ncode\n\nmore\n```\nafter\n"},
+	{"A [link](gemini://a.example/) here.\n\nNext", "A link here.\n=> gemini://a.example/ link\n\nNext\n"},
+	{"![alt](/img.png \"title\")\n", "alt\n=> /img.png Image: alt\n"},
+	{"See [this][1].\n\n[1]: https://b.example\n", "See this.\n=> https://b.example this\n"},
+	{"<gemini://c.example>\n", "gemini://c.example\n=> gemini://c.example\n"},
+	{"#tag and \\*stars\\*\n", " #tag and *stars*\n"},
+	{"line one  \nline two\n", "line one\nline two\n"},
+	{"***\n", "---\n"},
+}
+
+func TestMarkdownToGemtext(t *testing.T) {
+	for _, tt := range markdownTests {
+		if actual := MarkdownToGemtext(tt.md); actual != tt.expected {
+			t.Errorf("MarkdownToGemtext(%q): expected %q, actual %q", tt.md, tt.expected, actual)
+		}
+	}
+}
@@ -73,20 +73,78 @@ func CanDisplay(res *gemini.Response) bool {
 	return err == nil && enc != nil
 }
 
+// How often a page is rendered while it's being downloaded, at most.
+// Big pages wait longer between renders, see MakePage.
+const progressInterval = 100 * time.Millisecond
+
+// Pages kept loading past the limits are rendered less often, because all
+// of them is rendered each time, and they can get large.
+const streamProgressInterval = time.Second
+
+// now is used to time the partial pages, so tests can replace it.
+var now = time.Now
+
 // MakePage creates a formatted, rendered Page from the given network response and params.
 // You must set the Page.Width value yourself.
-func MakePage(url string, res *gemini.Response, width int, proxied bool) (*structs.Page, error) {
+//
+// If progress is not nil, it's called with partial pages while the response
+// is being downloaded, so the page can be displayed before it's complete.
+// Partial pages only have complete lines, and are only made for UTF-8 text.
+func MakePage(url string, res *gemini.Response, width int, proxied bool, progress func(*structs.Page)) (*structs.Page, error) {
+	return makePageMax(url, res, width, proxied, progress, viper.GetInt64("a-general.page_max_size"), progressInterval)
+}
+
+// MakeStreamPage is like MakePage, but for responses that are streamed
+// without an end, which the user chose to keep loading. The max size is the
+// larger stream_max_size, and partial pages are made less often.
+// The read timeout of the response should be disabled too.
+func MakeStreamPage(url string, res *gemini.Response, width int, proxied bool, progress func(*structs.Page)) (*structs.Page, error) {
+	return makePageMax(url, res, width, proxied, progress, viper.GetInt64("a-general.stream_max_size"),
+		streamProgressInterval)
+}
+
+// makePageMax is MakePage, with the max size of the page in bytes, and how
+// often partial pages are made at most.
+func makePageMax(url string, res *gemini.Response, width int, proxied bool, progress func(*structs.Page),
+	maxSize int64, interval time.Duration) (*structs.Page, error) {
 	if !CanDisplay(res) {
 		return nil, ErrCantDisplay
 	}
 
+	mediatype, params, _ := decodeMeta(res.Meta)
+	streaming := progress != nil && isUTF8(params["charset"])
+
 	buf := new(bytes.Buffer)
-	_, err := io.CopyN(buf, res.Body, viper.GetInt64("a-general.page_max_size")+1)
+	chunk := make([]byte, 32*1024)
+	nextProgress := now().Add(interval)
+	var err error
+	for {
+		var n int
+		n, err = res.Body.Read(chunk)
+		buf.Write(chunk[:n])
+		if int64(buf.Len()) > maxSize {
+			// Content was larger than max size
+			return nil, ErrTooLarge
+		}
+		if err != nil {
+			break
+		}
 
-	if err == nil {
-		// Content was larger than max size
-		return nil, ErrTooLarge
-	} else if err != io.EOF {
+		if streaming && now().After(nextProgress) {
+			if i := bytes.LastIndexByte(buf.Bytes(), '\n'); i != -1 {
+				start := now()
+				progress(makePage(url, mediatype, params, string(buf.Bytes()[:i+1]), width, proxied))
+				// Don't spend most of the time rendering partial pages
+				took := now().Sub(start)
+				if took*4 > interval {
+					nextProgress = now().Add(took * 4)
+				} else {
+					nextProgress = now().Add(interval)
+				}
+			}
+		}
+	}
+	if err != io.EOF {
 		if os.IsTimeout(err) {
 			// I would use
 			// errors.Is(err, os.ErrDeadlineExceeded)
@@ -99,60 +157,109 @@ func MakePage(url string, res *gemini.Response, width int, proxied bool) (*struc
 	}
 	// Otherwise, the error is EOF, which is what we want.
 
-	mediatype, params, _ := decodeMeta(res.Meta)
-
 	// Convert content first
-	var utfText string
+	utfText, err := DecodeBody(res.Meta, buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	page := makePage(url, mediatype, params, utfText, width, proxied)
+	if page == nil {
+		return nil, ErrBadMediatype
+	}
+	page.Meta = res.Meta
+	if !isUTF8(params["charset"]) {
+		page.Body = buf.Bytes()
+	}
+	return page, nil
+}
+
+// DecodeBody converts the body of a response with the meta to UTF-8,
+// using the charset in the meta.
+func DecodeBody(meta string, body []byte) (string, error) {
+	_, params, _ := decodeMeta(meta)
 	if isUTF8(params["charset"]) {
-		utfText = buf.String()
-	} else {
-		encoding, err := ianaindex.MIME.Encoding(params["charset"])
-		if encoding == nil || err != nil {
-			// Some encoding doesn't exist and wasn't caught in CanDisplay()
-			return nil, ErrBadEncoding
-		}
-		utfText, err = encoding.NewDecoder().String(buf.String())
-		if err != nil {
-			return nil, err
-		}
+		return string(body), nil
 	}
+	encoding, err := ianaindex.MIME.Encoding(params["charset"])
+	if encoding == nil || err != nil {
+		// Some encoding doesn't exist and wasn't caught in CanDisplay()
+		return "", ErrBadEncoding
+	}
+	return encoding.NewDecoder().String(string(body))
+}
 
+// makePage renders the UTF-8 text of a response into a Page.
+// It returns nil if the mediatype isn't handled.
+func makePage(url, mediatype string, params map[string]string, utfText string, width int, proxied bool) *structs.Page {
 	if mediatype == "text/gemini" {
-		rendered, links := RenderGemini(utfText, width, proxied)
+		rendered, links := RenderGeminiPage(utfText, url, width, proxied, ANSIEnabled(url), params["lang"],
+			viper.GetBool("a-general.wrap_pre"), false)
 		return &structs.Page{
 			Mediatype:    structs.TextGemini,
 			RawMediatype: mediatype,
+			Charset:      params["charset"],
 			URL:          url,
+			Lang:         params["lang"],
 			Raw:          utfText,
 			Content:      rendered,
 			Links:        links,
 			MadeAt:       time.Now(),
-		}, nil
+		}
+	} else if mediatype == "text/markdown" || mediatype == "text/x-markdown" {
+		rendered, links := RenderGeminiPage(MarkdownToGemtext(utfText), url, width, proxied, ANSIEnabled(url),
+			params["lang"], viper.GetBool("a-general.wrap_pre"), false)
+		return &structs.Page{
+			Mediatype:    structs.TextMarkdown,
+			RawMediatype: mediatype,
+			Charset:      params["charset"],
+			URL:          url,
+			Lang:         params["lang"],
+			Raw:          utfText,
+			Content:      rendered,
+			Links:        links,
+			MadeAt:       time.Now(),
+		}
 	} else if strings.HasPrefix(mediatype, "text/") {
 		if mediatype == "text/x-ansi" || strings.HasSuffix(url, ".ans") || strings.HasSuffix(url, ".ansi") {
 			// ANSI
 			return &structs.Page{
 				Mediatype:    structs.TextAnsi,
 				RawMediatype: mediatype,
+				Charset:      params["charset"],
 				URL:          url,
 				Raw:          utfText,
-				Content:      RenderANSI(utfText),
+				Content:      RenderANSI(utfText, ANSIEnabled(url)),
 				Links:        []string{},
 				MadeAt:       time.Now(),
-			}, nil
+			}
+		}
+
+		if mediatype == "text/x-diff" || mediatype == "text/x-patch" {
+			return &structs.Page{
+				Mediatype:    structs.TextDiff,
+				RawMediatype: mediatype,
+				Charset:      params["charset"],
+				URL:          url,
+				Raw:          utfText,
+				Content:      RenderDiff(utfText),
+				Links:        []string{},
+				MadeAt:       time.Now(),
+			}
 		}
 
 		// Treated as plaintext
 		return &structs.Page{
 			Mediatype:    structs.TextPlain,
 			RawMediatype: mediatype,
+			Charset:      params["charset"],
 			URL:          url,
 			Raw:          utfText,
 			Content:      RenderPlainText(utfText),
 			Links:        []string{},
 			MadeAt:       time.Now(),
-		}, nil
+		}
 	}
 
-	return nil, ErrBadMediatype
+	return nil
 }
@@ -0,0 +1,93 @@
+package renderer
+
+import (
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/makeworld-the-better-one/amfora/structs"
+	"github.com/makeworld-the-better-one/go-gemini"
+	"github.com/spf13/viper"
+)
+
+// chunk is what a scriptedReader returns for one read.
+type chunk struct {
+	data  string
+	after time.Duration // How much time passes before the chunk arrives
+}
+
+// scriptedReader returns one chunk per read, moving the clock used by
+// makePageMax forward instead of waiting.
+type scriptedReader struct {
+	chunks []chunk
+	clock  time.Time
+}
+
+func (r *scriptedReader) now() time.Time {
+	return r.clock
+}
+
+func (r *scriptedReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	r.clock = r.clock.Add(r.chunks[0].after)
+	n := copy(p, r.chunks[0].data)
+	r.chunks = r.chunks[1:]
+	return n, nil
+}
+
+func TestMakePageProgress(t *testing.T) {
+	viper.Set("a-general.page_max_size", 1<<20)
+	defer viper.Reset()
+
+	later := progressInterval + time.Millisecond
+	r := &scriptedReader{
+		chunks: []chunk{
+			{"first\nsec", later},
+			{"ond\n", 0}, // Too soon after the last partial page
+			{"thi", later},
+			{"rd\n", 0},
+		},
+		clock: time.Unix(0, 0),
+	}
+	now = r.now
+	defer func() { now = time.Now }()
+
+	res := &gemini.Response{Status: 20, Meta: "text/plain", Body: ioutil.NopCloser(r)}
+	var partials []string
+	page, err := MakePage("gemini://example.com/", res, 80, false, func(p *structs.Page) {
+		partials = append(partials, p.Raw)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Raw != "first\nsecond\nthird\n" {
+		t.Errorf("final page is %q", page.Raw)
+	}
+	// Partial pages only have complete lines
+	want := []string{"first\n", "first\nsecond\n"}
+	if !reflect.DeepEqual(partials, want) {
+		t.Errorf("partial pages are %q, want %q", partials, want)
+	}
+}
+
+func TestMakeStreamPageMaxSize(t *testing.T) {
+	viper.Set("a-general.page_max_size", 4)
+	viper.Set("a-general.stream_max_size", 10)
+	defer viper.Reset()
+
+	r := &scriptedReader{
+		chunks: []chunk{{"line 1\n", 0}, {"line 2\n", 0}},
+		clock:  time.Unix(0, 0),
+	}
+	now = r.now
+	defer func() { now = time.Now }()
+
+	res := &gemini.Response{Status: 20, Meta: "text/plain", Body: ioutil.NopCloser(r)}
+	if _, err := MakeStreamPage("gemini://example.com/", res, 80, false, nil); err != ErrTooLarge {
+		t.Errorf("expected ErrTooLarge for a stream over the max size, got %v", err)
+	}
+}
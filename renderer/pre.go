@@ -0,0 +1,37 @@
+package renderer
+
+import "strings"
+
+// PreBlock is a preformatted block of a gemtext document.
+type PreBlock struct {
+	Alt  string // The alt text after the opening backticks, empty if there's none
+	Text string // The lines of the block, without the backtick lines
+}
+
+// GemtextPreBlocks returns the preformatted blocks of the gemtext, in the
+// order they're in. A block that isn't closed goes to the end of the document.
+func GemtextPreBlocks(s string) []PreBlock {
+	var blocks []PreBlock
+	var lines []string
+	pre := false
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.HasPrefix(line, "```") {
+			if pre {
+				blocks[len(blocks)-1].Text = strings.Join(lines, "\n")
+			} else {
+				blocks = append(blocks, PreBlock{Alt: strings.TrimSpace(line[3:])})
+				lines = nil
+			}
+			pre = !pre
+			continue
+		}
+		if pre {
+			lines = append(lines, line)
+		}
+	}
+	if pre {
+		blocks[len(blocks)-1].Text = strings.TrimSuffix(strings.Join(lines, "\n"), "\n")
+	}
+	return blocks
+}
@@ -0,0 +1,60 @@
+package renderer
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestGemtextPreBlocks(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []PreBlock
+	}{
+		{"# Title\nNo blocks\n", nil},
+		{"Text\n```sh\necho hi\n  exit\n```\nMore\n", []PreBlock{{"sh", "echo hi\n  exit"}}},
+		{"```\r\na\r\n```\r\n``` config.toml \r\nb = 1\r\n```\r\n", []PreBlock{{"", "a"}, {"config.toml", "b = 1"}}},
+		{"```\n```\n", []PreBlock{{"", ""}}},
+		{"```\nnot closed\n", []PreBlock{{"", "not closed"}}},
+	}
+	for _, tt := range tests {
+		if got := GemtextPreBlocks(tt.s); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GemtextPreBlocks(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestPreAltText(t *testing.T) {
+	viper.Set("a-general.color", false)
+	defer viper.Set("a-general.color", true)
+	defer viper.Set("a-general.pre_alt_text", "hidden")
+
+	page := "```A cat\n=^.^=\n```\n```\nNo alt text\n```\n"
+	tests := []struct {
+		pos  string
+		want []string // The lines that are shown, without style tags
+	}{
+		{"hidden", []string{"=^.^=", "No alt text"}},
+		{"above", []string{"A cat", "=^.^=", "No alt text"}},
+		{"below", []string{"=^.^=", "A cat", "No alt text"}},
+	}
+	for _, tt := range tests {
+		viper.Set("a-general.pre_alt_text", tt.pos)
+		rendered, _ := RenderGeminiPage(page, "", 80, false, false, "", false, false)
+		var got []string
+		for _, line := range strings.Split(rendered, "\r\n") {
+			if line = styleTagRe.ReplaceAllString(line, ""); line != "" {
+				got = append(got, line)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.pos, got, tt.want)
+		}
+	}
+}
+
+// Matches the style tags in rendered lines
+var styleTagRe = regexp.MustCompile(`\[[^\[\]]*\]`)
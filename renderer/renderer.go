@@ -13,17 +13,48 @@ import (
 
 	"code.rocketnine.space/tslocum/cview"
 	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/mattn/go-runewidth"
 	"github.com/spf13/viper"
 )
 
 // Regex for identifying ANSI color codes
 var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
 
+// isBlocked returns true if links to the host are marked as blocked.
+var isBlocked = func(host string) bool { return false }
+
+// SetBlockedFunc sets how the renderer knows which hosts are blocked, so
+// links to them can be marked. It should be called before rendering pages.
+// Pages have to be rendered again when the blocked hosts change.
+func SetBlockedFunc(f func(host string) bool) {
+	isBlocked = f
+}
+
+// ANSIEnabled returns true if ANSI codes should be rendered as colors for
+// pages from the provided URL. The "ansi" config section can override the
+// "a-general.ansi" setting for specific hosts.
+func ANSIEnabled(u string) bool {
+	if !viper.GetBool("a-general.color") {
+		return false
+	}
+	parsed, err := urlPkg.Parse(u)
+	if err == nil && parsed.Hostname() != "" {
+		key := "ansi." + strings.ToLower(parsed.Hostname())
+		if viper.IsSet(key) {
+			return viper.GetBool(key)
+		}
+	}
+	return viper.GetBool("a-general.ansi")
+}
+
 // RenderANSI renders plain text pages containing ANSI codes.
 // Practically, it is used for the text/x-ansi.
-func RenderANSI(s string) string {
+//
+// ansi is whether ANSI codes should be turned into colors, see ANSIEnabled.
+// Otherwise they are removed.
+func RenderANSI(s string, ansi bool) string {
 	s = cview.Escape(s)
-	if viper.GetBool("a-general.color") && viper.GetBool("a-general.ansi") {
+	if ansi {
 		s = cview.TranslateANSI(s)
 	} else {
 		s = ansiRegex.ReplaceAllString(s, "")
@@ -40,12 +71,29 @@ func RenderPlainText(s string) string {
 }
 
 // wrapLine wraps a line to the provided width, and adds the provided prefix and suffix to each wrapped line.
+// Any right-to-left text is reordered for display, see reorderBidi. The line must not contain color tags.
 // It recovers from wrapping panics and should never cause a panic.
 // It returns a slice of lines, without newlines at the end.
 //
 // Set includeFirst to true if the prefix and suffix should be applied to the first wrapped line as well
 func wrapLine(line string, width int, prefix, suffix string, includeFirst bool) []string {
-	// Anonymous function to allow recovery from potential WordWrap panic
+	return wrapLineOpts(line, width, prefix, suffix, includeFirst, wrapOptions{})
+}
+
+// wrapOptions holds extra options for wrapping body text.
+type wrapOptions struct {
+	hyphens *hyphenator // Used to hyphenate words at the end of lines, if not nil
+	justify bool        // Whether to stretch all lines but the last to the full width
+}
+
+// wrapLineOpts is the same as wrapLine, but with extra options.
+func wrapLineOpts(line string, width int, prefix, suffix string, includeFirst bool, opts wrapOptions) []string {
+	key := newWrapKey(line, prefix, suffix, includeFirst, opts)
+	if lines, ok := cachedWrap(key, width); ok {
+		return lines
+	}
+
+	// Anonymous function to allow recovery from potential wrapping panic
 	var ret []string
 	func() {
 		defer func() {
@@ -59,7 +107,19 @@ func wrapLine(line string, width int, prefix, suffix string, includeFirst bool)
 			}
 		}()
 
-		wrapped := cview.WordWrap(line, width)
+		wrapped := wordWrap(line, width, opts.hyphens)
+		if viper.GetBool("a-general.bidi") {
+			// Reorder right-to-left text, using the direction of the whole line
+			rtl := isRTL(line)
+			for i := range wrapped {
+				wrapped[i] = reorderBidi(wrapped[i], rtl)
+			}
+		}
+		if opts.justify {
+			for i := 0; i < len(wrapped)-1; i++ {
+				wrapped[i] = justify(wrapped[i], width)
+			}
+		}
 		for i := range wrapped {
 			if !includeFirst && i == 0 {
 				continue
@@ -68,9 +128,59 @@ func wrapLine(line string, width int, prefix, suffix string, includeFirst bool)
 		}
 		ret = wrapped
 	}()
+	cacheWrap(key, width, ret)
 	return ret
 }
 
+// isPlainLine returns true if the provided gemtext line is just regular text,
+// and not a heading, link, list item, or quote.
+func isPlainLine(line string) bool {
+	return strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "#") &&
+		!strings.HasPrefix(line, "=>") && !strings.HasPrefix(line, "* ") &&
+		!strings.HasPrefix(line, ">")
+}
+
+// linkColor returns the theme key of the color for a link to u, on the page
+// at base, which can be nil. The keys for the kinds of links that
+// Amfora can't display fall back to "foreign_link", and the rest to
+// "amfora_link", see config.GetColor.
+func linkColor(u string, base *urlPkg.URL, proxied bool) string {
+	parsed, err := urlPkg.Parse(u)
+	if err != nil {
+		return "foreign_link"
+	}
+	switch parsed.Scheme {
+	case "":
+		if proxied {
+			// Relative links on proxied pages are to the same kind of page
+			return "http_link"
+		}
+		if parsed.Host != "" && base != nil && !strings.EqualFold(parsed.Hostname(), base.Hostname()) {
+			// Like //example.com/page
+			return "cross_host_link"
+		}
+		return "relative_link"
+	case "gemini":
+		if proxied {
+			return "foreign_link"
+		}
+		if base != nil && base.Scheme == "gemini" && !strings.EqualFold(parsed.Hostname(), base.Hostname()) {
+			return "cross_host_link"
+		}
+		return "amfora_link"
+	case "about":
+		if proxied {
+			return "foreign_link"
+		}
+		return "amfora_link"
+	case "gopher":
+		return "gopher_link"
+	case "http", "https":
+		return "http_link"
+	}
+	return "foreign_link"
+}
+
 // convertRegularGemini converts non-preformatted blocks of text/gemini
 // into a cview-compatible format.
 // Since this only works on non-preformatted blocks, RenderGemini
@@ -83,29 +193,65 @@ func wrapLine(line string, width int, prefix, suffix string, includeFirst bool)
 //
 // proxied is whether the request is through the gemini:// scheme.
 // If it's not a gemini:// page, set this to true.
-func convertRegularGemini(s string, numLinks, width int, proxied bool) (string, []string) {
+//
+// lang is the language of the page, used for hyphenation.
+//
+// base is the URL of the page, used to color links to other hosts. It can be nil.
+//
+// showNumbers is whether link numbers are shown when link_markers is "on_demand".
+func convertRegularGemini(s string, numLinks, width int, proxied bool, lang string,
+	base *urlPkg.URL, showNumbers bool) (string, []string) {
+	markers := viper.GetString("a-general.link_markers")
+	if markers == "on_demand" {
+		if showNumbers {
+			markers = "numbers"
+		} else {
+			markers = "none"
+		}
+	}
+
+	bodyOpts := wrapOptions{
+		hyphens: hyphenatorFor(lang),
+		justify: viper.GetBool("a-general.justify"),
+	}
 	links := make([]string, 0)
 	lines := strings.Split(s, "\n")
 	wrappedLines := make([]string, 0) // Final result
 
-	for i := range lines {
+	// In compact mode, links in a run of them, see linkRun, have no blank
+	// lines between them and their numbers line up
+	compact := viper.GetBool("a-general.compact_links")
+	var runLinks, runWidth int // The links left in the current run, and the width of its widest number
+
+	for i := 0; i < len(lines); i++ {
 		lines[i] = strings.TrimRight(lines[i], " \r\t\n")
 
+		if viper.GetBool("a-general.tables") && isPlainLine(lines[i]) {
+			// Tables can only be made of regular text lines
+			end := i + 1
+			for end < len(lines) && isPlainLine(lines[end]) {
+				end++
+			}
+			if n, t := findTable(lines[i:end], true); n > 0 {
+				for _, line := range t.render(viper.GetBool("a-general.table_borders") && !config.ScreenReader) {
+					wrappedLines = append(wrappedLines,
+						fmt.Sprintf("[%s]", config.GetColorString("regular_text"))+line+"[-]")
+				}
+				i += n - 1
+				continue
+			}
+		}
+
 		if strings.HasPrefix(lines[i], "#") {
 			// Headings
-			var tag string
+			level, text := splitHeading(lines[i])
+			heading := withMarker(cview.Escape(headingMarker(level)), text)
 			if viper.GetBool("a-general.color") {
-				if strings.HasPrefix(lines[i], "###") {
-					tag = fmt.Sprintf("[%s::b]", config.GetColorString("hdg_3"))
-				} else if strings.HasPrefix(lines[i], "##") {
-					tag = fmt.Sprintf("[%s::b]", config.GetColorString("hdg_2"))
-				} else if strings.HasPrefix(lines[i], "#") {
-					tag = fmt.Sprintf("[%s::b]", config.GetColorString("hdg_1"))
-				}
-				wrappedLines = append(wrappedLines, wrapLine(lines[i], width, tag, "[-::-]", true)...)
+				tag := fmt.Sprintf("[%s::b]", config.GetColorString("hdg_"+strconv.Itoa(level)))
+				wrappedLines = append(wrappedLines, wrapLine(heading, width, tag, "[-::-]", true)...)
 			} else {
 				// Just bold, no colors
-				wrappedLines = append(wrappedLines, wrapLine(lines[i], width, "[::b]", "[-::-]", true)...)
+				wrappedLines = append(wrappedLines, wrapLine(heading, width, "[::b]", "[-::-]", true)...)
 			}
 
 			// Links
@@ -140,23 +286,75 @@ func convertRegularGemini(s string, numLinks, width int, proxied bool) (string,
 			links = append(links, url)
 			num := numLinks + len(links) // Visible link number, one-indexed
 
-			var indent int
-			if num > 99 {
-				// Indent link text by 3 or more spaces
-				indent = len(strconv.Itoa(num)) + 4 // +4 indent for spaces and brackets
-			} else {
-				// One digit and two digit links have the same spacing - see #60
-				indent = 5 // +4 indent for spaces and brackets, and 1 for link number
+			if compact && runLinks == 0 {
+				// This line was already changed, so it's counted separately
+				if n := linkRun(lines[i+1:]) + 1; n > 1 {
+					runLinks = n
+					runWidth = len(strconv.Itoa(num + n - 1))
+				}
+			}
+			inRun := runLinks > 0
+			if inRun {
+				runLinks--
+			}
+
+			if viper.GetBool("a-general.link_scheme") {
+				// Label links that go somewhere other than Gemini, like "[gopher]"
+				if pU, err := urlPkg.Parse(url); err == nil && pU.Scheme != "" &&
+					pU.Scheme != "gemini" && pU.Scheme != "about" {
+					linkText += " " + cview.Escape("["+pU.Scheme+"]")
+				}
+			}
+			// Links to blocked hosts won't be loaded, so they're marked
+			if pU, err := urlPkg.Parse(url); err == nil && pU.Hostname() != "" && isBlocked(pU.Hostname()) {
+				linkText += " " + cview.Escape("[blocked]")
 			}
 
-			// Spacing after link number: 1 or 2 spaces?
+			// The marker before the link text, and how far wrapped lines are
+			// indented to line up with the text
+			var marker string
+			var indent int
 			var spacing string
-			if num > 9 {
-				// One space to keep it in line with other links - see #60
+			color := viper.GetBool("a-general.color")
+			switch markers {
+			case "bullets":
+				marker = cview.Escape(bullet())
+				indent = runewidth.StringWidth(bullet()) + 1
 				spacing = " "
-			} else {
-				// One digit numbers use two spaces
-				spacing = "  "
+			case "none":
+			default:
+				marker = "[" + strconv.Itoa(num) + "[]" // Escaped closing bracket
+				switch {
+				case !color:
+					indent = len(strconv.Itoa(num)) + 4 // +4 for spaces and brackets
+					spacing = "  "
+				case num > 99:
+					// Indent link text by 3 or more spaces
+					indent = len(strconv.Itoa(num)) + 4 // +4 indent for spaces and brackets
+					spacing = " "
+				case num > 9:
+					// One digit and two digit links have the same spacing - see #60
+					// One space to keep it in line with other links
+					indent = 5 // +4 indent for spaces and brackets, and 1 for link number
+					spacing = " "
+				default:
+					// One digit numbers use two spaces
+					indent = 5
+					spacing = "  "
+				}
+				if inRun {
+					// Right-align the numbers, with the text one space after them
+					marker = strings.Repeat(" ", runWidth-len(strconv.Itoa(num))) + marker
+					indent = runWidth + 3 // +3 for brackets and the space
+					spacing = " "
+				}
+			}
+
+			// The style tags for underlining links, if they should be
+			var underline, endUnderline string
+			if viper.GetBool("a-general.underline_links") {
+				underline = "::u"
+				endUnderline = "::-"
 			}
 
 			// Wrap and add link text
@@ -165,111 +363,218 @@ func convertRegularGemini(s string, numLinks, width int, proxied bool) (string,
 			// Add them to the first line
 
 			var wrappedLink []string
+			region := `["` + strconv.Itoa(num-1) + `"]`
 
-			if viper.GetBool("a-general.color") {
-				pU, err := urlPkg.Parse(url)
-				if !proxied && err == nil &&
-					(pU.Scheme == "" || pU.Scheme == "gemini" || pU.Scheme == "about") {
-					// A gemini link
-					// Add the link text in blue (in a region), and a gray link number to the left of it
-					// Those are the default colors, anyway
-
-					wrappedLink = wrapLine(linkText, width,
-						strings.Repeat(" ", indent)+
-							`["`+strconv.Itoa(num-1)+`"][`+config.GetColorString("amfora_link")+`]`,
-						`[-][""]`,
-						false, // Don't indent the first line, it's the one with link number
-					)
-
-					// Add special stuff to first line, like the link number
-					wrappedLink[0] = fmt.Sprintf(`[%s::b][`, config.GetColorString("link_number")) +
-						strconv.Itoa(num) + "[]" + "[-::-]" + spacing +
-						`["` + strconv.Itoa(num-1) + `"][` + config.GetColorString("amfora_link") + `]` +
-						wrappedLink[0] + `[-][""]`
-				} else {
-					// Not a gemini link
-
-					wrappedLink = wrapLine(linkText, width,
-						strings.Repeat(" ", indent)+
-							`["`+strconv.Itoa(num-1)+`"][`+config.GetColorString("foreign_link")+`]`,
-						`[-][""]`,
-						false, // Don't indent the first line, it's the one with link number
-					)
-
-					wrappedLink[0] = fmt.Sprintf(`[%s::b][`, config.GetColorString("link_number")) +
-						strconv.Itoa(num) + "[]" + "[-::-]" + spacing +
-						`["` + strconv.Itoa(num-1) + `"][` + config.GetColorString("foreign_link") + `]` +
-						wrappedLink[0] + `[-][""]`
+			if color {
+				// Add the link text in its color (in a region), and a gray link number to the left of it
+				start := region + `[` + config.GetColorString(linkColor(url, base, proxied)) + underline + `]`
+				end := `[-` + endUnderline + `][""]`
+
+				wrappedLink = wrapLine(linkText, width, strings.Repeat(" ", indent)+start, end,
+					false, // Don't indent the first line, it's the one with link number
+				)
+
+				// Add special stuff to first line, like the link number
+				if marker != "" {
+					marker = fmt.Sprintf(`[%s::b]`, config.GetColorString("link_number")) + marker + "[-::-]"
 				}
+				wrappedLink[0] = marker + spacing + start + wrappedLink[0] + end
 			} else {
 				// No colors allowed
+				start := region
+				end := `[""]`
+				if underline != "" {
+					start += "[" + underline + "]"
+					end = "[" + endUnderline + "]" + end
+				}
 
-				wrappedLink = wrapLine(linkText, width,
-					strings.Repeat(" ", len(strconv.Itoa(num))+4)+ // +4 for spaces and brackets
-						`["`+strconv.Itoa(num-1)+`"]`,
-					`[""]`,
+				wrappedLink = wrapLine(linkText, width, strings.Repeat(" ", indent)+start, end,
 					false, // Don't indent the first line, it's the one with link number
 				)
 
-				wrappedLink[0] = `[::b][` + strconv.Itoa(num) + "[][::-]  " +
-					`["` + strconv.Itoa(num-1) + `"]` +
-					wrappedLink[0] + `[""]`
+				if marker != "" {
+					marker = "[::b]" + marker + "[::-]"
+				}
+				wrappedLink[0] = marker + spacing + start + wrappedLink[0] + end
 			}
 
 			wrappedLines = append(wrappedLines, wrappedLink...)
 
 			// Lists
 		} else if strings.HasPrefix(lines[i], "* ") {
-			if viper.GetBool("a-general.bullets") {
-				// Wrap list item, and indent wrapped lines past the bullet
-				wrappedItem := wrapLine(lines[i][1:], width,
-					fmt.Sprintf("    [%s]", config.GetColorString("list_text")),
-					"[-]", false)
-				// Add bullet
-				wrappedItem[0] = fmt.Sprintf(" [%s]\u2022", config.GetColorString("list_text")) +
-					wrappedItem[0] + "[-]"
-				wrappedLines = append(wrappedLines, wrappedItem...)
-			} else {
-				wrappedItem := wrapLine(lines[i][1:], width,
-					fmt.Sprintf("    [%s]", config.GetColorString("list_text")),
-					"[-]", false)
-				// Add "*"
-				wrappedItem[0] = fmt.Sprintf(" [%s]*", config.GetColorString("list_text")) +
-					wrappedItem[0] + "[-]"
-				wrappedLines = append(wrappedLines, wrappedItem...)
-
-			}
-			// Optionally list lines could be colored here too, if color is enabled
+			// Nested items are indented further, with a different bullet
+			level, text := listItem(lines[i][2:])
+			marker := listBullet(level)
+			indent := 1 + 2*level
+
+			// Wrap list item, and indent wrapped lines past the bullet
+			wrappedItem := wrapLine(" "+text, width,
+				fmt.Sprintf("%s[%s]", strings.Repeat(" ", indent+runewidth.StringWidth(marker)+2),
+					config.GetColorString("list_text")),
+				"[-]", false)
+			// Add bullet
+			wrappedItem[0] = fmt.Sprintf("%s[%s]%s", strings.Repeat(" ", indent), config.GetColorString("list_text"),
+				cview.Escape(marker)) + wrappedItem[0] + "[-]"
+			wrappedLines = append(wrappedLines, wrappedItem...)
 		} else if strings.HasPrefix(lines[i], ">") {
 			// It's a quote line, add extra quote symbols and italics to the start of each wrapped line
 
+			marker := cview.Escape(viper.GetString("a-general.quote_marker"))
 			if len(lines[i]) == 1 {
 				// Just an empty quote line
-				wrappedLines = append(wrappedLines, fmt.Sprintf("[%s::i]>[-::-]", config.GetColorString("quote_text")))
+				wrappedLines = append(wrappedLines,
+					fmt.Sprintf("[%s::i]%s[-::-]", config.GetColorString("quote_text"), marker))
 			} else {
 				// Remove beginning quote and maybe space
 				lines[i] = strings.TrimPrefix(lines[i], ">")
 				lines[i] = strings.TrimPrefix(lines[i], " ")
+				if marker != "" {
+					marker += " "
+				}
 				wrappedLines = append(wrappedLines,
-					wrapLine(lines[i], width, fmt.Sprintf("[%s::i]> ", config.GetColorString("quote_text")),
+					wrapLine(lines[i], width, fmt.Sprintf("[%s::i]%s", config.GetColorString("quote_text"), marker),
 						"[-::-]", true)...,
 				)
 			}
 
 		} else if strings.TrimSpace(lines[i]) == "" {
+			if runLinks > 0 {
+				// Between links of a run in compact mode
+				continue
+			}
 			// Just add empty line without processing
 			wrappedLines = append(wrappedLines, "")
 		} else {
 			// Regular line, just wrap it
-			wrappedLines = append(wrappedLines, wrapLine(lines[i], width,
+			wrappedLines = append(wrappedLines, wrapLineOpts(lines[i], width,
 				fmt.Sprintf("[%s]", config.GetColorString("regular_text")),
-				"[-]", true)...)
+				"[-]", true, bodyOpts)...)
 		}
 	}
 
 	return strings.Join(wrappedLines, "\r\n"), links
 }
 
+// bullet returns what's shown before list items and links, when bullets are
+// used for them.
+func bullet() string {
+	if config.ScreenReader {
+		return "*"
+	}
+	if b := viper.GetString("a-general.bullet"); b != "" {
+		return b
+	}
+	return "\u2022"
+}
+
+// The bullets of nested list items, after the first level, see listItem.
+var nestedBullets = []string{"\u25e6", "\u25aa"}
+
+// How deeply list items can be nested.
+const maxListLevel = 4
+
+// listBullet returns the bullet for list items nested that deep, where 0 is
+// not nested.
+func listBullet(level int) string {
+	if !viper.GetBool("a-general.bullets") || config.ScreenReader {
+		return "*"
+	}
+	if level == 0 {
+		return bullet()
+	}
+	return nestedBullets[(level-1)%len(nestedBullets)]
+}
+
+// listItem returns how deeply a list item is nested, and its text without
+// what nests it. The text is the part after "* ". Every two spaces it starts
+// with, or a tab, nest it one more level, and so does each list marker it
+// starts with, like in "* * item" or "* - item".
+func listItem(text string) (int, string) {
+	level := 0
+	for {
+		spaces := 0
+		for len(text) > 0 && (text[0] == ' ' || text[0] == '\t') {
+			if text[0] == '\t' {
+				spaces += 2
+			} else {
+				spaces++
+			}
+			text = text[1:]
+		}
+		level += spaces / 2
+		if len(text) >= 2 && strings.ContainsRune("*-+", rune(text[0])) && (text[1] == ' ' || text[1] == '\t') {
+			level++
+			text = text[2:]
+			continue
+		}
+		break
+	}
+	if level > maxListLevel {
+		level = maxListLevel
+	}
+	return level, text
+}
+
+// headingMarker returns what's shown before headings of the level, from 1 to 3.
+func headingMarker(level int) string {
+	markers := viper.GetStringSlice("a-general.heading_markers")
+	if level > len(markers) {
+		return strings.Repeat("#", level)
+	}
+	return markers[level-1]
+}
+
+// splitHeading returns the level of the gemtext heading line, and its text.
+func splitHeading(line string) (int, string) {
+	level := 0
+	for level < 3 && level < len(line) && line[level] == '#' {
+		level++
+	}
+	return level, strings.TrimLeft(line[level:], " \t")
+}
+
+// withMarker returns the text with the marker before it, if there is one.
+func withMarker(marker, text string) string {
+	if marker == "" || text == "" {
+		return marker + text
+	}
+	return marker + " " + text
+}
+
+// HeadingText returns the gemtext heading line as it's shown, without its
+// style, see heading_markers in the config.
+func HeadingText(line string) string {
+	level, text := splitHeading(line)
+	return withMarker(headingMarker(level), text)
+}
+
+// preCaption returns the alt text of a preformatted block, as it's shown
+// above or below it. See pre_alt_text in the config.
+func preCaption(alt string, width int) string {
+	return strings.Join(
+		wrapLine(alt, width, fmt.Sprintf("[%s::i]", config.GetColorString("regular_text")), "[-::-]", true),
+		"\r\n") + "\r\n"
+}
+
+// isLinkLine returns true if the gemtext line is a link with a URL.
+func isLinkLine(line string) bool {
+	return strings.HasPrefix(line, "=>") && strings.TrimSpace(line[2:]) != ""
+}
+
+// linkRun returns the number of links at the start of the lines, which can
+// have blank lines between them.
+func linkRun(lines []string) int {
+	n := 0
+	for _, line := range lines {
+		if isLinkLine(line) {
+			n++
+		} else if strings.TrimSpace(line) != "" {
+			break
+		}
+	}
+	return n
+}
+
 // RenderGemini converts text/gemini into a cview displayable format.
 // It also returns a slice of link URLs.
 //
@@ -278,8 +583,31 @@ func convertRegularGemini(s string, numLinks, width int, proxied bool) (string,
 //
 // proxied is whether the request is through the gemini:// scheme.
 // If it's not a gemini:// page, set this to true.
-func RenderGemini(s string, width int, proxied bool) (string, []string) {
-	s = cview.Escape(s)
+//
+// ansi is whether ANSI codes in preformatted blocks should be turned into
+// colors, see ANSIEnabled.
+//
+// lang is the language of the page, from the lang parameter of the mediatype.
+// It can be empty.
+//
+// Preformatted lines are soft-wrapped if wrap_pre is enabled in the config,
+// use RenderGeminiPage to choose.
+func RenderGemini(s string, width int, proxied, ansi bool, lang string) (string, []string) {
+	return RenderGeminiPage(s, "", width, proxied, ansi, lang, viper.GetBool("a-general.wrap_pre"), false)
+}
+
+// RenderGeminiPage is the same as RenderGemini, but for the page at pageURL,
+// which is used to color links to other hosts differently. wrapPre sets
+// whether preformatted lines wider than width are soft-wrapped, instead of the config.
+// showNumbers sets whether link numbers are shown, if they're only shown on demand.
+func RenderGeminiPage(s, pageURL string, width int, proxied, ansi bool, lang string,
+	wrapPre, showNumbers bool) (string, []string) {
+	s = cview.Escape(applyContentRules(s, pageURL))
+
+	base, err := urlPkg.Parse(pageURL)
+	if err != nil || pageURL == "" {
+		base = nil
+	}
 
 	lines := strings.Split(s, "\n")
 	links := make([]string, 0)
@@ -288,12 +616,34 @@ func RenderGemini(s string, width int, proxied bool) (string, []string) {
 	rendered := "" // Final result
 	pre := false
 	buf := "" // Block of regular or preformatted lines
+	alt := "" // The alt text of the preformatted block
+
+	altPos := viper.GetString("a-general.pre_alt_text")
+	if config.ScreenReader && altPos != "above" && altPos != "below" {
+		// The alt text is all screen readers can make sense of
+		altPos = "above"
+	}
 
 	// processPre is for rendering preformatted blocks
 	processPre := func() {
+		if alt != "" && altPos == "above" {
+			rendered += preCaption(alt, width)
+		}
+
+		if viper.GetBool("a-general.tables") && alt == "" && !strings.Contains(buf, "\x1b") {
+			// Align pipe tables - whitespace tables in preformatted blocks
+			// are already aligned by the author. Blocks with alt text are
+			// left alone, the author described them as something else.
+			buf = alignPreTables(buf, viper.GetBool("a-general.table_borders") && !config.ScreenReader)
+		}
+
+		if wrapPre && !strings.Contains(buf, "\x1b") {
+			// Lines with ANSI codes aren't wrapped, the codes would be split
+			buf = wrapPreBlock(buf, width)
+		}
 
 		// Support ANSI color codes in preformatted blocks - see #59
-		if viper.GetBool("a-general.color") && viper.GetBool("a-general.ansi") {
+		if ansi {
 			buf = cview.TranslateANSI(buf)
 			// The TranslateANSI function will reset the colors when it encounters
 			// an ANSI reset code, injecting a full reset tag: [-:-:-]
@@ -317,6 +667,10 @@ func RenderGemini(s string, width int, proxied bool) (string, []string) {
 
 		rendered += fmt.Sprintf("[%s]", config.GetColorString("preformatted_text")) +
 			buf + fmt.Sprintf("[%s:%s:-]\r\n", config.GetColorString("regular_text"), config.GetColorString("bg"))
+
+		if alt != "" && altPos == "below" {
+			rendered += preCaption(alt, width)
+		}
 	}
 
 	// processRegular processes non-preformatted sections
@@ -324,7 +678,7 @@ func RenderGemini(s string, width int, proxied bool) (string, []string) {
 		// ANSI not allowed in regular text - see #59
 		buf = ansiRegex.ReplaceAllString(buf, "")
 
-		ren, lks := convertRegularGemini(buf, len(links), width, proxied)
+		ren, lks := convertRegularGemini(buf, len(links), width, proxied, lang, base, showNumbers)
 		links = append(links, lks...)
 		rendered += ren
 	}
@@ -339,6 +693,7 @@ func RenderGemini(s string, width int, proxied bool) (string, []string) {
 			} else {
 				// Not preformatted, regular text
 				processRegular()
+				alt = strings.TrimSpace(strings.TrimSuffix(lines[i][3:], "\r"))
 			}
 			buf = "" // Clear buffer for next block
 			pre = !pre
@@ -0,0 +1,96 @@
+package renderer
+
+import (
+	urlPkg "net/url"
+	"strings"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+)
+
+// applyContentRules changes the gemtext with the rules from the content-rules
+// config section for the host of the page.
+func applyContentRules(s, pageURL string) string {
+	if len(config.ContentRules) == 0 {
+		return s
+	}
+	parsed, err := urlPkg.Parse(pageURL)
+	if err != nil {
+		return s
+	}
+	host := strings.ToLower(parsed.Hostname())
+	for _, rule := range config.ContentRules {
+		if config.HasHost(rule.Hosts, host) {
+			s = applyContentRule(s, rule)
+		}
+	}
+	return s
+}
+
+// applyContentRule hides the lines, changes the link text, and makes text
+// preformatted, as the rule says.
+func applyContentRule(s string, rule config.ContentRule) string {
+	lines := strings.Split(s, "\n")
+	out := make([]string, 0, len(lines))
+	pre := false
+	added := false // Whether a preformatted block was added around text lines
+
+	for _, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			if added {
+				out = append(out, "```")
+				added = false
+			}
+			pre = !pre
+			out = append(out, line)
+			continue
+		}
+
+		hidden := false
+		for _, re := range rule.Hide {
+			if re.MatchString(line) {
+				hidden = true
+				break
+			}
+		}
+		if hidden {
+			continue
+		}
+
+		if !pre && strings.HasPrefix(line, "=>") {
+			if added {
+				out = append(out, "```")
+				added = false
+			}
+			out = append(out, replaceLinkText(line, rule.LinkText))
+			continue
+		}
+		if !pre && rule.Preformatted && !added {
+			out = append(out, "```")
+			added = true
+		}
+		out = append(out, line)
+	}
+	if added {
+		out = append(out, "```")
+	}
+	return strings.Join(out, "\n")
+}
+
+// replaceLinkText returns the link line with the replacements made in its
+// text. Links without text are left alone.
+func replaceLinkText(line string, rules []config.LinkTextRule) string {
+	link := strings.TrimSpace(line[2:])
+	i := strings.IndexAny(link, " \t")
+	if len(rules) == 0 || i == -1 {
+		return line
+	}
+	u := link[:i]
+	text := strings.TrimSpace(link[i:])
+	for _, r := range rules {
+		text = r.Pattern.ReplaceAllString(text, r.Replace)
+	}
+	if strings.TrimSpace(text) == "" {
+		return "=> " + u
+	}
+	return "=> " + u + " " + text
+}
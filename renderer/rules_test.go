@@ -0,0 +1,35 @@
+package renderer
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/makeworld-the-better-one/amfora/config"
+)
+
+func TestApplyContentRule(t *testing.T) {
+	hide := config.ContentRule{Hide: []*regexp.Regexp{regexp.MustCompile("^Powered by")}}
+	linkText := config.ContentRule{LinkText: []config.LinkTextRule{
+		{Pattern: regexp.MustCompile(`^Back to (.*)`), Replace: "← $1"},
+		{Pattern: regexp.MustCompile(`^\[\d+\]$`), Replace: ""},
+	}}
+	pre := config.ContentRule{Preformatted: true}
+
+	tests := []struct {
+		rule config.ContentRule
+		s    string
+		want string
+	}{
+		{hide, "# Title\nText\nPowered by X\n", "# Title\nText\n"},
+		{hide, "```\nPowered by X\n```", "```\n```"},
+		{linkText, "=> / Back to home\n=>\t/a\tBack to a", "=> / ← home\n=> /a ← a"},
+		{linkText, "=> /1 [1]\n=> /2\nBack to top", "=> /1\n=> /2\nBack to top"},
+		{pre, "# Title\n  art\n=> / Home\nText", "```\n# Title\n  art\n```\n=> / Home\n```\nText\n```"},
+		{pre, "```\ncode\n```\nText", "```\ncode\n```\n```\nText\n```"},
+	}
+	for _, tt := range tests {
+		if got := applyContentRule(tt.s, tt.rule); got != tt.want {
+			t.Errorf("applyContentRule(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
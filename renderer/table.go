@@ -0,0 +1,211 @@
+package renderer
+
+import (
+	"regexp"
+	"strings"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/makeworld-the-better-one/amfora/config"
+)
+
+// Functions for detecting and aligning simple text tables.
+//
+// Two kinds of tables are supported. Pipe tables, like the ones used in
+// Markdown, have cells separated by the | character. Whitespace tables have
+// cells separated by tabs or runs of two or more spaces.
+//
+// Only clear tables are aligned, so that regular text and ASCII art are left
+// alone: a header row, then a separator row like "---" or "|---|---|", then
+// at least one more row, all with the same number of cells.
+//
+// Tables are never wrapped, so a table wider than the screen can be viewed
+// by scrolling horizontally.
+
+// Matches a single cell of a Markdown style separator row, like "---" or ":--:"
+var tableSepCellRegex = regexp.MustCompile(`^:?-{3,}:?$`)
+
+// Separates the cells of a whitespace table
+var tableSpaceRegex = regexp.MustCompile(`\t+| {2,}`)
+
+// table holds the cells of a detected table.
+type table struct {
+	rows [][]string
+	// rules holds the indexes of rows that should have a horizontal line
+	// drawn after them, because there was a separator row there.
+	rules map[int]bool
+}
+
+// splitPipeRow splits a line into cells using pipes. It returns false
+// if the line is not a valid pipe table row.
+func splitPipeRow(line string) ([]string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.Contains(line, "|") {
+		return nil, false
+	}
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	cells := strings.Split(line, "|")
+	if len(cells) < 2 {
+		return nil, false
+	}
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells, true
+}
+
+// splitSpaceRow splits a line into cells using whitespace. It returns false
+// if the line is not a valid whitespace table row.
+func splitSpaceRow(line string) ([]string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil, false
+	}
+	cells := tableSpaceRegex.Split(line, -1)
+	if len(cells) < 2 {
+		return nil, false
+	}
+	return cells, true
+}
+
+// isSepRow returns true if all the cells are Markdown style separators.
+func isSepRow(cells []string) bool {
+	for _, c := range cells {
+		if !tableSepCellRegex.MatchString(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// findTable looks for a table at the beginning of the provided lines.
+// It returns the number of lines the table takes up, which is zero if
+// there is no table.
+//
+// Set whitespace to true to detect whitespace tables as well as pipe tables.
+func findTable(lines []string, whitespace bool) (int, *table) {
+	if n, t := findTableSplit(lines, splitPipeRow); n > 0 {
+		return n, t
+	}
+	if whitespace {
+		return findTableSplit(lines, splitSpaceRow)
+	}
+	return 0, nil
+}
+
+// findTableSplit is findTable for one kind of table, with split separating
+// the cells of a row.
+func findTableSplit(lines []string, split func(string) ([]string, bool)) (int, *table) {
+	if len(lines) < 3 {
+		return 0, nil
+	}
+	header, ok := split(lines[0])
+	if !ok {
+		return 0, nil
+	}
+	sep, ok := split(lines[1])
+	if !ok || len(sep) != len(header) || !isSepRow(sep) {
+		return 0, nil
+	}
+
+	t := &table{rows: [][]string{header}, rules: map[int]bool{0: true}}
+	n := 2
+	for n < len(lines) {
+		cells, ok := split(lines[n])
+		if !ok || len(cells) != len(header) {
+			break
+		}
+		if isSepRow(cells) {
+			t.rules[len(t.rows)-1] = true
+		} else {
+			t.rows = append(t.rows, cells)
+		}
+		n++
+	}
+	if len(t.rows) < 2 {
+		// Just a header
+		return 0, nil
+	}
+	return n, t
+}
+
+// render returns the lines of the table, with all the columns aligned.
+// The lines have no color tags added.
+func (t *table) render(borders bool) []string {
+	// Find the width of each column
+	widths := make([]int, 0)
+	for _, row := range t.rows {
+		for i, cell := range row {
+			w := cview.TaggedStringWidth(cell)
+			if i >= len(widths) {
+				widths = append(widths, w)
+			} else if w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	// rule creates a horizontal line, using the provided box-drawing characters
+	// to join the columns. pad is the number of line characters added to each
+	// column beyond its width.
+	line := "─"
+	if config.ScreenReader {
+		line = "-"
+	}
+	rule := func(left, mid, right string, pad int) string {
+		parts := make([]string, len(widths))
+		for i, w := range widths {
+			parts[i] = strings.Repeat(line, w+pad)
+		}
+		return left + strings.Join(parts, mid) + right
+	}
+
+	ret := make([]string, 0, len(t.rows)+2)
+	if borders {
+		ret = append(ret, rule("┌", "┬", "┐", 2))
+	}
+	for i, row := range t.rows {
+		cells := make([]string, len(widths))
+		for j := range widths {
+			var cell string
+			if j < len(row) {
+				cell = row[j]
+			}
+			cells[j] = cell + strings.Repeat(" ", widths[j]-cview.TaggedStringWidth(cell))
+		}
+		if borders {
+			ret = append(ret, "│ "+strings.Join(cells, " │ ")+" │")
+			if t.rules[i] && i != len(t.rows)-1 {
+				ret = append(ret, rule("├", "┼", "┤", 2))
+			}
+		} else {
+			ret = append(ret, strings.TrimRight(strings.Join(cells, "  "), " "))
+			if t.rules[i] && i != len(t.rows)-1 {
+				ret = append(ret, rule("", "  ", "", 0))
+			}
+		}
+	}
+	if borders {
+		ret = append(ret, rule("└", "┴", "┘", 2))
+	}
+	return ret
+}
+
+// alignPreTables aligns any pipe tables found in the provided preformatted
+// block. Each line of the block must end with \r\n.
+func alignPreTables(buf string, borders bool) string {
+	if buf == "" {
+		return buf
+	}
+	lines := strings.Split(strings.TrimSuffix(buf, "\r\n"), "\r\n")
+	ret := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		if n, t := findTable(lines[i:], false); n > 0 {
+			ret = append(ret, t.render(borders)...)
+			i += n - 1
+			continue
+		}
+		ret = append(ret, lines[i])
+	}
+	return strings.Join(ret, "\r\n") + "\r\n"
+}
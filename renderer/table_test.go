@@ -0,0 +1,86 @@
+package renderer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+var findTableTests = []struct {
+	lines      []string
+	whitespace bool
+	n          int
+}{
+	{[]string{"| a | b |", "|---|---|", "| c | d |"}, false, 3},
+	{[]string{"a | b", "--- | ---", "c | d", "not a table"}, false, 3},
+	{[]string{"| a | b |", "|---|---|", "| c | d |", "|---|---|", "| e | f |"}, false, 5},
+	{[]string{"| a | b |", "| c | d |"}, false, 0},                       // No separator row
+	{[]string{"| a | b |", "|---|---|"}, false, 0},                       // Just a header
+	{[]string{"| a | b |", "|---|---|---|", "| c | d |"}, false, 0},      // Separator doesn't match
+	{[]string{"| a | b |", "|---|---|", "| c | d | e |"}, false, 0},      // Row doesn't match
+	{[]string{"| a | b |", "|---|---|", "| c | d |", "| e |"}, false, 3}, // Ends at the row that doesn't match
+	{[]string{"a  b", "---  ---", "c  d"}, true, 3},
+	{[]string{"a  b", "---  ---", "c  d"}, false, 0},
+	{[]string{"a  b", "c  d", "e  f"}, true, 0},
+	{[]string{"a  b", "---  ---", "c  d  e"}, true, 0},
+}
+
+func TestFindTable(t *testing.T) {
+	for _, tt := range findTableTests {
+		n, _ := findTable(tt.lines, tt.whitespace)
+		if n != tt.n {
+			t.Errorf("findTable(%q, %v): expected %d, actual %d", tt.lines, tt.whitespace, tt.n, n)
+		}
+	}
+}
+
+func TestRenderTable(t *testing.T) {
+	_, tbl := findTable([]string{"| name | n |", "|---|---|", "| x | 100 |"}, false)
+
+	expected := []string{
+		"┌──────┬─────┐",
+		"│ name │ n   │",
+		"├──────┼─────┤",
+		"│ x    │ 100 │",
+		"└──────┴─────┘",
+	}
+	if actual := tbl.render(true); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("render(true): expected %q, actual %q", expected, actual)
+	}
+
+	expected = []string{
+		"name  n",
+		"────  ───",
+		"x     100",
+	}
+	if actual := tbl.render(false); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("render(false): expected %q, actual %q", expected, actual)
+	}
+}
+
+func TestPreTablesAltText(t *testing.T) {
+	viper.Set("a-general.color", false)
+	viper.Set("a-general.tables", true)
+	viper.Set("a-general.table_borders", false)
+	defer viper.Set("a-general.color", true)
+	defer viper.Set("a-general.tables", false)
+	defer viper.Set("a-general.table_borders", true)
+
+	table := "|a|bb|\n|---|---|\n|ccc|d|\n"
+	tests := []struct {
+		alt     string
+		aligned bool
+	}{
+		{"", true},
+		{"Diagram", false},
+	}
+	for _, tt := range tests {
+		rendered, _ := RenderGeminiPage("```"+tt.alt+"\n"+table+"```\n", "", 80, false, false, "", false, false)
+		rendered = styleTagRe.ReplaceAllString(rendered, "")
+		if aligned := strings.Contains(rendered, "ccc  d"); aligned != tt.aligned {
+			t.Errorf("alt text %q: aligned = %v, want %v\n%s", tt.alt, aligned, tt.aligned, rendered)
+		}
+	}
+}
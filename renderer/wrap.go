@@ -0,0 +1,244 @@
+package renderer
+
+import (
+	"strings"
+	"unicode"
+
+	"code.rocketnine.space/tslocum/cview"
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
+)
+
+// cluster is a single user-perceived character, and how many columns it
+// takes up in the terminal.
+type cluster struct {
+	s string
+	w int
+}
+
+// Characters that lines shouldn't start with, in East Asian text
+const noBreakBefore = ")]}、。，．：；！？）］｝」』】〉》〕・ー々ぁぃぅぇぉっゃゅょァィゥェォッャュョ"
+
+// Characters that lines shouldn't end with, in East Asian text
+const noBreakAfter = "([{（［｛「『【〈《〔"
+
+// clusterWidth returns the number of columns the grapheme cluster takes up.
+func clusterWidth(runes []rune) int {
+	for _, r := range runes {
+		if r == '\ufe0f' {
+			// Variation selector for emoji presentation, which is always wide
+			return 2
+		}
+	}
+	// Use the width of the first character that takes up space,
+	// the rest are combining characters
+	for _, r := range runes {
+		if w := runewidth.RuneWidth(r); w > 0 {
+			return w
+		}
+	}
+	return 0
+}
+
+// isSpace returns true if the cluster is whitespace.
+func (c cluster) isSpace() bool {
+	return strings.TrimSpace(c.s) == ""
+}
+
+// isEastAsian returns true if the cluster is from a script that doesn't
+// separate words with spaces, so lines can be broken between any characters.
+func (c cluster) isEastAsian() bool {
+	r := []rune(c.s)[0]
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) ||
+		(r >= 0x3000 && r <= 0x303f) || // CJK symbols and punctuation
+		(r >= 0xff00 && r <= 0xffef) // Fullwidth forms
+}
+
+// canBreakAfter returns true if a line can be broken between the cluster at
+// the provided index and the next one.
+func canBreakAfter(clusters []cluster, i int) bool {
+	if i+1 >= len(clusters) {
+		return false
+	}
+	c, next := clusters[i], clusters[i+1]
+	if next.isSpace() {
+		// Break after the last space instead
+		return false
+	}
+	if c.isSpace() {
+		return true
+	}
+	if c.s == "-" && i > 0 && !clusters[i-1].isSpace() {
+		// Hyphenated words
+		return true
+	}
+	if c.isEastAsian() || next.isEastAsian() {
+		return !strings.Contains(noBreakBefore, next.s) && !strings.Contains(noBreakAfter, c.s)
+	}
+	return false
+}
+
+// joinClusters joins the clusters into one escaped line, without trailing whitespace.
+func joinClusters(clusters []cluster) string {
+	var sb strings.Builder
+	for _, c := range clusters {
+		sb.WriteString(c.s)
+	}
+	return cview.Escape(strings.TrimRightFunc(sb.String(), unicode.IsSpace))
+}
+
+// wordWrap splits the text into lines that are at most width columns wide,
+// breaking at spaces where possible. It measures whole grapheme clusters, so
+// emoji and East Asian wide characters are handled correctly, and allows
+// breaking between East Asian characters, which aren't separated by spaces.
+//
+// If h is not nil, words at the end of lines are hyphenated using it.
+//
+// The text can be escaped with cview.Escape, but must not contain any color tags.
+// At least one line is always returned.
+func wordWrap(s string, width int, h *hyphenator) []string {
+	s = escapedRegex.ReplaceAllString(s, "$1]")
+	if width < 1 {
+		return []string{cview.Escape(s)}
+	}
+
+	clusters := make([]cluster, 0, len(s))
+	g := uniseg.NewGraphemes(s)
+	for g.Next() {
+		clusters = append(clusters, cluster{g.Str(), clusterWidth(g.Runes())})
+	}
+
+	lines := make([]string, 0, 1)
+	start := 0 // First cluster of the current line
+	brk := -1  // Last cluster the current line can be broken after
+	lineW := 0
+	for i, c := range clusters {
+		if lineW+c.w > width && i > start && !c.isSpace() {
+			// Too wide, break at the last opportunity, or right here if there's none
+			end := i
+			wordStart := start // Start of the word that didn't fit
+			if brk >= start && joinClusters(clusters[start:brk+1]) != "" {
+				end = brk + 1
+				wordStart = end
+			}
+			line := joinClusters(clusters[start:end])
+
+			// Try to hyphenate that word instead
+			if h != nil {
+				avail := width
+				for _, c2 := range clusters[start:wordStart] {
+					avail -= c2.w
+				}
+				if n, ok := h.split(clusters[wordStart:], avail); ok {
+					end = wordStart + n
+					line = joinClusters(clusters[start:end]) + "-"
+				}
+			}
+			lines = append(lines, line)
+
+			// Spaces at the start of wrapped lines are removed
+			for end < i && clusters[end].isSpace() {
+				end++
+			}
+			start = end
+			brk = -1
+			lineW = 0
+			for j := start; j < i; j++ {
+				lineW += clusters[j].w
+				if canBreakAfter(clusters, j) {
+					brk = j
+				}
+			}
+		}
+		lineW += c.w
+		if canBreakAfter(clusters, i) {
+			brk = i
+		}
+	}
+	return append(lines, joinClusters(clusters[start:]))
+}
+
+// Marks preformatted lines that continue the line above, see wrapPreBlock
+const preContinuation = "↪ "
+
+// wrapPreLine splits a preformatted line into lines that are at most width
+// columns wide. Lines are broken anywhere, not just at spaces, so the text
+// keeps its alignment. Lines after the first leave room for preContinuation,
+// but don't include it.
+//
+// The text can be escaped with cview.Escape, but must not contain any color tags.
+// At least one line is always returned.
+func wrapPreLine(s string, width int) []string {
+	s = escapedRegex.ReplaceAllString(s, "$1]")
+	markW := runewidth.StringWidth(preContinuation)
+	if width <= markW {
+		return []string{cview.Escape(s)}
+	}
+
+	lines := make([]string, 0, 1)
+	var sb strings.Builder
+	lineW := 0
+	avail := width
+	g := uniseg.NewGraphemes(s)
+	for g.Next() {
+		w := clusterWidth(g.Runes())
+		if lineW+w > avail && lineW > 0 {
+			lines = append(lines, cview.Escape(sb.String()))
+			sb.Reset()
+			lineW = 0
+			avail = width - markW
+		}
+		sb.WriteString(g.Str())
+		lineW += w
+	}
+	return append(lines, cview.Escape(sb.String()))
+}
+
+// wrapPreBlock soft-wraps each line of the preformatted block, which uses
+// \r\n line endings, with wrapPreLine. Continuation lines start with a dim
+// preContinuation.
+func wrapPreBlock(buf string, width int) string {
+	lines := strings.Split(buf, "\r\n")
+	wrapped := make([]string, 0, len(lines))
+	for _, line := range lines {
+		for i, part := range wrapPreLine(line, width) {
+			if i > 0 {
+				part = "[::d]" + preContinuation + "[::-]" + part
+			}
+			wrapped = append(wrapped, part)
+		}
+	}
+	return strings.Join(wrapped, "\r\n")
+}
+
+// justify stretches the line to the provided width, by adding spaces between
+// the words. Indentation at the start of the line is kept.
+func justify(line string, width int) string {
+	trimmed := strings.TrimLeft(line, " ")
+	indent := line[:len(line)-len(trimmed)]
+	words := strings.Fields(trimmed)
+	if len(words) < 2 {
+		return line
+	}
+
+	extra := width - cview.TaggedStringWidth(indent+strings.Join(words, " "))
+	if extra <= 0 {
+		return line
+	}
+	gaps := len(words) - 1
+
+	var sb strings.Builder
+	sb.WriteString(indent)
+	for i, word := range words {
+		sb.WriteString(word)
+		if i == gaps {
+			break
+		}
+		n := 1 + extra/gaps
+		if i < extra%gaps {
+			n++
+		}
+		sb.WriteString(strings.Repeat(" ", n))
+	}
+	return sb.String()
+}
@@ -0,0 +1,84 @@
+package renderer
+
+import (
+	"reflect"
+	"testing"
+)
+
+var wordWrapTests = []struct {
+	s        string
+	width    int
+	expected []string
+}{
+	{"", 10, []string{""}},
+	{"hello world", 20, []string{"hello world"}},
+	{"hello world", 8, []string{"hello", "world"}},
+	{" item text", 6, []string{" item", "text"}},
+	{"abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+	{"well-known words", 8, []string{"well-", "known", "words"}},
+	{"日本語の文章です", 6, []string{"日本語", "の文章", "です"}},
+	{"日本語です。", 10, []string{"日本語で", "す。"}},
+	{"😀😀😀 😀", 6, []string{"😀😀😀", "😀"}},
+	{"[tag[] text", 6, []string{"[tag[]", "text"}},
+}
+
+func TestWordWrap(t *testing.T) {
+	for _, tt := range wordWrapTests {
+		if actual := wordWrap(tt.s, tt.width, nil); !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("wordWrap(%q, %d): expected %q, actual %q", tt.s, tt.width, tt.expected, actual)
+		}
+	}
+}
+
+var wrapPreLineTests = []struct {
+	s        string
+	width    int
+	expected []string
+}{
+	{"", 10, []string{""}},
+	{"short", 10, []string{"short"}},
+	{"abcdefghij", 4, []string{"abcd", "ef", "gh", "ij"}},
+	{"  x   y", 5, []string{"  x  ", " y"}},
+	{"日本語です", 6, []string{"日本語", "です"}},
+	{"[a[]bc", 3, []string{"[a[]", "b", "c"}},
+	{"abc", 2, []string{"abc"}},
+}
+
+func TestWrapPreLine(t *testing.T) {
+	for _, tt := range wrapPreLineTests {
+		if actual := wrapPreLine(tt.s, tt.width); !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("wrapPreLine(%q, %d): expected %q, actual %q", tt.s, tt.width, tt.expected, actual)
+		}
+	}
+}
+
+func TestWrapCache(t *testing.T) {
+	wrapCache = make(map[wrapKey]wrapEntry)
+	short := "A short line"
+	long := "A longer line that has to be wrapped when it's narrow"
+
+	tests := []struct {
+		line   string
+		width  int
+		cached bool // Whether it's already cached, from the ones before
+	}{
+		{short, 40, false},
+		{short, 20, true}, // It still fits
+		{short, 12, false},
+		{long, 40, false},
+		{long, 40, true},
+		{long, 41, false},
+		{long, 80, false},
+		{long, 100, true},
+	}
+	for _, tt := range tests {
+		_, cached := cachedWrap(newWrapKey(tt.line, "", "", true, wrapOptions{}), tt.width)
+		if cached != tt.cached {
+			t.Errorf("%q at %d: cached is %v, want %v", tt.line, tt.width, cached, tt.cached)
+		}
+		got := wrapLine(tt.line, tt.width, "", "", true)
+		if want := wordWrap(tt.line, tt.width, nil); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q at %d: got %q, want %q", tt.line, tt.width, got, want)
+		}
+	}
+}
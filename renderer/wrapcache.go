@@ -0,0 +1,66 @@
+package renderer
+
+import (
+	"sync"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/spf13/viper"
+)
+
+// Wrapped lines are cached, so rendering a page again, like when the terminal
+// is resized or link numbers are shown, only wraps the lines that need it.
+// A line that didn't need wrapping is used as is for any width it fits in.
+
+// The number of lines that are cached. The cache is emptied when it's full.
+const maxWrapCache = 50000
+
+type wrapKey struct {
+	line         string
+	prefix       string
+	suffix       string
+	includeFirst bool
+	bidi         bool
+	opts         wrapOptions
+}
+
+type wrapEntry struct {
+	lines []string
+	width int // The width the line was wrapped to
+	fits  int // The width the line needs, if it didn't need wrapping, 0 otherwise
+}
+
+var (
+	wrapCache   = make(map[wrapKey]wrapEntry)
+	wrapCacheMu sync.Mutex
+)
+
+// cachedWrap returns the wrapped lines from the cache, if they're the same
+// for that width. The lines are a copy, so they can be changed.
+func cachedWrap(key wrapKey, width int) ([]string, bool) {
+	wrapCacheMu.Lock()
+	e, ok := wrapCache[key]
+	wrapCacheMu.Unlock()
+	if !ok || (e.width != width && (e.fits == 0 || width < e.fits)) {
+		return nil, false
+	}
+	return append([]string(nil), e.lines...), true
+}
+
+// cacheWrap adds the wrapped lines to the cache.
+func cacheWrap(key wrapKey, width int, lines []string) {
+	e := wrapEntry{lines: append([]string(nil), lines...), width: width}
+	if w := runewidth.StringWidth(key.line); len(lines) == 1 && w < width {
+		e.fits = w + 1
+	}
+	wrapCacheMu.Lock()
+	if len(wrapCache) >= maxWrapCache {
+		wrapCache = make(map[wrapKey]wrapEntry)
+	}
+	wrapCache[key] = e
+	wrapCacheMu.Unlock()
+}
+
+// newWrapKey returns the key of the cache for wrapping the line like that.
+func newWrapKey(line, prefix, suffix string, includeFirst bool, opts wrapOptions) wrapKey {
+	return wrapKey{line, prefix, suffix, includeFirst, viper.GetBool("a-general.bidi"), opts}
+}
@@ -3,6 +3,7 @@ package rr
 import (
 	"errors"
 	"io"
+	"time"
 )
 
 var ErrClosed = errors.New("RestartReader: closed")
@@ -71,6 +72,15 @@ func (rr *RestartReader) Close() error {
 	return rr.r.Close()
 }
 
+// SetReadDeadline sets the read deadline of the underlying io.ReadCloser,
+// if it has one, like a network connection.
+func (rr *RestartReader) SetReadDeadline(t time.Time) error {
+	if r, ok := rr.r.(interface{ SetReadDeadline(time.Time) error }); ok {
+		return r.SetReadDeadline(t)
+	}
+	return nil
+}
+
 // NewRestartReader creates and initializes a new RestartReader that reads from
 // the provided io.ReadCloser.
 func NewRestartReader(r io.ReadCloser) *RestartReader {
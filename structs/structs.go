@@ -1,14 +1,19 @@
 //nolint:lll
 package structs
 
-import "time"
+import (
+	"crypto/x509"
+	"time"
+)
 
 type Mediatype string
 
 const (
-	TextGemini Mediatype = "text/gemini"
-	TextPlain  Mediatype = "text/plain"
-	TextAnsi   Mediatype = "text/x-ansi"
+	TextGemini   Mediatype = "text/gemini"
+	TextPlain    Mediatype = "text/plain"
+	TextAnsi     Mediatype = "text/x-ansi"
+	TextMarkdown Mediatype = "text/markdown" // Rendered by converting it to gemtext
+	TextDiff     Mediatype = "text/x-diff"   // A unified diff, colored by line
 )
 
 type PageMode int
@@ -24,7 +29,11 @@ type Page struct {
 	URL          string
 	Mediatype    Mediatype // Used for rendering purposes, generalized
 	RawMediatype string    // The actual mediatype sent by the server
+	Lang         string    // The lang parameter of the mediatype, if any
+	Charset      string    // The charset parameter of the mediatype, if any
 	Raw          string    // The raw response, as received over the network
+	Meta         string    // The meta of the response header, as received over the network
+	Body         []byte    // The response as received, only if Raw is different because it was converted to UTF-8
 	Content      string    // The processed content, NOT raw. Uses cview color tags. It will also have a left margin.
 	Links        []string  // URLs, for each region in the content.
 	Row          int       // Vertical scroll position
@@ -33,12 +42,20 @@ type Page struct {
 	Selected     string    // The current text or link selected
 	SelectedID   string    // The cview region ID for the selected text/link
 	Mode         PageMode
-	MadeAt       time.Time // When the page was made. Zero value indicates it should stay in cache forever.
+	MadeAt       time.Time         // When the page was made. Zero value indicates it should stay in cache forever.
+	TLSVersion   uint16            // The TLS version of the connection the page was fetched over, zero if unknown
+	Cert         *x509.Certificate // The server cert of that connection, nil if there was none
+	Redirects    []string          // The URLs that redirected to this page, in order
+	ViewedAs     Mediatype         // The original Mediatype, if the page is being viewed as another one. Empty otherwise.
+	LoadTime     time.Duration     // How long the page took to fetch and render, zero if unknown
+	ToggleWrap   bool              // Whether wrapping preformatted text is the opposite of the wrap_pre config option
+	ShowNumbers  bool              // Whether link numbers are shown, when they're only shown on demand
+	Private      bool              // Whether the page is from a private tab, so it's never cached or saved to disk
 }
 
 // Size returns an approx. size of a Page in bytes.
 func (p *Page) Size() int {
-	n := len(p.Raw) + len(p.Content) + len(p.URL) + len(p.Selected) + len(p.SelectedID)
+	n := len(p.Raw) + len(p.Meta) + len(p.Body) + len(p.Content) + len(p.URL) + len(p.Selected) + len(p.SelectedID)
 	for i := range p.Links {
 		n += len(p.Links[i])
 	}
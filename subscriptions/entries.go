@@ -44,7 +44,7 @@ func GetPageEntries() *PageEntries {
 
 	data.RLock()
 
-	for _, feed := range data.Feeds {
+	for feedURL, feed := range data.Feeds {
 		for _, item := range feed.Items {
 			if item.Links == nil || len(item.Links) == 0 {
 				// Ignore items without links
@@ -98,11 +98,16 @@ func GetPageEntries() *PageEntries {
 				}
 			}
 
+			entryURL := getURL(item.Links)
+			_, read := data.Read[entryURL]
+
 			pe.Entries = append(pe.Entries, &PageEntry{
 				Prefix:    prefix,
 				Title:     item.Title,
-				URL:       getURL(item.Links),
+				URL:       entryURL,
 				Published: pub,
+				Read:      read,
+				Feed:      feedURL,
 			})
 		}
 	}
@@ -134,6 +139,8 @@ func GetPageEntries() *PageEntries {
 			Title:     title,
 			URL:       u,
 			Published: page.Changed,
+			Read:      data.Read[u].After(page.Changed),
+			Feed:      u,
 		})
 	}
 
@@ -0,0 +1,154 @@
+package subscriptions
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// This file is for importing the subscriptions of Lagrange, another Gemini
+// client. It subscribes to bookmarks, by giving them the "subscribed" tag.
+// Bookmarks are stored in bookmarks.ini, or bookmarks.txt by older versions.
+
+// LagrangeBookmarksPath returns the path of the file Lagrange stores its
+// bookmarks in, or an empty string if it can't be found.
+func LagrangeBookmarksPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	if runtime.GOOS == "linux" || runtime.GOOS == "freebsd" || runtime.GOOS == "openbsd" || runtime.GOOS == "netbsd" {
+		dir = filepath.Join(dir, "lagrange")
+	} else {
+		dir = filepath.Join(dir, "fi.skyjake.Lagrange")
+	}
+	for _, name := range []string{"bookmarks.ini", "bookmarks.txt"} {
+		p := filepath.Join(dir, name)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// isSubscribedTag returns true if the space-separated tags of a Lagrange
+// bookmark mark it as subscribed. Newer versions start special tags with a dot.
+func isSubscribedTag(tags string) bool {
+	for _, tag := range strings.Fields(tags) {
+		if tag == "subscribed" || tag == ".subscribed" {
+			return true
+		}
+	}
+	return false
+}
+
+// LagrangeSubscriptions returns the URLs of the subscribed bookmarks in a
+// Lagrange bookmarks file, in either format.
+func LagrangeSubscriptions(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			return lagrangeINI(lines), nil
+		}
+		break
+	}
+	return lagrangeTXT(lines), nil
+}
+
+// lagrangeINI returns the subscribed URLs of bookmarks.ini, which has a section
+// for each bookmark:
+//
+//	[1]
+//	url = "gemini://example.com/gemlog/"
+//	title = "Example"
+//	tags = "subscribed"
+func lagrangeINI(lines []string) []string {
+	var urls []string
+	var url, tags string
+	end := func() {
+		if url != "" && isSubscribedTag(tags) {
+			urls = append(urls, url)
+		}
+		url, tags = "", ""
+	}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			end()
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(kv[1])
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		switch strings.TrimSpace(kv[0]) {
+		case "url":
+			url = value
+		case "tags":
+			tags = value
+		}
+	}
+	end()
+	return urls
+}
+
+// lagrangeTXT returns the subscribed URLs of bookmarks.txt, which has three
+// lines for each bookmark: when it was made and its URL, its title, and its tags.
+func lagrangeTXT(lines []string) []string {
+	var urls []string
+	for i := 0; i+2 < len(lines); i += 3 {
+		fields := strings.Fields(lines[i])
+		if len(fields) == 2 && isSubscribedTag(lines[i+2]) {
+			urls = append(urls, fields[1])
+		}
+	}
+	return urls
+}
+
+// Subscribe fetches the URL and subscribes to it, as a feed if it is one,
+// or as a page to track for changes if it isn't. If the URL has permanently
+// moved, the new one is subscribed to. It returns whether it's a feed.
+func Subscribe(url string) (bool, error) {
+	newURL, res, err := getResource(url, nil)
+	if err != nil {
+		if res != nil {
+			res.Body.Close()
+		}
+		return false, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return false, err
+	}
+	mediatype, _, _ := mime.ParseMediaType(res.Meta)
+	if feed, ok := GetFeed(mediatype, path.Base(newURL), bytes.NewReader(body)); ok {
+		return true, AddFeed(newURL, feed)
+	}
+	return false, AddPage(newURL, bytes.NewReader(body))
+}
@@ -0,0 +1,39 @@
+package subscriptions
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLagrangeSubscriptions(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want []string
+	}{
+		{
+			"ini",
+			"[1]\nurl = \"gemini://a.example/gemlog/\"\ntitle = \"A \\\"gemlog\\\"\"\ntags = \"subscribed headings\"\nicon = 0x1f4da\n\n" +
+				"[2]\nurl = \"gemini://b.example/\"\ntitle = \"B\"\ntags = \"\"\n\n" +
+				"[3]\nurl = \"gemini://c.example/feed.xml\"\ntitle = \"C\"\ntags = \".subscribed\"\n",
+			[]string{"gemini://a.example/gemlog/", "gemini://c.example/feed.xml"},
+		},
+		{
+			"txt",
+			"1612345678 gemini://a.example/gemlog/\nA\nsubscribed\n" +
+				"1612345679 gemini://b.example/\nB\n\n" +
+				"1612345680 gemini://c.example/\nC\nusenet subscribed\n",
+			[]string{"gemini://a.example/gemlog/", "gemini://c.example/"},
+		},
+	}
+	for _, tt := range tests {
+		got, err := LagrangeSubscriptions(strings.NewReader(tt.file))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
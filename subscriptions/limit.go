@@ -0,0 +1,122 @@
+package subscriptions
+
+import (
+	"io"
+	urlPkg "net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostLimiter limits the requests made to each host while updating, so that
+// many subscriptions on the same host don't all hit it at once. Only a few
+// requests to a host can be made at the same time, and each one starts a
+// minimum delay after the last.
+type hostLimiter struct {
+	workers int           // Requests to a host that can be made at the same time
+	delay   time.Duration // Time between the starts of requests to a host
+
+	mu    sync.Mutex
+	hosts map[string]*hostSlots
+}
+
+type hostSlots struct {
+	sem  chan struct{}
+	next time.Time // When the next request can start
+}
+
+func newHostLimiter(workers int, delay time.Duration) *hostLimiter {
+	if workers < 1 {
+		workers = 1
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return &hostLimiter{
+		workers: workers,
+		delay:   delay,
+		hosts:   make(map[string]*hostSlots),
+	}
+}
+
+// wait blocks until a request can be made to the host, and returns a function
+// to call once the request is done.
+func (l *hostLimiter) wait(host string) func() {
+	l.mu.Lock()
+	h, ok := l.hosts[host]
+	if !ok {
+		h = &hostSlots{sem: make(chan struct{}, l.workers)}
+		l.hosts[host] = h
+	}
+	l.mu.Unlock()
+
+	h.sem <- struct{}{}
+
+	l.mu.Lock()
+	now := time.Now()
+	start := h.next
+	if start.Before(now) {
+		start = now
+	}
+	h.next = start.Add(l.delay)
+	l.mu.Unlock()
+
+	time.Sleep(time.Until(start))
+	return func() { <-h.sem }
+}
+
+// limitedBody is the body of a response fetched after waiting for a
+// hostLimiter. The host's slot is released when it's closed, so the limit
+// covers reading the body, not just getting the header.
+type limitedBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (b *limitedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
+
+// urlHost returns the host requests for the URL are limited by. It's
+// lowercase and has no port, so all the capsules on one server share limits.
+func urlHost(u string) string {
+	parsed, err := urlPkg.Parse(u)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+// interleaveJobs orders the update jobs so that URLs on the same host are
+// spread out, taking one from each host in turn. That way the workers can
+// update other hosts while they wait for a busy one.
+func interleaveJobs(jobs [][2]string) [][2]string {
+	byHost := make(map[string][][2]string)
+	var hosts []string
+	for _, j := range jobs {
+		host := urlHost(j[1])
+		if _, ok := byHost[host]; !ok {
+			hosts = append(hosts, host)
+		}
+		byHost[host] = append(byHost[host], j)
+	}
+	// Hosts with the most URLs first, as they take the longest
+	sort.SliceStable(hosts, func(i, j int) bool {
+		return len(byHost[hosts[i]]) > len(byHost[hosts[j]])
+	})
+
+	ordered := make([][2]string, 0, len(jobs))
+	for len(ordered) < len(jobs) {
+		for _, host := range hosts {
+			if js := byHost[host]; len(js) > 0 {
+				ordered = append(ordered, js[0])
+				byHost[host] = js[1:]
+			}
+		}
+	}
+	return ordered
+}
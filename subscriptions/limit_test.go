@@ -0,0 +1,83 @@
+package subscriptions
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInterleaveJobs(t *testing.T) {
+	tests := []struct {
+		name string
+		jobs [][2]string
+		want [][2]string
+	}{
+		{
+			"empty",
+			[][2]string{},
+			[][2]string{},
+		},
+		{
+			"one host",
+			[][2]string{{"feed", "gemini://a.example/1"}, {"page", "gemini://a.example/2"}},
+			[][2]string{{"feed", "gemini://a.example/1"}, {"page", "gemini://a.example/2"}},
+		},
+		{
+			"spread out",
+			[][2]string{
+				{"feed", "gemini://b.example/"},
+				{"feed", "gemini://a.example/1"},
+				{"feed", "gemini://A.example:1965/2"},
+				{"page", "gemini://a.example/3"},
+				{"page", "gemini://c.example/"},
+			},
+			[][2]string{
+				{"feed", "gemini://a.example/1"},
+				{"feed", "gemini://b.example/"},
+				{"page", "gemini://c.example/"},
+				{"feed", "gemini://A.example:1965/2"},
+				{"page", "gemini://a.example/3"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := interleaveJobs(tt.jobs); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("interleaveJobs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitedBody(t *testing.T) {
+	limit := newHostLimiter(1, 0)
+	body := &limitedBody{
+		ReadCloser: ioutil.NopCloser(strings.NewReader("body")),
+		done:       limit.wait("example.com"),
+	}
+
+	waited := make(chan struct{})
+	go func() {
+		done := limit.wait("example.com")
+		close(waited)
+		done()
+	}()
+	if _, err := ioutil.ReadAll(body); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-waited:
+		t.Fatal("the slot was released before the body was closed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	body.Close()
+	body.Close() // Only released once
+	select {
+	case <-waited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the slot wasn't released when the body was closed")
+	}
+}
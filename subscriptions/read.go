@@ -0,0 +1,82 @@
+package subscriptions
+
+import "time"
+
+// isEntry returns true if u is the URL of an entry of a feed, or a tracked
+// page. The data must be locked.
+func isEntry(u string) bool {
+	if _, ok := data.Pages[u]; ok {
+		return true
+	}
+	for _, feed := range data.Feeds {
+		for _, item := range feed.Items {
+			if getURL(item.Links) == u {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MarkRead marks the entries with the URL as read, if there are any.
+// Tracked pages become unread again when they change.
+//
+// It returns any errors that occurred when saving to disk.
+func MarkRead(u string) error {
+	data.Lock()
+	if !isEntry(u) {
+		data.Unlock()
+		return nil
+	}
+	if read, ok := data.Read[u]; ok && (data.Pages[u] == nil || read.After(data.Pages[u].Changed)) {
+		// Already read
+		data.Unlock()
+		return nil
+	}
+	data.Read[u] = time.Now()
+	data.Unlock()
+
+	LastUpdated = time.Now()
+	return writeJSON()
+}
+
+// MarkUnread marks the entries with the URL as unread.
+//
+// It returns any errors that occurred when saving to disk.
+func MarkUnread(u string) error {
+	data.Lock()
+	if _, ok := data.Read[u]; !ok {
+		data.Unlock()
+		return nil
+	}
+	delete(data.Read, u)
+	data.Unlock()
+
+	LastUpdated = time.Now()
+	return writeJSON()
+}
+
+// MarkFeedRead marks all the entries of the feed or tracked page with the
+// URL as read.
+//
+// It returns any errors that occurred when saving to disk.
+func MarkFeedRead(u string) error {
+	now := time.Now()
+	data.Lock()
+	if _, ok := data.Pages[u]; ok {
+		data.Read[u] = now
+	}
+	if feed, ok := data.Feeds[u]; ok {
+		for _, item := range feed.Items {
+			if entryURL := getURL(item.Links); entryURL != "" {
+				if _, read := data.Read[entryURL]; !read {
+					data.Read[entryURL] = now
+				}
+			}
+		}
+	}
+	data.Unlock()
+
+	LastUpdated = now
+	return writeJSON()
+}
@@ -24,44 +24,55 @@ Example stored JSON.
 			"hash": <hash>,
 			"changed": <time>
 		}
+	},
+	"read": {
+		"url1": <time>
 	}
 }
 
 "pages" are the pages tracked for changes that aren't feeds.
 The hash used is SHA-256.
 The time is in RFC 3339 format, preferably in the UTC timezone.
+
+"read" has the URLs of entries that were opened, and when.
 */
 
 // Decoded JSON
 type jsonData struct {
 	feedMu *sync.RWMutex
 	pageMu *sync.RWMutex
+	readMu *sync.RWMutex
 	Feeds  map[string]*gofeed.Feed `json:"feeds,omitempty"`
 	Pages  map[string]*pageJSON    `json:"pages,omitempty"`
+	Read   map[string]time.Time    `json:"read,omitempty"`
 }
 
-// Lock locks both feed and page mutexes.
+// Lock locks the feed, page, and read mutexes.
 func (j *jsonData) Lock() {
 	j.feedMu.Lock()
 	j.pageMu.Lock()
+	j.readMu.Lock()
 }
 
-// Unlock unlocks both feed and page mutexes.
+// Unlock unlocks the feed, page, and read mutexes.
 func (j *jsonData) Unlock() {
 	j.feedMu.Unlock()
 	j.pageMu.Unlock()
+	j.readMu.Unlock()
 }
 
-// RLock read-locks both feed and page mutexes.
+// RLock read-locks the feed, page, and read mutexes.
 func (j *jsonData) RLock() {
 	j.feedMu.RLock()
 	j.pageMu.RLock()
+	j.readMu.RLock()
 }
 
-// RUnlock read-unlocks both feed and page mutexes.
+// RUnlock read-unlocks the feed, page, and read mutexes.
 func (j *jsonData) RUnlock() {
 	j.feedMu.RUnlock()
 	j.pageMu.RUnlock()
+	j.readMu.RUnlock()
 }
 
 type pageJSON struct {
@@ -73,6 +84,7 @@ type pageJSON struct {
 var data = jsonData{
 	feedMu: &sync.RWMutex{},
 	pageMu: &sync.RWMutex{},
+	readMu: &sync.RWMutex{},
 	// Maps are created in Init()
 }
 
@@ -83,6 +95,8 @@ type PageEntry struct {
 	Title     string
 	URL       string
 	Published time.Time
+	Read      bool   // Whether it was opened since it was published, see MarkRead
+	Feed      string // The URL of the feed or page it's from
 }
 
 // PageEntries is new-to-old list of Entry structs, used to create a
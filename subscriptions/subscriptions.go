@@ -11,13 +11,16 @@ import (
 	urlPkg "net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/makeworld-the-better-one/amfora/client"
 	"github.com/makeworld-the-better-one/amfora/config"
+	"github.com/makeworld-the-better-one/amfora/hooks"
 	"github.com/makeworld-the-better-one/go-gemini"
 	"github.com/mmcdole/gofeed"
 	"github.com/spf13/viper"
@@ -68,9 +71,19 @@ func Init() error {
 	if data.Pages == nil {
 		data.Pages = make(map[string]*pageJSON)
 	}
+	if data.Read == nil {
+		data.Read = make(map[string]time.Time)
+	}
 
 	LastUpdated = time.Now()
+	return nil
+}
 
+// StartUpdating updates the subscriptions in the background, now and then
+// every so often. It should be called after Init, and only once. Commands that
+// change subscriptions.json and then quit don't call it, so their writes
+// don't race with the ones of the updates.
+func StartUpdating() {
 	if viper.GetInt("subscriptions.update_interval") > 0 {
 		// Update subscriptions every so often
 		go func() {
@@ -84,8 +97,6 @@ func Init() error {
 		// So just update once at the beginning
 		go updateAll()
 	}
-
-	return nil
 }
 
 // IsSubscribed returns true if the URL is already subscribed to,
@@ -110,6 +121,17 @@ func IsSubscribed(url string) bool {
 	return false
 }
 
+// Title returns the title of the subscribed feed, or an empty string if
+// the URL isn't a feed that's subscribed to, or it has no title.
+func Title(url string) string {
+	data.feedMu.RLock()
+	defer data.feedMu.RUnlock()
+	if feed, ok := data.Feeds[url]; ok && feed != nil {
+		return feed.Title
+	}
+	return ""
+}
+
 // GetFeed returns a Feed object and a bool indicating whether the passed
 // content was actually recognized as a feed.
 func GetFeed(mediatype, filename string, r io.Reader) (*gofeed.Feed, bool) {
@@ -142,11 +164,28 @@ func writeJSON() error {
 	if err != nil {
 		return err
 	}
-	err = ioutil.WriteFile(config.SubscriptionPath, jsonBytes, 0666)
+	return writeFileAtomic(config.SubscriptionPath, jsonBytes)
+}
+
+// writeFileAtomic writes the data to a temporary file next to path, and then
+// renames it to path. So if Amfora quits in the middle of writing, the old
+// file is still there, instead of a cut off one.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp-*")
 	if err != nil {
 		return err
 	}
-
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
 	return nil
 }
 
@@ -192,12 +231,34 @@ func AddFeed(url string, feed *gofeed.Feed) error {
 		if err != nil {
 			return ErrSaving
 		}
+		if ok {
+			if entries := newEntries(oldFeed, feed); len(entries) > 0 {
+				hooks.Run(hooks.NewEntries, map[string]string{"URL": url, "COUNT": strconv.Itoa(len(entries))},
+					strings.Join(entries, "\n")+"\n")
+			}
+		}
 	} else {
 		data.feedMu.Unlock()
 	}
 	return nil
 }
 
+// newEntries returns the URLs of the entries of the feed that weren't in the
+// old version of it.
+func newEntries(oldFeed, feed *gofeed.Feed) []string {
+	old := make(map[string]bool, len(oldFeed.Items))
+	for _, item := range oldFeed.Items {
+		old[getURL(item.Links)] = true
+	}
+	var entries []string
+	for _, item := range feed.Items {
+		if u := getURL(item.Links); u != "" && !old[u] {
+			entries = append(entries, u)
+		}
+	}
+	return entries
+}
+
 // AddPage stores a page to track for changes.
 // It can be used to update the page as well, although the package
 // will handle that on its own.
@@ -228,6 +289,9 @@ func AddPage(url string, r io.Reader) error {
 		if err != nil {
 			return ErrSaving
 		}
+		if ok {
+			hooks.Run(hooks.NewEntries, map[string]string{"URL": url, "COUNT": "1"}, url+"\n")
+		}
 	} else {
 		data.pageMu.Unlock()
 	}
@@ -242,8 +306,10 @@ func AddPage(url string, r io.Reader) error {
 //
 // If there is over 5 redirects the error will be ErrTooManyRedirects.
 // ErrNotSuccess, as well as other fetch errors will also be returned.
-func getResource(url string) (string, *gemini.Response, error) {
-	res, err := client.Fetch(url)
+//
+// If limit isn't nil, each request waits for it.
+func getResource(url string, limit *hostLimiter) (string, *gemini.Response, error) {
+	res, err := fetch(url, limit)
 	if err != nil {
 		if res != nil {
 			res.Body.Close()
@@ -273,12 +339,14 @@ func getResource(url string) (string, *gemini.Response, error) {
 		tmp, err := parsed.Parse(res.Meta)
 		if err != nil {
 			// Redirect URL returned by the server is invalid
+			res.Body.Close()
 			return url, nil, err
 		}
 		parsed = tmp
+		res.Body.Close()
 
 		// Make the new request
-		res, err := client.Fetch(parsed.String())
+		res, err = fetch(parsed.String(), limit)
 		if err != nil {
 			if res != nil {
 				res.Body.Close()
@@ -312,12 +380,12 @@ func getResource(url string) (string, *gemini.Response, error) {
 						return url, res, nil
 					}
 					// There were permanent redirects before this one
-					// Return the URL of the latest permanent redirect
-					return urls[j-1].String(), res, nil
+					// Return the URL the latest permanent redirect went to
+					return urls[j].String(), res, nil
 				}
 			}
 			// They were all permanent redirects
-			return urls[len(urls)-1].String(), res, nil
+			return parsed.String(), res, nil
 		}
 
 		// It stopped because there was a non-redirect, non-success response
@@ -325,11 +393,32 @@ func getResource(url string) (string, *gemini.Response, error) {
 	}
 
 	// Too many redirects, return original
+	res.Body.Close()
 	return url, nil, ErrTooManyRedirects
 }
 
-func updateFeed(url string) {
-	newURL, res, err := getResource(url)
+// fetch fetches the URL, after waiting for the limiter if there is one.
+// The limiter is waited on again once the body of the response is closed,
+// so it must always be closed.
+func fetch(url string, limit *hostLimiter) (*gemini.Response, error) {
+	if limit == nil {
+		return client.Fetch(url)
+	}
+	done := limit.wait(urlHost(url))
+	res, err := client.Fetch(url)
+	if res == nil || res.Body == nil {
+		done()
+		return res, err
+	}
+	res.Body = &limitedBody{ReadCloser: res.Body, done: done}
+	return res, err
+}
+
+func updateFeed(url string, limit *hostLimiter) {
+	newURL, res, err := getResource(url, limit)
+	if res != nil {
+		defer res.Body.Close()
+	}
 	if err != nil {
 		return
 	}
@@ -351,8 +440,11 @@ func updateFeed(url string) {
 	}
 }
 
-func updatePage(url string) {
-	newURL, res, err := getResource(url)
+func updatePage(url string, limit *hostLimiter) {
+	newURL, res, err := getResource(url, limit)
+	if res != nil {
+		defer res.Body.Close()
+	}
 	if err != nil {
 		return
 	}
@@ -364,9 +456,15 @@ func updatePage(url string) {
 	}
 }
 
-// updateAll updates all subscriptions using workers.
+// updateAll updates all subscriptions using workers. Requests to each host
+// are limited by the host_workers and host_delay settings.
 // It only returns once all the workers are done.
 func updateAll() {
+	limit := newHostLimiter(
+		viper.GetInt("subscriptions.host_workers"),
+		time.Duration(viper.GetFloat64("subscriptions.host_delay")*float64(time.Second)),
+	)
+
 	worker := func(jobs <-chan [2]string, wg *sync.WaitGroup) {
 		// Each job is: [2]string{<type>, "url"}
 		// where <type> is "feed" or "page"
@@ -374,9 +472,9 @@ func updateAll() {
 		defer wg.Done()
 		for j := range jobs {
 			if j[0] == "feed" {
-				updateFeed(j[1])
+				updateFeed(j[1], limit)
 			} else if j[0] == "page" {
-				updatePage(j[1])
+				updatePage(j[1], limit)
 			}
 		}
 	}
@@ -405,30 +503,19 @@ func updateAll() {
 		}()
 	}
 
-	// Get map keys in a slice
+	// Get the jobs in a slice
 
-	feedKeys := make([]string, len(data.Feeds))
-	i := 0
+	allJobs := make([][2]string, 0, numJobs)
 	for k := range data.Feeds {
-		feedKeys[i] = k
-		i++
+		allJobs = append(allJobs, [2]string{"feed", k})
 	}
-
-	pageKeys := make([]string, len(data.Pages))
-	i = 0
 	for k := range data.Pages {
-		pageKeys[i] = k
-		i++
+		allJobs = append(allJobs, [2]string{"page", k})
 	}
 	data.RUnlock()
 
-	for j := 0; j < numJobs; j++ {
-		if j < len(feedKeys) {
-			jobs <- [2]string{"feed", feedKeys[j]}
-		} else {
-			// In the Pages
-			jobs <- [2]string{"page", pageKeys[j-len(feedKeys)]}
-		}
+	for _, j := range interleaveJobs(allJobs) {
+		jobs <- j
 	}
 	close(jobs)
 
@@ -454,6 +541,25 @@ func AllURLS() []string {
 	return urls
 }
 
+// Move changes the URL of a subscription, keeping what's stored for it.
+// It's used when a subscribed URL has permanently moved. It does nothing
+// if the old URL is not an actual subscription.
+//
+// It returns any errors that occurred when saving to disk.
+func Move(oldURL, newURL string) error {
+	data.Lock()
+	if feed, ok := data.Feeds[oldURL]; ok {
+		delete(data.Feeds, oldURL)
+		data.Feeds[newURL] = feed
+	}
+	if page, ok := data.Pages[oldURL]; ok {
+		delete(data.Pages, oldURL)
+		data.Pages[newURL] = page
+	}
+	data.Unlock()
+	return writeJSON()
+}
+
 // Remove removes a subscription from memory and from the disk.
 // The URL must be provided. It will do nothing if the URL is
 // not an actual subscription.
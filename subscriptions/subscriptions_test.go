@@ -0,0 +1,29 @@
+package subscriptions
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "amfora-subscriptions-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "subscriptions.json")
+
+	assert := assert.New(t)
+	assert.NoError(writeFileAtomic(path, []byte("old")))
+	assert.NoError(writeFileAtomic(path, []byte("new")))
+	got, err := ioutil.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal("new", string(got))
+
+	files, _ := ioutil.ReadDir(dir)
+	assert.Len(files, 1, "the temporary file should be renamed")
+}
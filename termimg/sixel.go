@@ -0,0 +1,90 @@
+package termimg
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"io"
+)
+
+// writeSixel encodes the image using sixel graphics. The image is not scaled.
+//
+// The image is dithered to the 216 web-safe colors, which every terminal
+// with sixel support can handle.
+func writeSixel(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	pal := image.NewPaletted(image.Rect(0, 0, b.Dx(), b.Dy()), palette.WebSafe)
+	draw.FloydSteinberg.Draw(pal, pal.Bounds(), img, b.Min)
+
+	bw := bufio.NewWriter(w)
+	width, height := pal.Bounds().Dx(), pal.Bounds().Dy()
+
+	// Start sixel mode, and set the pixel aspect ratio to 1:1 with the image size
+	fmt.Fprintf(bw, "\x1bPq\"1;1;%d;%d", width, height)
+
+	// Define the palette, using RGB percentages
+	for i, c := range pal.Palette {
+		r, g, b, _ := c.RGBA()
+		fmt.Fprintf(bw, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+	}
+
+	// Each band is six pixels tall
+	for y := 0; y < height; y += 6 {
+		// Find the colors used in this band
+		used := make(map[uint8]bool)
+		for x := 0; x < width; x++ {
+			for dy := 0; dy < 6 && y+dy < height; dy++ {
+				used[pal.ColorIndexAt(x, y+dy)] = true
+			}
+		}
+
+		first := true
+		for c := range used {
+			if !first {
+				// Go back to the start of the band for the next color
+				bw.WriteByte('$') //nolint:errcheck
+			}
+			first = false
+			fmt.Fprintf(bw, "#%d", c)
+
+			// Run-length encode the sixels for this color
+			var last byte
+			run := 0
+			flush := func() {
+				switch {
+				case run > 3:
+					fmt.Fprintf(bw, "!%d%c", run, last)
+				case run > 0:
+					for i := 0; i < run; i++ {
+						bw.WriteByte(last) //nolint:errcheck
+					}
+				}
+			}
+			for x := 0; x < width; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && y+dy < height; dy++ {
+					if pal.ColorIndexAt(x, y+dy) == c {
+						bits |= 1 << uint(dy)
+					}
+				}
+				ch := '?' + bits
+				if ch == last {
+					run++
+					continue
+				}
+				flush()
+				last = ch
+				run = 1
+			}
+			flush()
+		}
+		// Next band
+		bw.WriteByte('-') //nolint:errcheck
+	}
+
+	// Leave sixel mode
+	bw.WriteString("\x1b\\") //nolint:errcheck
+	return bw.Flush()
+}
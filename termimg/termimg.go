@@ -0,0 +1,195 @@
+// Package termimg displays images directly in the terminal, using the graphics
+// protocols supported by some terminal emulators.
+//
+// Write and Clear write straight to the terminal, so they should only be used
+// while the TUI is suspended. Blocks and ASCII can be used in any terminal.
+package termimg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+
+	// Register decoders for image.Decode
+	_ "image/gif"
+	_ "image/jpeg"
+)
+
+// Protocol is a way of displaying images in the terminal.
+type Protocol int
+
+const (
+	None   Protocol = iota // Images can't be displayed
+	Kitty                  // https://sw.kovidgoyal.net/kitty/graphics-protocol/
+	ITerm2                 // https://iterm2.com/documentation-images.html
+	Sixel
+)
+
+// Approximate size of a terminal cell in pixels, used to scale images.
+// Terminals don't reliably report this, so a common value is assumed.
+const (
+	cellWidth  = 10
+	cellHeight = 20
+)
+
+// Terminals known to support sixel graphics, matched against the start of $TERM.
+var sixelTerms = []string{"foot", "mlterm", "yaft", "contour"}
+
+// Detect guesses which graphics protocol the terminal supports using
+// environment variables. It returns None if no protocol is detected.
+func Detect() Protocol {
+	term := os.Getenv("TERM")
+	termProgram := os.Getenv("TERM_PROGRAM")
+
+	if os.Getenv("KITTY_WINDOW_ID") != "" || strings.Contains(term, "kitty") {
+		return Kitty
+	}
+	if termProgram == "iTerm.app" || termProgram == "WezTerm" || os.Getenv("LC_TERMINAL") == "iTerm2" {
+		return ITerm2
+	}
+	for _, s := range sixelTerms {
+		if strings.HasPrefix(term, s) {
+			return Sixel
+		}
+	}
+	return None
+}
+
+// ParseProtocol turns a config value into a Protocol. "auto" uses Detect,
+// and any unknown value is treated as None.
+func ParseProtocol(s string) Protocol {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "auto":
+		return Detect()
+	case "kitty":
+		return Kitty
+	case "iterm2":
+		return ITerm2
+	case "sixel":
+		return Sixel
+	default:
+		return None
+	}
+}
+
+// fit returns the size in pixels the image should be scaled to so it fits
+// in the provided number of terminal columns and rows, as well as the
+// number of columns and rows that size takes up.
+// Images are never scaled up.
+func fit(b image.Rectangle, cols, rows int) (int, int, int, int) {
+	w, h := b.Dx(), b.Dy()
+	maxW, maxH := cols*cellWidth, rows*cellHeight
+	if w > maxW {
+		h = h * maxW / w
+		w = maxW
+	}
+	if h > maxH {
+		w = w * maxH / h
+		h = maxH
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h, (w + cellWidth - 1) / cellWidth, (h + cellHeight - 1) / cellHeight
+}
+
+// scale resizes an image using nearest-neighbour sampling.
+func scale(img image.Image, w, h int) image.Image {
+	b := img.Bounds()
+	if b.Dx() == w && b.Dy() == h {
+		return img
+	}
+	ret := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			ret.Set(x, y, img.At(b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h))
+		}
+	}
+	return ret
+}
+
+// Write draws the image to w using the provided protocol, scaled to fit in
+// the given number of terminal columns and rows. The image is drawn at the
+// current cursor position.
+func Write(w io.Writer, img image.Image, p Protocol, cols, rows int) error {
+	pxW, pxH, c, r := fit(img.Bounds(), cols, rows)
+
+	switch p {
+	case Kitty:
+		return writeKitty(w, img, c, r)
+	case ITerm2:
+		return writeITerm2(w, img, c, r)
+	case Sixel:
+		return writeSixel(w, scale(img, pxW, pxH))
+	}
+	return fmt.Errorf("no graphics protocol to display the image with") //nolint:goerr113
+}
+
+// Clear removes any images that were drawn and would stay on the screen
+// otherwise. It does nothing for protocols that don't need it.
+func Clear(w io.Writer, p Protocol) error {
+	if p == Kitty {
+		// Delete all visible placements
+		_, err := io.WriteString(w, "\x1b_Ga=d\x1b\\")
+		return err
+	}
+	return nil
+}
+
+func encodePNG(img image.Image) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func writeKitty(w io.Writer, img image.Image, cols, rows int) error {
+	data, err := encodePNG(img)
+	if err != nil {
+		return err
+	}
+
+	// The data has to be sent in chunks of at most 4096 bytes
+	const chunkSize = 4096
+	first := true
+	for len(data) > 0 {
+		n := chunkSize
+		if n > len(data) {
+			n = len(data)
+		}
+		more := 0
+		if n < len(data) {
+			more = 1
+		}
+		if first {
+			_, err = fmt.Fprintf(w, "\x1b_Ga=T,f=100,c=%d,r=%d,m=%d;%s\x1b\\", cols, rows, more, data[:n])
+			first = false
+		} else {
+			_, err = fmt.Fprintf(w, "\x1b_Gm=%d;%s\x1b\\", more, data[:n])
+		}
+		if err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+func writeITerm2(w io.Writer, img image.Image, cols, rows int) error {
+	data, err := encodePNG(img)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "\x1b]1337;File=inline=1;size=%d;width=%d;height=%d;preserveAspectRatio=1:%s\a",
+		base64.StdEncoding.DecodedLen(len(data)), cols, rows, data)
+	return err
+}
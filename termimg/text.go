@@ -0,0 +1,90 @@
+package termimg
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+)
+
+// Functions for turning images into text, for terminals that don't support
+// any graphics protocol. Unlike the rest of the package, these don't write
+// to the terminal, and can be displayed inside the TUI.
+
+// Characters used for ASCII output, from darkest to brightest.
+const asciiRamp = " .:-=+*#%@"
+
+// fitText returns the largest size that fits in maxW and maxH while
+// keeping the aspect ratio of the w by h image. Images are never scaled up.
+func fitText(w, h, maxW, maxH int) (int, int) {
+	if w > maxW {
+		h = h * maxW / w
+		w = maxW
+	}
+	if h > maxH {
+		w = w * maxH / h
+		h = maxH
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
+
+// Blocks renders the image using half-block characters and cview color tags,
+// scaled to fit in the given number of columns and rows. Each character
+// holds two pixels, one using the foreground color and one the background.
+func Blocks(img image.Image, cols, rows int) string {
+	b := img.Bounds()
+	w, h := fitText(b.Dx(), b.Dy(), cols, rows*2)
+	img = scale(img, w, h)
+	o := img.Bounds().Min
+
+	var sb strings.Builder
+	for y := 0; y < h; y += 2 {
+		last := ""
+		for x := 0; x < w; x++ {
+			top := hexColor(img.At(o.X+x, o.Y+y))
+			bottom := top
+			if y+1 < h {
+				bottom = hexColor(img.At(o.X+x, o.Y+y+1))
+			}
+			tag := "[" + top + ":" + bottom + "]"
+			if tag != last {
+				sb.WriteString(tag)
+				last = tag
+			}
+			sb.WriteRune('▀')
+		}
+		sb.WriteString("[-:-]\n")
+	}
+	return sb.String()
+}
+
+// ASCII renders the image using plain characters based on brightness,
+// scaled to fit in the given number of columns and rows.
+func ASCII(img image.Image, cols, rows int) string {
+	b := img.Bounds()
+	// Terminal cells are about twice as tall as they are wide
+	w, h := fitText(b.Dx(), b.Dy()/2, cols, rows)
+	img = scale(img, w, h)
+	o := img.Bounds().Min
+
+	var sb strings.Builder
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			g := color.GrayModel.Convert(img.At(o.X+x, o.Y+y)).(color.Gray).Y
+			sb.WriteByte(asciiRamp[int(g)*(len(asciiRamp)-1)/255])
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func hexColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
@@ -0,0 +1,38 @@
+package termimg
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+var fitTextTests = []struct {
+	w, h, maxW, maxH int
+	outW, outH       int
+}{
+	{10, 10, 80, 24, 10, 10},
+	{160, 40, 80, 24, 80, 20},
+	{100, 200, 80, 24, 12, 24},
+	{1000, 1, 80, 24, 80, 1},
+}
+
+func TestFitText(t *testing.T) {
+	for _, tt := range fitTextTests {
+		w, h := fitText(tt.w, tt.h, tt.maxW, tt.maxH)
+		if w != tt.outW || h != tt.outH {
+			t.Errorf("fitText(%d, %d, %d, %d): expected %dx%d, actual %dx%d",
+				tt.w, tt.h, tt.maxW, tt.maxH, tt.outW, tt.outH, w, h)
+		}
+	}
+}
+
+func TestASCII(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 4, 2))
+	img.SetGray(0, 0, color.Gray{Y: 255})
+	img.SetGray(3, 0, color.Gray{Y: 255})
+
+	expected := "@  @\n"
+	if actual := ASCII(img, 80, 24); actual != expected {
+		t.Errorf("expected %q, actual %q", expected, actual)
+	}
+}